@@ -0,0 +1,255 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"fmt"
+	"image"
+	"sync"
+	"time"
+)
+
+// maxMemoized is the number of Results an Analysis remembers.
+const maxMemoized = 64
+
+// ImageAnalyzer is implemented by Analyzers which can return the Analysis of
+// an image. The Analyzers returned by NewAnalyzer, NewAnalyzerWithLogger and
+// NewAnalyzerWithSettings implement it.
+type ImageAnalyzer interface {
+	ResultAnalyzer
+	Analyze(img image.Image) (*Analysis, error)
+}
+
+// Analysis contains the detector planes of an image. They can be inspected,
+// and crops of any dimensions can be found without detecting again. An
+// Analysis is safe for concurrent use.
+//
+// It remembers the Results it returned, so asking for the same crop again,
+// as templates rendering an image repeatedly do, costs next to nothing. It
+// doesn't if the settings have hooks for the later stages, callbacks or the
+// debug mode enabled, which expect every crop to be searched for.
+type Analysis struct {
+	analyzer smartcropAnalyzer
+	state    State
+
+	mu      sync.Mutex
+	results map[string]Result
+}
+
+// Analyze runs the detectors on img. Unlike FindBestResult, it doesn't coarsen
+// the analysis to finish within the Budget of the settings.
+func (o smartcropAnalyzer) Analyze(img image.Image) (a *Analysis, err error) {
+	defer recoverAnalysis(&err, img, 0, 0, o.settings)
+
+	if img == nil {
+		return nil, ErrNilImage
+	}
+	if img.Bounds().Empty() {
+		return nil, ErrEmptyImage
+	}
+	if err := o.settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	st := State{Source: img}
+	p := pipeline{logger: o.logger, settings: &o.settings, resizer: o.Resizer}
+	if err := p.runStages(&st, StagePrescale, StageDetect); err != nil {
+		return nil, err
+	}
+	return &Analysis{analyzer: o, state: st}, nil
+}
+
+// FindBestResult returns the best crop of the analysed image for the given
+// dimensions, see ResultAnalyzer.
+func (a *Analysis) FindBestResult(width, height int) (Result, error) {
+	return a.FindBoostedResult(width, height, nil)
+}
+
+// FindBoostedResult returns the best crop of the analysed image for the given
+// dimensions, preferring the regions of boosts like Request.Boosts does.
+func (a *Analysis) FindBoostedResult(width, height int, boosts []Boost) (res Result, err error) {
+	defer recoverAnalysis(&err, a.state.Source, width, height, a.analyzer.settings)
+
+	if width, height, err = validateImage(a.state.Source, width, height); err != nil {
+		return Result{}, err
+	}
+
+	key := fmt.Sprintf("%dx%d %v", width, height, boosts)
+	if res, ok := a.memoized(key); ok {
+		return res, nil
+	}
+
+	st := a.state
+	st.Width, st.Height = width, height
+	if len(boosts) > 0 {
+		st.Boost = applyBoosts(&st, boosts)
+	}
+	if a.analyzer.logger.DebugMode {
+		// the debug output draws onto the planes
+		st.Detected = cloneRGBA(st.Detected)
+	}
+
+	// the hooks of the stages already run must not run again
+	o := a.analyzer
+	o.settings.Hooks = o.settings.Hooks.from(StageCandidates)
+	res, _, err = o.find(&st, 0)
+	if err != nil {
+		return Result{}, err
+	}
+	if !res.Truncated {
+		// a later call may have the time to finish
+		a.memoize(key, res)
+	}
+	return res, nil
+}
+
+// memoizes reports whether the Results of a are remembered.
+func (a *Analysis) memoizes() bool {
+	s := a.analyzer.settings
+	for stage, hooks := range s.Hooks.Before {
+		if stage >= StageCandidates && len(hooks) > 0 {
+			return false
+		}
+	}
+	for stage, hooks := range s.Hooks.After {
+		if stage >= StageCandidates && len(hooks) > 0 {
+			return false
+		}
+	}
+	return !a.analyzer.logger.DebugMode && s.OnResult == nil && s.OnTrace == nil
+}
+
+// memoized returns the remembered Result for key, if any.
+func (a *Analysis) memoized(key string) (Result, bool) {
+	if !a.memoizes() {
+		return Result{}, false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	res, ok := a.results[key]
+	return res.clone(), ok
+}
+
+// memoize remembers res for key, unless a remembers too many Results already.
+func (a *Analysis) memoize(key string, res Result) {
+	if !a.memoizes() {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.results == nil {
+		a.results = map[string]Result{}
+	}
+	if len(a.results) < maxMemoized {
+		a.results[key] = res.clone()
+	}
+}
+
+// Prescaled returns the prescaled image the planes have been computed on.
+func (a *Analysis) Prescaled() *image.RGBA {
+	return a.state.Prescaled
+}
+
+// DetailMap returns the output of the edge detection. Like the other maps,
+// it is in coordinates of the prescaled image, scaled down by the Reduction
+// of the State if ReducedPlanes is enabled in the settings.
+func (a *Analysis) DetailMap() *image.Gray {
+	return channel(a.state.Detected, 1)
+}
+
+// SkinMap returns the output of the skin detection.
+func (a *Analysis) SkinMap() *image.Gray {
+	return channel(a.state.Detected, 0)
+}
+
+// SaturationMap returns the output of the saturation detection.
+func (a *Analysis) SaturationMap() *image.Gray {
+	return channel(a.state.Detected, 2)
+}
+
+// BoostMap returns the combined output of the Detectors enabled in the
+// settings, or nil if there are none.
+func (a *Analysis) BoostMap() *image.Gray {
+	if a.state.Boost == nil {
+		return nil
+	}
+	b := *a.state.Boost
+	b.Pix = append([]uint8(nil), b.Pix...)
+	return &b
+}
+
+// channel returns a copy of the channel c of img.
+func channel(img *image.RGBA, c int) *image.Gray {
+	b := img.Bounds()
+	plane := image.NewGray(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		i := img.PixOffset(b.Min.X, y)
+		o := plane.PixOffset(b.Min.X, y)
+		for x := b.Min.X; x < b.Max.X; x, i, o = x+1, i+4, o+1 {
+			plane.Pix[o] = img.Pix[i+c]
+		}
+	}
+	return plane
+}
+
+// find runs the pipeline on st and reports the Result to the callbacks of
+// the settings.
+func (o smartcropAnalyzer) find(st *State, degradation int) (Result, time.Duration, error) {
+	if o.settings.OnTrace != nil {
+		st.trace = newTrace(o.settings, st)
+	}
+
+	now := time.Now()
+	if o.settings.MaxDuration > 0 {
+		st.deadline = now.Add(o.settings.MaxDuration)
+	}
+	p := pipeline{logger: o.logger, settings: &o.settings, resizer: o.Resizer}
+	err := p.runStrategies(st)
+	elapsed := time.Since(now)
+	if st.trace != nil {
+		o.settings.OnTrace(st.trace.finish(st, elapsed, err))
+	}
+	if err != nil {
+		return Result{}, elapsed, err
+	}
+
+	st.Result.Degradation = degradation
+	if o.settings.OnResult != nil {
+		o.settings.OnResult(Decision{
+			ImageFingerprint: imageFingerprint(st),
+			Width:            st.Width,
+			Height:           st.Height,
+			Settings:         o.settings,
+			Result:           st.Result,
+			Source:           st.Source,
+		})
+	}
+	return st.Result, elapsed, nil
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestAnalysis(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := DefaultCropSettings()
+	settings.Hooks.AddAfter(StageDetect, func(stage Stage, st *State) error {
+		st.Boost = applyBoosts(st, []Boost{{Rectangle: image.Rect(0, 0, 200, 284), Weight: 0.5}})
+		return nil
+	})
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ImageAnalyzer)
+	a, err := analyzer.Analyze(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := a.state.Detected
+	for name, tc := range map[string]struct {
+		plane *image.Gray
+		c     int
+	}{
+		"skin":       {a.SkinMap(), 0},
+		"detail":     {a.DetailMap(), 1},
+		"saturation": {a.SaturationMap(), 2},
+	} {
+		if tc.plane.Bounds() != d.Bounds() {
+			t.Fatalf("expected %s map bounds %v, got %v", name, d.Bounds(), tc.plane.Bounds())
+		}
+		for i := range tc.plane.Pix {
+			if tc.plane.Pix[i] != d.Pix[4*i+tc.c] {
+				t.Fatalf("expected %s map to match the planes at %d", name, i)
+			}
+		}
+	}
+	if a.BoostMap() == nil {
+		t.Fatal("expected a boost map")
+	}
+
+	for _, dim := range []image.Point{{250, 250}, {100, 200}, {250, 250}} {
+		expected, err := analyzer.FindBestResult(img, dim.X, dim.Y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := a.FindBestResult(dim.X, dim.Y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(res, expected) {
+			t.Fatalf("expected %v, got %v", expected, res)
+		}
+	}
+}
+
+func TestAnalysisMemoization(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := DefaultCropSettings()
+	settings.AttentionPoints = 2
+	a, err := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ImageAnalyzer).Analyze(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	boosts := []Boost{{Rectangle: image.Rect(0, 0, 200, 284), Weight: 1}}
+	boosted, err := FindCrop(img, Request{Width: 250, Height: 250, Settings: &settings, Boosts: boosts, Resizer: nfnt.NewDefaultResizer()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		res, err := a.FindBoostedResult(250, 250, boosts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(res, boosted) {
+			t.Fatalf("expected %v, got %v", boosted, res)
+		}
+		// the remembered Result must not be affected by changes of callers
+		res.AttentionPoints[0].X = -1
+	}
+	if _, err := a.FindBestResult(250, 250); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.results) != 2 {
+		t.Fatalf("expected the results for and without boosts to be remembered, got %d", len(a.results))
+	}
+
+	// hooks expect every crop to be searched for
+	var runs int
+	settings.Hooks.AddAfter(StageSelect, func(stage Stage, st *State) error {
+		runs++
+		return nil
+	})
+	a, err = NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ImageAnalyzer).Analyze(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.FindBestResult(250, 250)
+	a.FindBestResult(250, 250)
+	if runs != 2 || len(a.results) != 0 {
+		t.Fatalf("expected no results to be remembered with hooks, got %d runs", runs)
+	}
+}
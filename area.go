@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"errors"
+	"image"
+	"math"
+)
+
+// ErrAreaOutside gets returned when the area of interest of a request doesn't
+// overlap the image.
+var ErrAreaOutside = errors.New("Area lies outside of the image")
+
+// area returns the area of interest of st in coordinates of the source image,
+// which is the whole image unless st.Area is set.
+func (st *State) area() (image.Rectangle, error) {
+	b := st.Source.Bounds()
+	if st.Area.Empty() {
+		return b, nil
+	}
+
+	a := st.Area.Intersect(b)
+	if a.Empty() {
+		return a, ErrAreaOutside
+	}
+	return a, nil
+}
+
+// normalizedArea returns the area of interest of st relative to the source
+// image.
+func (st *State) normalizedArea() NormalizedRect {
+	b := st.Source.Bounds()
+	a, err := st.area()
+	if err != nil {
+		a = b
+	}
+
+	bw, bh := float64(b.Dx()), float64(b.Dy())
+	return NormalizedRect{
+		X:      float64(a.Min.X-b.Min.X) / bw,
+		Y:      float64(a.Min.Y-b.Min.Y) / bh,
+		Width:  float64(a.Dx()) / bw,
+		Height: float64(a.Dy()) / bh,
+	}
+}
+
+// PrescaledArea returns the area of interest of st in coordinates of the
+// prescaled image, rounded inwards to whole pixels. All candidates lie within
+// it.
+func (st *State) PrescaledArea() image.Rectangle {
+	n := st.normalizedArea()
+	lw, lh := float64(st.Prescaled.Bounds().Dx()), float64(st.Prescaled.Bounds().Dy())
+	r := image.Rect(
+		int(math.Ceil(n.X*lw-1e-9)), int(math.Ceil(n.Y*lh-1e-9)),
+		int((n.X+n.Width)*lw+1e-9), int((n.Y+n.Height)*lh+1e-9),
+	)
+	// keep at least a pixel of tiny areas
+	if r.Dx() < 1 {
+		r.Max.X = r.Min.X + 1
+	}
+	if r.Dy() < 1 {
+		r.Max.Y = r.Min.Y + 1
+	}
+	return r
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestArea(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	left := image.Rect(0, 0, img.Bounds().Dx()/4, img.Bounds().Dy())
+	area := image.Rect(450, 20, 900, 284)
+	for _, tc := range []struct {
+		name     string
+		settings func(*CropSettings)
+	}{
+		{"default", func(s *CropSettings) {}},
+		{"refine", func(s *CropSettings) { s.Refine = true }},
+		{"margin", func(s *CropSettings) { s.Margin = 0.2 }},
+		{"exact ratio", func(s *CropSettings) { s.ExactRatio = true }},
+		{"center", func(s *CropSettings) { s.Strategies = []string{StrategyCenter} }},
+		{"entropy", func(s *CropSettings) { s.Strategies = []string{StrategyEntropy} }},
+	} {
+		settings := DefaultCropSettings()
+		tc.settings(&settings)
+
+		res, err := FindCrop(img, Request{
+			Resizer:  nfnt.NewDefaultResizer(),
+			Width:    200,
+			Height:   200,
+			Settings: &settings,
+			Boosts:   []Boost{{Rectangle: left, Weight: 1.0}},
+			Area:     area,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !res.Crop.In(area) {
+			t.Errorf("%s: expected crop within %v, got %v", tc.name, area, res.Crop.Rectangle)
+		}
+	}
+
+	_, err = FindCrop(img, Request{Width: 200, Height: 200, Area: image.Rect(1000, 0, 1100, 100), Resizer: nfnt.NewDefaultResizer()})
+	if err != ErrAreaOutside {
+		t.Fatalf("expected ErrAreaOutside, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import "sort"
+
+// AttentionPoint is the centroid of a salient region of the image, relative
+// to the dimensions of the source image, ranging from 0 to 1. Weight is the
+// share of the saliency of the whole image the region holds.
+type AttentionPoint struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Weight float64 `json:"weight"`
+}
+
+// attentionPoints returns the centroids of the n heaviest salient regions,
+// i.e. connected components of salient pixels, heaviest first.
+func (m *saliencyMap) attentionPoints(n int) []AttentionPoint {
+	if n <= 0 || m.max <= 0 {
+		return nil
+	}
+
+	total := 0.0
+	for _, v := range m.values {
+		total += v
+	}
+
+	var points []AttentionPoint
+	for _, b := range m.blobs() {
+		var sx, sy float64
+		for _, i := range b.pixels {
+			v := m.values[i]
+			sx += v * (float64(i%m.width) + 0.5)
+			sy += v * (float64(i/m.width) + 0.5)
+		}
+		points = append(points, AttentionPoint{
+			X:      sx / b.mass / float64(m.width),
+			Y:      sy / b.mass / float64(m.height),
+			Weight: b.mass / total,
+		})
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Weight > points[j].Weight
+	})
+	if len(points) > n {
+		points = points[:n]
+	}
+	return points
+}
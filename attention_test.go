@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestAttentionPoints(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 100))
+	for i := range img.Pix {
+		img.Pix[i] = 128
+	}
+
+	settings := DefaultCropSettings()
+	settings.AttentionPoints = 3
+	res, err := FindCrop(img, Request{
+		Resizer:  nfnt.NewDefaultResizer(),
+		Width:    100,
+		Height:   100,
+		Settings: &settings,
+		Boosts: []Boost{
+			{Rectangle: image.Rect(20, 20, 60, 80), Weight: 1.0},
+			{Rectangle: image.Rect(200, 30, 240, 70), Weight: 1.0},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	points := res.AttentionPoints
+	if len(points) != 2 {
+		t.Fatalf("expected 2 attention points, got %v", points)
+	}
+	if points[0].Weight <= points[1].Weight || points[0].Weight+points[1].Weight > 1 {
+		t.Fatalf("expected the points to be ordered by weight, got %v", points)
+	}
+
+	expected := []AttentionPoint{{X: 40.0 / 300.0, Y: 0.5}, {X: 220.0 / 300.0, Y: 0.5}}
+	for i, p := range points {
+		if math.Abs(p.X-expected[i].X) > 0.02 || math.Abs(p.Y-expected[i].Y) > 0.02 {
+			t.Fatalf("expected attention point %d at %v, got %v", i, expected[i], p)
+		}
+	}
+}
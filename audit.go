@@ -0,0 +1,189 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"image"
+	"image/color"
+	"io"
+	"sync"
+	"time"
+)
+
+// AuditRecord is a crop decision as recorded by an AuditLog.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+
+	// SourceHash is the sha256 sum of the dimensions and the 8-bit RGBA
+	// pixels of the source image, so it identifies the image regardless of
+	// how it has been encoded or resized for the analysis.
+	SourceHash string `json:"sourceHash"`
+
+	// Width and Height are the requested dimensions, Settings and
+	// ParamsHash the parameters of the analysis.
+	Width           int          `json:"width"`
+	Height          int          `json:"height"`
+	AnalyzerVersion string       `json:"analyzerVersion"`
+	ParamsHash      string       `json:"paramsHash"`
+	Settings        CropSettings `json:"settings"`
+
+	Crop       Crop    `json:"crop"`
+	Confidence float64 `json:"confidence"`
+	Strategy   string  `json:"strategy,omitempty"`
+
+	// Degradation, Truncated, Partial and Reused are the ones of the Result,
+	// explaining crops found by a reduced analysis.
+	Degradation int  `json:"degradation,omitempty"`
+	Truncated   bool `json:"truncated,omitempty"`
+	Partial     bool `json:"partial,omitempty"`
+	Reused      bool `json:"reused,omitempty"`
+}
+
+// AuditLog writes every crop decision it records as a line of JSON, e.g. for
+// media organizations which need to explain the automated editorial
+// decisions of their systems. It is safe for concurrent use.
+type AuditLog struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewAuditLog returns an AuditLog writing to w.
+func NewAuditLog(w io.Writer) *AuditLog {
+	return &AuditLog{w: w}
+}
+
+// Attach makes s record its crop decisions in the AuditLog, along with
+// calling the OnResult callback it had before.
+func (l *AuditLog) Attach(s *CropSettings) {
+	prev := s.OnResult
+	s.OnResult = func(d Decision) {
+		if prev != nil {
+			prev(d)
+		}
+		_ = l.Record(d)
+	}
+}
+
+// Record writes the AuditRecord of d.
+func (l *AuditLog) Record(d Decision) error {
+	return l.Write(NewAuditRecord(d))
+}
+
+// NewAuditRecord returns the AuditRecord of d. Its SourceHash is left empty
+// if d has no Source, e.g. for callers which have hashed it with SourceHash
+// before.
+func NewAuditRecord(d Decision) AuditRecord {
+	rec := AuditRecord{
+		Time:            time.Now().UTC(),
+		Width:           d.Width,
+		Height:          d.Height,
+		AnalyzerVersion: d.Result.AnalyzerVersion,
+		ParamsHash:      d.Result.ParamsHash,
+		Settings:        d.Settings,
+		Crop:            d.Result.Crop,
+		Confidence:      d.Result.Confidence,
+		Strategy:        d.Result.Strategy,
+		Degradation:     d.Result.Degradation,
+		Truncated:       d.Result.Truncated,
+		Partial:         d.Result.Partial,
+		Reused:          d.Result.Reused,
+	}
+	if d.Source != nil {
+		rec.SourceHash = SourceHash(d.Source)
+	}
+	return rec
+}
+
+// Write writes rec. Once a write has failed, it keeps returning the error
+// without writing anything, so the log never continues after a gap.
+func (l *AuditLog) Write(rec AuditRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return l.err
+	}
+	_, l.err = l.w.Write(append(line, '\n'))
+	return l.err
+}
+
+// Err returns the error a write of the AuditLog has failed with, if any.
+func (l *AuditLog) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// SourceHash returns the SourceHash of img, as recorded by an AuditLog.
+func SourceHash(img image.Image) string {
+	h := sha256.New()
+	b := img.Bounds()
+	_ = binary.Write(h, binary.LittleEndian, []int64{int64(b.Dx()), int64(b.Dy())})
+
+	if rgba, ok := img.(*image.RGBA); ok {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			off := rgba.PixOffset(b.Min.X, y)
+			h.Write(rgba.Pix[off : off+b.Dx()*4])
+		}
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	row := make([]byte, b.Dx()*4)
+	if ycc, ok := img.(*image.YCbCr); ok {
+		// decoded JPEGs, converted without boxing every pixel in a color.Color
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				yi, ci := ycc.YOffset(x, y), ycc.COffset(x, y)
+				r, g, bl := color.YCbCrToRGB(ycc.Y[yi], ycc.Cb[ci], ycc.Cr[ci])
+				i := (x - b.Min.X) * 4
+				row[i], row[i+1], row[i+2], row[i+3] = r, g, bl, 0xff
+			}
+			h.Write(row)
+		}
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			i := (x - b.Min.X) * 4
+			row[i], row[i+1], row[i+2], row[i+3] = uint8(r>>8), uint8(g>>8), uint8(bl>>8), uint8(a>>8)
+		}
+		h.Write(row)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestAuditLog(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	log := NewAuditLog(&buf)
+	decisions := 0
+	settings := DefaultCropSettings()
+	settings.OnResult = func(Decision) {
+		decisions++
+	}
+	log.Attach(&settings)
+
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ResultAnalyzer)
+	var results []Result
+	for _, src := range []image.Image{img, toRGBA(img)} {
+		res, err := analyzer.FindBestResult(src, 250, 250)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, res)
+	}
+	if decisions != 2 {
+		t.Fatalf("expected the previous callback to be called twice, got %d", decisions)
+	}
+
+	var records []AuditRecord
+	s := bufio.NewScanner(&buf)
+	for s.Scan() {
+		var rec AuditRecord
+		if err := json.Unmarshal(s.Bytes(), &rec); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	rec := records[0]
+	if rec.Time.IsZero() || rec.Width != 250 || rec.ParamsHash != settings.Fingerprint() || rec.Settings.Fingerprint() != settings.Fingerprint() {
+		t.Errorf("expected the time and parameters to be recorded, got %+v", rec)
+	}
+	if rec.Crop.Rectangle != results[0].Crop.Rectangle || rec.Confidence != results[0].Confidence || rec.AnalyzerVersion != Version {
+		t.Errorf("expected the crop %v with confidence %v, got %+v", results[0].Crop.Rectangle, results[0].Confidence, rec)
+	}
+	// the hash doesn't depend on the type of the image
+	if len(rec.SourceHash) != 64 || rec.SourceHash != records[1].SourceHash {
+		t.Errorf("expected the same source hash for both images, got %q and %q", rec.SourceHash, records[1].SourceHash)
+	}
+	// nor on the conversion of the decoded JPEG
+	if _, ok := img.(*image.YCbCr); !ok {
+		t.Fatalf("expected a YCbCr image, got %T", img)
+	}
+	if h := SourceHash(struct{ image.Image }{img}); h != rec.SourceHash {
+		t.Errorf("expected the source hash %q converting the pixels one by one, got %q", rec.SourceHash, h)
+	}
+
+	log = NewAuditLog(failingWriter{})
+	for i := 0; i < 2; i++ {
+		if err := log.Record(Decision{Result: results[0]}); err == nil {
+			t.Fatal("expected the write to fail")
+		}
+	}
+	if log.Err() == nil {
+		t.Error("expected the error to be kept")
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package batch
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// ErrCompleted gets returned when submitting a job the Checkpoint of a
+// Processor has already recorded as completed.
+var ErrCompleted = errors.New("Job has already been completed")
+
+// Checkpoint records the IDs of completed jobs in a file, so an interrupted
+// batch run can resume where it stopped instead of starting over. Each ID
+// gets appended to the file as a quoted line once it's recorded, and a line
+// cut short by a crash gets ignored when the file is opened again.
+type Checkpoint struct {
+	mu   sync.Mutex
+	f    *os.File
+	done map[string]struct{}
+}
+
+// OpenCheckpoint opens the Checkpoint file at path, creating it if it doesn't
+// exist yet.
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Checkpoint{f: f, done: make(map[string]struct{})}
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// start a new line after one cut short
+			if len(line) > 0 {
+				if _, err := f.WriteString("\n"); err != nil {
+					f.Close()
+					return nil, err
+				}
+			}
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if id, err := strconv.Unquote(string(line[:len(line)-1])); err == nil {
+			c.done[id] = struct{}{}
+		}
+	}
+	return c, nil
+}
+
+// Done returns whether the job with the given ID has been completed.
+func (c *Checkpoint) Done(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.done[id]
+	return ok
+}
+
+// Len returns the number of completed jobs.
+func (c *Checkpoint) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.done)
+}
+
+// Record records the job with the given ID as completed.
+func (c *Checkpoint) Record(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.done[id]; ok {
+		return nil
+	}
+	if _, err := c.f.WriteString(strconv.Quote(id) + "\n"); err != nil {
+		return err
+	}
+	c.done[id] = struct{}{}
+	return nil
+}
+
+// Close closes the Checkpoint file.
+func (c *Checkpoint) Close() error {
+	return c.f.Close()
+}
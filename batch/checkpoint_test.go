@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package batch
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smartcrop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint")
+
+	c, err := OpenCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a.jpg", "b\n.jpg", "a.jpg"} {
+		if err := c.Record(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate a crash while recording
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`"c.j`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	c, err = OpenCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 2 || !c.Done("a.jpg") || !c.Done("b\n.jpg") || c.Done("c.jpg") {
+		t.Fatalf("expected a.jpg and b\\n.jpg to be done, got %d jobs", c.Len())
+	}
+	if err := c.Record("c.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	c, err = OpenCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if c.Len() != 3 || !c.Done("c.jpg") {
+		t.Fatalf("expected c.jpg to be done after resuming, got %d jobs", c.Len())
+	}
+}
+
+func TestProcessorCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smartcrop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, err := OpenCheckpoint(filepath.Join(dir, "checkpoint"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	job := FileJob(testFile, 100, 100)
+	job.ID = "done"
+	if err := c.Record(checkpointKey(job)); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewProcessor(newAnalyzer(), Options{Workers: 1, Checkpoint: c})
+	if err := p.Submit(context.Background(), job); err != ErrCompleted {
+		t.Fatalf("expected ErrCompleted, got %v", err)
+	}
+	job.ID = "todo"
+	if err := p.Submit(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+	for res := range p.Results() {
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+	}
+	if !c.Done(checkpointKey(job)) {
+		t.Fatal("expected the processed job to be recorded")
+	}
+}
+
+func TestProcessorCheckpointSizes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smartcrop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint")
+
+	c, err := OpenCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewProcessor(newAnalyzer(), Options{Workers: 1, Checkpoint: c})
+	if err := p.Submit(context.Background(), FileJob(testFile, 100, 100)); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+	for res := range p.Results() {
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+	}
+	c.Close()
+
+	// resume with the same image at another size
+	c, err = OpenCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	p = NewProcessor(newAnalyzer(), Options{Workers: 1, Checkpoint: c})
+	if err := p.Submit(context.Background(), FileJob(testFile, 100, 100)); err != ErrCompleted {
+		t.Fatalf("expected ErrCompleted for the completed size, got %v", err)
+	}
+	if err := p.Submit(context.Background(), FileJob(testFile, 200, 100)); err != nil {
+		t.Fatalf("expected the other size to be processed, got %v", err)
+	}
+	p.Close()
+	var n int
+	for res := range p.Results() {
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+		if res.Width != 200 || res.Height != 100 {
+			t.Fatalf("expected a 200x100 result, got %dx%d", res.Width, res.Height)
+		}
+		n++
+	}
+	if n != 1 || c.Len() != 2 {
+		t.Fatalf("expected 1 result and 2 completed jobs, got %d and %d", n, c.Len())
+	}
+}
@@ -0,0 +1,358 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+/*
+Package batch runs smartcrop as a background subsystem: a Processor finds the
+best crops of submitted jobs on a pool of workers, so applications don't need
+to block their request handlers on the analysis.
+
+	p := batch.NewProcessor(analyzer, batch.Options{Workers: 4, QueueSize: 100})
+	go func() {
+		for res := range p.Results() {
+			// store res.Result or handle res.Err
+		}
+	}()
+	err := p.Submit(ctx, batch.FileJob("photo.jpg", 250, 250))
+	// ...
+	p.Close()
+*/
+package batch
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // register the GIF decoder
+	_ "image/jpeg" // register the JPEG decoder
+	_ "image/png"  // register the PNG decoder
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/muesli/smartcrop"
+)
+
+// ErrClosed gets returned when submitting jobs to a closed Processor.
+var ErrClosed = errors.New("Processor is closed")
+
+// Job is an image to find the best crop of.
+type Job struct {
+	// ID identifies the job in its Result.
+	ID string
+
+	// Image is the image to crop. If it's nil, it gets loaded with Load
+	// once a worker picks up the job, so queued jobs don't hold on to
+	// decoded images.
+	Image image.Image
+	Load  func() (image.Image, error)
+
+	// Width and Height are the requested dimensions of the crop.
+	Width, Height int
+
+	// Priority orders the queued jobs: jobs of a higher priority get picked
+	// up first, jobs of the same priority in the order they were submitted.
+	Priority int
+
+	// Path is the path of the image file, if the job loads one. Sidecars
+	// get written next to it.
+	Path string
+}
+
+// FileJob returns a Job loading the image file at path, identified by its
+// path.
+func FileJob(path string, width, height int) Job {
+	return Job{
+		ID:     path,
+		Path:   path,
+		Width:  width,
+		Height: height,
+		Load: func() (image.Image, error) {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+
+			img, _, err := smartcrop.Decode(f)
+			if err != nil {
+				return nil, &DecodeError{ID: path, Err: err}
+			}
+			return img, nil
+		},
+	}
+}
+
+// Result is the outcome of a Job. Its Err is a LoadError or DecodeError if
+// the image couldn't be loaded.
+type Result struct {
+	ID     string
+	Result smartcrop.Result
+	Err    error
+
+	// Width and Height are the requested dimensions of the crop.
+	Width, Height int
+}
+
+// Options configures a Processor.
+type Options struct {
+	// Workers is the number of jobs processed at a time, defaulting to the
+	// number of CPUs.
+	Workers int
+	// QueueSize is the number of jobs waiting to be picked up by a worker,
+	// beyond which Submit blocks. It defaults to Workers.
+	QueueSize int
+
+	// Checkpoint, if set, records the jobs whose crops have been found, and
+	// Submit rejects jobs it has already recorded with ErrCompleted. Jobs are
+	// recorded by their ID and dimensions, so the crops of an image at other
+	// dimensions still get found. If the
+	// Results need to be persisted before a job counts as completed, leave it
+	// nil and record them with a Checkpoint of their own instead.
+	Checkpoint *Checkpoint
+
+	// Sidecars enables recording the crop of each job with a Path in the
+	// sidecar of its image, see smartcrop.Sidecar, so it ends up with the
+	// crops of all the jobs of the image. SidecarSettings are the settings
+	// of the analyzer to record in them, if any.
+	Sidecars        bool
+	SidecarSettings *smartcrop.CropSettings
+}
+
+// Processor finds the best crops of submitted jobs in the background.
+type Processor struct {
+	analyzer   smartcrop.ResultAnalyzer
+	checkpoint *Checkpoint
+	sidecars   bool
+	settings   *smartcrop.CropSettings
+
+	// sidecarMu serializes the updates of the sidecars, as several jobs may
+	// update the same one
+	sidecarMu sync.Mutex
+
+	room    chan struct{}
+	ready   chan struct{}
+	results chan Result
+	done    chan struct{}
+
+	mu      sync.Mutex
+	queue   jobQueue
+	seq     uint64
+	closed  bool
+	discard bool
+}
+
+// NewProcessor returns a new Processor finding the best crops with analyzer.
+// Its workers run until it gets closed.
+func NewProcessor(analyzer smartcrop.ResultAnalyzer, opts Options) *Processor {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = opts.Workers
+	}
+
+	p := &Processor{
+		analyzer:   analyzer,
+		checkpoint: opts.Checkpoint,
+		sidecars:   opts.Sidecars,
+		settings:   opts.SidecarSettings,
+		room:       make(chan struct{}, opts.QueueSize),
+		ready:      make(chan struct{}, opts.QueueSize),
+		results:    make(chan Result, opts.Workers),
+		done:       make(chan struct{}),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go func() {
+			defer wg.Done()
+			p.work()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(p.results)
+		close(p.done)
+	}()
+	return p
+}
+
+// Submit queues job, waiting for room in the queue if it's full. It returns
+// ErrClosed if the Processor has been closed, ErrCompleted if its Checkpoint
+// has recorded the job as completed, and the error of ctx if it gets
+// cancelled while waiting.
+func (p *Processor) Submit(ctx context.Context, job Job) error {
+	if p.checkpoint != nil && p.checkpoint.Done(checkpointKey(job)) {
+		return ErrCompleted
+	}
+
+	select {
+	case p.room <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		<-p.room
+		return ErrClosed
+	}
+	heap.Push(&p.queue, queuedJob{job: job, seq: p.seq})
+	p.seq++
+
+	// there are never more tokens than room in the queue, so this can't block
+	p.ready <- struct{}{}
+	return nil
+}
+
+// Results returns the channel the Results of the jobs get sent on. It has to
+// be drained for the workers to proceed, and gets closed once the Processor
+// has been closed and all its jobs are done.
+func (p *Processor) Results() <-chan Result {
+	return p.results
+}
+
+// Close stops accepting jobs. The queued jobs still get processed.
+func (p *Processor) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		p.closed = true
+		close(p.ready)
+	}
+}
+
+// Shutdown closes the Processor and waits for its queued jobs to be done. If
+// ctx ends first, the jobs still queued get discarded without a Result, and
+// the error of ctx is returned. The jobs in progress get finished either way.
+func (p *Processor) Shutdown(ctx context.Context) error {
+	p.Close()
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		p.discard = true
+		p.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// work processes queued jobs until the Processor is closed and its queue
+// drained.
+func (p *Processor) work() {
+	for range p.ready {
+		p.mu.Lock()
+		job := heap.Pop(&p.queue).(queuedJob).job
+		discard := p.discard
+		p.mu.Unlock()
+		<-p.room
+
+		if !discard {
+			p.results <- p.process(job)
+		}
+	}
+}
+
+// process finds the best crop of job.
+func (p *Processor) process(job Job) Result {
+	img := job.Image
+	if img == nil {
+		if job.Load == nil {
+			return Result{ID: job.ID, Err: smartcrop.ErrNilImage, Width: job.Width, Height: job.Height}
+		}
+		var err error
+		if img, err = job.Load(); err != nil {
+			if _, ok := err.(*DecodeError); !ok {
+				err = &LoadError{ID: job.ID, Err: err}
+			}
+			return Result{ID: job.ID, Err: err, Width: job.Width, Height: job.Height}
+		}
+	}
+
+	res, err := p.analyzer.FindBestResult(img, job.Width, job.Height)
+	if err == nil && p.sidecars && job.Path != "" {
+		err = p.updateSidecar(job, res)
+	}
+	if err == nil && p.checkpoint != nil {
+		err = p.checkpoint.Record(checkpointKey(job))
+	}
+	return Result{ID: job.ID, Result: res, Err: err, Width: job.Width, Height: job.Height}
+}
+
+// checkpointKey returns the key job gets recorded by in a Checkpoint. The
+// dimensions come first, so no ID can make two jobs collide.
+func checkpointKey(job Job) string {
+	return fmt.Sprintf("%dx%d %s", job.Width, job.Height, job.ID)
+}
+
+// updateSidecar records res, the crop of job, in the sidecar of its image.
+func (p *Processor) updateSidecar(job Job, res smartcrop.Result) error {
+	p.sidecarMu.Lock()
+	defer p.sidecarMu.Unlock()
+
+	s, err := smartcrop.ReadSidecar(job.Path)
+	if err != nil {
+		return err
+	}
+	s.Settings = p.settings
+	s.Add(job.Width, job.Height, res)
+	return smartcrop.WriteSidecar(job.Path, s)
+}
+
+// queuedJob is a Job in the queue, along with the order it was submitted in.
+type queuedJob struct {
+	job Job
+	seq uint64
+}
+
+// jobQueue is a priority queue of jobs, implementing heap.Interface.
+type jobQueue []queuedJob
+
+func (q jobQueue) Len() int { return len(q) }
+
+func (q jobQueue) Less(i, j int) bool {
+	if q[i].job.Priority != q[j].job.Priority {
+		return q[i].job.Priority > q[j].job.Priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q jobQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *jobQueue) Push(x interface{}) { *q = append(*q, x.(queuedJob)) }
+
+func (q *jobQueue) Pop() interface{} {
+	old := *q
+	job := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return job
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package batch
+
+import (
+	"context"
+	"image"
+	"testing"
+	"time"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+const testFile = "../examples/gopher.jpg"
+
+func newAnalyzer() smartcrop.ResultAnalyzer {
+	return smartcrop.NewAnalyzer(nfnt.NewDefaultResizer()).(smartcrop.ResultAnalyzer)
+}
+
+func TestProcessor(t *testing.T) {
+	p := NewProcessor(newAnalyzer(), Options{Workers: 2})
+	for _, id := range []string{"a", "b", "c"} {
+		job := FileJob(testFile, 250, 250)
+		job.ID = id
+		if err := p.Submit(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Close()
+	if err := p.Submit(context.Background(), FileJob(testFile, 250, 250)); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+
+	n := 0
+	for res := range p.Results() {
+		if res.Err != nil {
+			t.Fatalf("%s: %v", res.ID, res.Err)
+		}
+		if res.Result.Crop.Dx() == 0 || res.Result.Crop.Dx() != res.Result.Crop.Dy() {
+			t.Fatalf("%s: expected a square crop, got %v", res.ID, res.Result.Crop)
+		}
+		n++
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 results, got %d", n)
+	}
+}
+
+func TestProcessorPriority(t *testing.T) {
+	p := NewProcessor(newAnalyzer(), Options{Workers: 1, QueueSize: 3})
+
+	// keep the worker busy until all other jobs are queued
+	release := make(chan struct{})
+	block := FileJob(testFile, 100, 100)
+	block.ID = "block"
+	load := block.Load
+	block.Load = func() (image.Image, error) {
+		<-release
+		return load()
+	}
+	if err := p.Submit(context.Background(), block); err != nil {
+		t.Fatal(err)
+	}
+	for len(p.room) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	for i, id := range []string{"low", "high", "mid"} {
+		job := FileJob(testFile, 100, 100)
+		job.ID = id
+		job.Priority = []int{1, 3, 2}[i]
+		if err := p.Submit(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the queue is full now
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Submit(ctx, FileJob(testFile, 100, 100)); err != context.DeadlineExceeded {
+		t.Fatalf("expected the submission to time out, got %v", err)
+	}
+
+	close(release)
+	p.Close()
+	var order []string
+	for res := range p.Results() {
+		order = append(order, res.ID)
+	}
+	for i, id := range []string{"block", "high", "mid", "low"} {
+		if i >= len(order) || order[i] != id {
+			t.Fatalf("expected the jobs in order of priority, got %v", order)
+		}
+	}
+}
+
+func TestProcessorShutdown(t *testing.T) {
+	p := NewProcessor(newAnalyzer(), Options{Workers: 1, QueueSize: 2})
+
+	release := make(chan struct{})
+	block := Job{ID: "block", Width: 100, Height: 100, Load: func() (image.Image, error) {
+		<-release
+		return nil, context.Canceled
+	}}
+	for _, id := range []string{"block", "dropped"} {
+		job := block
+		job.ID = id
+		if err := p.Submit(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected the shutdown to time out, got %v", err)
+	}
+
+	close(release)
+	var ids []string
+	for res := range p.Results() {
+		ids = append(ids, res.ID)
+	}
+	if len(ids) != 1 || ids[0] != "block" {
+		t.Fatalf("expected only the job in progress to be done, got %v", ids)
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// defaultMaxExamples is the number of examples kept per failure reason by
+// default.
+const defaultMaxExamples = 10
+
+// Reason is the reason a job failed.
+type Reason string
+
+// The reasons a job can fail for.
+const (
+	// ReasonLoad means the image couldn't be read, e.g. because the file
+	// doesn't exist.
+	ReasonLoad Reason = "load"
+	// ReasonDecode means the image couldn't be decoded.
+	ReasonDecode Reason = "decode"
+	// ReasonAnalysis means the analysis of the image failed.
+	ReasonAnalysis Reason = "analysis"
+	// ReasonTooSmall means the crop is smaller than requested, so it would
+	// have to be upscaled.
+	ReasonTooSmall Reason = "too_small"
+	// ReasonLowConfidence means the confidence in the crop is below the
+	// MinConfidence of the Report.
+	ReasonLowConfidence Reason = "low_confidence"
+)
+
+// LoadError is the error of a job whose image couldn't be loaded.
+type LoadError struct {
+	ID  string
+	Err error
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("loading %s: %v", e.ID, e.Err)
+}
+
+// Unwrap returns the error the image couldn't be loaded with.
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is the error of a job whose image couldn't be decoded. Load
+// functions should return it to tell such images apart from the ones which
+// couldn't be read at all.
+type DecodeError struct {
+	ID  string
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decoding %s: %v", e.ID, e.Err)
+}
+
+// Unwrap returns the error the image couldn't be decoded with.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Failure is an example of a failed job.
+type Failure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// Failures are the failures of a batch run for one Reason.
+type Failures struct {
+	Count    int       `json:"count"`
+	Examples []Failure `json:"examples"`
+}
+
+// Report aggregates the Results of a batch run into a machine-readable
+// summary of its failures, counting them by Reason along with a few examples
+// each, so problem assets can be triaged automatically. It's safe for
+// concurrent use.
+type Report struct {
+	// MinConfidence is the confidence below which a crop counts as failed
+	// with ReasonLowConfidence. It's disabled if 0.
+	MinConfidence float64 `json:"minConfidence,omitempty"`
+	// MaxExamples is the number of examples kept per Reason, defaulting to
+	// 10.
+	MaxExamples int `json:"-"`
+
+	Total     int                  `json:"total"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Failures  map[Reason]*Failures `json:"failures"`
+
+	mu sync.Mutex
+}
+
+// Add adds res to the Report, returning the Reason it failed for, or an empty
+// Reason if it succeeded.
+func (r *Report) Add(res Result) Reason {
+	reason, msg := r.classify(res)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Total++
+	if reason == "" {
+		r.Succeeded++
+		return ""
+	}
+	r.Failed++
+
+	if r.Failures == nil {
+		r.Failures = make(map[Reason]*Failures)
+	}
+	f := r.Failures[reason]
+	if f == nil {
+		f = &Failures{}
+		r.Failures[reason] = f
+	}
+	f.Count++
+
+	max := r.MaxExamples
+	if max <= 0 {
+		max = defaultMaxExamples
+	}
+	if len(f.Examples) < max {
+		f.Examples = append(f.Examples, Failure{ID: res.ID, Error: msg})
+	}
+	return reason
+}
+
+// classify returns the Reason res failed for along with a description, or
+// an empty Reason if it succeeded.
+func (r *Report) classify(res Result) (Reason, string) {
+	switch err := res.Err.(type) {
+	case nil:
+	case *DecodeError:
+		return ReasonDecode, err.Error()
+	case *LoadError:
+		return ReasonLoad, err.Error()
+	default:
+		return ReasonAnalysis, err.Error()
+	}
+
+	crop := res.Result.Crop
+	if (res.Width > 0 && crop.Dx() < res.Width) || (res.Height > 0 && crop.Dy() < res.Height) {
+		return ReasonTooSmall, fmt.Sprintf("%dx%d crop is smaller than the requested %dx%d",
+			crop.Dx(), crop.Dy(), res.Width, res.Height)
+	}
+	if res.Result.Confidence < r.MinConfidence {
+		return ReasonLowConfidence, fmt.Sprintf("confidence %.2f is below %.2f",
+			res.Result.Confidence, r.MinConfidence)
+	}
+	return "", ""
+}
+
+// Reasons returns the Reasons jobs failed for, most frequent first.
+func (r *Report) Reasons() []Reason {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reasons := make([]Reason, 0, len(r.Failures))
+	for reason := range r.Failures {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		ci, cj := r.Failures[reasons[i]].Count, r.Failures[reasons[j]].Count
+		if ci != cj {
+			return ci > cj
+		}
+		return reasons[i] < reasons[j]
+	})
+	return reasons
+}
+
+// WriteJSON writes the Report to w as JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
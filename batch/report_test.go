@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/muesli/smartcrop"
+)
+
+func TestReport(t *testing.T) {
+	p := NewProcessor(newAnalyzer(), Options{Workers: 2})
+	jobs := []Job{
+		FileJob(testFile, 100, 100),
+		FileJob(testFile, 1000, 1000),
+		FileJob("../examples/missing.jpg", 100, 100),
+		FileJob("../README.md", 100, 100),
+		{ID: "broken", Width: 100, Height: 100, Load: func() (image.Image, error) {
+			return nil, &DecodeError{ID: "broken", Err: errors.New("unexpected EOF")}
+		}},
+	}
+	jobs[1].ID = "huge"
+	for _, job := range jobs {
+		if err := p.Submit(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Close()
+
+	r := &Report{MaxExamples: 1}
+	reasons := map[string]Reason{}
+	for res := range p.Results() {
+		reasons[res.ID] = r.Add(res)
+	}
+
+	expected := map[string]Reason{
+		testFile:                  "",
+		"huge":                    ReasonTooSmall,
+		"../examples/missing.jpg": ReasonLoad,
+		"../README.md":            ReasonDecode,
+		"broken":                  ReasonDecode,
+	}
+	for id, reason := range expected {
+		if reasons[id] != reason {
+			t.Errorf("expected %s to fail for %q, got %q", id, reason, reasons[id])
+		}
+	}
+	if r.Total != 5 || r.Succeeded != 1 || r.Failed != 4 {
+		t.Fatalf("expected 1 of 5 jobs to succeed, got %d of %d (%d failed)", r.Succeeded, r.Total, r.Failed)
+	}
+	if f := r.Failures[ReasonDecode]; f.Count != 2 || len(f.Examples) != 1 {
+		t.Fatalf("expected 2 decode failures with 1 example, got %+v", f)
+	}
+	if reasons := r.Reasons(); len(reasons) != 3 || reasons[0] != ReasonDecode {
+		t.Fatalf("expected the decode failures first, got %v", reasons)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Failed != 4 || decoded.Failures[ReasonLoad].Examples[0].ID != "../examples/missing.jpg" {
+		t.Fatalf("unexpected JSON report: %s", buf.String())
+	}
+}
+
+func TestReportConfidence(t *testing.T) {
+	r := &Report{MinConfidence: 0.5}
+	res := Result{ID: "a", Width: 10, Height: 10, Result: smartcrop.Result{
+		Crop:       smartcrop.Crop{Rectangle: image.Rect(0, 0, 10, 10)},
+		Confidence: 0.4,
+	}}
+	if reason := r.Add(res); reason != ReasonLowConfidence {
+		t.Fatalf("expected a low confidence, got %q", reason)
+	}
+	res.Result.Confidence = 0.6
+	if reason := r.Add(res); reason != "" {
+		t.Fatalf("expected the crop to succeed, got %q", reason)
+	}
+}
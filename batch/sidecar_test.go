@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package batch
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/muesli/smartcrop"
+)
+
+func TestProcessorSidecars(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smartcrop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "gopher.jpg")
+	b, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := smartcrop.DefaultCropSettings()
+	p := NewProcessor(newAnalyzer(), Options{Workers: 2, Sidecars: true, SidecarSettings: &settings})
+	for _, size := range [][2]int{{100, 100}, {160, 90}, {300, 300}} {
+		if err := p.Submit(context.Background(), FileJob(path, size[0], size[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Close()
+	for res := range p.Results() {
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+	}
+
+	s, err := smartcrop.ReadSidecar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Crops) != 2 || s.Crops["1:1"].Crop.Dx() == 0 || s.Crops["16:9"].Crop.Dx() == 0 || s.Settings == nil {
+		t.Errorf("expected the crops for 1:1 and 16:9 with the settings, got %+v", s)
+	}
+}
@@ -0,0 +1,254 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/muesli/smartcrop/options"
+)
+
+// manifestFile is the name of the manifest in the directory of a Store.
+const manifestFile = "manifest.jsonl"
+
+// Entry is an output of a job recorded in the manifest of a Store.
+type Entry struct {
+	ID     string `json:"id"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	// Path is the path of the output, relative to the directory of the
+	// Store.
+	Path string `json:"path"`
+}
+
+// StoreOptions configures a Store.
+type StoreOptions struct {
+	// Resizer, if set, scales the crops to the requested dimensions.
+	// Otherwise they get stored at the size they were cut out at.
+	Resizer options.Resizer
+
+	// Encode encodes the outputs, defaulting to JPEG at quality 90, and Ext
+	// is the extension of their files, defaulting to ".jpg".
+	Encode func(w io.Writer, img image.Image) error
+	Ext    string
+}
+
+// Store writes the cropped images of Results to content-addressed files
+// named after the sha256 sum of their encoded bytes, so outputs never change
+// once they're written and can be served as immutable URLs. Its manifest
+// maps the jobs to their outputs: each output gets appended to it as a line
+// of JSON once it's written, a later line replacing an earlier one of the
+// same job and dimensions, so a batch run writing the same outputs again
+// leaves the directory as it was.
+type Store struct {
+	dir  string
+	opts StoreOptions
+
+	mu       sync.Mutex
+	f        *os.File
+	manifest map[Entry]string
+}
+
+// OpenStore opens the Store in dir, creating the directory if it doesn't
+// exist yet.
+func OpenStore(dir string, opts StoreOptions) (*Store, error) {
+	if opts.Encode == nil {
+		opts.Encode = func(w io.Writer, img image.Image) error {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: 90})
+		}
+		if opts.Ext == "" {
+			opts.Ext = ".jpg"
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, manifestFile), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{dir: dir, opts: opts, f: f, manifest: make(map[Entry]string)}
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// start a new line after one cut short
+			if len(line) > 0 {
+				if _, err := f.WriteString("\n"); err != nil {
+					f.Close()
+					return nil, err
+				}
+			}
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err == nil {
+			s.manifest[e.key()] = e.Path
+		}
+	}
+	return s, nil
+}
+
+// Write crops img, the image of the job res is the Result of, stores the
+// output and records it in the manifest. It returns the path of the output
+// relative to the directory of the Store.
+func (s *Store) Write(img image.Image, res Result) (string, error) {
+	type SubImager interface {
+		SubImage(r image.Rectangle) image.Image
+	}
+
+	if res.Err != nil {
+		return "", res.Err
+	}
+	sub, ok := img.(SubImager)
+	if !ok {
+		rgba := image.NewRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Rect, img, rgba.Rect.Min, draw.Src)
+		sub = rgba
+	}
+	out := sub.SubImage(res.Result.Crop.Rectangle)
+	if s.opts.Resizer != nil && res.Width > 0 && res.Height > 0 {
+		out = s.opts.Resizer.Resize(out, uint(res.Width), uint(res.Height))
+	}
+
+	var buf bytes.Buffer
+	if err := s.opts.Encode(&buf, out); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	name := hex.EncodeToString(sum[:])
+	path := filepath.Join(name[:2], name+s.opts.Ext)
+	if err := s.create(path, buf.Bytes()); err != nil {
+		return "", err
+	}
+
+	e := Entry{ID: res.ID, Width: res.Width, Height: res.Height, Path: filepath.ToSlash(path)}
+	return e.Path, s.record(e)
+}
+
+// create writes the file at path below the directory of the Store, unless it
+// exists already. It's written to a temporary file first, so it's never seen
+// incomplete.
+func (s *Store) create(path string, data []byte) error {
+	path = filepath.Join(s.dir, path)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// record appends e to the manifest, unless it's recorded already.
+func (s *Store) record(e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if path, ok := s.manifest[e.key()]; ok && path == e.Path {
+		return nil
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := s.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.manifest[e.key()] = e.Path
+	return nil
+}
+
+// Manifest returns the outputs recorded in the manifest by the IDs of their
+// jobs, ordered by their dimensions.
+func (s *Store) Manifest() map[string][]Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := make(map[string][]Entry)
+	for k, path := range s.manifest {
+		k.Path = path
+		m[k.ID] = append(m[k.ID], k)
+	}
+	for _, entries := range m {
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].Width != entries[j].Width {
+				return entries[i].Width < entries[j].Width
+			}
+			return entries[i].Height < entries[j].Height
+		})
+	}
+	return m
+}
+
+// Close closes the manifest of the Store.
+func (s *Store) Close() error {
+	return s.f.Close()
+}
+
+// key returns e without its Path, identifying the output of a job.
+func (e Entry) key() Entry {
+	e.Path = ""
+	return e
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package batch
+
+import (
+	"image"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smartcrop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	img := image.NewGray(image.Rect(0, 0, 400, 200))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	res := Result{ID: "a.jpg", Width: 100, Height: 100}
+	res.Result.Crop.Rectangle = image.Rect(50, 0, 250, 200)
+
+	s, err := OpenStore(dir, StoreOptions{Resizer: nfnt.NewDefaultResizer()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := s.Write(img, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filepath.Join(dir, path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := jpeg.DecodeConfig(f)
+	f.Close()
+	if err != nil || c.Width != 100 || c.Height != 100 {
+		t.Fatalf("expected a 100x100 output, got %dx%d (%v)", c.Width, c.Height, err)
+	}
+
+	// the same output for another job gets stored once
+	other := res
+	other.ID = "b.jpg"
+	if p, err := s.Write(img, other); err != nil || p != path {
+		t.Fatalf("expected %s for an identical output, got %s (%v)", path, p, err)
+	}
+	res.Result.Crop.Rectangle = image.Rect(150, 0, 350, 200)
+	moved, err := s.Write(img, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved == path {
+		t.Fatal("expected another path for a different crop")
+	}
+	s.Close()
+
+	// a re-run writing the same outputs doesn't change anything
+	manifest, err := ioutil.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = OpenStore(dir, StoreOptions{Resizer: nfnt.NewDefaultResizer()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if p, err := s.Write(img, res); err != nil || p != moved {
+		t.Fatalf("expected %s again, got %s (%v)", moved, p, err)
+	}
+	if again, _ := ioutil.ReadFile(filepath.Join(dir, manifestFile)); string(again) != string(manifest) {
+		t.Errorf("expected the manifest to be unchanged, got\n%s", again)
+	}
+
+	m := s.Manifest()
+	if len(m) != 2 || len(m["a.jpg"]) != 1 || m["a.jpg"][0].Path != moved || m["b.jpg"][0].Path != path {
+		t.Errorf("expected a.jpg at %s and b.jpg at %s, got %v", moved, path, m)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*", "*.jpg"))
+	if len(files) != 2 {
+		t.Errorf("expected 2 outputs, got %v", files)
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import "math"
+
+// blob is a salient region of a saliencyMap, i.e. a connected component of
+// salient pixels.
+type blob struct {
+	// pixels are the indices of the pixels of the blob in the map.
+	pixels []int
+	// x0, y0, x1 and y1 are the bounds of the blob, exclusive of x1 and y1.
+	x0, y0, x1, y1 int
+	mass           float64
+}
+
+// blobs returns the 4-connected regions of salient pixels of m.
+func (m *saliencyMap) blobs() []blob {
+	if m.max <= 0 {
+		return nil
+	}
+
+	var blobs []blob
+	seen := make([]bool, len(m.values))
+	var stack []int
+	for start := range m.values {
+		if seen[start] || !m.salient(start%m.width, start/m.width) {
+			continue
+		}
+
+		b := blob{x0: m.width, y0: m.height}
+		seen[start] = true
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x, y := i%m.width, i/m.width
+			b.pixels = append(b.pixels, i)
+			b.mass += m.values[i]
+			b.x0, b.y0 = minInt(b.x0, x), minInt(b.y0, y)
+			b.x1, b.y1 = maxInt(b.x1, x+1), maxInt(b.y1, y+1)
+
+			for _, d := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+				nx, ny := x+d[0], y+d[1]
+				if j := ny*m.width + nx; m.salient(nx, ny) && !seen[j] {
+					seen[j] = true
+					stack = append(stack, j)
+				}
+			}
+		}
+		blobs = append(blobs, b)
+	}
+	return blobs
+}
+
+// blobCuts rates how badly crops cut through the blobs of a saliencyMap.
+type blobCuts struct {
+	m     *saliencyMap
+	blobs []blob
+	total float64
+}
+
+func newBlobCuts(m *saliencyMap) *blobCuts {
+	c := &blobCuts{m: m, blobs: m.blobs()}
+	for _, b := range c.blobs {
+		c.total += b.mass
+	}
+	return c
+}
+
+// cut returns how badly crop r, in coordinates of the prescaled image, cuts
+// through the blobs, from 0 if it either fully includes or fully excludes
+// each of them, to 1 if it cuts all of them in half. Blobs count by their
+// share of the mass of all blobs.
+func (c *blobCuts) cut(r rect) float64 {
+	if c.total <= 0 {
+		return 0
+	}
+
+	red := float64(c.m.reduction)
+	x0, y0 := int(math.Round(r.x/red)), int(math.Round(r.y/red))
+	x1, y1 := int(math.Round((r.x+r.w)/red)), int(math.Round((r.y+r.h)/red))
+
+	cut := 0.0
+	for _, b := range c.blobs {
+		if b.x0 >= x1 || b.x1 <= x0 || b.y0 >= y1 || b.y1 <= y0 {
+			continue
+		}
+		if b.x0 >= x0 && b.x1 <= x1 && b.y0 >= y0 && b.y1 <= y1 {
+			continue
+		}
+
+		inside := 0.0
+		for _, i := range b.pixels {
+			if x, y := i%c.m.width, i/c.m.width; x >= x0 && x < x1 && y >= y0 && y < y1 {
+				inside += c.m.values[i]
+			}
+		}
+		f := inside / b.mass
+		cut += b.mass / c.total * 4.0 * f * (1.0 - f)
+	}
+	return cut
+}
+
+// penalize lowers the total score of crop by s.BlobPenalty times how badly it
+// cuts through the blobs.
+func (c *blobCuts) penalize(s *CropSettings, r rect, score *Score) {
+	score.Cut = c.cut(r)
+	score.Total -= s.BlobPenalty * score.Cut * math.Abs(score.Total)
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestBlobCuts(t *testing.T) {
+	m := &saliencyMap{values: make([]float64, 20*10), width: 20, height: 10, max: 1.0, radius: 1, reduction: 1}
+	for y := 2; y < 6; y++ {
+		for x := 4; x < 8; x++ {
+			m.values[y*m.width+x] = 1.0
+		}
+	}
+
+	c := newBlobCuts(m)
+	if len(c.blobs) != 1 {
+		t.Fatalf("expected 1 blob, got %d", len(c.blobs))
+	}
+	for _, tc := range []struct {
+		r        rect
+		expected float64
+	}{
+		{rect{0, 0, 20, 10}, 0.0},
+		{rect{10, 0, 10, 10}, 0.0},
+		{rect{0, 0, 6, 10}, 1.0},
+		{rect{0, 0, 5, 10}, 0.75},
+	} {
+		if cut := c.cut(tc.r); math.Abs(cut-tc.expected) > 1e-9 {
+			t.Errorf("expected cut %v for %v, got %v", tc.expected, tc.r, cut)
+		}
+	}
+}
+
+func TestBlobPenalty(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cut := func(penalty float64) float64 {
+		settings := DefaultCropSettings()
+		settings.BlobPenalty = penalty
+		analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ResultAnalyzer)
+		res, err := analyzer.FindBestResult(img, 150, 250)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Crop.Score.Cut
+	}
+
+	// a negligible penalty only reports the cut
+	unpenalized, penalized := cut(1e-9), cut(1.0)
+	if unpenalized <= 0 {
+		t.Fatalf("expected the best crop to cut through a blob, got %v", unpenalized)
+	}
+	if penalized >= unpenalized {
+		t.Fatalf("expected the penalty to avoid cutting through blobs, got %v, before %v", penalized, unpenalized)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// maxDegradation is the coarsest level of degradation, see degrade.
+	maxDegradation = 5
+	// opCostSmoothing is the weight of a new observation of the cost of an
+	// operation in its moving average.
+	opCostSmoothing = 0.2
+)
+
+// opCost is the moving average of the time an operation of an Estimate takes,
+// in nanoseconds. It follows the load of the machine.
+var opCost = struct {
+	sync.Mutex
+	ns float64
+}{ns: 30}
+
+// degrade returns s coarsened to the given level of degradation, ranging from
+// 0, which leaves s as it is, to maxDegradation. Every level makes the
+// analysis cheaper than the previous one.
+func degrade(s CropSettings, level int) CropSettings {
+	if level >= 1 {
+		s.Step *= 2
+		s.StepFraction *= 2
+	}
+	if level >= 2 {
+		s.ScaleStep *= 2
+	}
+	if level >= 3 {
+		s.PrescaleMin = math.Round(s.PrescaleMin * 2.0 / 3.0)
+		s.ReducedPlanes = true
+	}
+	if level >= 4 {
+		s.ScoreDownSample *= 2
+		s.Step *= 2
+		s.StepFraction *= 2
+	}
+	if level >= 5 {
+		s.PrescaleMin = math.Round(s.PrescaleMin / 2.0)
+		s.ScaleStep = math.Max(s.MaxScale-s.MinScale, s.ScaleStep)
+	}
+	return s
+}
+
+// forBudget returns the least degraded settings an analysis of an image with
+// the given dimensions is predicted to finish within the Budget with, along
+// with the level of degradation. Without a Budget, s is returned as it is.
+func (s CropSettings) forBudget(imgWidth, imgHeight, width, height int) (CropSettings, int) {
+	if s.Budget <= 0 {
+		return s, 0
+	}
+
+	opCost.Lock()
+	ns := opCost.ns
+	opCost.Unlock()
+
+	for level := 0; level < maxDegradation; level++ {
+		d := degrade(s, level)
+		e, err := EstimateCost(imgWidth, imgHeight, width, height, d)
+		if err != nil {
+			return s, 0
+		}
+		if time.Duration(float64(e.DetectorOps+e.ScoringOps)*ns) <= s.Budget {
+			return d, level
+		}
+	}
+	return degrade(s, maxDegradation), maxDegradation
+}
+
+// observeOpCost updates the cost of an operation from an analysis which took
+// d.
+func observeOpCost(e Estimate, d time.Duration) {
+	ops := e.DetectorOps + e.ScoringOps
+	if ops <= 0 {
+		return
+	}
+
+	opCost.Lock()
+	defer opCost.Unlock()
+	opCost.ns += opCostSmoothing * (float64(d.Nanoseconds())/float64(ops) - opCost.ns)
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestDegrade(t *testing.T) {
+	s := DefaultCropSettings()
+	prev, _ := EstimateCost(1200, 800, 250, 250, s)
+	for level := 1; level <= maxDegradation; level++ {
+		e, err := EstimateCost(1200, 800, 250, 250, degrade(s, level))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.DetectorOps+e.ScoringOps >= prev.DetectorOps+prev.ScoringOps {
+			t.Fatalf("expected level %d to be cheaper than %+v, got %+v", level, prev, e)
+		}
+		prev = e
+	}
+}
+
+func TestBudget(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	find := func(budget time.Duration) Result {
+		settings := DefaultCropSettings()
+		settings.Budget = budget
+		analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ResultAnalyzer)
+		res, err := analyzer.FindBestResult(img, 250, 250)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	if res := find(time.Hour); res.Degradation != 0 {
+		t.Fatalf("expected no degradation with a generous budget, got %d", res.Degradation)
+	}
+	res := find(time.Nanosecond)
+	if res.Degradation != maxDegradation {
+		t.Fatalf("expected full degradation with a tiny budget, got %d", res.Degradation)
+	}
+	if res.Crop.Dx() != res.Crop.Dy() {
+		t.Fatalf("expected a degraded crop of the requested ratio, got %v", res.Crop.Rectangle)
+	}
+}
+
+func TestMaxDuration(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	find := func(d time.Duration) Result {
+		settings := DefaultCropSettings()
+		settings.MaxDuration = d
+		settings.Refine = true
+		analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ResultAnalyzer)
+		res, err := analyzer.FindBestResult(img, 250, 250)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	if res := find(time.Hour); res.Truncated {
+		t.Fatal("expected a generous MaxDuration not to truncate the analysis")
+	}
+	res := find(time.Nanosecond)
+	if !res.Truncated {
+		t.Fatal("expected a tiny MaxDuration to truncate the analysis")
+	}
+	if res.Crop.Dx() == 0 || res.Crop.Dx() != res.Crop.Dy() {
+		t.Fatalf("expected a truncated crop of the requested ratio, got %v", res.Crop.Rectangle)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"sync"
+)
+
+const (
+	// burstDistance is the number of bits the hashes of two images may
+	// differ in for them to count as near-identical.
+	burstDistance = 6
+	// burstSamples is the number of pixels sampled per dimension of each
+	// cell of the hash.
+	burstSamples = 8
+)
+
+// BurstCache is a ResultAnalyzer reusing the crops of recently analysed,
+// near-identical images, like burst shots or re-exports of the same photo, by
+// comparing their perceptual hashes. Reused crops get adjusted to the
+// dimensions of the new image and are flagged as Reused in the Result. It is
+// safe for concurrent use.
+type BurstCache struct {
+	analyzer ResultAnalyzer
+	size     int
+
+	mu      sync.Mutex
+	entries []burstEntry
+}
+
+// burstEntry is a recently analysed image.
+type burstEntry struct {
+	hash          uint64
+	aspect        float64
+	width, height int
+	result        Result
+}
+
+// NewBurstCache returns a BurstCache remembering the last size images analysed
+// by analyzer.
+func NewBurstCache(analyzer ResultAnalyzer, size int) *BurstCache {
+	return &BurstCache{analyzer: analyzer, size: size}
+}
+
+// FindBestCrop implements Analyzer.
+func (c *BurstCache) FindBestCrop(img image.Image, width, height int) (image.Rectangle, error) {
+	res, err := c.FindBestResult(img, width, height)
+	return res.Crop.Rectangle, err
+}
+
+// FindBestResult implements ResultAnalyzer. The crop of a near-identical
+// image of the same aspect ratio, requested for the same aspect ratio, gets
+// reused; otherwise img gets analysed.
+func (c *BurstCache) FindBestResult(img image.Image, width, height int) (Result, error) {
+	width, height, err := validateImage(img, width, height)
+	if err != nil {
+		return Result{}, err
+	}
+
+	b := img.Bounds()
+	hash := dHash(img)
+	aspect := float64(b.Dx()) / float64(b.Dy())
+
+	c.mu.Lock()
+	for i := len(c.entries) - 1; i >= 0; i-- {
+		e := c.entries[i]
+		if bits.OnesCount64(e.hash^hash) > burstDistance ||
+			math.Abs(e.aspect/aspect-1.0) > 0.01 ||
+			e.width*height != e.height*width {
+			continue
+		}
+
+		// keep the entry the most recent one
+		c.entries = append(append(c.entries[:i:i], c.entries[i+1:]...), e)
+		c.mu.Unlock()
+		return e.result.rebase(b, float64(width)/float64(height)), nil
+	}
+	c.mu.Unlock()
+
+	res, err := c.analyzer.FindBestResult(img, width, height)
+	if err != nil {
+		return Result{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, burstEntry{hash: hash, aspect: aspect, width: width, height: height, result: res})
+	if len(c.entries) > c.size {
+		c.entries = append(c.entries[:0:0], c.entries[len(c.entries)-c.size:]...)
+	}
+	return res, nil
+}
+
+// rebase returns a copy of res, flagged as Reused, with its normalized crop
+// mapped onto an image with the given bounds.
+func (res Result) rebase(bounds image.Rectangle, ratio float64) Result {
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	norm := res.Normalized
+
+	res.ImageWidth, res.ImageHeight = bounds.Dx(), bounds.Dy()
+	res.Crop.Rectangle = norm.Rect(bounds, ratio)
+	res.FloatCrop = FloatRect{
+		X:      float64(bounds.Min.X) + norm.X*w,
+		Y:      float64(bounds.Min.Y) + norm.Y*h,
+		Width:  norm.Width * w,
+		Height: norm.Height * h,
+	}
+	res.Reused = true
+	return res
+}
+
+// dHash returns the difference hash of img: whether the brightness increases
+// between horizontally adjacent cells of a 9×8 grid.
+func dHash(img image.Image) uint64 {
+	t := getColorTables()
+	b := img.Bounds()
+
+	var cells [8][9]float64
+	for cy := 0; cy < 8; cy++ {
+		for cx := 0; cx < 9; cx++ {
+			sum := 0.0
+			for sy := 0; sy < burstSamples; sy++ {
+				y := b.Min.Y + ((cy*burstSamples+sy)*b.Dy()+b.Dy()/2)/(8*burstSamples)
+				for sx := 0; sx < burstSamples; sx++ {
+					x := b.Min.X + ((cx*burstSamples+sx)*b.Dx()+b.Dx()/2)/(9*burstSamples)
+					r, g, bl, _ := img.At(x, y).RGBA()
+					sum += t.cie(uint8(r>>8), uint8(g>>8), uint8(bl>>8))
+				}
+			}
+			cells[cy][cx] = sum
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if cells[y][x+1] > cells[y][x] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
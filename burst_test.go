@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+// countingAnalyzer counts the analyses of the wrapped ResultAnalyzer.
+type countingAnalyzer struct {
+	ResultAnalyzer
+	n int
+}
+
+func (a *countingAnalyzer) FindBestResult(img image.Image, width, height int) (Result, error) {
+	a.n++
+	return a.ResultAnalyzer.FindBestResult(img, width, height)
+}
+
+func TestBurstCache(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resizer := nfnt.NewDefaultResizer()
+	analyzer := &countingAnalyzer{ResultAnalyzer: NewAnalyzer(resizer).(ResultAnalyzer)}
+	cache := NewBurstCache(analyzer, 4)
+
+	res, err := cache.FindBestResult(img, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Reused {
+		t.Fatal("expected the first image to get analysed")
+	}
+
+	// a re-export at a lower quality and half the size
+	var buf bytes.Buffer
+	half := resizer.Resize(img, uint(img.Bounds().Dx()/2), 0)
+	if err := jpeg.Encode(&buf, half, &jpeg.Options{Quality: 40}); err != nil {
+		t.Fatal(err)
+	}
+	reexport, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reused, err := cache.FindBestResult(reexport, 100, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reused.Reused || analyzer.n != 1 {
+		t.Fatalf("expected the crop to get reused, got %d analyses", analyzer.n)
+	}
+	r := res.Crop.Rectangle
+	expected := image.Rect(r.Min.X/2, r.Min.Y/2, r.Max.X/2, r.Max.Y/2)
+	if d := reused.Crop.Rectangle; absInt(d.Min.X-expected.Min.X) > 1 || absInt(d.Dx()-expected.Dx()) > 1 {
+		t.Fatalf("expected crop %v, got %v", expected, d)
+	}
+
+	// a different aspect ratio of the crop can't be reused
+	if res, _ := cache.FindBestResult(img, 100, 200); res.Reused {
+		t.Fatal("expected a crop of another aspect ratio to get analysed")
+	}
+
+	// nor can the crop of a different image
+	mirrored := image.NewRGBA(img.Bounds())
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			mirrored.Set(b.Max.X-1-(x-b.Min.X), y, img.At(x, y))
+		}
+	}
+	if res, _ := cache.FindBestResult(mirrored, 250, 250); res.Reused {
+		t.Fatal("expected a different image to get analysed")
+	}
+	if analyzer.n != 3 {
+		t.Fatalf("expected 3 analyses, got %d", analyzer.n)
+	}
+}
@@ -0,0 +1,13 @@
+module github.com/muesli/smartcrop/cmd
+
+go 1.12
+
+require (
+	github.com/muesli/smartcrop v0.0.0-20261016125610-7709603b20e0
+	github.com/muesli/smartcrop/yaml v0.0.0-20261016125610-7709603b20e0
+)
+
+replace (
+	github.com/muesli/smartcrop => ../
+	github.com/muesli/smartcrop/yaml => ../yaml
+)
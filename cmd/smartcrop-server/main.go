@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+// smartcrop-server serves crop requests over HTTP, see package server.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/server"
+	_ "github.com/muesli/smartcrop/yaml"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	metrics := flag.Bool("metrics", false, "expose expvar metrics at /debug/vars")
+	profiling := flag.Bool("pprof", false, "expose the pprof endpoints at /debug/pprof/")
+	maxConcurrent := flag.Int("max-concurrent", 0, "maximum number of images analysed at a time, 0 for no limit")
+	maxQueue := flag.Int("max-queue", 0, "maximum number of requests waiting for an analysis")
+	queueTimeout := flag.Duration("queue-timeout", time.Second, "maximum time a request waits for an analysis")
+	config := flag.String("config", "", "config file of the settings, defaults to $SMARTCROP_CONFIG")
+	profile := flag.String("profile", "", "profile of the config file, defaults to $SMARTCROP_PROFILE")
+	imaginary := flag.Bool("imaginary", false, "serve the imaginary API instead of the JSON one")
+	fetch := flag.Bool("fetch", false, "fetch images from public hosts by the url parameter")
+	auditLog := flag.String("audit-log", "", "file to append a JSON line to for every crop decision")
+	flag.Parse()
+
+	// SMARTCROP_* variables override the settings of the config file
+	settings, err := smartcrop.LoadEnvSettings(*config, *profile, os.Environ())
+	if err != nil {
+		log.Fatalf("can't load settings: %v", err)
+	}
+	opts := server.Options{
+		Settings:      &settings,
+		Metrics:       *metrics,
+		Profiling:     *profiling,
+		MaxConcurrent: *maxConcurrent,
+		MaxQueue:      *maxQueue,
+		QueueTimeout:  *queueTimeout,
+		Imaginary:     *imaginary,
+	}
+	if *fetch {
+		opts.Fetch = &server.FetchOptions{}
+	}
+	if *auditLog != "" {
+		f, err := os.OpenFile(*auditLog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			log.Fatalf("can't open audit log: %v", err)
+		}
+		defer f.Close()
+		opts.AuditLog = smartcrop.NewAuditLog(f)
+	}
+	s := server.New(opts)
+
+	fmt.Printf("Listening on http://%s\n", *addr)
+	log.Fatal(http.ListenAndServe(*addr, s))
+}
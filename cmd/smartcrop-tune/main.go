@@ -0,0 +1,241 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+// smartcrop-tune serves a web UI for tuning the crop settings: it shows the
+// detector planes and the best crop of the images in a folder, updating them
+// live while the weights and thresholds get adjusted.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+type server struct {
+	dir string
+
+	mu     sync.Mutex
+	images map[string]image.Image
+}
+
+func main() {
+	dir := flag.String("dir", ".", "folder containing the images to tune on")
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	flag.Parse()
+
+	s := &server{dir: *dir, images: map[string]image.Image{}}
+	http.HandleFunc("/", s.handleIndex)
+	http.HandleFunc("/images", s.handleImages)
+	http.HandleFunc("/image", s.handleImage)
+	http.HandleFunc("/defaults", s.handleDefaults)
+	http.HandleFunc("/crop", s.handleCrop)
+	http.HandleFunc("/heatmap", s.handleHeatmap)
+
+	fmt.Printf("Serving %s on http://%s\n", *dir, *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+// names returns the names of all images in the folder.
+func (s *server) names() ([]string, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range files {
+		switch strings.ToLower(filepath.Ext(f.Name())) {
+		case ".jpg", ".jpeg", ".png":
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// image returns the decoded image with the given name, which must be one of
+// the names of the folder.
+func (s *server) image(name string) (image.Image, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if img, ok := s.images[name]; ok {
+		return img, nil
+	}
+	if name != filepath.Base(name) || name == "." || name == ".." {
+		return nil, fmt.Errorf("invalid image name %q", name)
+	}
+
+	f, err := os.Open(filepath.Join(s.dir, name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	s.images[name] = img
+	return img, nil
+}
+
+// analyze runs the analysis requested by r and returns its final state.
+func (s *server) analyze(r *http.Request) (*smartcrop.State, error) {
+	img, err := s.image(r.FormValue("name"))
+	if err != nil {
+		return nil, err
+	}
+
+	width, _ := strconv.Atoi(r.FormValue("width"))
+	height, _ := strconv.Atoi(r.FormValue("height"))
+	if width <= 0 || height <= 0 {
+		width, height = 1, 1
+	}
+
+	settings := smartcrop.DefaultCropSettings()
+	if v := r.FormValue("settings"); v != "" {
+		if err := json.Unmarshal([]byte(v), &settings); err != nil {
+			return nil, err
+		}
+	}
+
+	var state *smartcrop.State
+	settings.Hooks.AddAfter(smartcrop.StageSelect, func(stage smartcrop.Stage, st *smartcrop.State) error {
+		state = st
+		return nil
+	})
+
+	analyzer := smartcrop.NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), smartcrop.Logger{}, settings)
+	if _, err := analyzer.(smartcrop.ResultAnalyzer).FindBestResult(img, width, height); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s *server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, indexHTML)
+}
+
+func (s *server) handleImages(w http.ResponseWriter, r *http.Request) {
+	names, err := s.names()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, names)
+}
+
+func (s *server) handleImage(w http.ResponseWriter, r *http.Request) {
+	img, err := s.image(r.FormValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	png.Encode(w, img)
+}
+
+func (s *server) handleDefaults(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, smartcrop.DefaultCropSettings())
+}
+
+func (s *server) handleCrop(w http.ResponseWriter, r *http.Request) {
+	st, err := s.analyze(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, st.Result)
+}
+
+// handleHeatmap renders the detector planes of the prescaled image, with the
+// best crop outlined in white.
+func (s *server) handleHeatmap(w http.ResponseWriter, r *http.Request) {
+	st, err := s.analyze(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b := st.Detected.Bounds()
+	heat := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := st.Detected.RGBAAt(x, y)
+			c.A = 255
+			heat.SetRGBA(x, y, c)
+		}
+	}
+
+	n := st.Result.Normalized
+	crop := image.Rect(
+		b.Min.X+int(n.X*float64(b.Dx())), b.Min.Y+int(n.Y*float64(b.Dy())),
+		b.Min.X+int((n.X+n.Width)*float64(b.Dx()))-1, b.Min.Y+int((n.Y+n.Height)*float64(b.Dy()))-1,
+	)
+	white := color.RGBA{255, 255, 255, 255}
+	for x := crop.Min.X; x <= crop.Max.X; x++ {
+		heat.SetRGBA(x, crop.Min.Y, white)
+		heat.SetRGBA(x, crop.Max.Y, white)
+	}
+	for y := crop.Min.Y; y <= crop.Max.Y; y++ {
+		heat.SetRGBA(crop.Min.X, y, white)
+		heat.SetRGBA(crop.Max.X, y, white)
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	png.Encode(w, heat)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("can't encode response:", err)
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package main
+
+// indexHTML is the tuning UI. Its sliders update the settings, which get sent
+// along with every request for a crop or heatmap.
+const indexHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>smartcrop tuning</title>
+<style>
+body { font-family: sans-serif; margin: 0; display: flex; }
+#controls { width: 320px; padding: 1em; background: #eee; height: 100vh; overflow-y: auto; box-sizing: border-box; }
+#controls label { display: block; margin-top: .6em; font-size: .85em; }
+#controls input[type=range] { width: 100%; }
+#main { flex: 1; padding: 1em; }
+#preview { display: flex; gap: 1em; align-items: flex-start; }
+#preview div { position: relative; }
+#preview img { max-width: 45vw; display: block; }
+#box { position: absolute; border: 2px solid #f0f; box-sizing: border-box; }
+#result { font-family: monospace; white-space: pre; font-size: .8em; }
+</style>
+</head>
+<body>
+<div id="controls">
+  <label>Image <select id="name"></select></label>
+  <label>Width <input id="width" type="number" value="250"></label>
+  <label>Height <input id="height" type="number" value="250"></label>
+  <div id="sliders"></div>
+</div>
+<div id="main">
+  <div id="preview">
+    <div><img id="image"><div id="box"></div></div>
+    <div><img id="heatmap"></div>
+  </div>
+  <div id="result"></div>
+</div>
+<script>
+var sliders = [
+  {key: "detailWeight", min: 0, max: 1, step: 0.01},
+  {key: "skinWeight", min: 0, max: 5, step: 0.05},
+  {key: "skinBias", min: 0, max: 0.1, step: 0.001},
+  {key: "skinThreshold", min: 0, max: 1, step: 0.01},
+  {key: "skinBrightnessMin", min: 0, max: 1, step: 0.01},
+  {key: "skinBrightnessMax", min: 0, max: 1, step: 0.01},
+  {key: "saturationWeight", min: 0, max: 1, step: 0.01},
+  {key: "saturationBias", min: 0, max: 1, step: 0.01},
+  {key: "saturationThreshold", min: 0, max: 1, step: 0.01},
+  {key: "saturationBrightnessMin", min: 0, max: 1, step: 0.01},
+  {key: "saturationBrightnessMax", min: 0, max: 1, step: 0.01},
+  {key: "edgeRadius", min: 0, max: 1, step: 0.01},
+  {key: "edgeWeight", min: -1, max: 0, step: 0.01},
+  {key: "outsideImportance", min: -1, max: 0, step: 0.01},
+  {key: "minScale", min: 0.1, max: 1, step: 0.05}
+];
+var settings = {};
+var pending = null;
+var last = null;
+
+function $(id) { return document.getElementById(id); }
+
+function query() {
+  return "name=" + encodeURIComponent($("name").value) +
+    "&width=" + $("width").value + "&height=" + $("height").value +
+    "&settings=" + encodeURIComponent(JSON.stringify(settings));
+}
+
+function drawBox() {
+  if (!last) return;
+  var img = $("image"), box = $("box"), n = last.normalized;
+  box.style.left = (n.x * img.width) + "px";
+  box.style.top = (n.y * img.height) + "px";
+  box.style.width = (n.width * img.width) + "px";
+  box.style.height = (n.height * img.height) + "px";
+}
+
+function update() {
+  clearTimeout(pending);
+  pending = setTimeout(function() {
+    var q = query();
+    $("heatmap").src = "/heatmap?" + q;
+    fetch("/crop?" + q).then(function(r) { return r.json(); }).then(function(res) {
+      last = res;
+      drawBox();
+      $("result").textContent = JSON.stringify({settings: settings, crop: res.crop}, null, 2);
+    });
+  }, 150);
+}
+
+function load() {
+  last = null;
+  $("image").src = "/image?name=" + encodeURIComponent($("name").value);
+  update();
+}
+
+fetch("/defaults").then(function(r) { return r.json(); }).then(function(d) {
+  settings = d;
+  sliders.forEach(function(s) {
+    var label = document.createElement("label");
+    var input = document.createElement("input");
+    var value = document.createElement("span");
+    input.type = "range";
+    input.min = s.min; input.max = s.max; input.step = s.step;
+    input.value = settings[s.key];
+    value.textContent = " " + settings[s.key];
+    input.oninput = function() {
+      settings[s.key] = parseFloat(input.value);
+      value.textContent = " " + input.value;
+      update();
+    };
+    label.textContent = s.key;
+    label.appendChild(value);
+    label.appendChild(input);
+    $("sliders").appendChild(label);
+  });
+  return fetch("/images");
+}).then(function(r) { return r.json(); }).then(function(names) {
+  (names || []).forEach(function(n) {
+    var o = document.createElement("option");
+    o.value = o.textContent = n;
+    $("name").appendChild(o);
+  });
+  load();
+});
+
+$("name").onchange = load;
+$("width").onchange = $("height").onchange = update;
+$("image").onload = drawBox;
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,449 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+// thumbnailSize is the size of the longer side of the images in a report.
+const thumbnailSize = 320
+
+// configFlags collects the values of a repeated flag.
+type configFlags []string
+
+func (c *configFlags) String() string {
+	return strings.Join(*c, ",")
+}
+
+func (c *configFlags) Set(v string) error {
+	*c = append(*c, v)
+	return nil
+}
+
+// comparison is a row of a report: the crops of an image found by each of
+// the compared sides.
+type comparison struct {
+	Name     string
+	Original template.URL
+	Crops    []smartcrop.Crop
+	Images   []template.URL
+	Overlap  float64
+}
+
+// recording contains the crops of the images in a folder found by a version
+// of the library, to compare the crops of another version to, e.g. the ones
+// cached by an application before upgrading.
+type recording struct {
+	Version    string                    `json:"version"`
+	ParamsHash string                    `json:"paramsHash"`
+	Width      int                       `json:"width"`
+	Height     int                       `json:"height"`
+	Crops      map[string]smartcrop.Crop `json:"crops"`
+}
+
+// side is what finds the crops of one side of a comparison: either the
+// library with the settings of a config file, or a recording.
+type side struct {
+	Label    string
+	settings smartcrop.CropSettings
+	analyzer smartcrop.ResultAnalyzer
+	recorded *recording
+}
+
+// crop returns the crop of img, the image at path, found by the side.
+func (s side) crop(path string, img image.Image, w, h int) (smartcrop.Crop, error) {
+	if s.recorded != nil {
+		crop, ok := s.recorded.Crops[filepath.Base(path)]
+		if !ok {
+			return smartcrop.Crop{}, fmt.Errorf("no crop recorded in %s", s.Label)
+		}
+		return crop, nil
+	}
+
+	res, err := s.analyzer.FindBestResult(img, w, h)
+	if err != nil {
+		return smartcrop.Crop{}, err
+	}
+	return res.Crop, nil
+}
+
+// compare runs the compare subcommand, which writes an HTML report of the
+// crops of the images in a folder found with two settings profiles, or of
+// crops recorded with another version of the library and the ones found now:
+//
+//	smartcrop compare --config a.json --config b.json dir/
+//	smartcrop compare --config a.json --record crops.json dir/
+//	smartcrop compare --baseline crops.json --config b.json dir/
+func compare(args []string) {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	var configs configFlags
+	fs.Var(&configs, "config", "config file of the settings to compare, given up to twice")
+	baseline := fs.String("baseline", "", "crops recorded with --record to compare to, e.g. by a previous version")
+	record := fs.String("record", "", "file to record the crops found with the last config in, for a later --baseline")
+	w := fs.Int("width", 250, "crop width, defaults to the one of the baseline")
+	h := fs.Int("height", 250, "crop height, defaults to the one of the baseline")
+	output := fs.String("output", "compare.html", "report filename, - for stdout")
+	fs.Parse(args)
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	var sides []side
+	if *baseline != "" {
+		rec, err := readRecording(*baseline)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "can't read baseline: %v\n", err)
+			os.Exit(1)
+		}
+		if (set["width"] && *w != rec.Width) || (set["height"] && *h != rec.Height) {
+			fmt.Fprintf(os.Stderr, "baseline has crops of %dx%d, not %dx%d\n", rec.Width, rec.Height, *w, *h)
+			os.Exit(1)
+		}
+		*w, *h = rec.Width, rec.Height
+		sides = append(sides, side{Label: fmt.Sprintf("%s (%s)", *baseline, rec.Version), recorded: rec})
+	}
+	if (*baseline != "" || *record != "") && len(configs) == 0 {
+		// compare to the crops found with the default settings
+		configs = append(configs, "")
+	}
+
+	resizer := nfnt.NewDefaultResizer()
+	for _, c := range configs {
+		settings := smartcrop.DefaultCropSettings()
+		label := "defaults"
+		if c != "" {
+			var err error
+			if settings, err = smartcrop.LoadSettingsFile(c); err != nil {
+				fmt.Fprintf(os.Stderr, "can't read config: %v\n", err)
+				os.Exit(1)
+			}
+			label = c
+		}
+		analyzer := smartcrop.NewAnalyzerWithSettings(resizer, smartcrop.Logger{}, settings).(smartcrop.ResultAnalyzer)
+		sides = append(sides, side{Label: fmt.Sprintf("%s (%s)", label, smartcrop.Version), settings: settings, analyzer: analyzer})
+	}
+
+	recordOnly := *record != "" && len(sides) == 1 && sides[0].recorded == nil
+	if (len(sides) != 2 && !recordOnly) || fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: smartcrop compare --config a.json --config b.json dir/")
+		fmt.Fprintln(os.Stderr, "       smartcrop compare --config a.json --record crops.json dir/")
+		fmt.Fprintln(os.Stderr, "       smartcrop compare --baseline crops.json [--config b.json] dir/")
+		os.Exit(1)
+	}
+
+	files, err := ioutil.ReadDir(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't read folder: %v\n", err)
+		os.Exit(1)
+	}
+
+	last := sides[len(sides)-1]
+	rec := &recording{Version: smartcrop.Version, Width: *w, Height: *h, Crops: map[string]smartcrop.Crop{}}
+	if last.analyzer != nil {
+		rec.ParamsHash = last.settings.Fingerprint()
+	}
+
+	var rows []comparison
+	for _, f := range files {
+		switch strings.ToLower(filepath.Ext(f.Name())) {
+		case ".jpg", ".jpeg", ".png":
+		default:
+			continue
+		}
+
+		row, err := compareImage(filepath.Join(fs.Arg(0), f.Name()), sides, *w, *h, !recordOnly)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "skipping %s: %v\n", f.Name(), err)
+			continue
+		}
+		rec.Crops[f.Name()] = row.Crops[len(row.Crops)-1]
+		rows = append(rows, row)
+	}
+
+	if *record != "" {
+		if err := writeRecording(*record, rec); err != nil {
+			fmt.Fprintf(os.Stderr, "can't write recording: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if recordOnly {
+		return
+	}
+
+	// the most different crops come first, as they need the closest review
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Overlap < rows[j].Overlap
+	})
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "can't create output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	err = compareTemplate.Execute(out, struct {
+		Sides  []side
+		Width  int
+		Height int
+		Stats  overlapStats
+		Rows   []comparison
+	}{sides, *w, *h, newOverlapStats(rows), rows})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't write report: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// Percent returns the overlap of the crops in percent.
+func (c comparison) Percent() float64 {
+	return c.Overlap * 100
+}
+
+// compareImage finds the crops of the image at path with each side, and
+// renders the thumbnails of the report unless only the crops are needed.
+func compareImage(path string, sides []side, w, h int, render bool) (comparison, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return comparison{}, err
+	}
+	defer f.Close()
+
+	img, _, err := smartcrop.Decode(f)
+	if err != nil {
+		return comparison{}, err
+	}
+
+	row := comparison{Name: filepath.Base(path)}
+	var thumb *image.RGBA
+	var scale float64
+	if render {
+		thumb, scale = thumbnail(img)
+		if row.Original, err = dataURL(thumb); err != nil {
+			return comparison{}, err
+		}
+	}
+	for _, s := range sides {
+		crop, err := s.crop(path, img, w, h)
+		if err != nil {
+			return comparison{}, err
+		}
+		row.Crops = append(row.Crops, crop)
+		if !render {
+			continue
+		}
+		u, err := dataURL(outline(thumb, scale, img.Bounds(), crop.Rectangle))
+		if err != nil {
+			return comparison{}, err
+		}
+		row.Images = append(row.Images, u)
+	}
+	if len(row.Crops) == 2 {
+		row.Overlap = overlap(row.Crops[0].Rectangle, row.Crops[1].Rectangle)
+	}
+	return row, nil
+}
+
+// thumbnail returns img scaled down to thumbnailSize, and the factor it got
+// scaled by.
+func thumbnail(img image.Image) (*image.RGBA, float64) {
+	b := img.Bounds()
+	scale := float64(thumbnailSize) / float64(b.Dx())
+	if b.Dy() > b.Dx() {
+		scale = float64(thumbnailSize) / float64(b.Dy())
+	}
+	tw, th := int(float64(b.Dx())*scale+0.5), int(float64(b.Dy())*scale+0.5)
+
+	thumb := image.NewRGBA(image.Rect(0, 0, tw, th))
+	draw.Draw(thumb, thumb.Bounds(), nfnt.NewDefaultResizer().Resize(img, uint(tw), uint(th)), image.Point{}, draw.Src)
+	return thumb, scale
+}
+
+// outline returns a copy of thumb, the thumbnail of an image with bounds b,
+// with the crop outlined.
+func outline(thumb *image.RGBA, scale float64, b image.Rectangle, crop image.Rectangle) *image.RGBA {
+	thumb = copyRGBA(thumb)
+	r := image.Rect(
+		int(float64(crop.Min.X-b.Min.X)*scale), int(float64(crop.Min.Y-b.Min.Y)*scale),
+		int(float64(crop.Max.X-b.Min.X)*scale)-1, int(float64(crop.Max.Y-b.Min.Y)*scale)-1,
+	)
+	red := color.RGBA{255, 0, 0, 255}
+	for i := 0; i < 2; i++ {
+		for x := r.Min.X; x <= r.Max.X; x++ {
+			thumb.SetRGBA(x, r.Min.Y+i, red)
+			thumb.SetRGBA(x, r.Max.Y-i, red)
+		}
+		for y := r.Min.Y; y <= r.Max.Y; y++ {
+			thumb.SetRGBA(r.Min.X+i, y, red)
+			thumb.SetRGBA(r.Max.X-i, y, red)
+		}
+	}
+	return thumb
+}
+
+func copyRGBA(img *image.RGBA) *image.RGBA {
+	c := image.NewRGBA(img.Bounds())
+	copy(c.Pix, img.Pix)
+	return c
+}
+
+// dataURL returns img encoded as a JPEG data URL.
+func dataURL(img image.Image) (template.URL, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return "", err
+	}
+	return template.URL("data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
+
+// overlap returns the intersection over union of two crops, 1 for identical
+// crops.
+func overlap(a, b image.Rectangle) float64 {
+	area := func(r image.Rectangle) float64 {
+		return float64(r.Dx()) * float64(r.Dy())
+	}
+	inter := area(a.Intersect(b))
+	union := area(a) + area(b) - inter
+	if union <= 0 {
+		return 1
+	}
+	return inter / union
+}
+
+// overlapStats sums up the overlaps of the crops of a report.
+type overlapStats struct {
+	Mean, Median, Min float64
+	// Identical is the number of identical crops, Similar the number of
+	// crops overlapping by at least 90%, Different by less than 50%.
+	Identical, Similar, Different int
+}
+
+func newOverlapStats(rows []comparison) overlapStats {
+	if len(rows) == 0 {
+		return overlapStats{}
+	}
+
+	overlaps := make([]float64, len(rows))
+	stats := overlapStats{Min: 1}
+	for i, row := range rows {
+		overlaps[i] = row.Overlap
+		stats.Mean += row.Overlap / float64(len(rows))
+		stats.Min = math.Min(stats.Min, row.Overlap)
+		switch {
+		case row.Overlap >= 1:
+			stats.Identical++
+		case row.Overlap >= 0.9:
+			stats.Similar++
+		case row.Overlap < 0.5:
+			stats.Different++
+		}
+	}
+
+	sort.Float64s(overlaps)
+	stats.Median = overlaps[len(overlaps)/2]
+	if len(overlaps)%2 == 0 {
+		stats.Median = (overlaps[len(overlaps)/2-1] + overlaps[len(overlaps)/2]) / 2
+	}
+	return stats
+}
+
+func readRecording(path string) (*recording, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rec recording
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func writeRecording(path string, rec *recording) error {
+	b, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+var compareTemplate = template.Must(template.New("compare").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>smartcrop comparison</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 0.5em 1em; border-bottom: 1px solid #ddd; text-align: left; vertical-align: top; }
+img { display: block; margin-bottom: 0.25em; }
+.changed { background: #fff4e0; }
+</style>
+</head>
+<body>
+<h1>Crops of {{.Width}}x{{.Height}}</h1>
+{{with .Stats}}<p>{{len $.Rows}} images: {{.Identical}} crops identical, {{.Similar}} overlapping by at least 90%, {{.Different}} by less than 50%.</p>
+<p>Intersection over union: mean {{printf "%.2f" .Mean}}, median {{printf "%.2f" .Median}}, minimum {{printf "%.2f" .Min}}.</p>{{end}}
+<table>
+<tr><th>Image</th><th>Overlap</th><th>Original</th>{{range .Sides}}<th>{{.Label}}</th>{{end}}</tr>
+{{range .Rows}}<tr{{if lt .Overlap 1.0}} class="changed"{{end}}>
+<td>{{.Name}}</td>
+<td>{{printf "%.0f%%" .Percent}}</td>
+<td><img src="{{.Original}}"></td>
+{{$crops := .Crops}}{{range $i, $img := .Images}}<td><img src="{{$img}}"><code>{{index $crops $i}}</code></td>{{end}}
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
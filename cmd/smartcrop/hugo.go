@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/hugo"
+)
+
+// generateSite runs the hugo subcommand, which crops the images of a Hugo
+// site for the aspect ratios its pages declare and writes a data file
+// listing the crops, see package hugo:
+//
+//	smartcrop hugo --ratio 16:9 --width 1200 mysite/
+func generateSite(args []string) {
+	fs := flag.NewFlagSet("hugo", flag.ExitOnError)
+	var ratios configFlags
+	fs.Var(&ratios, "ratio", "aspect ratio to crop all images for, e.g. 16:9, besides the ones declared by the pages")
+	content := fs.String("content", "content", "directory of the pages declaring aspect ratios in their front matter")
+	images := fs.String("images", "static/images", "directory of the images to crop")
+	output := fs.String("output", "static/smartcrop", "directory to write the crops to")
+	url := fs.String("url", "/smartcrop", "URL the output directory is served at")
+	data := fs.String("data", "data/smartcrop.json", "data file to list the crops in")
+	width := fs.Int("width", 0, "width to scale the crops down to, 0 to keep them as they are")
+	quality := fs.Int("quality", 85, "jpeg quality")
+	config := fs.String("config", "", "config file of the settings, defaults to $SMARTCROP_CONFIG")
+	profile := fs.String("profile", "", "profile of the config file, defaults to $SMARTCROP_PROFILE")
+	fs.Parse(args)
+
+	root := "."
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+	settings, err := smartcrop.LoadEnvSettings(*config, *profile, os.Environ())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't load settings: %v\n", err)
+		os.Exit(1)
+	}
+
+	opts := hugo.Options{
+		ContentDir: *content,
+		ImageDir:   *images,
+		OutputDir:  *output,
+		URL:        *url,
+		DataFile:   *data,
+		Width:      *width,
+		Quality:    *quality,
+		Settings:   &settings,
+	}
+	for _, s := range ratios {
+		r, err := hugo.ParseRatio(s)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		opts.Ratios = append(opts.Ratios, r)
+	}
+
+	res, err := hugo.Generate(root, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't generate crops: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("cropped %d images\n", len(res))
+}
@@ -36,20 +36,43 @@ import (
 	"image/png"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/muesli/smartcrop"
 	"github.com/muesli/smartcrop/nfnt"
+	_ "github.com/muesli/smartcrop/yaml"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		compare(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "hugo" {
+		generateSite(os.Args[2:])
+		return
+	}
+
 	input := flag.String("input", "", "input filename")
 	output := flag.String("output", "", "output filename")
 	w := flag.Int("width", 0, "crop width")
 	h := flag.Int("height", 0, "crop height")
 	resize := flag.Bool("resize", true, "resize after cropping")
 	quality := flag.Int("quality", 85, "jpeg quality")
+	debugDir := flag.String("debug-dir", "", "directory to write debug heatmaps and overlays to")
+	config := flag.String("config", "", "config file of the settings, defaults to $SMARTCROP_CONFIG")
+	profile := flag.String("profile", "", "profile of the config file, defaults to $SMARTCROP_PROFILE")
+	sidecar := flag.Bool("sidecar", false, "record the crop in a .smartcrop.json file next to the input")
 	flag.Parse()
 
+	// SMARTCROP_* variables override the settings of the config file
+	settings, err := smartcrop.LoadEnvSettings(*config, *profile, os.Environ())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't load settings: %v\n", err)
+		os.Exit(1)
+	}
+
 	if *input == "" {
 		fmt.Fprintln(os.Stderr, "No input file given")
 		os.Exit(1)
@@ -62,7 +85,7 @@ func main() {
 	}
 	defer f.Close()
 
-	img, format, err := image.Decode(f)
+	img, format, err := smartcrop.Decode(f)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "can't decode input file: %v\n", err)
 		os.Exit(1)
@@ -81,7 +104,25 @@ func main() {
 		defer fOut.Close()
 	}
 
-	img = crop(img, *w, *h, *resize)
+	logger := smartcrop.Logger{}
+	if *debugDir != "" {
+		// name the debug images after the input, e.g. photo_edge.png
+		name := filepath.Base(*input)
+		logger.DebugMode = true
+		logger.DebugPrefix = filepath.Join(*debugDir, strings.TrimSuffix(name, filepath.Ext(name))) + "_"
+	}
+
+	img, width, height, res, err := crop(img, *w, *h, *resize, logger, settings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't find crop: %v\n", err)
+		os.Exit(1)
+	}
+	if *sidecar {
+		if err := writeSidecar(*input, width, height, res, settings); err != nil {
+			fmt.Fprintf(os.Stderr, "can't write sidecar: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	switch format {
 	case "png":
 		png.Encode(fOut, img)
@@ -90,20 +131,39 @@ func main() {
 	}
 }
 
-func crop(img image.Image, w, h int, resize bool) image.Image {
+// crop crops img to the dimensions w and h, defaulting to a square of its
+// shorter side, and returns the cropped image along with the dimensions it
+// was cropped to and the Result of the analysis.
+func crop(img image.Image, w, h int, resize bool, logger smartcrop.Logger, settings smartcrop.CropSettings) (image.Image, int, int, smartcrop.Result, error) {
 	width, height := getCropDimensions(img, w, h)
 	resizer := nfnt.NewDefaultResizer()
-	analyzer := smartcrop.NewAnalyzer(resizer)
-	topCrop, _ := analyzer.FindBestCrop(img, width, height)
+	analyzer := smartcrop.NewAnalyzerWithSettings(resizer, logger, settings).(smartcrop.ResultAnalyzer)
+	res, err := analyzer.FindBestResult(img, width, height)
+	if err != nil {
+		return nil, 0, 0, smartcrop.Result{}, err
+	}
 
 	type SubImager interface {
 		SubImage(r image.Rectangle) image.Image
 	}
-	img = img.(SubImager).SubImage(topCrop)
+	img = img.(SubImager).SubImage(res.Crop.Rectangle)
 	if resize && (img.Bounds().Dx() != width || img.Bounds().Dy() != height) {
 		img = resizer.Resize(img, uint(width), uint(height))
 	}
-	return img
+	return img, width, height, res, nil
+}
+
+// writeSidecar records res, the crop for the dimensions w and h, in the
+// sidecar of the image at path, keeping the crops of other aspect ratios
+// recorded there.
+func writeSidecar(path string, w, h int, res smartcrop.Result, settings smartcrop.CropSettings) error {
+	s, err := smartcrop.ReadSidecar(path)
+	if err != nil {
+		return err
+	}
+	s.Settings = &settings
+	s.Add(w, h, res)
+	return smartcrop.WriteSidecar(path, s)
 }
 
 func getCropDimensions(img image.Image, width, height int) (int, int) {
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/server"
+)
+
+// config is the config file of the daemon: the options of the server, and
+// the settings and profiles of the analysis as a smartcrop config.
+//
+//	{
+//	  "cacheSize": 10000,
+//	  "maxConcurrent": 4,
+//	  "queueTimeout": "2s",
+//	  "smartcrop": {
+//	    "skinWeight": 2.0,
+//	    "profiles": {"thumbnails": {"step": 16}}
+//	  }
+//	}
+type config struct {
+	CacheSize     int             `json:"cacheSize"`
+	MaxImageSize  int64           `json:"maxImageSize"`
+	MaxConcurrent int             `json:"maxConcurrent"`
+	MaxQueue      int             `json:"maxQueue"`
+	QueueTimeout  string          `json:"queueTimeout"`
+	Imaginary     bool            `json:"imaginary"`
+	Fetch         bool            `json:"fetch"`
+	Smartcrop     json.RawMessage `json:"smartcrop"`
+}
+
+// loadConfig reads the config file at path and returns the options of the
+// server it configures. SMARTCROP_* variables override the settings, like
+// for the other commands.
+func loadConfig(path string) (server.Options, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return server.Options{}, err
+	}
+	var c config
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&c); err != nil {
+		return server.Options{}, fmt.Errorf("invalid config: %v", err)
+	}
+
+	opts := server.Options{
+		CacheSize:     c.CacheSize,
+		MaxImageSize:  c.MaxImageSize,
+		MaxConcurrent: c.MaxConcurrent,
+		MaxQueue:      c.MaxQueue,
+		Imaginary:     c.Imaginary,
+	}
+	if c.Fetch {
+		opts.Fetch = &server.FetchOptions{}
+	}
+	if c.QueueTimeout != "" {
+		if opts.QueueTimeout, err = time.ParseDuration(c.QueueTimeout); err != nil {
+			return server.Options{}, fmt.Errorf("invalid config: queueTimeout: %v", err)
+		}
+	}
+
+	settings := smartcrop.DefaultCropSettings()
+	if len(c.Smartcrop) > 0 {
+		sc, err := smartcrop.LoadConfig(bytes.NewReader(c.Smartcrop))
+		if err != nil {
+			return server.Options{}, err
+		}
+		settings, opts.Profiles = sc.Settings, sc.Profiles
+	}
+	if settings, err = settings.WithEnv(os.Environ()); err != nil {
+		return server.Options{}, err
+	}
+	opts.Settings = &settings
+	return opts, nil
+}
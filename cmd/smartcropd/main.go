@@ -0,0 +1,210 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+// smartcropd serves crop requests over HTTP like smartcrop-server, but gets
+// configured by a config file it reloads without a restart: on SIGHUP, or
+// when POSTing to /reload on the admin address. Requests in progress finish
+// with the previous config, and an invalid config gets rejected while the
+// previous one stays in effect.
+//
+//	smartcropd -config smartcropd.json
+//	kill -HUP $(pidof smartcropd)
+//	curl -X POST http://localhost:8081/reload
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/muesli/smartcrop/server"
+)
+
+// shutdownTimeout is how long the requests in progress may take to finish
+// when shutting down.
+const shutdownTimeout = 30 * time.Second
+
+// daemon serves the requests with the Server of the current config.
+type daemon struct {
+	path      string
+	metrics   bool
+	profiling bool
+
+	mu       sync.Mutex // serializes reloads
+	handler  atomic.Value
+	current  server.Options
+	reloaded time.Time
+}
+
+// reload loads the config file and swaps in a Server configured by it. The
+// previous Server stays in effect if it's invalid.
+func (d *daemon) reload() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	opts, err := loadConfig(d.path)
+	if err != nil {
+		return err
+	}
+	opts.Metrics = d.metrics
+	opts.Profiling = d.profiling
+	d.handler.Store(server.New(opts))
+	d.current = opts
+	d.reloaded = time.Now()
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (d *daemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.handler.Load().(http.Handler).ServeHTTP(w, r)
+}
+
+// status is the response of the admin endpoints.
+type status struct {
+	Config      string    `json:"config"`
+	Reloaded    time.Time `json:"reloaded"`
+	Fingerprint string    `json:"fingerprint"`
+	Profiles    []string  `json:"profiles,omitempty"`
+	CacheSize   int       `json:"cacheSize"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// admin returns the handler of the admin endpoints: GET /config reports the
+// config in effect, POST /reload reloads it.
+func (d *daemon) admin() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/config", func(w http.ResponseWriter, r *http.Request) {
+		d.writeStatus(w, http.StatusOK, nil)
+	})
+	mux.HandleFunc("/reload", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		if err := d.reload(); err != nil {
+			log.Printf("can't reload %s: %v", d.path, err)
+			d.writeStatus(w, http.StatusBadRequest, err)
+			return
+		}
+		log.Printf("reloaded %s", d.path)
+		d.writeStatus(w, http.StatusOK, nil)
+	})
+	return mux
+}
+
+func (d *daemon) writeStatus(w http.ResponseWriter, code int, err error) {
+	d.mu.Lock()
+	s := status{
+		Config:      d.path,
+		Reloaded:    d.reloaded,
+		Fingerprint: d.current.Settings.Fingerprint(),
+		CacheSize:   d.current.CacheSize,
+	}
+	for name := range d.current.Profiles {
+		s.Profiles = append(s.Profiles, name)
+	}
+	d.mu.Unlock()
+	sort.Strings(s.Profiles)
+	if err != nil {
+		s.Error = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		log.Println("can't encode response:", err)
+	}
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	adminAddr := flag.String("admin-addr", "localhost:8081", "address to serve the admin endpoints on, empty to disable them")
+	path := flag.String("config", "smartcropd.json", "config file, reloaded on SIGHUP")
+	metrics := flag.Bool("metrics", false, "expose expvar metrics at /debug/vars")
+	profiling := flag.Bool("pprof", false, "expose the pprof endpoints at /debug/pprof/")
+	flag.Parse()
+
+	d := &daemon{path: *path, metrics: *metrics, profiling: *profiling}
+	if err := d.reload(); err != nil {
+		log.Fatalf("can't load %s: %v", *path, err)
+	}
+
+	srv := &http.Server{Addr: *addr, Handler: d}
+	var admin *http.Server
+	if *adminAddr != "" {
+		admin = &http.Server{Addr: *adminAddr, Handler: d.admin()}
+		go func() {
+			if err := admin.ListenAndServe(); err != http.ErrServerClosed {
+				log.Fatal(err)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		for sig := range signals {
+			if sig == syscall.SIGHUP {
+				if err := d.reload(); err != nil {
+					log.Printf("can't reload %s: %v", *path, err)
+				} else {
+					log.Printf("reloaded %s", *path)
+				}
+				continue
+			}
+
+			// let the requests in progress finish
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			if admin != nil {
+				admin.Shutdown(ctx)
+			}
+			if err := srv.Shutdown(ctx); err != nil {
+				log.Printf("can't shut down: %v", err)
+			}
+			cancel()
+			close(done)
+			return
+		}
+	}()
+
+	fmt.Printf("Listening on http://%s\n", *addr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-done
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+// tinycrop prints the best crop of the image read from stdin, found by the
+// tiny package. It's built with TinyGo by the CI, as a program depending on
+// nothing but the tiny package and the image decoders of the standard
+// library, e.g.:
+//
+//	tinygo build -o tinycrop ./tinycrop
+//	./tinycrop -width 250 -height 250 < ../examples/gopher.jpg
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+
+	"github.com/muesli/smartcrop/tiny"
+)
+
+func main() {
+	width := flag.Int("width", 250, "width of the crop")
+	height := flag.Int("height", 250, "height of the crop")
+	flag.Parse()
+
+	img, _, err := image.Decode(os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "can't decode the image:", err)
+		os.Exit(1)
+	}
+	crop, err := tiny.FindBestCrop(img, *width, *height)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d,%d,%d,%d\n", crop.Min.X, crop.Min.Y, crop.Dx(), crop.Dy())
+}
@@ -0,0 +1,264 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AutoWorkers makes analyses spread their work over the number of goroutines
+// the Calibration of the host picked, see CropSettings.Workers.
+const AutoWorkers = -1
+
+const (
+	// defaultTilePixels is the size of the tiles the detector planes get
+	// split into with a fixed number of Workers.
+	defaultTilePixels = 1 << 16
+	// scoreBatchPerWorker is the number of candidates per worker scored
+	// concurrently at a time.
+	scoreBatchPerWorker = 16
+	// tileDuration is the time the detectors should take for a tile on a
+	// single core, so handing it to a goroutine doesn't cost more than it
+	// saves.
+	tileDuration = 250 * time.Microsecond
+	// calibrationSize is the width and height of the image the calibration
+	// measures the detectors on.
+	calibrationSize = 256
+)
+
+// Calibration is how analyses spread their work over goroutines on the host,
+// as measured by Calibrate.
+type Calibration struct {
+	// Workers is the number of goroutines the detectors and the scoring get
+	// spread over. More goroutines didn't make the detectors considerably
+	// faster anymore.
+	Workers int `json:"workers"`
+
+	// PixelsPerSecond is the throughput of the detectors on a single core.
+	PixelsPerSecond float64 `json:"pixelsPerSecond"`
+
+	// TilePixels is the number of pixels of the bands of rows the detector
+	// planes get split into, ScoreBatch the number of candidates scored
+	// concurrently at a time.
+	TilePixels int `json:"tilePixels"`
+	ScoreBatch int `json:"scoreBatch"`
+}
+
+var calibration struct {
+	once sync.Once
+	c    Calibration
+}
+
+// Calibrate returns the Calibration of the host. It's measured on the first
+// call, which takes some tens of milliseconds, and cached from then on. Call
+// it on startup to keep the first analysis with AutoWorkers from paying for
+// it.
+func Calibrate() Calibration {
+	calibration.once.Do(func() {
+		calibration.c = calibrate(runtime.GOMAXPROCS(0))
+	})
+	return calibration.c
+}
+
+// calibrate measures the throughput of the detectors on a single core, and
+// how it scales with up to procs goroutines.
+func calibrate(procs int) Calibration {
+	s := DefaultCropSettings()
+	img := calibrationImage()
+	o := image.NewRGBA(img.Bounds())
+	pixels := calibrationSize * calibrationSize
+
+	single := Calibration{Workers: 1}
+	perCore := measure(func() { detectTiled(&s, single, img, o) }) / float64(pixels)
+	c := Calibration{
+		Workers:         1,
+		PixelsPerSecond: 1 / perCore,
+		TilePixels:      maxInt(int(tileDuration.Seconds()/perCore), calibrationSize),
+	}
+
+	// every worker gets a few tiles, so the goroutines get measured rather
+	// than the load balancing between them
+	best := c.PixelsPerSecond
+	for n := 2; n <= procs; n = nextWorkers(n, procs) {
+		try := Calibration{Workers: n, TilePixels: c.TilePixels}
+		rows := (try.TilePixels/calibrationSize + 1) * 4 * n
+		large := image.NewRGBA(image.Rect(0, 0, calibrationSize, rows))
+		for y := 0; y < rows; y++ {
+			copy(large.Pix[y*large.Stride:(y+1)*large.Stride], img.Pix[(y%calibrationSize)*img.Stride:])
+		}
+		lo := image.NewRGBA(large.Bounds())
+
+		pps := float64(calibrationSize*rows) / measure(func() { detectTiled(&s, try, large, lo) })
+		// settle for fewer goroutines unless more are considerably faster
+		if pps < best*1.1 {
+			break
+		}
+		best, c.Workers = pps, n
+	}
+
+	c.ScoreBatch = c.Workers * scoreBatchPerWorker
+	return c
+}
+
+// nextWorkers returns the number of workers to try after n, doubling it up to procs.
+func nextWorkers(n, procs int) int {
+	if n < procs && n*2 > procs {
+		return procs
+	}
+	return n * 2
+}
+
+// measure returns the seconds fn takes at best, running it repeatedly for a
+// few milliseconds to even out the noise.
+func measure(fn func()) float64 {
+	fn()
+	best := time.Duration(1<<63 - 1)
+	for start := time.Now(); time.Since(start) < 5*time.Millisecond; {
+		now := time.Now()
+		fn()
+		if took := time.Since(now); took < best {
+			best = took
+		}
+	}
+	return best.Seconds()
+}
+
+// calibrationImage returns an image for the detectors to be measured on,
+// a mix of gradients and noise of skin tones, saturated and gray colors.
+func calibrationImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, calibrationSize, calibrationSize))
+	seed := uint32(1)
+	for y := 0; y < calibrationSize; y++ {
+		for x := 0; x < calibrationSize; x++ {
+			seed = seed*1664525 + 1013904223
+			noise := uint8(seed >> 28)
+			i := img.PixOffset(x, y)
+			img.Pix[i] = uint8(x) + noise
+			img.Pix[i+1] = uint8(y)/2 + noise
+			img.Pix[i+2] = uint8(x+y) / 3
+			img.Pix[i+3] = 255
+		}
+	}
+	return img
+}
+
+// detectTiled computes the built-in detector planes of img into o, both with
+// the same bounds, tile by tile as c says.
+func detectTiled(s *CropSettings, c Calibration, img, o *image.RGBA) {
+	c.inTiles(img, func(y0, y1 int) { edgeDetectRows(img, o, y0, y1) })
+	c.inTiles(img, func(y0, y1 int) { skinDetect(s, rowsOf(img, y0, y1), rowsOf(o, y0, y1)) })
+	c.inTiles(img, func(y0, y1 int) { saturationDetect(s, rowsOf(img, y0, y1), rowsOf(o, y0, y1)) })
+}
+
+// rowsOf returns the rows y0 up to y1 of img, relative to its bounds.
+func rowsOf(img *image.RGBA, y0, y1 int) *image.RGBA {
+	b := img.Bounds()
+	return img.SubImage(image.Rect(b.Min.X, b.Min.Y+y0, b.Max.X, b.Min.Y+y1)).(*image.RGBA)
+}
+
+// concurrency returns how the analysis spreads its work over goroutines, as
+// set by CropSettings.Workers.
+func (p pipeline) concurrency() Calibration {
+	switch n := p.settings.Workers; {
+	case n == AutoWorkers:
+		return Calibrate()
+	case n > 1:
+		return Calibration{Workers: n, TilePixels: defaultTilePixels, ScoreBatch: n * scoreBatchPerWorker}
+	}
+	return Calibration{Workers: 1}
+}
+
+// inTiles calls fn for the bands of about c.TilePixels pixels img gets split
+// into, with the rows y0 up to y1 of each relative to the bounds of img, on
+// up to c.Workers goroutines. It returns once all of them are done.
+func (c Calibration) inTiles(img *image.RGBA, fn func(y0, y1 int)) {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	rows := height
+	if width > 0 && c.TilePixels > 0 {
+		rows = maxInt(c.TilePixels/width, 1)
+	}
+	if c.Workers <= 1 || rows >= height {
+		fn(0, height)
+		return
+	}
+
+	tiles := (height + rows - 1) / rows
+	parallel(c.Workers, tiles, func(i int) {
+		fn(i*rows, minInt((i+1)*rows, height))
+	})
+}
+
+// parallel calls fn for every i from 0 up to n on up to workers goroutines,
+// and returns once all of them are done. A panic of fn is raised again on the
+// calling goroutine, so it gets recovered like any other panic of an
+// analysis.
+func parallel(workers, n int, fn func(i int)) {
+	workers = minInt(workers, n)
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	var (
+		next     int64
+		wg       sync.WaitGroup
+		panicked sync.Once
+		value    interface{}
+	)
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if v := recover(); v != nil {
+					panicked.Do(func() { value = v })
+					// keep the other goroutines from picking up more work
+					atomic.StoreInt64(&next, int64(n))
+				}
+			}()
+			for {
+				i := int(atomic.AddInt64(&next, 1) - 1)
+				if i >= n {
+					return
+				}
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if value != nil {
+		panic(value)
+	}
+}
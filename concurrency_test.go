@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"bytes"
+	"image"
+	"math/rand"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestDetectTiled(t *testing.T) {
+	s := DefaultCropSettings()
+	sub, _ := randomSubImage(rand.New(rand.NewSource(3)))
+
+	expected := image.NewRGBA(sub.Bounds())
+	detectTiled(&s, Calibration{Workers: 1}, sub, expected)
+	// tiles of a single row or a few, with the last one cut short
+	for _, tile := range []int{1, 3, 7} {
+		o := image.NewRGBA(sub.Bounds())
+		detectTiled(&s, Calibration{Workers: 4, TilePixels: tile * sub.Bounds().Dx()}, sub, o)
+		if !bytes.Equal(o.Pix, expected.Pix) {
+			t.Fatalf("expected the planes detected in tiles of %d rows to match the ones detected at once", tile)
+		}
+	}
+}
+
+func TestWorkers(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tweaks := map[string]func(*CropSettings){
+		"default":    func(s *CropSettings) {},
+		"fixedPoint": func(s *CropSettings) { s.FixedPoint = true },
+		"quadtree":   func(s *CropSettings) { s.Quadtree = true },
+		"blobs":      func(s *CropSettings) { s.BlobPenalty = 0.5 },
+		"textZone": func(s *CropSettings) {
+			s.TextZone = &NormalizedRect{X: 0.1, Y: 0.7, Width: 0.8, Height: 0.2}
+			s.TextColor = "#ffffff"
+		},
+	}
+	for name, tweak := range tweaks {
+		find := func(workers int) Crop {
+			s := DefaultCropSettings()
+			tweak(&s)
+			s.Workers = workers
+			analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, s).(ResultAnalyzer)
+			res, err := analyzer.FindBestResult(img, 250, 250)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return res.Crop
+		}
+
+		expected := find(0)
+		for _, workers := range []int{3, AutoWorkers} {
+			if crop := find(workers); crop != expected {
+				t.Fatalf("%s: expected %+v with %d workers, got %+v", name, expected, workers, crop)
+			}
+		}
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	c := Calibrate()
+	if c.Workers < 1 || c.Workers > runtime.GOMAXPROCS(0) {
+		t.Fatalf("expected between 1 and %d workers, got %d", runtime.GOMAXPROCS(0), c.Workers)
+	}
+	if c.PixelsPerSecond <= 0 || c.TilePixels < calibrationSize || c.ScoreBatch < c.Workers {
+		t.Fatalf("expected a plausible calibration, got %+v", c)
+	}
+	if again := Calibrate(); again != c {
+		t.Fatalf("expected the calibration to be cached, got %+v and %+v", c, again)
+	}
+
+	if c := calibrate(1); c.Workers != 1 {
+		t.Fatalf("expected a single worker on a single core, got %d", c.Workers)
+	}
+}
+
+func TestParallelPanic(t *testing.T) {
+	defer func() {
+		if v := recover(); v != "boom" {
+			t.Fatalf("expected the panic of a worker to be raised again, got %v", v)
+		}
+	}()
+
+	parallel(4, 100, func(i int) {
+		if i == 42 {
+			panic("boom")
+		}
+	})
+}
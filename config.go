@@ -0,0 +1,237 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config is a declarative configuration of the analysis, so services can
+// change their cropping behavior by deploying a config file instead of code.
+//
+// A config file holds the CropSettings by the names they have in JSON. Settings
+// missing from the file keep their defaults, and Budget and MaxDuration can be
+// given as durations like "50ms". Named profiles override some of the settings
+// for particular uses:
+//
+//	{
+//		"skinWeight": 2.0,
+//		"detectors": {"faces": 1.5},
+//		"profiles": {
+//			"thumbnails": {"step": 16, "budget": "20ms"}
+//		}
+//	}
+//
+// Other formats can be registered with RegisterConfigFormat, e.g. YAML by
+// importing smartcrop/yaml.
+type Config struct {
+	// Settings are the settings of the file, on top of the defaults.
+	Settings CropSettings
+	// Profiles are the named profiles of the file, each on top of Settings.
+	Profiles map[string]CropSettings
+}
+
+// ConfigFormat converts a config file in some format to JSON.
+type ConfigFormat func(b []byte) ([]byte, error)
+
+var (
+	configFormatsMu sync.RWMutex
+	configFormats   = map[string]ConfigFormat{}
+)
+
+// RegisterConfigFormat makes LoadConfigFile read the files with the given
+// extension, e.g. ".yaml", in another format than JSON. It is meant to be
+// called from the init function of the package implementing it.
+func RegisterConfigFormat(ext string, format ConfigFormat) {
+	configFormatsMu.Lock()
+	defer configFormatsMu.Unlock()
+
+	if format == nil {
+		panic("smartcrop: RegisterConfigFormat format is nil")
+	}
+	configFormats[strings.ToLower(ext)] = format
+}
+
+// LoadConfig reads a Config in JSON from r. Unknown settings and settings
+// which don't pass Validate are rejected. An empty file keeps the defaults.
+func LoadConfig(r io.Reader) (*Config, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{} = map[string]interface{}{}
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 {
+		d := json.NewDecoder(bytes.NewReader(trimmed))
+		d.UseNumber()
+		err = d.Decode(&doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("smartcrop: invalid config: %v", err)
+	}
+	fields, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("smartcrop: invalid config: expected a mapping of settings")
+	}
+
+	profiles, ok := fields["profiles"].(map[string]interface{})
+	if !ok && fields["profiles"] != nil {
+		return nil, fmt.Errorf("smartcrop: invalid config: expected a mapping of profiles")
+	}
+	delete(fields, "profiles")
+
+	c := &Config{Settings: DefaultCropSettings()}
+	if err := decodeSettings(fields, &c.Settings); err != nil {
+		return nil, fmt.Errorf("smartcrop: invalid config: %v", err)
+	}
+	if err := c.Settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	for name, p := range profiles {
+		fields, ok := p.(map[string]interface{})
+		if !ok && p != nil {
+			return nil, fmt.Errorf("smartcrop: invalid config: expected a mapping of settings for profile %q", name)
+		}
+		s := c.Settings.copy()
+		if err := decodeSettings(fields, &s); err != nil {
+			return nil, fmt.Errorf("smartcrop: invalid config: profile %q: %v", name, err)
+		}
+		if err := s.Validate(); err != nil {
+			return nil, fmt.Errorf("%v in profile %q", err, name)
+		}
+		if c.Profiles == nil {
+			c.Profiles = map[string]CropSettings{}
+		}
+		c.Profiles[name] = s
+	}
+	return c, nil
+}
+
+// LoadConfigFile reads a Config from the file at path, see LoadConfig. Files
+// with the extension of a format registered with RegisterConfigFormat get
+// converted from it first.
+func LoadConfigFile(path string) (*Config, error) {
+	configFormatsMu.RLock()
+	format := configFormats[strings.ToLower(filepath.Ext(path))]
+	configFormatsMu.RUnlock()
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if format != nil {
+		if b, err = format(b); err != nil {
+			return nil, fmt.Errorf("smartcrop: invalid config: %v", err)
+		}
+	}
+	return LoadConfig(bytes.NewReader(b))
+}
+
+// LoadSettings reads the settings of a config from r, ignoring its profiles.
+// See LoadConfig.
+func LoadSettings(r io.Reader) (CropSettings, error) {
+	c, err := LoadConfig(r)
+	if err != nil {
+		return CropSettings{}, err
+	}
+	return c.Settings, nil
+}
+
+// LoadSettingsFile reads the settings of the config file at path, see
+// LoadSettings.
+func LoadSettingsFile(path string) (CropSettings, error) {
+	c, err := LoadConfigFile(path)
+	if err != nil {
+		return CropSettings{}, err
+	}
+	return c.Settings, nil
+}
+
+// Profile returns the settings of the named profile, or the settings of the
+// config if name is empty.
+func (c *Config) Profile(name string) (CropSettings, error) {
+	if name == "" {
+		return c.Settings, nil
+	}
+	s, ok := c.Profiles[name]
+	if !ok {
+		return CropSettings{}, fmt.Errorf("smartcrop: unknown profile %q", name)
+	}
+	return s, nil
+}
+
+// decodeSettings decodes the fields of a config onto s.
+func decodeSettings(fields map[string]interface{}, s *CropSettings) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	for _, name := range []string{"budget", "maxDuration"} {
+		if v, ok := fields[name].(string); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			fields[name] = int64(d)
+		}
+	}
+
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	return d.Decode(s)
+}
+
+// copy returns a copy of s which doesn't share its maps, slices and
+// pointers, so decoding onto it leaves s untouched.
+func (s CropSettings) copy() CropSettings {
+	if s.Detectors != nil {
+		detectors := make(map[string]float64, len(s.Detectors))
+		for name, weight := range s.Detectors {
+			detectors[name] = weight
+		}
+		s.Detectors = detectors
+	}
+	s.Strategies = append([]string(nil), s.Strategies...)
+	if s.TextZone != nil {
+		zone := *s.TextZone
+		s.TextZone = &zone
+	}
+	return s
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testConfig = `{
+	"skinWeight": 2.0,
+	"ruleOfThirds": false,
+	"detectors": {"faces": 1.5},
+	"strategies": ["faces", "saliency"],
+	"textZone": {"x": 0.1, "y": 0.7, "width": 0.8, "height": 0.2},
+	"profiles": {
+		"thumbnails": {
+			"step": 16,
+			"budget": "20ms",
+			"detectors": {"text": 0.5}
+		},
+		"banners": {}
+	}
+}`
+
+func TestLoadConfig(t *testing.T) {
+	c, err := LoadConfig(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := DefaultCropSettings()
+	expected.SkinWeight = 2
+	expected.RuleOfThirds = false
+	expected.Detectors = map[string]float64{"faces": 1.5}
+	expected.Strategies = []string{"faces", "saliency"}
+	expected.TextZone = &NormalizedRect{X: 0.1, Y: 0.7, Width: 0.8, Height: 0.2}
+	if !reflect.DeepEqual(c.Settings, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, c.Settings)
+	}
+
+	thumbnails, err := c.Profile("thumbnails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thumbnails.Step != 16 || thumbnails.Budget != 20*time.Millisecond || thumbnails.SkinWeight != 2 {
+		t.Fatalf("expected the profile to override the settings, got %+v", thumbnails)
+	}
+	if len(thumbnails.Detectors) != 2 || len(c.Settings.Detectors) != 1 {
+		t.Fatalf("expected the profile to add a detector, got %v and %v", thumbnails.Detectors, c.Settings.Detectors)
+	}
+	if banners, _ := c.Profile("banners"); !reflect.DeepEqual(banners, c.Settings) {
+		t.Fatalf("expected an empty profile to keep the settings, got %+v", banners)
+	}
+	if _, err := c.Profile("posters"); err == nil {
+		t.Fatal("expected an error for an unknown profile")
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	for _, config := range []string{
+		`{"skinWieght": 2.0}`,
+		`{"step": 0}`,
+		`{"step": "many"}`,
+		`{"budget": "soon"}`,
+		`[{"step": 8}]`,
+		`{"profiles": {"thumbnails": {"step": -1}}}`,
+		`{"profiles": [{"step": 8}]}`,
+		`{"step": 8`,
+		"step: 8",
+	} {
+		if _, err := LoadConfig(strings.NewReader(config)); err == nil {
+			t.Fatalf("expected an error for %q", config)
+		}
+	}
+
+	if s, err := LoadSettings(strings.NewReader("")); err != nil || !reflect.DeepEqual(s, DefaultCropSettings()) {
+		t.Fatalf("expected an empty config to keep the defaults, got %+v, %v", s, err)
+	}
+}
+
+func TestRegisterConfigFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smartcrop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a format of a setting per line
+	RegisterConfigFormat(".lines", func(b []byte) ([]byte, error) {
+		fields := map[string]json.RawMessage{}
+		for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+			kv := strings.SplitN(line, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("invalid line %q", line)
+			}
+			fields[kv[0]] = json.RawMessage(kv[1])
+		}
+		return json.Marshal(fields)
+	})
+
+	for name, config := range map[string]string{
+		"smartcrop.lines": "step=16\nskinWeight=2.5",
+		"SMARTCROP.LINES": "step=16\nskinWeight=2.5",
+		"smartcrop.json":  `{"step": 16, "skinWeight": 2.5}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		s, err := LoadSettingsFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Step != 16 || s.SkinWeight != 2.5 {
+			t.Fatalf("expected the settings of %s, got %+v", name, s)
+		}
+	}
+
+	path := filepath.Join(dir, "invalid.lines")
+	if err := ioutil.WriteFile(path, []byte("step"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfigFile(path); err == nil {
+		t.Fatal("expected an error for an invalid file")
+	}
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// defaultMinContrast is the contrast ratio WCAG 2 level AA requires for normal
+// text.
+const defaultMinContrast = 4.5
+
+// parseHexColor parses a color in the form #rrggbb or #rgb.
+func parseHexColor(s string) (color.RGBA, error) {
+	h := strings.TrimPrefix(s, "#")
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if len(h) != 6 || err != nil {
+		return color.RGBA{}, fmt.Errorf("smartcrop: invalid text color %q", s)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, nil
+}
+
+// linearize converts an 8-bit sRGB channel to linear light.
+func linearize(c uint8) float64 {
+	v := float64(c) / 255.0
+	if v <= 0.03928 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// luminance returns the relative luminance of c as defined by WCAG 2.
+func luminance(c color.RGBA) float64 {
+	return 0.2126*linearize(c.R) + 0.7152*linearize(c.G) + 0.0722*linearize(c.B)
+}
+
+// contrastRatio returns the WCAG 2 contrast ratio of two relative luminances,
+// ranging from 1 to 21.
+func contrastRatio(l1, l2 float64) float64 {
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+// zoneContrast returns the contrast between text with luminance textLum and
+// the area of img covered by zone. To account for busy backgrounds it
+// compares against the mean luminance of the area, shifted towards textLum by
+// its standard deviation.
+func zoneContrast(s *CropSettings, img *image.RGBA, zone image.Rectangle, textLum float64) float64 {
+	zone = zone.Intersect(img.Bounds())
+	step := s.ScoreDownSample
+	if step < 1 {
+		step = 1
+	}
+
+	rows := newRGBARows(img)
+	zone = zone.Sub(img.Bounds().Min)
+
+	var sum, sumSq, n float64
+	for y := zone.Min.Y; y < zone.Max.Y; y += step {
+		row := rows.row(y)
+		for x := zone.Min.X; x < zone.Max.X; x += step {
+			p := row[x*4 : x*4+3 : x*4+3]
+			l := luminance(color.RGBA{p[0], p[1], p[2], 255})
+			sum += l
+			sumSq += l * l
+			n++
+		}
+	}
+	if n == 0 {
+		return 21.0
+	}
+
+	mean := sum / n
+	dev := math.Sqrt(math.Max(sumSq/n-mean*mean, 0.0))
+	if textLum > mean {
+		return contrastRatio(textLum, math.Min(mean+dev, textLum))
+	}
+	return contrastRatio(textLum, math.Max(mean-dev, textLum))
+}
+
+// textLuminance returns the relative luminance of the text color of the
+// settings, which defaults to white.
+func (s *CropSettings) textLuminance() (float64, error) {
+	if s.TextColor == "" {
+		return 1.0, nil
+	}
+	c, err := parseHexColor(s.TextColor)
+	if err != nil {
+		return 0, err
+	}
+	return luminance(c), nil
+}
+
+// textZone returns the text zone of the settings within crop r.
+func (s *CropSettings) textZone(r rect) image.Rectangle {
+	z := s.TextZone
+	return image.Rect(
+		int(r.x+z.X*r.w), int(r.y+z.Y*r.h),
+		int(math.Ceil(r.x+(z.X+z.Width)*r.w)), int(math.Ceil(r.y+(z.Y+z.Height)*r.h)),
+	)
+}
+
+// readable reports whether the contrast of sc meets the minimum required by
+// the settings.
+func (s *CropSettings) readable(sc Score) bool {
+	min := s.MinContrast
+	if min <= 0 {
+		min = defaultMinContrast
+	}
+	return sc.Contrast >= min
+}
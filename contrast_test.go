@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestContrastRatio(t *testing.T) {
+	black, _ := parseHexColor("#000")
+	white, _ := parseHexColor("#ffffff")
+	if r := contrastRatio(luminance(white), luminance(black)); math.Abs(r-21.0) > 1e-9 {
+		t.Fatalf("expected contrast of 21, got %v", r)
+	}
+	if _, err := parseHexColor("#12345"); err == nil {
+		t.Fatal("expected an error for an invalid color")
+	}
+}
+
+func TestTextZone(t *testing.T) {
+	// white on the left, black on the right
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			img.SetRGBA(x, y, color.RGBA{255, 255, 255, 255})
+			img.SetRGBA(x+100, y, color.RGBA{0, 0, 0, 255})
+		}
+	}
+
+	settings := DefaultCropSettings()
+	settings.TextZone = &NormalizedRect{X: 0, Y: 0.5, Width: 1, Height: 0.5}
+	settings.TextColor = "#fff"
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ResultAnalyzer)
+	res, err := analyzer.FindBestResult(img, 100, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Crop.Min.X < 100 {
+		t.Fatalf("expected crop on the dark half, got %v", res.Crop)
+	}
+	if res.Crop.Score.Contrast < defaultMinContrast {
+		t.Fatalf("expected sufficient contrast, got %v", res.Crop.Score.Contrast)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	// cutThreshold is the fraction of the maximum saliency above which a
+	// region counts as part of a subject.
+	cutThreshold = 0.5
+	// cutFraction is the fraction of the border of a crop which needs to cut
+	// through a subject for the crop to get flagged.
+	cutFraction = 0.05
+)
+
+// saliencyMap contains the smoothed per-pixel score contributions of the
+// detector planes, i.e. how much each pixel attracts a crop.
+type saliencyMap struct {
+	values        []float64
+	width, height int
+	max           float64
+	// radius is the smoothing radius, in pixels of the planes.
+	radius    int
+	reduction int
+}
+
+// newSaliencyMap combines the detector planes, which are scaled down by the
+// factor reduction, into a saliencyMap. They get smoothed over the stride of
+// the scorer, so isolated pixels don't count as subjects.
+func newSaliencyMap(s *CropSettings, o *image.RGBA, boost *image.Gray, reduction int) *saliencyMap {
+	w, h := o.Bounds().Dx(), o.Bounds().Dy()
+	radius := s.ScoreDownSample / reduction
+	if radius < 1 {
+		radius = 1
+	}
+
+	// integral image of the unsmoothed saliency
+	integral := make([]float64, (w+1)*(h+1))
+	rows := newRGBARows(o)
+	var boostRows grayRows
+	if boost != nil {
+		boostRows = newGrayRows(boost)
+	}
+	for y := 0; y < h; y++ {
+		row := 0.0
+		pixels := rows.row(y)
+		var boostRow []uint8
+		if boost != nil {
+			boostRow = boostRows.row(y)
+		}
+		for x := 0; x < w; x++ {
+			p := pixels[x*4 : x*4+3 : x*4+3]
+			det := float64(p[1]) / 255.0
+			v := det*s.DetailWeight +
+				float64(p[0])/255.0*(det+s.SkinBias)*s.SkinWeight +
+				float64(p[2])/255.0*(det+s.SaturationBias)*s.SaturationWeight
+			if boost != nil {
+				v += float64(boostRow[x]) / 255.0 * s.BoostWeight
+			}
+			row += v
+			integral[(y+1)*(w+1)+x+1] = integral[y*(w+1)+x+1] + row
+		}
+	}
+
+	m := &saliencyMap{
+		values:    make([]float64, w*h),
+		width:     w,
+		height:    h,
+		radius:    radius,
+		reduction: reduction,
+	}
+	for y := 0; y < h; y++ {
+		y0, y1 := maxInt(y-radius, 0), minInt(y+radius+1, h)
+		for x := 0; x < w; x++ {
+			x0, x1 := maxInt(x-radius, 0), minInt(x+radius+1, w)
+			sum := integral[y1*(w+1)+x1] - integral[y0*(w+1)+x1] - integral[y1*(w+1)+x0] + integral[y0*(w+1)+x0]
+			v := sum / float64((x1-x0)*(y1-y0))
+			m.values[y*w+x] = v
+			m.max = math.Max(m.max, v)
+		}
+	}
+
+	return m
+}
+
+// salient reports whether the pixel at x, y is part of a subject.
+func (m *saliencyMap) salient(x, y int) bool {
+	if x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return false
+	}
+	return m.values[y*m.width+x] >= m.max*cutThreshold
+}
+
+// cuts reports whether the border of crop r, in coordinates of the prescaled
+// image, runs through a subject, i.e. salient pixels on both sides of it.
+func (m *saliencyMap) cuts(r rect) bool {
+	if m.max <= 0 {
+		return false
+	}
+
+	red := float64(m.reduction)
+	x0, y0 := int(math.Round(r.x/red)), int(math.Round(r.y/red))
+	x1, y1 := int(math.Round((r.x+r.w)/red)), int(math.Round((r.y+r.h)/red))
+	d := m.radius
+
+	border, cut := 0, 0
+	check := func(ix, iy, ox, oy int) {
+		border++
+		if m.salient(ix, iy) && m.salient(ox, oy) {
+			cut++
+		}
+	}
+	for x := x0; x < x1; x++ {
+		if y0 > 0 {
+			check(x, y0+d, x, y0-d-1)
+		}
+		if y1 < m.height {
+			check(x, y1-d-1, x, y1+d)
+		}
+	}
+	for y := y0; y < y1; y++ {
+		if x0 > 0 {
+			check(x0+d, y, x0-d-1, y)
+		}
+		if x1 < m.width {
+			check(x1-d-1, y, x1+d, y)
+		}
+	}
+
+	return border > 0 && float64(cut) >= float64(border)*cutFraction
+}
+
+// confidence rates how well crop r, in coordinates of the prescaled image,
+// captures the salient content: 0 if it doesn't hold more saliency than its
+// share of the area, up to 1 if it holds all of it.
+func (m *saliencyMap) confidence(r rect) float64 {
+	red := float64(m.reduction)
+	x0, y0 := maxInt(int(math.Round(r.x/red)), 0), maxInt(int(math.Round(r.y/red)), 0)
+	x1, y1 := minInt(int(math.Round((r.x+r.w)/red)), m.width), minInt(int(math.Round((r.y+r.h)/red)), m.height)
+
+	var total, inside float64
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			v := m.values[y*m.width+x]
+			total += v
+			if x >= x0 && x < x1 && y >= y0 && y < y1 {
+				inside += v
+			}
+		}
+	}
+
+	area := float64((x1-x0)*(y1-y0)) / float64(m.width*m.height)
+	if area >= 1.0 || total <= 0 {
+		return 1.0
+	}
+	return math.Min(math.Max((inside/total-area)/(1.0-area), 0.0), 1.0)
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestSubjectCut(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 100))
+	for i := range img.Pix {
+		img.Pix[i] = 128
+	}
+
+	// a subject which fits into the crop
+	res, err := FindCrop(img, Request{
+		Resizer: nfnt.NewDefaultResizer(),
+		Width:   100,
+		Height:  100,
+		Boosts:  []Boost{{Rectangle: image.Rect(130, 30, 170, 70), Weight: 1.0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.SubjectCut {
+		t.Fatalf("expected %v not to cut the subject", res.Crop.Rectangle)
+	}
+
+	// and one which is wider than any crop
+	res, err = FindCrop(img, Request{
+		Resizer: nfnt.NewDefaultResizer(),
+		Width:   100,
+		Height:  100,
+		Boosts:  []Boost{{Rectangle: image.Rect(50, 20, 250, 80), Weight: 1.0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.SubjectCut {
+		t.Fatalf("expected %v to cut the subject", res.Crop.Rectangle)
+	}
+}
@@ -34,19 +34,27 @@ package smartcrop
 import (
 	"errors"
 	"image"
-	"image/color"
 	"image/jpeg"
 	"image/png"
 	"os"
 	"path/filepath"
 )
 
-func debugOutput(debug bool, img *image.RGBA, debugType string) {
-	if debug {
-		writeImage("png", img, "./smartcrop_"+debugType+".png")
+func debugOutput(logger Logger, img image.Image, debugType string) {
+	if logger.DebugMode {
+		writeImage("png", img, logger.debugPath(debugType))
 	}
 }
 
+// debugPath returns the file name of the debug image of the given type.
+func (l Logger) debugPath(debugType string) string {
+	prefix := l.DebugPrefix
+	if prefix == "" {
+		prefix = "./smartcrop_"
+	}
+	return prefix + debugType + ".png"
+}
+
 func writeImage(imgtype string, img image.Image, name string) error {
 	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
 		panic(err)
@@ -82,18 +90,18 @@ func writeImageToPng(img image.Image, name string) error {
 	return png.Encode(fso, img)
 }
 
-func drawDebugCrop(topCrop Crop, o *image.RGBA) {
-	width := o.Bounds().Dx()
-	height := o.Bounds().Dy()
+func drawDebugCrop(s *CropSettings, topCrop Crop, o *image.RGBA) {
+	rows := newRGBARows(o)
 
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			r, g, b, _ := o.At(x, y).RGBA()
-			r8 := float64(r >> 8)
-			g8 := float64(g >> 8)
-			b8 := uint8(b >> 8)
+	for y := 0; y < rows.height; y++ {
+		row := rows.row(y)
+		for x := 0; x < rows.width; x++ {
+			p := row[x*4 : x*4+4 : x*4+4]
+			r8 := float64(p[0])
+			g8 := float64(p[1])
+			b8 := p[2]
 
-			imp := importance(topCrop, x, y)
+			imp := importance(s, topCrop, x, y)
 
 			if imp > 0 {
 				g8 += imp * 32
@@ -101,8 +109,7 @@ func drawDebugCrop(topCrop Crop, o *image.RGBA) {
 				r8 += imp * -64
 			}
 
-			nc := color.RGBA{uint8(bounds(r8)), uint8(bounds(g8)), b8, 255}
-			o.SetRGBA(x, y, nc)
+			p[0], p[1], p[2], p[3] = uint8(bounds(r8)), uint8(bounds(g8)), b8, 255
 		}
 	}
 }
@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"image"
+)
+
+// Decision describes a single crop decision, as passed to the OnResult
+// callback of the settings. It is meant for logging the decisions of
+// different weight profiles, e.g. in A/B tests, so they can later be related
+// to click-through data.
+type Decision struct {
+	// ImageFingerprint identifies the analysed image. It is derived from its
+	// dimensions and the prescaled image, so it's only stable for the same
+	// Resizer.
+	ImageFingerprint string       `json:"imageFingerprint"`
+	Width            int          `json:"width"`
+	Height           int          `json:"height"`
+	Settings         CropSettings `json:"settings"`
+	Result           Result       `json:"result"`
+
+	// Source is the analysed image, for callbacks needing more than its
+	// fingerprint.
+	Source image.Image `json:"-"`
+}
+
+// imageFingerprint returns the fingerprint of the image analysed in st.
+func imageFingerprint(st *State) string {
+	h := sha256.New()
+	b := st.Source.Bounds()
+	_ = binary.Write(h, binary.LittleEndian, []int64{int64(b.Dx()), int64(b.Dy())})
+
+	p := st.Prescaled
+	pb := p.Bounds()
+	for y := pb.Min.Y; y < pb.Max.Y; y++ {
+		off := p.PixOffset(pb.Min.X, y)
+		h.Write(p.Pix[off : off+pb.Dx()*4])
+	}
+
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
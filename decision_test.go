@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"encoding/json"
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestOnResult(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decisions []Decision
+	settings := DefaultCropSettings()
+	settings.OnResult = func(d Decision) {
+		decisions = append(decisions, d)
+	}
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings)
+	for i := 0; i < 2; i++ {
+		if _, err := analyzer.FindBestCrop(img, 250, 250); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(decisions) != 2 {
+		t.Fatalf("expected 2 decisions, got %d", len(decisions))
+	}
+	d := decisions[0]
+	if d.ImageFingerprint == "" || d.ImageFingerprint != decisions[1].ImageFingerprint {
+		t.Fatalf("expected stable image fingerprints, got %q and %q", d.ImageFingerprint, decisions[1].ImageFingerprint)
+	}
+	if d.Result.ParamsHash != d.Settings.Fingerprint() {
+		t.Fatalf("expected params hash %q, got %q", d.Settings.Fingerprint(), d.Result.ParamsHash)
+	}
+	if _, err := json.Marshal(d); err != nil {
+		t.Fatal(err)
+	}
+}
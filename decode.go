@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+)
+
+// ErrUnsupportedFormat is matched by the UnsupportedFormatError returned when
+// an image can't be decoded because no decoder is registered for its format.
+var ErrUnsupportedFormat = errors.New("Unsupported image format")
+
+// UnsupportedFormatError is returned by Decode for images of a format no
+// decoder is registered for. Format is the format sniffed from the first
+// bytes of the image, or empty if it's not recognized at all.
+type UnsupportedFormatError struct {
+	Format string
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	if e.Format == "" {
+		return "smartcrop: unrecognized image format"
+	}
+	return fmt.Sprintf("smartcrop: unsupported image format %s", e.Format)
+}
+
+// Is reports whether target is ErrUnsupportedFormat.
+func (e *UnsupportedFormatError) Is(target error) bool {
+	return target == ErrUnsupportedFormat
+}
+
+// sniffLen is the number of bytes SniffFormat needs to recognize a format.
+const sniffLen = 16
+
+// formatMagic is the magic a format starts with, where ? matches any byte.
+type formatMagic struct {
+	format string
+	magic  string
+}
+
+var formatMagics = []formatMagic{
+	{"jpeg", "\xff\xd8\xff"},
+	{"png", "\x89PNG\r\n\x1a\n"},
+	{"gif", "GIF87a"},
+	{"gif", "GIF89a"},
+	{"webp", "RIFF????WEBP"},
+	{"bmp", "BM"},
+	{"tiff", "II*\x00"},
+	{"tiff", "MM\x00*"},
+	{"avif", "????ftypavif"},
+	{"avif", "????ftypavis"},
+	{"heif", "????ftypheic"},
+	{"heif", "????ftypheix"},
+	{"heif", "????ftypmif1"},
+	{"heif", "????ftypmsf1"},
+	{"jxl", "\xff\x0a"},
+	{"jxl", "\x00\x00\x00\x0cJXL \r\n\x87\n"},
+	{"ico", "\x00\x00\x01\x00"},
+	{"psd", "8BPS"},
+	{"pdf", "%PDF-"},
+}
+
+// SniffFormat returns the name of the image format header starts with, e.g.
+// "jpeg" or "webp", or an empty string if it's not recognized. It only looks
+// at the magic bytes, so it's not fooled by misleading file extensions or
+// content types, and it recognizes formats whose decoders aren't registered.
+func SniffFormat(header []byte) string {
+	for _, m := range formatMagics {
+		if matchMagic(header, m.magic) {
+			return m.format
+		}
+	}
+	if isSVG(header) {
+		return "svg"
+	}
+	return ""
+}
+
+func matchMagic(header []byte, magic string) bool {
+	if len(header) < len(magic) {
+		return false
+	}
+	for i := 0; i < len(magic); i++ {
+		if magic[i] != '?' && magic[i] != header[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isSVG(header []byte) bool {
+	header = bytes.TrimLeft(header, " \t\r\n")
+	return bytes.HasPrefix(header, []byte("<svg")) || bytes.HasPrefix(header, []byte("<?xml"))
+}
+
+// Decode decodes an image of any format registered with the image package,
+// like image.Decode. The format is determined from the magic bytes of the
+// image only, and if no decoder is registered for it, an
+// UnsupportedFormatError naming the sniffed format is returned.
+func Decode(r io.Reader) (image.Image, string, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return nil, "", err
+	}
+
+	img, format, err := image.Decode(br)
+	if err == image.ErrFormat {
+		return nil, "", &UnsupportedFormatError{Format: SniffFormat(header)}
+	}
+	return img, format, err
+}
+
+// FindCropReader decodes the image read from r with Decode and finds its best
+// crop for everything specified in req, see FindCrop.
+func FindCropReader(r io.Reader, req Request) (Result, error) {
+	img, _, err := Decode(r)
+	if err != nil {
+		return Result{}, err
+	}
+	return FindCrop(img, req)
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	_ "image/jpeg"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestSniffFormat(t *testing.T) {
+	tests := map[string]string{
+		"\xff\xd8\xff\xe0\x00\x10JFIF":      "jpeg",
+		"\x89PNG\r\n\x1a\n\x00\x00\x00\x0d": "png",
+		"GIF89a\x01\x00":                    "gif",
+		"RIFF\x24\x00\x00\x00WEBPVP8 ":      "webp",
+		"\x00\x00\x00\x18ftypheic\x00\x00":  "heif",
+		"\x00\x00\x00\x1cftypavif\x00\x00":  "avif",
+		"%PDF-1.7":                          "pdf",
+		"\n  <svg xmlns=":                   "svg",
+		"hello world":                       "",
+		"":                                  "",
+	}
+	for header, format := range tests {
+		if f := SniffFormat([]byte(header)); f != format {
+			t.Errorf("expected %q for %q, got %q", format, header, f)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	buf, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, format, err := Decode(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "jpeg" || img.Bounds().Dx() != 900 {
+		t.Fatalf("expected a 900 pixels wide jpeg, got a %d pixels wide %s", img.Bounds().Dx(), format)
+	}
+
+	_, _, err = Decode(strings.NewReader("RIFF\x24\x00\x00\x00WEBPVP8 \x00\x00"))
+	if e, ok := err.(*UnsupportedFormatError); !ok || e.Format != "webp" {
+		t.Fatalf("expected an unsupported webp image, got %v", err)
+	}
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("expected %v to match ErrUnsupportedFormat", err)
+	}
+
+	_, _, err = Decode(strings.NewReader("not an image"))
+	if e, ok := err.(*UnsupportedFormatError); !ok || e.Format != "" {
+		t.Fatalf("expected an unrecognized image, got %v", err)
+	}
+}
+
+func TestFindCropReader(t *testing.T) {
+	buf, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := FindCropReader(bytes.NewReader(buf), Request{Width: 250, Height: 250, Resizer: nfnt.NewDefaultResizer()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Crop.Dx() != res.Crop.Dy() || res.Crop.Dx() == 0 {
+		t.Fatalf("expected a square crop, got %v", res.Crop.Rectangle)
+	}
+}
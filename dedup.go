@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import "image"
+
+// candidateSet is a spatial hash of the crop candidates generated so far, to
+// skip near-duplicates of them.
+type candidateSet struct {
+	eps   int
+	cells map[image.Point][]image.Rectangle
+}
+
+func newCandidateSet(eps int) *candidateSet {
+	return &candidateSet{eps: eps, cells: map[image.Point][]image.Rectangle{}}
+}
+
+// add adds r to the set, unless all edges of another candidate of the set
+// lie less than eps pixels from the ones of r. It reports whether r has been
+// added.
+func (c *candidateSet) add(r image.Rectangle) bool {
+	// near-duplicates lie in the cell of r or one of its neighbours
+	cell := image.Pt(floorDiv(r.Min.X, c.eps), floorDiv(r.Min.Y, c.eps))
+	for y := cell.Y - 1; y <= cell.Y+1; y++ {
+		for x := cell.X - 1; x <= cell.X+1; x++ {
+			for _, o := range c.cells[image.Pt(x, y)] {
+				if absInt(o.Min.X-r.Min.X) < c.eps && absInt(o.Min.Y-r.Min.Y) < c.eps &&
+					absInt(o.Max.X-r.Max.X) < c.eps && absInt(o.Max.Y-r.Max.Y) < c.eps {
+					return false
+				}
+			}
+		}
+	}
+	c.cells[cell] = append(c.cells[cell], r)
+	return true
+}
+
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
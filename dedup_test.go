@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"testing"
+)
+
+func TestDedupCandidates(t *testing.T) {
+	s := DefaultCropSettings()
+	s.ScaleStep = 0.02
+
+	count := func(s CropSettings) []image.Rectangle {
+		var res []image.Rectangle
+		crops(&s, image.Rect(0, 0, 403, 301), 200, 200, 0.9, func(crop Crop) bool {
+			res = append(res, crop.Rectangle)
+			return true
+		})
+		return res
+	}
+	all := count(s)
+	s.DedupEpsilon = 5
+	deduped := count(s)
+	t.Logf("candidates: %d, deduplicated: %d", len(all), len(deduped))
+	if len(deduped) >= len(all)*3/4 {
+		t.Fatalf("expected at least a quarter of %d candidates to be skipped, got %d", len(all), len(deduped))
+	}
+
+	for i, a := range deduped {
+		for _, b := range deduped[i+1:] {
+			if absInt(a.Min.X-b.Min.X) < 5 && absInt(a.Min.Y-b.Min.Y) < 5 && absInt(a.Max.X-b.Max.X) < 5 && absInt(a.Max.Y-b.Max.Y) < 5 {
+				t.Fatalf("expected no near-duplicates, got %v and %v", a, b)
+			}
+		}
+	}
+}
+
+func TestCandidateSet(t *testing.T) {
+	set := newCandidateSet(4)
+	for _, c := range []struct {
+		r     image.Rectangle
+		added bool
+	}{
+		{image.Rect(-2, -2, 10, 10), true},
+		{image.Rect(2, 2, 14, 14), true},
+		{image.Rect(0, 1, 12, 13), false},
+		{image.Rect(-5, -5, 9, 9), false},
+		{image.Rect(-6, -2, 10, 10), true},
+	} {
+		if added := set.add(c.r); added != c.added {
+			t.Fatalf("expected adding %v to report %v", c.r, c.added)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+)
+
+// The names of the denoise filters, see CropSettings.Denoise.
+const (
+	// DenoiseMedian replaces every pixel by the median of its 3x3
+	// neighborhood, per channel. It removes salt-and-pepper noise best.
+	DenoiseMedian = "median"
+	// DenoiseBilateral averages every pixel with the ones of its 5x5
+	// neighborhood of similar lightness, which smooths noise but keeps edges.
+	DenoiseBilateral = "bilateral"
+)
+
+const (
+	// bilateralRadius is the radius of the neighborhood of the bilateral
+	// filter, in pixels.
+	bilateralRadius = 2
+	// bilateralSpace and bilateralRange are the standard deviations of the
+	// Gaussians weighting the neighbors by distance and lightness difference.
+	bilateralSpace = 1.5
+	bilateralRange = 25.0
+)
+
+// medianFilter returns img with every channel of every pixel replaced by the
+// median of its 3x3 neighborhood.
+func medianFilter(img *image.RGBA) *image.RGBA {
+	b := img.Bounds()
+	d := image.NewRGBA(b)
+
+	var window [9]uint8
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			di := d.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				n := 0
+				for ny := maxInt(y-1, b.Min.Y); ny <= minInt(y+1, b.Max.Y-1); ny++ {
+					for nx := maxInt(x-1, b.Min.X); nx <= minInt(x+1, b.Max.X-1); nx++ {
+						// insertion sort
+						v := img.Pix[img.PixOffset(nx, ny)+c]
+						j := n
+						for ; j > 0 && window[j-1] > v; j-- {
+							window[j] = window[j-1]
+						}
+						window[j] = v
+						n++
+					}
+				}
+				d.Pix[di+c] = window[n/2]
+			}
+		}
+	}
+	return d
+}
+
+// bilateralFilter returns img smoothed by an approximate bilateral filter,
+// which weights the neighbors of a pixel by their lightness difference
+// instead of the full color difference.
+func bilateralFilter(img *image.RGBA) *image.RGBA {
+	t := getColorTables()
+	b := img.Bounds()
+	d := image.NewRGBA(b)
+
+	var space [2*bilateralRadius + 1][2*bilateralRadius + 1]float64
+	for dy := -bilateralRadius; dy <= bilateralRadius; dy++ {
+		for dx := -bilateralRadius; dx <= bilateralRadius; dx++ {
+			space[dy+bilateralRadius][dx+bilateralRadius] = math.Exp(-float64(dx*dx+dy*dy) / (2.0 * bilateralSpace * bilateralSpace))
+		}
+	}
+	var similarity [256]float64
+	for i := range similarity {
+		similarity[i] = math.Exp(-float64(i*i) / (2.0 * bilateralRange * bilateralRange))
+	}
+
+	lightness := func(i int) int {
+		return int(t.cie(img.Pix[i], img.Pix[i+1], img.Pix[i+2]))
+	}
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			ci := img.PixOffset(x, y)
+			l := lightness(ci)
+
+			var sum [4]float64
+			norm := 0.0
+			for ny := maxInt(y-bilateralRadius, b.Min.Y); ny <= minInt(y+bilateralRadius, b.Max.Y-1); ny++ {
+				for nx := maxInt(x-bilateralRadius, b.Min.X); nx <= minInt(x+bilateralRadius, b.Max.X-1); nx++ {
+					ni := img.PixOffset(nx, ny)
+					diff := minInt(absInt(lightness(ni)-l), 255)
+					w := space[ny-y+bilateralRadius][nx-x+bilateralRadius] * similarity[diff]
+					for c := 0; c < 4; c++ {
+						sum[c] += w * float64(img.Pix[ni+c])
+					}
+					norm += w
+				}
+			}
+
+			di := d.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				d.Pix[di+c] = uint8(math.Round(sum[c] / norm))
+			}
+		}
+	}
+	return d
+}
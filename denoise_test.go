@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestDenoise(t *testing.T) {
+	// a noisy photo of a dark and a bright half
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	rnd := rand.New(rand.NewSource(1))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			v := 60
+			if x >= 50 {
+				v = 190
+			}
+			v += rnd.Intn(21) - 10
+			img.SetRGBA(x, y, color.RGBA{uint8(v), uint8(v), uint8(v), 255})
+		}
+	}
+
+	edges := func(img *image.RGBA) (noise, edge int) {
+		o := image.NewRGBA(img.Bounds())
+		edgeDetect(img, o)
+		for y := 1; y < 99; y++ {
+			for x := 1; x < 99; x++ {
+				if v := int(o.Pix[o.PixOffset(x, y)+1]); x == 49 || x == 50 {
+					edge += v
+				} else {
+					noise += v
+				}
+			}
+		}
+		return noise, edge
+	}
+
+	noise, edge := edges(img)
+	for name, filter := range map[string]func(*image.RGBA) *image.RGBA{
+		DenoiseMedian:    medianFilter,
+		DenoiseBilateral: bilateralFilter,
+	} {
+		n, e := edges(filter(img))
+		if n > noise/3 {
+			t.Fatalf("expected %s to remove most of the noise, got %d, was %d", name, n, noise)
+		}
+		if e < edge/2 {
+			t.Fatalf("expected %s to keep the edge, got %d, was %d", name, e, edge)
+		}
+	}
+
+	settings := DefaultCropSettings()
+	settings.Denoise = "unknown"
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings)
+	if _, err := analyzer.FindBestCrop(img, 50, 50); err == nil {
+		t.Fatal("expected an error for an unknown denoise filter")
+	}
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"sync"
+)
+
+// Detector finds regions of interest the built-in detectors don't know about,
+// e.g. faces or text.
+type Detector interface {
+	// Detect returns the importance of every pixel of img, ranging from 0 to
+	// 255, as an image with the same bounds as img.
+	Detect(img *image.RGBA) (*image.Gray, error)
+}
+
+// DetectorFactory creates a Detector for the given settings.
+type DetectorFactory func(s CropSettings) (Detector, error)
+
+var (
+	detectorsMu sync.RWMutex
+	detectors   = map[string]DetectorFactory{}
+)
+
+// RegisterDetector makes a Detector available under the given name, so it can
+// be enabled in CropSettings.Detectors. It is meant to be called from the init
+// function of the package implementing it, similar to image.RegisterFormat.
+func RegisterDetector(name string, factory DetectorFactory) {
+	detectorsMu.Lock()
+	defer detectorsMu.Unlock()
+
+	if factory == nil {
+		panic("smartcrop: RegisterDetector factory is nil")
+	}
+	detectors[name] = factory
+}
+
+// Detectors returns the names of all registered Detectors.
+func Detectors() []string {
+	detectorsMu.RLock()
+	defer detectorsMu.RUnlock()
+
+	names := make([]string, 0, len(detectors))
+	for name := range detectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// boostDetect runs all Detectors enabled in the settings and mixes their output
+// into a single boost plane.
+func boostDetect(s *CropSettings, img *image.RGBA) (*image.Gray, error) {
+	names := make([]string, 0, len(s.Detectors))
+	for name := range s.Detectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	r := img.Bounds()
+	acc := getFloats(r.Dx() * r.Dy())
+	defer putFloats(acc)
+	for _, name := range names {
+		detectorsMu.RLock()
+		factory, ok := detectors[name]
+		detectorsMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("smartcrop: unknown detector %q", name)
+		}
+
+		d, err := factory(*s)
+		if err != nil {
+			return nil, fmt.Errorf("smartcrop: can't create detector %q: %v", name, err)
+		}
+		plane, err := d.Detect(img)
+		if err != nil {
+			return nil, fmt.Errorf("smartcrop: detector %q failed: %v", name, err)
+		}
+		if plane.Bounds() != r {
+			return nil, fmt.Errorf("smartcrop: detector %q returned bounds %v, expected %v", name, plane.Bounds(), r)
+		}
+
+		weight := s.Detectors[name]
+		rows := newGrayRows(plane)
+		i := 0
+		for y := 0; y < rows.height; y++ {
+			for _, v := range rows.row(y) {
+				acc[i] += float64(v) * weight
+				i++
+			}
+		}
+	}
+
+	boost := image.NewGray(r)
+	i := 0
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			boost.Pix[y*boost.Stride+x] = uint8(bounds(acc[i]))
+			i++
+		}
+	}
+
+	return boost, nil
+}
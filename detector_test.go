@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+// leftDetector marks the left quarter of an image as important.
+type leftDetector struct{}
+
+func (leftDetector) Detect(img *image.RGBA) (*image.Gray, error) {
+	plane := image.NewGray(img.Bounds())
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx()/4; x++ {
+			plane.Pix[plane.PixOffset(x, y)] = 255
+		}
+	}
+	return plane, nil
+}
+
+func TestDetector(t *testing.T) {
+	RegisterDetector("left", func(s CropSettings) (Detector, error) {
+		return leftDetector{}, nil
+	})
+
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := DefaultCropSettings()
+	settings.Detectors = map[string]float64{"left": 1.0}
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings)
+	topCrop, err := analyzer.FindBestCrop(img, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if topCrop.Min.X > img.Bounds().Dx()/4 {
+		t.Fatalf("expected crop to include the left quarter, got %v", topCrop)
+	}
+
+	settings.Detectors = map[string]float64{"unknown": 1.0}
+	analyzer = NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings)
+	if _, err := analyzer.FindBestCrop(img, 250, 250); err == nil {
+		t.Fatal("expected an error for an unknown detector")
+	}
+}
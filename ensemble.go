@@ -0,0 +1,189 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+)
+
+// The ways of combining the crops of the strategies of an ensemble, see
+// CropSettings.EnsembleMode.
+const (
+	// EnsembleBest returns the crop of the strategy with the highest
+	// confidence times its weight. It's the default.
+	EnsembleBest = "best"
+	// EnsembleBlend blends the crops of the strategies into the boost plane
+	// of the smart analysis, which then finds the crop. Each crop counts as
+	// an importance map of the confidence of its strategy within the crop,
+	// weighted by the weight of the strategy relative to the one of
+	// StrategySmart, which is 1 if it's not part of the ensemble. BoostWeight
+	// scales them all.
+	EnsembleBlend = "blend"
+)
+
+// runEnsemble runs the strategies of the ensemble of the settings, and
+// combines their crops as set by EnsembleMode.
+func (p pipeline) runEnsemble(st *State) error {
+	names := make([]string, 0, len(p.settings.Ensemble))
+	for name := range p.settings.Ensemble {
+		names = append(names, name)
+	}
+	// in a fixed order, so ties always get resolved the same way
+	sort.Strings(names)
+
+	switch p.settings.EnsembleMode {
+	case "", EnsembleBest:
+		return p.ensembleBest(st, names)
+	case EnsembleBlend:
+		return p.ensembleBlend(st, names)
+	}
+	return fmt.Errorf("smartcrop: unknown ensemble mode %q", p.settings.EnsembleMode)
+}
+
+func (p pipeline) ensembleBest(st *State, names []string) error {
+	if err := p.runStages(st, StagePrescale, StagePrescale); err != nil {
+		return err
+	}
+
+	var best Result
+	var points []AttentionPoint
+	top := -1.0
+	for _, name := range names {
+		var res Result
+		if name == StrategySmart {
+			err := p.runStages(st, StageDetect, StageSelect)
+			if err == ErrVetoed {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			res = st.Result
+			res.Strategy = name
+			points = res.AttentionPoints
+		} else {
+			strategy, err := lookupStrategy(name)
+			if err != nil {
+				return err
+			}
+			var ok bool
+			if res, ok, err = p.strategyResult(st, name, strategy); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+		}
+
+		if weighted := res.Confidence * p.settings.Ensemble[name]; weighted > top {
+			best, top = res, weighted
+		}
+	}
+	if top < 0 {
+		return ErrVetoed
+	}
+
+	st.Result = best
+	if best.Strategy != StrategySmart {
+		// the attention points don't depend on the strategy
+		st.Result.AttentionPoints = points
+	}
+	return nil
+}
+
+func (p pipeline) ensembleBlend(st *State, names []string) error {
+	if err := p.runStages(st, StagePrescale, StagePrescale); err != nil {
+		return err
+	}
+
+	base := 1.0
+	if w, ok := p.settings.Ensemble[StrategySmart]; ok {
+		base = w
+	}
+	var crops []Result
+	for _, name := range names {
+		if name == StrategySmart {
+			continue
+		}
+		strategy, err := lookupStrategy(name)
+		if err != nil {
+			return err
+		}
+		res, ok, err := p.strategyResult(st, name, strategy)
+		if err != nil {
+			return err
+		}
+		if ok {
+			crops = append(crops, res)
+		}
+	}
+
+	if err := p.runStages(st, StageDetect, StageDetect); err != nil {
+		return err
+	}
+	for _, res := range crops {
+		st.Boost = blendCrop(st.Boost, st.Detected.Bounds(), res.Normalized, res.Confidence*p.settings.Ensemble[res.Strategy]/base)
+	}
+	if err := p.runStages(st, StageCandidates, StageSelect); err != nil {
+		return err
+	}
+	st.Result.Strategy = StrategySmart
+	return nil
+}
+
+// blendCrop adds the crop norm, in normalized coordinates, to the boost
+// plane with the given bounds, with an importance of weight within it,
+// ranging from 0 to 1. It creates the plane if it's nil.
+func blendCrop(boost *image.Gray, bounds image.Rectangle, norm NormalizedRect, weight float64) *image.Gray {
+	if boost == nil {
+		boost = image.NewGray(bounds)
+	}
+	v := int(math.Round(math.Min(weight, 1.0) * 255.0))
+	if v <= 0 {
+		return boost
+	}
+
+	b := boost.Bounds()
+	w, h := float64(b.Dx()), float64(b.Dy())
+	r := image.Rect(
+		int(math.Floor(norm.X*w)), int(math.Floor(norm.Y*h)),
+		int(math.Ceil((norm.X+norm.Width)*w)), int(math.Ceil((norm.Y+norm.Height)*h)),
+	).Add(b.Min).Intersect(b)
+
+	rows := newGrayRows(boost)
+	r = r.Sub(b.Min)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		row := rows.row(y)
+		for x := r.Min.X; x < r.Max.X; x++ {
+			row[x] = uint8(minInt(int(row[x])+v, 255))
+		}
+	}
+	return boost
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+// rightStrategy crops the right edge of an image, fully confident about it.
+type rightStrategy struct{}
+
+func (rightStrategy) Find(s *CropSettings, st *State) (NormalizedRect, float64, error) {
+	return widest(st, 1.0, 0.5), 1.0, nil
+}
+
+func TestEnsemble(t *testing.T) {
+	RegisterStrategy("right", rightStrategy{})
+
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	find := func(ensemble map[string]float64, mode string) Result {
+		settings := DefaultCropSettings()
+		settings.Ensemble = ensemble
+		settings.EnsembleMode = mode
+		analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings)
+		res, err := analyzer.(ResultAnalyzer).FindBestResult(img, 250, 250)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	smart := find(map[string]float64{StrategySmart: 1}, "")
+	if smart.Strategy != StrategySmart {
+		t.Fatalf("expected a smart crop, got %q", smart.Strategy)
+	}
+
+	// the center crop is fully confident, but weighs too little
+	res := find(map[string]float64{StrategySmart: 1, StrategyCenter: smart.Confidence / 2}, EnsembleBest)
+	if res.Strategy != StrategySmart || res.Crop.Rectangle != smart.Crop.Rectangle {
+		t.Fatalf("expected the smart crop %v, got %q with %v", smart.Crop.Rectangle, res.Strategy, res.Crop.Rectangle)
+	}
+	res = find(map[string]float64{StrategySmart: 1, StrategyCenter: 1}, EnsembleBest)
+	if res.Strategy != StrategyCenter || res.Crop.Rectangle != image.Rect(308, 0, 592, 284) {
+		t.Fatalf("expected the center crop, got %q with %v", res.Strategy, res.Crop.Rectangle)
+	}
+	if len(res.AttentionPoints) != len(smart.AttentionPoints) {
+		t.Fatalf("expected the attention points of the smart analysis, got %v", res.AttentionPoints)
+	}
+
+	// blended in, the crop of the right strategy pulls the smart crop over
+	res = find(map[string]float64{StrategySmart: 1, "right": 1}, EnsembleBlend)
+	if res.Strategy != StrategySmart || res.Crop.Min.X <= smart.Crop.Min.X {
+		t.Fatalf("expected a smart crop right of %v, got %q with %v", smart.Crop.Rectangle, res.Strategy, res.Crop.Rectangle)
+	}
+}
+
+func TestBlendCrop(t *testing.T) {
+	bounds := image.Rect(10, 10, 20, 20)
+	boost := image.NewGray(bounds)
+	boost.SetGray(12, 12, color.Gray{Y: 200})
+
+	boost = blendCrop(boost, bounds, NormalizedRect{X: 0, Y: 0, Width: 0.5, Height: 0.5}, 0.5)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			expected := uint8(0)
+			switch {
+			case x == 12 && y == 12:
+				expected = 255
+			case x < 15 && y < 15:
+				expected = 128
+			}
+			if v := boost.GrayAt(x, y).Y; v != expected {
+				t.Fatalf("expected %d at %d,%d, got %d", expected, x, y, v)
+			}
+		}
+	}
+
+	if blendCrop(nil, bounds, NormalizedRect{Width: 1, Height: 1}, 2).Bounds() != bounds {
+		t.Fatal("expected a new plane with the given bounds")
+	}
+}
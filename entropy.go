@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"io/ioutil"
+	"log"
+	"math"
+
+	"github.com/muesli/smartcrop/options"
+)
+
+// StrategyEntropy trims the image from the side with the least information,
+// see NewEntropyAnalyzer.
+const StrategyEntropy = "entropy"
+
+// entropySlice is the width of the slices trimmed off per step, in pixels of
+// the prescaled image.
+const entropySlice = 10
+
+func init() {
+	RegisterStrategy(StrategyEntropy, entropyStrategy{})
+}
+
+type entropyAnalyzer struct {
+	logger   Logger
+	settings CropSettings
+	options.Resizer
+}
+
+// NewEntropyAnalyzer returns an Analyzer implementing the classic entropy
+// crop: starting from the whole image, slices get trimmed off the side with
+// the lower entropy of its luminance histogram until the crop has the
+// requested aspect ratio. It is a lot faster than the default analysis and
+// deterministic, but only knows about detail, not about skin, saturation or
+// composition. Only the prescale settings are used.
+func NewEntropyAnalyzer(resizer options.Resizer, logger Logger, settings CropSettings) ResultAnalyzer {
+	if logger.Log == nil {
+		logger.Log = log.New(ioutil.Discard, "", 0)
+	}
+	return &entropyAnalyzer{Resizer: resizer, logger: logger, settings: settings}
+}
+
+func (o entropyAnalyzer) FindBestCrop(img image.Image, width, height int) (image.Rectangle, error) {
+	res, err := o.FindBestResult(img, width, height)
+	return res.Crop.Rectangle, err
+}
+
+func (o entropyAnalyzer) FindBestResult(img image.Image, width, height int) (res Result, err error) {
+	defer recoverAnalysis(&err, img, width, height, o.settings)
+
+	if width, height, err = validateImage(img, width, height); err != nil {
+		return Result{}, err
+	}
+
+	st := &State{Source: img, Width: width, Height: height}
+	p := pipeline{logger: o.logger, settings: &o.settings, resizer: o.Resizer}
+	if err := p.runStages(st, StagePrescale, StagePrescale); err != nil {
+		return Result{}, err
+	}
+
+	norm, confidence, err := entropyStrategy{}.Find(&o.settings, st)
+	if err != nil {
+		return Result{}, err
+	}
+	res = p.result(st, Crop{}, norm)
+	res.Strategy = StrategyEntropy
+	res.Confidence = confidence
+	return res, nil
+}
+
+type entropyStrategy struct{}
+
+// Find trims the prescaled image down to the widest crop of the requested
+// aspect ratio. The confidence is the share of the entropy of the image the
+// crop retains.
+func (entropyStrategy) Find(s *CropSettings, st *State) (NormalizedRect, float64, error) {
+	img := st.Prescaled
+	b := img.Bounds()
+	target := widest(st, 0.5, 0.5)
+	tw := int(math.Round(target.Width * float64(b.Dx())))
+	th := int(math.Round(target.Height * float64(b.Dy())))
+
+	area := st.PrescaledArea().Add(b.Min)
+	r := area
+	for r.Dx() > tw {
+		n := minInt(entropySlice, r.Dx()-tw)
+		left := image.Rect(r.Min.X, r.Min.Y, r.Min.X+n, r.Max.Y)
+		right := image.Rect(r.Max.X-n, r.Min.Y, r.Max.X, r.Max.Y)
+		if entropy(img, left) < entropy(img, right) {
+			r.Min.X += n
+		} else {
+			r.Max.X -= n
+		}
+	}
+	for r.Dy() > th {
+		n := minInt(entropySlice, r.Dy()-th)
+		top := image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+n)
+		bottom := image.Rect(r.Min.X, r.Max.Y-n, r.Max.X, r.Max.Y)
+		if entropy(img, top) < entropy(img, bottom) {
+			r.Min.Y += n
+		} else {
+			r.Max.Y -= n
+		}
+	}
+
+	confidence := 1.0
+	if total := entropy(img, area); total > 0 {
+		confidence = math.Min(entropy(img, r)/total, 1.0)
+	}
+
+	lw, lh := float64(b.Dx()), float64(b.Dy())
+	return NormalizedRect{
+		X:      float64(r.Min.X-b.Min.X) / lw,
+		Y:      float64(r.Min.Y-b.Min.Y) / lh,
+		Width:  float64(r.Dx()) / lw,
+		Height: float64(r.Dy()) / lh,
+	}, confidence, nil
+}
+
+// entropy returns the Shannon entropy of the luminance histogram of the
+// pixels of img within r, in bits.
+func entropy(img *image.RGBA, r image.Rectangle) float64 {
+	t := getColorTables()
+	var hist [256]int
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		i := img.PixOffset(r.Min.X, y)
+		for x := r.Min.X; x < r.Max.X; x, i = x+1, i+4 {
+			l := t.cie(img.Pix[i], img.Pix[i+1], img.Pix[i+2])
+			hist[uint8(math.Min(math.Max(l, 0), 255))]++
+		}
+	}
+
+	n := float64(r.Dx() * r.Dy())
+	e := 0.0
+	for _, c := range hist {
+		if c > 0 {
+			p := float64(c) / n
+			e -= p * math.Log2(p)
+		}
+	}
+	return e
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestEntropy(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	if e := entropy(img, img.Bounds()); e != 0 {
+		t.Fatalf("expected no entropy for a flat image, got %v", e)
+	}
+
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 5; x++ {
+			img.SetRGBA(x, y, color.RGBA{255, 255, 255, 255})
+		}
+	}
+	if e := entropy(img, img.Bounds()); e != 1 {
+		t.Fatalf("expected an entropy of 1 bit for two colors, got %v", e)
+	}
+}
+
+func TestEntropyAnalyzer(t *testing.T) {
+	// a flat image with noise on its right
+	img := image.NewRGBA(image.Rect(0, 0, 600, 200))
+	rnd := rand.New(rand.NewSource(1))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 600; x++ {
+			c := color.RGBA{128, 128, 128, 255}
+			if x >= 450 {
+				c = color.RGBA{uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	analyzer := NewEntropyAnalyzer(nfnt.NewDefaultResizer(), Logger{}, DefaultCropSettings())
+	res, err := analyzer.FindBestResult(img, 100, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Crop.Rectangle != image.Rect(400, 0, 600, 200) {
+		t.Fatalf("expected the crop to cover the noise, got %v", res.Crop.Rectangle)
+	}
+	if res.Strategy != StrategyEntropy || res.Confidence <= 0.9 {
+		t.Fatalf("expected a confident entropy crop, got %q with confidence %v", res.Strategy, res.Confidence)
+	}
+
+	// the same crop as a strategy of the default analyzer
+	settings := DefaultCropSettings()
+	settings.Strategies = []string{StrategyEntropy}
+	smart := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ResultAnalyzer)
+	sres, err := smart.FindBestResult(img, 100, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sres.Strategy != StrategyEntropy || sres.Crop.Rectangle != res.Crop.Rectangle {
+		t.Fatalf("expected the entropy crop %v, got %q with %v", res.Crop.Rectangle, sres.Strategy, sres.Crop.Rectangle)
+	}
+}
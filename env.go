@@ -0,0 +1,215 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding settings.
+const EnvPrefix = "SMARTCROP_"
+
+// WithEnv returns s with the settings given in environ, which holds
+// "KEY=value" pairs like os.Environ does. Each setting is read from the
+// variable named after its JSON name, e.g. SMARTCROP_SKIN_WEIGHT for
+// skinWeight. Other variables are ignored.
+//
+// Strategies are given as a comma-separated list, Detectors as a
+// comma-separated list of name=weight pairs, TextZone as x,y,width,height
+// and Budget and MaxDuration as durations like 50ms.
+func (s CropSettings) WithEnv(environ []string) (CropSettings, error) {
+	env := map[string]string{}
+	for _, kv := range environ {
+		if i := strings.IndexByte(kv, '='); i > 0 && strings.HasPrefix(kv, EnvPrefix) {
+			env[kv[:i]] = kv[i+1:]
+		}
+	}
+
+	s = s.copy()
+	v := reflect.ValueOf(&s).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		key := envName(name)
+		value, ok := env[key]
+		if !ok {
+			continue
+		}
+		if err := setEnvField(v.Field(i), strings.TrimSpace(value)); err != nil {
+			return CropSettings{}, fmt.Errorf("smartcrop: invalid %s: %v", key, err)
+		}
+	}
+	return s, nil
+}
+
+// LoadEnvSettings returns the settings of a deployment, layering the
+// config file at path and its profile, see LoadConfig, and the variables
+// of environ, see WithEnv, on top of the defaults. If path or profile are
+// empty, they are read from SMARTCROP_CONFIG and SMARTCROP_PROFILE.
+func LoadEnvSettings(path, profile string, environ []string) (CropSettings, error) {
+	for _, kv := range environ {
+		switch {
+		case path == "" && strings.HasPrefix(kv, EnvPrefix+"CONFIG="):
+			path = strings.TrimPrefix(kv, EnvPrefix+"CONFIG=")
+		case profile == "" && strings.HasPrefix(kv, EnvPrefix+"PROFILE="):
+			profile = strings.TrimPrefix(kv, EnvPrefix+"PROFILE=")
+		}
+	}
+
+	settings := DefaultCropSettings()
+	if path != "" {
+		c, err := LoadConfigFile(path)
+		if err != nil {
+			return CropSettings{}, err
+		}
+		if settings, err = c.Profile(profile); err != nil {
+			return CropSettings{}, err
+		}
+	} else if profile != "" {
+		return CropSettings{}, fmt.Errorf("smartcrop: profile %q requires a config file", profile)
+	}
+
+	settings, err := settings.WithEnv(environ)
+	if err != nil {
+		return CropSettings{}, err
+	}
+	return settings, settings.Validate()
+}
+
+// envName returns the name of the environment variable of a setting with
+// the given JSON name.
+func envName(name string) string {
+	var b strings.Builder
+	b.WriteString(EnvPrefix)
+	for i, c := range name {
+		if unicode.IsUpper(c) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(c))
+	}
+	return b.String()
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setEnvField sets a field of CropSettings to the value of its environment
+// variable.
+func setEnvField(f reflect.Value, value string) error {
+	if f.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.Float64:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		f.SetFloat(v)
+	case reflect.Int, reflect.Int64:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.SetInt(v)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		f.SetBool(v)
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Slice:
+		f.Set(reflect.ValueOf(splitList(value)))
+	case reflect.Map:
+		var weights map[string]float64
+		for _, item := range splitList(value) {
+			i := strings.IndexByte(item, '=')
+			if i <= 0 {
+				return fmt.Errorf("expected name=weight, got %q", item)
+			}
+			w, err := strconv.ParseFloat(strings.TrimSpace(item[i+1:]), 64)
+			if err != nil {
+				return err
+			}
+			if weights == nil {
+				weights = map[string]float64{}
+			}
+			weights[strings.TrimSpace(item[:i])] = w
+		}
+		f.Set(reflect.ValueOf(weights))
+	case reflect.Ptr:
+		if f.Type() != reflect.TypeOf(&NormalizedRect{}) {
+			return fmt.Errorf("can't be set from the environment")
+		}
+		items := splitList(value)
+		if len(items) == 0 {
+			f.Set(reflect.Zero(f.Type()))
+			return nil
+		}
+		if len(items) != 4 {
+			return fmt.Errorf("expected x,y,width,height, got %q", value)
+		}
+		var v [4]float64
+		for i, item := range items {
+			var err error
+			if v[i], err = strconv.ParseFloat(item, 64); err != nil {
+				return err
+			}
+		}
+		f.Set(reflect.ValueOf(&NormalizedRect{X: v[0], Y: v[1], Width: v[2], Height: v[3]}))
+	default:
+		return fmt.Errorf("can't be set from the environment")
+	}
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithEnv(t *testing.T) {
+	s, err := DefaultCropSettings().WithEnv([]string{
+		"SMARTCROP_SKIN_WEIGHT=2.5",
+		"SMARTCROP_SCORE_DOWN_SAMPLE=4",
+		"SMARTCROP_SEED=-7",
+		"SMARTCROP_RULE_OF_THIRDS=false",
+		"SMARTCROP_BUDGET=50ms",
+		"SMARTCROP_STRATEGIES=faces, saliency",
+		"SMARTCROP_DETECTORS=faces=1.5,text=0.5",
+		"SMARTCROP_TEXT_ZONE=0.1,0.7,0.8,0.2",
+		"SMARTCROP_UNKNOWN=1",
+		"SKIN_WEIGHT=3",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := DefaultCropSettings()
+	expected.SkinWeight = 2.5
+	expected.ScoreDownSample = 4
+	expected.Seed = -7
+	expected.RuleOfThirds = false
+	expected.Budget = 50 * time.Millisecond
+	expected.Strategies = []string{"faces", "saliency"}
+	expected.Detectors = map[string]float64{"faces": 1.5, "text": 0.5}
+	expected.TextZone = &NormalizedRect{X: 0.1, Y: 0.7, Width: 0.8, Height: 0.2}
+	if !reflect.DeepEqual(s, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, s)
+	}
+
+	for _, kv := range []string{
+		"SMARTCROP_STEP=eight",
+		"SMARTCROP_BUDGET=50",
+		"SMARTCROP_DETECTORS=faces",
+		"SMARTCROP_TEXT_ZONE=0.1,0.7",
+	} {
+		if _, err := DefaultCropSettings().WithEnv([]string{kv}); err == nil {
+			t.Fatalf("expected an error for %s", kv)
+		}
+	}
+}
+
+func TestLoadEnvSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smartcrop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "smartcrop.json")
+	config := `{"skinWeight": 2.0, "step": 16, "profiles": {"thumbnails": {"step": 4}}}`
+	if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := LoadEnvSettings("", "", []string{
+		"SMARTCROP_CONFIG=" + path,
+		"SMARTCROP_PROFILE=thumbnails",
+		"SMARTCROP_SKIN_WEIGHT=3",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.SkinWeight != 3 || s.Step != 4 {
+		t.Fatalf("expected the environment on top of the profile, got %v and %d", s.SkinWeight, s.Step)
+	}
+
+	// flags take precedence over the environment
+	s, err = LoadEnvSettings(path, "", []string{"SMARTCROP_CONFIG=missing.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Step != 16 {
+		t.Fatalf("expected the settings of the config file, got %d", s.Step)
+	}
+
+	for _, env := range []string{"SMARTCROP_STEP=0", "SMARTCROP_BLOB_PENALTY=NaN", "SMARTCROP_DETECTORS=faces=Inf"} {
+		if _, err := LoadEnvSettings("", "", []string{env}); err == nil {
+			t.Fatalf("expected invalid settings to be rejected for %s", env)
+		}
+	}
+	if _, err := LoadEnvSettings("", "thumbnails", nil); err == nil {
+		t.Fatal("expected an error for a profile without a config file")
+	}
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+)
+
+// The names of the contrast normalizations, see CropSettings.Equalize.
+const (
+	// EqualizeGlobal equalizes the histogram of the lightness of the whole
+	// image.
+	EqualizeGlobal = "global"
+	// EqualizeCLAHE equalizes the histograms of the lightness of tiles of the
+	// image, limiting the contrast gain so noise in flat regions doesn't get
+	// amplified (contrast limited adaptive histogram equalization).
+	EqualizeCLAHE = "clahe"
+)
+
+const (
+	// claheTiles is the number of tiles per dimension of the image.
+	claheTiles = 8
+	// claheClipLimit is the maximum height of a histogram bin, relative to
+	// the mean height.
+	claheClipLimit = 4.0
+)
+
+// lightnessMapping maps the lightness of the pixels of an image, 0..255.
+type lightnessMapping [256]float64
+
+// newLightnessMapping returns the mapping equalizing hist, after clipping its
+// bins at clip times their mean height if clip > 0.
+func newLightnessMapping(hist [256]int, clip float64) lightnessMapping {
+	total := 0
+	for _, c := range hist {
+		total += c
+	}
+
+	var h [256]float64
+	for i, c := range hist {
+		h[i] = float64(c)
+	}
+	if clip > 0 {
+		// redistribute the excess of the clipped bins evenly
+		limit := clip * float64(total) / 256.0
+		excess := 0.0
+		for i := range h {
+			if h[i] > limit {
+				excess += h[i] - limit
+				h[i] = limit
+			}
+		}
+		for i := range h {
+			h[i] += excess / 256.0
+		}
+	}
+
+	var m lightnessMapping
+	if total == 0 {
+		for i := range m {
+			m[i] = float64(i)
+		}
+		return m
+	}
+	cdf := 0.0
+	for i := range h {
+		cdf += h[i]
+		m[i] = 255.0 * cdf / float64(total)
+	}
+	return m
+}
+
+// equalize returns img with its lightness equalized according to method,
+// EqualizeGlobal or EqualizeCLAHE. The colors get scaled to the new
+// lightness, keeping their hue.
+func equalize(img *image.RGBA, method string) *image.RGBA {
+	t := getColorTables()
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	lightness := make([]uint8, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := img.PixOffset(b.Min.X+x, b.Min.Y+y)
+			lightness[y*w+x] = uint8(bounds(t.cie(img.Pix[i], img.Pix[i+1], img.Pix[i+2])))
+		}
+	}
+
+	tiles := 1
+	clip := 0.0
+	if method == EqualizeCLAHE {
+		tiles, clip = claheTiles, claheClipLimit
+	}
+	tw, th := float64(w)/float64(tiles), float64(h)/float64(tiles)
+
+	mappings := make([]lightnessMapping, tiles*tiles)
+	for ty := 0; ty < tiles; ty++ {
+		for tx := 0; tx < tiles; tx++ {
+			var hist [256]int
+			for y := int(float64(ty) * th); y < int(float64(ty+1)*th); y++ {
+				for x := int(float64(tx) * tw); x < int(float64(tx+1)*tw); x++ {
+					hist[lightness[y*w+x]]++
+				}
+			}
+			mappings[ty*tiles+tx] = newLightnessMapping(hist, clip)
+		}
+	}
+
+	d := image.NewRGBA(b)
+	for y := 0; y < h; y++ {
+		// interpolate bilinearly between the mappings of the four nearest
+		// tile centers
+		fy := math.Min(math.Max((float64(y)+0.5)/th-0.5, 0), float64(tiles-1))
+		ty0 := int(fy)
+		ty1 := minInt(ty0+1, tiles-1)
+		wy := fy - float64(ty0)
+		for x := 0; x < w; x++ {
+			fx := math.Min(math.Max((float64(x)+0.5)/tw-0.5, 0), float64(tiles-1))
+			tx0 := int(fx)
+			tx1 := minInt(tx0+1, tiles-1)
+			wx := fx - float64(tx0)
+
+			l := lightness[y*w+x]
+			mapped := (1-wy)*((1-wx)*mappings[ty0*tiles+tx0][l]+wx*mappings[ty0*tiles+tx1][l]) +
+				wy*((1-wx)*mappings[ty1*tiles+tx0][l]+wx*mappings[ty1*tiles+tx1][l])
+
+			si := img.PixOffset(b.Min.X+x, b.Min.Y+y)
+			di := d.PixOffset(b.Min.X+x, b.Min.Y+y)
+			if l == 0 {
+				v := uint8(math.Round(mapped))
+				d.Pix[di], d.Pix[di+1], d.Pix[di+2] = v, v, v
+			} else {
+				f := mapped / float64(l)
+				for c := 0; c < 3; c++ {
+					d.Pix[di+c] = uint8(math.Round(bounds(float64(img.Pix[si+c]) * f)))
+				}
+			}
+			d.Pix[di+3] = img.Pix[si+3]
+		}
+	}
+	return d
+}
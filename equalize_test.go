@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestEqualize(t *testing.T) {
+	// a faint square in fog
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			v := uint8(130 + x/20)
+			if x >= 40 && x < 60 && y >= 40 && y < 60 {
+				v = 124
+			}
+			img.SetRGBA(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+
+	edges := func(img *image.RGBA) int {
+		o := image.NewRGBA(img.Bounds())
+		edgeDetect(img, o)
+		sum := 0
+		for i := 1; i < len(o.Pix); i += 4 {
+			sum += int(o.Pix[i])
+		}
+		return sum
+	}
+
+	before := edges(img)
+	for _, method := range []string{EqualizeGlobal, EqualizeCLAHE} {
+		eq := equalize(img, method)
+		if after := edges(eq); after < 2*before {
+			t.Fatalf("expected %s equalization to recover the edges, got %d, was %d", method, after, before)
+		}
+		if a, b := eq.RGBAAt(50, 50).R, eq.RGBAAt(10, 10).R; a >= b {
+			t.Fatalf("expected %s equalization to keep the square darker, got %d and %d", method, a, b)
+		}
+	}
+
+	settings := DefaultCropSettings()
+	settings.Equalize = "unknown"
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings)
+	if _, err := analyzer.FindBestCrop(img, 50, 50); err == nil {
+		t.Fatal("expected an error for an unknown contrast normalization")
+	}
+}
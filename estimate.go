@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+)
+
+// Estimate contains the predicted cost of an analysis.
+type Estimate struct {
+	// AnalysisWidth and AnalysisHeight are the dimensions of the image after
+	// prescaling it.
+	AnalysisWidth  int `json:"analysisWidth"`
+	AnalysisHeight int `json:"analysisHeight"`
+	// Candidates is the number of crops that get scored.
+	Candidates int `json:"candidates"`
+	// WorkingMemory is the number of bytes allocated for the analysis, not
+	// including the source image itself.
+	WorkingMemory int64 `json:"workingMemory"`
+	// DetectorOps and ScoringOps are the number of pixels the detectors
+	// process and the number of samples scored, respectively. They are a
+	// measure for the CPU time the analysis takes.
+	DetectorOps int64 `json:"detectorOps"`
+	ScoringOps  int64 `json:"scoringOps"`
+}
+
+// EstimateCost predicts the cost of finding the best crop with the given width
+// and height on an image with the given dimensions, without actually running
+// the analysis. Schedulers can use it to route huge jobs to bigger workers or
+// reject them.
+func EstimateCost(imgWidth, imgHeight, width, height int, settings CropSettings) (Estimate, error) {
+	width, height, err := validateDimensions(imgWidth, imgHeight, width, height)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	scale := math.Min(float64(imgWidth)/float64(width), float64(imgHeight)/float64(height))
+	prescalefactor := settings.prescaleFactor(imgWidth, imgHeight)
+
+	e := Estimate{
+		AnalysisWidth:  imgWidth,
+		AnalysisHeight: imgHeight,
+	}
+	if settings.Prescale {
+		e.AnalysisWidth = int(float64(imgWidth) * prescalefactor)
+		e.AnalysisHeight = int(math.Round(float64(imgHeight) * float64(e.AnalysisWidth) / float64(imgWidth)))
+	}
+
+	cropWidth, cropHeight := chop(float64(width)*scale*prescalefactor), chop(float64(height)*scale*prescalefactor)
+	var sizes map[image.Point]int
+	e.Candidates, sizes = candidates(&settings, e.AnalysisWidth, e.AnalysisHeight, cropWidth, cropHeight, settings.realMinScale(scale))
+
+	pixels := int64(e.AnalysisWidth) * int64(e.AnalysisHeight)
+	ds := int64(settings.ScoreDownSample)
+	// prescaled image and detector output, both RGBA, plus the luminance plane
+	passes := int64(settings.detectionPasses())
+	e.WorkingMemory = pixels*4*2 + pixels*8
+	e.DetectorOps = pixels * passes
+	if settings.ReducedPlanes && ds > 1 {
+		reduced := (int64(e.AnalysisWidth) / ds) * (int64(e.AnalysisHeight) / ds)
+		e.WorkingMemory = pixels*4 + reduced*4
+		e.DetectorOps = reduced * passes
+	}
+	if len(settings.Detectors) > 0 {
+		// the accumulated and the final boost plane
+		e.WorkingMemory += pixels*8 + pixels
+	}
+	if settings.FixedPoint {
+		for size, n := range sizes {
+			e.WorkingMemory += int64(n) * int64(size.X) * int64(size.Y) * 8
+		}
+	}
+
+	e.ScoringOps = int64(e.Candidates) * (int64(e.AnalysisWidth) / ds) * (int64(e.AnalysisHeight) / ds)
+
+	return e, nil
+}
+
+// candidates returns the number of crops crops enumerates on an image with the
+// given dimensions, computing it from the step and scale grid instead of
+// enumerating them. It also returns the sizes of the crops, each mapped to the
+// number of distinct sizes it stands for. For random samples, and with
+// DedupEpsilon, the numbers are upper bounds.
+func candidates(s *CropSettings, width, height int, cropWidth, cropHeight, realMinScale float64) (int, map[image.Point]int) {
+	minDimension := math.Min(float64(width), float64(height))
+	cropW, cropH := cropWidth, cropHeight
+	if cropW == 0.0 {
+		cropW = minDimension
+	}
+	if cropH == 0.0 {
+		cropH = minDimension
+	}
+
+	sizes := map[image.Point]int{}
+	if s.Samples > 0 {
+		// every sample may have a different size, none larger than the largest
+		size := image.Pt(int(cropW*s.MaxScale), int(cropH*s.MaxScale))
+		sizes[size] = s.Samples
+		return s.Samples, sizes
+	}
+
+	n := 0
+	for scale := s.MaxScale; scale >= realMinScale; scale -= s.ScaleStep {
+		stepX, stepY := s.steps(cropW*scale, cropH*scale)
+		c := countPositions(stepX, cropW*scale, width) * countPositions(stepY, cropH*scale, height)
+		if c > 0 {
+			n += c
+			sizes[image.Pt(int(cropW*scale), int(cropH*scale))] = 1
+		}
+	}
+	return n, sizes
+}
+
+// countPositions returns len(positions(step, crop, size)).
+func countPositions(step int, crop float64, size int) int {
+	if crop > float64(size) {
+		return 0
+	}
+	n := int((float64(size)-crop)/float64(step)) + 1
+	if anchored := size - int(crop); anchored > (n-1)*step {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"testing"
+)
+
+func TestEstimateCost(t *testing.T) {
+	s := DefaultCropSettings()
+	e, err := EstimateCost(4000, 3000, 250, 250, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.AnalysisWidth != 533 || e.AnalysisHeight != 400 {
+		t.Fatalf("expected the analysis to run at 533x400, got %dx%d", e.AnalysisWidth, e.AnalysisHeight)
+	}
+	if e.Candidates == 0 || e.WorkingMemory == 0 || e.DetectorOps == 0 || e.ScoringOps == 0 {
+		t.Fatalf("expected a non-zero estimate, got %+v", e)
+	}
+
+	s.Prescale = false
+	big, err := EstimateCost(4000, 3000, 250, 250, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if big.Candidates <= e.Candidates || big.WorkingMemory <= e.WorkingMemory {
+		t.Fatalf("expected analysing without prescaling to cost more: %+v vs %+v", big, e)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	for _, tc := range []struct {
+		name                  string
+		width, height         int
+		cropWidth, cropHeight float64
+		setup                 func(*CropSettings)
+	}{
+		{"default", 533, 400, 250, 250, func(*CropSettings) {}},
+		{"free", 400, 300, 0, 0, func(*CropSettings) {}},
+		{"fraction", 640, 480, 320, 180, func(s *CropSettings) { s.StepFraction = 0.07 }},
+		{"coarse", 1000, 999, 333, 500, func(s *CropSettings) { s.Step = 13; s.ScaleStep = 0.05 }},
+		{"too big", 100, 100, 250, 250, func(*CropSettings) {}},
+	} {
+		s := DefaultCropSettings()
+		tc.setup(&s)
+
+		want := 0
+		wantSizes := map[image.Point]int{}
+		crops(&s, image.Rect(0, 0, tc.width, tc.height), tc.cropWidth, tc.cropHeight, s.MinScale, func(crop Crop) bool {
+			want++
+			wantSizes[crop.Size()] = 1
+			return true
+		})
+
+		got, sizes := candidates(&s, tc.width, tc.height, tc.cropWidth, tc.cropHeight, s.MinScale)
+		if got != want || len(sizes) != len(wantSizes) {
+			t.Errorf("%s: expected %d candidates of %d sizes, got %d of %d", tc.name, want, len(wantSizes), got, len(sizes))
+		}
+	}
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+)
+
+// Feedback records a manual override of a crop: the crop that has been found
+// for an image and the one a human chose instead.
+type Feedback struct {
+	Previous  image.Rectangle `json:"previous"`
+	Corrected image.Rectangle `json:"corrected"`
+}
+
+// Boosts returns the boosts to pass along with future Requests for the same
+// image, so crops at other sizes and aspect ratios follow the correction. The
+// center of the corrected crop gets boosted the most, as it's the part most
+// likely to contain what the human cared about. The more the correction
+// deviates from the previous crop, the stronger the boosts get.
+func (f Feedback) Boosts() []Boost {
+	c := f.Corrected.Canon()
+	if c.Empty() {
+		return nil
+	}
+
+	weight := 0.5 + 0.5*(1.0-iou(f.Previous.Canon(), c))
+	center := image.Rect(
+		c.Min.X+c.Dx()/4, c.Min.Y+c.Dy()/4,
+		c.Max.X-c.Dx()/4, c.Max.Y-c.Dy()/4,
+	)
+	return []Boost{
+		{Rectangle: c, Weight: weight},
+		{Rectangle: center, Weight: weight},
+	}
+}
+
+// iou returns the intersection over union of two rectangles.
+func iou(a, b image.Rectangle) float64 {
+	i := a.Intersect(b)
+	if i.Empty() {
+		return 0.0
+	}
+	ia := float64(i.Dx() * i.Dy())
+	return ia / (float64(a.Dx()*a.Dy()+b.Dx()*b.Dy()) - ia)
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestFeedback(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := FindCrop(img, Request{Width: 250, Height: 250, Resizer: nfnt.NewDefaultResizer()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a human preferred the right edge of the image
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	fb := Feedback{
+		Previous:  res.Crop.Rectangle,
+		Corrected: image.Rect(w-h, 0, w, h),
+	}
+
+	// which should carry over to other aspect ratios
+	res, err = FindCrop(img, Request{Width: 100, Height: 200, Boosts: fb.Boosts(), Resizer: nfnt.NewDefaultResizer()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Crop.Overlaps(fb.Corrected) {
+		t.Fatalf("expected crop to follow the correction %v, got %v", fb.Corrected, res.Crop.Rectangle)
+	}
+
+	if b := (Feedback{}).Boosts(); b != nil {
+		t.Fatalf("expected no boosts without a correction, got %v", b)
+	}
+}
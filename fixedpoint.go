@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	// importance values are stored as Q16 fixed-point numbers
+	fixedShift = 16
+	fixedOne   = 1 << fixedShift
+	// detail values are scaled by 255*fixedDetail, so the biases keep some
+	// precision
+	fixedDetail = 256
+)
+
+// importanceTable contains the fixed-point importance of every pixel inside a
+// crop of a given size. Since all crops of the same size share the same
+// importance map, it only needs to be computed once per scale.
+type importanceTable struct {
+	width, height int
+	inside        []int64
+	outside       int64
+}
+
+func newImportanceTable(s *CropSettings, width, height int) *importanceTable {
+	t := &importanceTable{
+		width:   width,
+		height:  height,
+		inside:  make([]int64, width*height),
+		outside: toFixed(s.OutsideImportance),
+	}
+
+	crop := Crop{Rectangle: image.Rect(0, 0, width, height)}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			t.inside[y*width+x] = toFixed(importance(s, crop, x, y))
+		}
+	}
+
+	return t
+}
+
+// importanceTables caches importanceTables by crop size.
+type importanceTables map[image.Point]*importanceTable
+
+func (ts importanceTables) get(s *CropSettings, width, height int) *importanceTable {
+	p := image.Pt(width, height)
+	if t, ok := ts[p]; ok {
+		return t
+	}
+
+	t := newImportanceTable(s, width, height)
+	ts[p] = t
+	return t
+}
+
+func toFixed(f float64) int64 {
+	return int64(math.Round(f * fixedOne))
+}
+
+// scoreFixed is the fixed-point equivalent of score. It only uses integer math
+// in its loops, which is considerably faster on devices with slow floating-point
+// units.
+func scoreFixed(s *CropSettings, output *image.RGBA, boost *image.Gray, crop Crop, t *importanceTable, reduction int) Score {
+	width := output.Bounds().Dx()
+	height := output.Bounds().Dy()
+	step := s.ScoreDownSample / reduction
+
+	skinBias := int64(math.Round(s.SkinBias * 255 * fixedDetail))
+	saturationBias := int64(math.Round(s.SaturationBias * 255 * fixedDetail))
+
+	rows := newRGBARows(output)
+	var boostRows grayRows
+	if boost != nil {
+		boostRows = newGrayRows(boost)
+	}
+
+	var detail, skin, saturation, boosted int64
+	for y := 0; y <= height-step; y += step {
+		row := rows.row(y)
+		var boostRow []uint8
+		if boost != nil {
+			boostRow = boostRows.row(y)
+		}
+		cy := y * reduction
+		inY := cy >= crop.Min.Y && cy < crop.Max.Y
+
+		for x := 0; x <= width-step; x += step {
+			p := row[x*4 : x*4+3 : x*4+3]
+			r := int64(p[0])
+			g := int64(p[1])
+			b := int64(p[2])
+
+			imp := t.outside
+			if cx := x * reduction; inY && cx >= crop.Min.X && cx < crop.Max.X {
+				imp = t.inside[(cy-crop.Min.Y)*t.width+cx-crop.Min.X]
+			}
+
+			det := g * fixedDetail
+			detail += g * imp
+			skin += r * (det + skinBias) * imp
+			saturation += b * (det + saturationBias) * imp
+			if boost != nil {
+				boosted += int64(boostRow[x]) * imp
+			}
+		}
+	}
+
+	return Score{
+		Detail:     float64(detail) / (255 * fixedOne),
+		Skin:       float64(skin) / (255 * 255 * fixedDetail * fixedOne),
+		Saturation: float64(saturation) / (255 * 255 * fixedDetail * fixedOne),
+		Boost:      float64(boosted) / (255 * fixedOne),
+	}
+}
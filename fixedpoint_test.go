@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestFixedPointScore(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := DefaultCropSettings()
+	rgba := toRGBA(nfnt.NewDefaultResizer().Resize(img, 400, 0))
+	o := image.NewRGBA(rgba.Bounds())
+	edgeDetect(rgba, o)
+	skinDetect(&s, rgba, o)
+	saturationDetect(&s, rgba, o)
+
+	tables := importanceTables{}
+	candidates := 0
+	crops(&s, o, 100, 100, 0.9, func(crop Crop) bool {
+		crop.Score = score(&s, o, nil, crop, 1)
+		expected := crop.totalScore(&s)
+		crop.Score = scoreFixed(&s, o, nil, crop, tables.get(&s, crop.Dx(), crop.Dy()), 1)
+		got := crop.totalScore(&s)
+
+		if math.Abs(expected-got) > 1e-6 {
+			t.Errorf("crop %v: expected score %g, got %g", crop.Rectangle, expected, got)
+			return false
+		}
+
+		candidates++
+		return true
+	})
+	if candidates == 0 {
+		t.Fatal("expected crop candidates")
+	}
+}
@@ -0,0 +1,72 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+// FuzzFindCropUntrusted feeds the examples and the malformed images of
+// testdata/fuzz to FindCropUntrusted, which must neither panic nor exceed
+// its limits on any of them.
+func FuzzFindCropUntrusted(f *testing.F) {
+	examples, err := filepath.Glob("examples/*.jpg")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, example := range examples {
+		buf, err := ioutil.ReadFile(example)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(buf)
+	}
+
+	limits := Limits{MaxBytes: 1 << 20, MaxPixels: 1 << 20, MaxDimension: 2048, Timeout: time.Second}
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		res, err := FindCropUntrusted(bytes.NewReader(buf), Request{Width: 100, Height: 100, Resizer: nfnt.NewDefaultResizer()}, limits)
+		if err != nil {
+			return
+		}
+		if res.Crop.Dx() <= 0 || res.Crop.Dy() <= 0 {
+			t.Errorf("expected a crop, got %v", res.Crop)
+		}
+	})
+}
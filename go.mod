@@ -2,4 +2,7 @@ module github.com/muesli/smartcrop
 
 go 1.12
 
-require golang.org/x/image v0.0.0-20190802002840-cff245a6509b
+require (
+	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
+	golang.org/x/image v0.0.0-20190802002840-cff245a6509b
+)
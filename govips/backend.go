@@ -0,0 +1,166 @@
+//go:build vips
+// +build vips
+
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package govips
+
+import (
+	"errors"
+	"image"
+	"math"
+
+	"github.com/davidbyttow/govips/v2/vips"
+	"github.com/muesli/smartcrop"
+)
+
+// Backend crops encoded images. It delegates decoding, prescaling, cropping and
+// encoding to libvips, while the crops still get scored in Go. This pays off
+// when decoding and resizing dominate the CPU time rather than scoring.
+type Backend struct {
+	settings smartcrop.CropSettings
+	logger   smartcrop.Logger
+}
+
+// NewBackend returns a new Backend using the given CropSettings.
+func NewBackend(settings smartcrop.CropSettings) *Backend {
+	return NewBackendWithLogger(settings, smartcrop.Logger{})
+}
+
+// NewBackendWithLogger returns a new Backend using the given CropSettings and
+// Logger.
+func NewBackendWithLogger(settings smartcrop.CropSettings, logger smartcrop.Logger) *Backend {
+	Startup(nil)
+	return &Backend{settings: settings, logger: logger}
+}
+
+// FindBestCrop decodes buf and returns the Result for the best crop with the
+// given width and height.
+func (b *Backend) FindBestCrop(buf []byte, width, height int) (smartcrop.Result, error) {
+	ref, err := vips.NewImageFromBuffer(buf)
+	if err != nil {
+		return smartcrop.Result{}, err
+	}
+	defer ref.Close()
+
+	return b.findBestCrop(ref, width, height)
+}
+
+// Crop decodes buf, crops it to the best crop with the given width and height
+// and returns it encoded in the format of the source image. If resize is true,
+// the crop gets scaled to exactly width x height.
+func (b *Backend) Crop(buf []byte, width, height int, resize bool) ([]byte, smartcrop.Result, error) {
+	ref, err := vips.NewImageFromBuffer(buf)
+	if err != nil {
+		return nil, smartcrop.Result{}, err
+	}
+	defer ref.Close()
+
+	res, err := b.findBestCrop(ref, width, height)
+	if err != nil {
+		return nil, res, err
+	}
+
+	r := res.Crop.Rectangle
+	if err := ref.ExtractArea(r.Min.X, r.Min.Y, r.Dx(), r.Dy()); err != nil {
+		return nil, res, err
+	}
+	if resize && width > 0 && height > 0 && (r.Dx() != width || r.Dy() != height) {
+		if err := ref.ResizeWithVScale(float64(width)/float64(r.Dx()), float64(height)/float64(r.Dy()), vips.KernelAuto); err != nil {
+			return nil, res, err
+		}
+	}
+
+	out, _, err := ref.ExportNative()
+	return out, res, err
+}
+
+func (b *Backend) findBestCrop(ref *vips.ImageRef, width, height int) (smartcrop.Result, error) {
+	if width == 0 && height == 0 {
+		return smartcrop.Result{}, smartcrop.ErrInvalidDimensions
+	}
+	w, h := ref.Width(), ref.Height()
+	if w == 0 || h == 0 {
+		return smartcrop.Result{}, errors.New("empty image")
+	}
+
+	// prescale in libvips, so Go only ever sees the small image
+	settings := b.settings
+	factor := 1.0
+	if settings.Prescale {
+		if f := settings.PrescaleMin / math.Min(float64(w), float64(h)); f < 1.0 {
+			factor = f
+		}
+	}
+	settings.Prescale = false
+
+	small, err := ref.Copy()
+	if err != nil {
+		return smartcrop.Result{}, err
+	}
+	defer small.Close()
+	if factor < 1.0 {
+		if err := small.Resize(factor, vips.KernelAuto); err != nil {
+			return smartcrop.Result{}, err
+		}
+	}
+
+	params := vips.NewDefaultPNGExportParams()
+	params.Compression = 0
+	img, err := small.ToImage(params)
+	if err != nil {
+		return smartcrop.Result{}, err
+	}
+
+	// the analyzer only cares about the ratio, as long as the target doesn't
+	// exceed the source
+	analyzer := smartcrop.NewAnalyzerWithSettings(nil, b.logger, settings).(smartcrop.ResultAnalyzer)
+	res, err := analyzer.FindBestResult(img,
+		int(math.Max(1.0, float64(width)*factor)),
+		int(math.Max(1.0, float64(height)*factor)))
+	if err != nil {
+		return res, err
+	}
+
+	ratio := 0.0
+	if width > 0 && height > 0 {
+		ratio = float64(width) / float64(height)
+	}
+	res.Crop.Rectangle = res.Normalized.Rect(image.Rect(0, 0, w, h), ratio)
+	res.FloatCrop = smartcrop.FloatRect{
+		X:      res.Normalized.X * float64(w),
+		Y:      res.Normalized.Y * float64(h),
+		Width:  res.Normalized.Width * float64(w),
+		Height: res.Normalized.Height * float64(h),
+	}
+	res.ImageWidth = w
+	res.ImageHeight = h
+	res.ParamsHash = b.settings.Fingerprint()
+
+	return res, nil
+}
@@ -0,0 +1,16 @@
+module github.com/muesli/smartcrop/govips
+
+go 1.17
+
+require (
+	github.com/davidbyttow/govips/v2 v2.16.0
+	github.com/muesli/smartcrop v0.0.0-20261016125610-7709603b20e0
+)
+
+require (
+	golang.org/x/image v0.18.0 // indirect
+	golang.org/x/net v0.25.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
+)
+
+replace github.com/muesli/smartcrop => ../
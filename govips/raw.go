@@ -0,0 +1,122 @@
+//go:build vips
+// +build vips
+
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package govips
+
+/*
+#cgo pkg-config: vips
+#include <vips/vips.h>
+
+// resize_rgba scales a packed 8-bit RGBA buffer with vips_resize and writes
+// the result to a newly allocated buffer, which the caller must g_free.
+static int resize_rgba(void *pix, size_t size, int width, int height,
+		double hscale, double vscale, int kernel,
+		void **out, size_t *outsize, int *outwidth, int *outheight) {
+	VipsImage *in, *resized;
+	int err;
+
+	in = vips_image_new_from_memory_copy(pix, size, width, height, 4, VIPS_FORMAT_UCHAR);
+	if (in == NULL) {
+		return -1;
+	}
+	err = vips_resize(in, &resized, hscale, "vscale", vscale, "kernel", kernel, NULL);
+	g_object_unref(in);
+	if (err != 0) {
+		return err;
+	}
+
+	*outwidth = vips_image_get_width(resized);
+	*outheight = vips_image_get_height(resized);
+	*out = vips_image_write_to_memory(resized, outsize);
+	g_object_unref(resized);
+	return *out == NULL ? -1 : 0;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"unsafe"
+
+	"github.com/davidbyttow/govips/v2/vips"
+)
+
+// resizeRGBA scales img by hscale and vscale in libvips. The pixels get handed
+// over as a raw buffer, sparing the encoding round trip through a file format.
+func resizeRGBA(img *image.RGBA, hscale, vscale float64, kernel vips.Kernel) (*image.RGBA, error) {
+	b := img.Bounds()
+	if kernel == vips.KernelAuto {
+		// same default as govips
+		kernel = vips.KernelLanczos3
+	}
+
+	var (
+		out           unsafe.Pointer
+		size          C.size_t
+		width, height C.int
+	)
+	if C.resize_rgba(unsafe.Pointer(&img.Pix[0]), C.size_t(len(img.Pix)), C.int(b.Dx()), C.int(b.Dy()),
+		C.double(hscale), C.double(vscale), C.int(kernel),
+		&out, &size, &width, &height) != 0 {
+		err := errors.New(C.GoString(C.vips_error_buffer()))
+		C.vips_error_clear()
+		return nil, err
+	}
+	defer C.g_free(C.gpointer(out))
+
+	if int(size) != 4*int(width)*int(height) {
+		return nil, errors.New("unexpected pixel buffer size")
+	}
+	return &image.RGBA{
+		Pix:    C.GoBytes(out, C.int(size)),
+		Stride: 4 * int(width),
+		Rect:   image.Rect(0, 0, int(width), int(height)),
+	}, nil
+}
+
+// packedRGBA returns img as an *image.RGBA whose rows follow each other
+// without gaps, copying the pixels only if necessary.
+func packedRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Stride == 4*b.Dx() {
+		i := rgba.PixOffset(b.Min.X, b.Min.Y)
+		return &image.RGBA{
+			Pix:    rgba.Pix[i : i+4*b.Dx()*b.Dy()],
+			Stride: rgba.Stride,
+			Rect:   image.Rect(0, 0, b.Dx(), b.Dy()),
+		}
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
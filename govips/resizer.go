@@ -0,0 +1,93 @@
+//go:build vips
+// +build vips
+
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package govips
+
+import (
+	"image"
+	"sync"
+
+	"github.com/davidbyttow/govips/v2/vips"
+	"github.com/muesli/smartcrop/options"
+	"github.com/muesli/smartcrop/xdraw"
+)
+
+var startup sync.Once
+
+type vipsResizer struct {
+	kernel   vips.Kernel
+	fallback options.Resizer
+}
+
+// Resize scales img to the given dimensions using libvips. If either width or
+// height is 0, it gets derived from the other one, preserving the aspect ratio
+// of img. Should libvips fail to process the image, it gets scaled in Go.
+func (r vipsResizer) Resize(img image.Image, width, height uint) image.Image {
+	b := img.Bounds()
+	if (width == 0 && height == 0) || b.Empty() {
+		return img
+	}
+
+	hscale := float64(width) / float64(b.Dx())
+	vscale := float64(height) / float64(b.Dy())
+	if width == 0 {
+		hscale = vscale
+	}
+	if height == 0 {
+		vscale = hscale
+	}
+
+	out, err := resizeRGBA(packedRGBA(img), hscale, vscale, r.kernel)
+	if err != nil {
+		return r.fallback.Resize(img, width, height)
+	}
+	return out
+}
+
+// Startup initializes libvips with the given configuration. It gets called
+// with the default configuration by the constructors of this package, so you
+// only need to call it yourself to customize libvips, before creating any
+// Resizer.
+func Startup(config *vips.Config) {
+	startup.Do(func() {
+		vips.Startup(config)
+	})
+}
+
+// NewResizer creates a new Resizer with the given kernel.
+func NewResizer(kernel vips.Kernel) options.Resizer {
+	Startup(nil)
+	return vipsResizer{kernel: kernel, fallback: xdraw.NewDefaultResizer()}
+}
+
+// NewDefaultResizer creates a new Resizer with the default kernel.
+func NewDefaultResizer() options.Resizer {
+	return NewResizer(vips.KernelAuto)
+}
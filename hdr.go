@@ -0,0 +1,329 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sync"
+)
+
+// The transfer functions of HDR images, see CropSettings.ToneMap.
+const (
+	// ToneMapLinear is for images containing linear light, like a FloatImage
+	// decoded from an OpenEXR render, with 1.0 being SDR white.
+	ToneMapLinear = "linear"
+	// ToneMapPQ is for images encoded with the perceptual quantizer of SMPTE
+	// ST 2084 and BT.2020 primaries, as used by HDR10.
+	ToneMapPQ = "pq"
+	// ToneMapHLG is for images encoded with the hybrid log-gamma curve of
+	// BT.2100 and BT.2020 primaries.
+	ToneMapHLG = "hlg"
+)
+
+const (
+	// hdrWhite is the luminance of SDR white in HDR signals, in nits, as
+	// recommended by BT.2408.
+	hdrWhite = 203.0
+	// hlgPeak is the luminance of the display HLG signals get rendered for.
+	hlgPeak = 1000.0
+	// toneMapPercentile is the share of pixels the tone curve keeps below
+	// white, so a few specular highlights don't darken the whole image.
+	toneMapPercentile = 0.999
+)
+
+// bt2020ToSRGB maps linear BT.2020 to linear sRGB.
+var bt2020ToSRGB = [3][3]float64{
+	{1.6605, -0.5876, -0.0728},
+	{-0.1246, 1.1329, -0.0083},
+	{-0.0182, -0.1006, 1.1187},
+}
+
+// FloatImage is an image of linear RGB values, e.g. decoded from an OpenEXR
+// render. 1.0 is SDR white, brighter values are allowed. Unless tone mapped
+// with ToneMapLinear, it gets analysed with all values clipped to SDR.
+type FloatImage struct {
+	// Pix holds the red, green and blue values of the pixels.
+	Pix    []float32
+	Stride int
+	Rect   image.Rectangle
+}
+
+// NewFloatImage returns a new, black FloatImage with the given bounds.
+func NewFloatImage(r image.Rectangle) *FloatImage {
+	return &FloatImage{
+		Pix:    make([]float32, 3*r.Dx()*r.Dy()),
+		Stride: 3 * r.Dx(),
+		Rect:   r,
+	}
+}
+
+// ColorModel implements image.Image.
+func (p *FloatImage) ColorModel() color.Model {
+	return color.RGBA64Model
+}
+
+// Bounds implements image.Image.
+func (p *FloatImage) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At implements image.Image, returning the sRGB encoded color of the pixel,
+// clipped to SDR.
+func (p *FloatImage) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return color.RGBA64{}
+	}
+	r, g, b := p.RGBAt(x, y)
+	return color.RGBA64{
+		R: uint16(encodeSRGB(float64(r)) * 0xffff),
+		G: uint16(encodeSRGB(float64(g)) * 0xffff),
+		B: uint16(encodeSRGB(float64(b)) * 0xffff),
+		A: 0xffff,
+	}
+}
+
+// PixOffset returns the index of the red value of the pixel at x, y in Pix.
+func (p *FloatImage) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*3
+}
+
+// RGBAt returns the linear values of the pixel at x, y.
+func (p *FloatImage) RGBAt(x, y int) (r, g, b float32) {
+	i := p.PixOffset(x, y)
+	return p.Pix[i], p.Pix[i+1], p.Pix[i+2]
+}
+
+// SetRGB sets the linear values of the pixel at x, y.
+func (p *FloatImage) SetRGB(x, y int, r, g, b float32) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	p.Pix[i], p.Pix[i+1], p.Pix[i+2] = r, g, b
+}
+
+// encodeSRGB applies the transfer function of sRGB to v, clipped to 0..1.
+func encodeSRGB(v float64) float64 {
+	v = math.Min(math.Max(v, 0.0), 1.0)
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1.0/2.4) - 0.055
+}
+
+// pq returns the luminance a PQ signal e, 0..1, encodes, in nits.
+func pq(e float64) float64 {
+	const (
+		m1 = 2610.0 / 16384.0
+		m2 = 2523.0 / 4096.0 * 128.0
+		c1 = 3424.0 / 4096.0
+		c2 = 2413.0 / 4096.0 * 32.0
+		c3 = 2392.0 / 4096.0 * 32.0
+	)
+	p := math.Pow(e, 1.0/m2)
+	return 10000.0 * math.Pow(math.Max(p-c1, 0.0)/(c2-c3*p), 1.0/m1)
+}
+
+// hlg returns the relative scene light an HLG signal e, 0..1, encodes.
+func hlg(e float64) float64 {
+	const (
+		a = 0.17883277
+		b = 1.0 - 4.0*a
+	)
+	c := 0.5 - a*math.Log(4.0*a)
+	if e <= 0.5 {
+		return e * e / 3.0
+	}
+	return (math.Exp((e-c)/a) + b) / 12.0
+}
+
+// hdrTables holds the decoded values of all 16-bit signals, by transfer
+// function.
+var hdrTables struct {
+	sync.Mutex
+	tables map[string][]float32
+}
+
+// hdrTable returns the linear values of all 16-bit signals encoded with the
+// given transfer function, relative to SDR white.
+func hdrTable(transfer string) []float32 {
+	hdrTables.Lock()
+	defer hdrTables.Unlock()
+
+	if t, ok := hdrTables.tables[transfer]; ok {
+		return t
+	}
+	t := make([]float32, 1<<16)
+	for i := range t {
+		e := float64(i) / 0xffff
+		switch transfer {
+		case ToneMapPQ:
+			t[i] = float32(pq(e) / hdrWhite)
+		case ToneMapHLG:
+			t[i] = float32(hlg(e))
+		default:
+			t[i] = float32(e)
+		}
+	}
+	if hdrTables.tables == nil {
+		hdrTables.tables = map[string][]float32{}
+	}
+	hdrTables.tables[transfer] = t
+	return t
+}
+
+// hdrReader returns a function reading the linear values of the pixels of img,
+// relative to SDR white, with sRGB primaries.
+func hdrReader(img image.Image, transfer string) func(x, y int) (r, g, b float64) {
+	if f, ok := img.(*FloatImage); ok && transfer == ToneMapLinear {
+		return func(x, y int) (float64, float64, float64) {
+			r, g, b := f.RGBAt(x, y)
+			return float64(r), float64(g), float64(b)
+		}
+	}
+
+	t := hdrTable(transfer)
+	read := read16(img)
+	return func(x, y int) (float64, float64, float64) {
+		r16, g16, b16 := read(x, y)
+		r, g, b := float64(t[r16]), float64(t[g16]), float64(t[b16])
+		if transfer == ToneMapLinear {
+			return r, g, b
+		}
+
+		m := bt2020ToSRGB
+		r, g, b = m[0][0]*r+m[0][1]*g+m[0][2]*b, m[1][0]*r+m[1][1]*g+m[1][2]*b, m[2][0]*r+m[2][1]*g+m[2][2]*b
+		if transfer == ToneMapHLG {
+			// render the scene light for the reference display
+			f := hlgPeak * math.Pow(math.Max(linearLuminance(r, g, b), 0.0), 0.2) / hdrWhite
+			r, g, b = r*f, g*f, b*f
+		}
+		return r, g, b
+	}
+}
+
+// read16 returns a function reading the 16-bit values of the pixels of img,
+// without alpha. The 16-bit image types HDR images get decoded to are read
+// directly.
+func read16(img image.Image) func(x, y int) (r, g, b uint32) {
+	switch img := img.(type) {
+	case *image.NRGBA64:
+		return func(x, y int) (uint32, uint32, uint32) {
+			i := img.PixOffset(x, y)
+			p := img.Pix[i : i+6 : i+6]
+			return uint32(p[0])<<8 | uint32(p[1]), uint32(p[2])<<8 | uint32(p[3]), uint32(p[4])<<8 | uint32(p[5])
+		}
+	case *image.RGBA64:
+		// opaque images don't need to be unpremultiplied
+		if img.Opaque() {
+			return func(x, y int) (uint32, uint32, uint32) {
+				i := img.PixOffset(x, y)
+				p := img.Pix[i : i+6 : i+6]
+				return uint32(p[0])<<8 | uint32(p[1]), uint32(p[2])<<8 | uint32(p[3]), uint32(p[4])<<8 | uint32(p[5])
+			}
+		}
+	}
+
+	return func(x, y int) (uint32, uint32, uint32) {
+		r, g, b, a := img.At(x, y).RGBA()
+		if a > 0 && a < 0xffff {
+			// colors which aren't validly premultiplied, with components
+			// above their alpha, would exceed 16 bits
+			r, g, b = unpremultiply16(r, a), unpremultiply16(g, a), unpremultiply16(b, a)
+		}
+		return r, g, b
+	}
+}
+
+// unpremultiply16 returns the 16-bit value c premultiplied by alpha a had,
+// clamped to 0xffff.
+func unpremultiply16(c, a uint32) uint32 {
+	if c >= a {
+		return 0xffff
+	}
+	return c * 0xffff / a
+}
+
+// linearLuminance returns the relative luminance of linear sRGB values.
+func linearLuminance(r, g, b float64) float64 {
+	return 0.2126*r + 0.7152*g + 0.0722*b
+}
+
+// toneMap maps the HDR image img, encoded with the given transfer function, to
+// SDR with the extended Reinhard operator, applied to the luminance. The white
+// point is chosen so only the brightest toneMapPercentile of the pixels clip.
+func toneMap(img image.Image, transfer string) *image.RGBA {
+	read := hdrReader(img, transfer)
+	b := img.Bounds()
+
+	// histogram the luminance in steps of 1/8 stop, from 2^-16 to 2^16
+	const (
+		stops   = 32
+		buckets = stops * 8
+	)
+	var hist [buckets + 1]int
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			l := linearLuminance(read(x, y))
+			i := 0
+			if l > 0 {
+				i = int(math.Min(math.Max((math.Log2(l)+stops/2)*8, 0), buckets))
+			}
+			hist[i]++
+		}
+	}
+
+	white := 1.0
+	limit := int(float64(b.Dx()*b.Dy()) * toneMapPercentile)
+	for i, sum := 0, 0; i <= buckets; i++ {
+		if sum += hist[i]; sum >= limit {
+			white = math.Max(math.Exp2(float64(i+1)/8-stops/2), 1.0)
+			break
+		}
+	}
+
+	out := image.NewRGBA(b)
+	w2 := white * white
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		o := out.PixOffset(b.Min.X, y)
+		for x := b.Min.X; x < b.Max.X; x, o = x+1, o+4 {
+			r, g, bl := read(x, y)
+			if l := linearLuminance(r, g, bl); l > 0 {
+				f := (1.0 + l/w2) / (1.0 + l)
+				r, g, bl = r*f, g*f, bl*f
+			}
+			out.Pix[o] = uint8(math.Round(encodeSRGB(r) * 255.0))
+			out.Pix[o+1] = uint8(math.Round(encodeSRGB(g) * 255.0))
+			out.Pix[o+2] = uint8(math.Round(encodeSRGB(bl) * 255.0))
+			out.Pix[o+3] = 255
+		}
+	}
+	return out
+}
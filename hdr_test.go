@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestTransferFunctions(t *testing.T) {
+	// the signals of SDR white as given by BT.2408
+	if l := pq(0.58); math.Abs(l-hdrWhite) > 2.0 {
+		t.Errorf("expected PQ 0.58 to be %v nits, got %v", hdrWhite, l)
+	}
+	if l := pq(1.0); math.Abs(l-10000.0) > 1e-6 {
+		t.Errorf("expected PQ 1.0 to be 10000 nits, got %v", l)
+	}
+	if l := hlgPeak * math.Pow(hlg(0.75), 1.2); math.Abs(l-hdrWhite) > 2.0 {
+		t.Errorf("expected HLG 0.75 to be %v nits, got %v", hdrWhite, l)
+	}
+}
+
+func TestRead16(t *testing.T) {
+	img := image.NewRGBA64(image.Rect(0, 0, 2, 1))
+	img.SetRGBA64(0, 0, color.RGBA64{0x4000, 0x2000, 0, 0x8000})
+	// not validly premultiplied, as its red exceeds its alpha
+	img.SetRGBA64(1, 0, color.RGBA64{0xffff, 0x8000, 0, 0x8000})
+
+	read := read16(img)
+	if r, g, b := read(0, 0); r != 0x7fff || g != 0x3fff || b != 0 {
+		t.Fatalf("expected the unpremultiplied color, got %x, %x, %x", r, g, b)
+	}
+	if r, g, b := read(1, 0); r != 0xffff || g != 0xffff || b != 0 {
+		t.Fatalf("expected the color to be clamped, got %x, %x, %x", r, g, b)
+	}
+
+	// the tone mapping indexes its tables with the values
+	for _, transfer := range []string{ToneMapLinear, ToneMapPQ, ToneMapHLG} {
+		toneMap(img, transfer)
+	}
+}
+
+func TestToneMap(t *testing.T) {
+	// a dark frame with a window of bright, textured highlights on the right
+	img := NewFloatImage(image.Rect(0, 0, 300, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 300; x++ {
+			v := float32(0.05)
+			if x >= 200 {
+				v = 4.0
+				if (x/4+y/4)%2 == 0 {
+					v = 16.0
+				}
+			}
+			img.SetRGB(x, y, v, v, v)
+		}
+	}
+
+	// without tone mapping, the highlights are plain white
+	clipped := toRGBA(img)
+	if c1, c2 := clipped.RGBAAt(200, 0), clipped.RGBAAt(204, 0); c1 != c2 {
+		t.Fatalf("expected clipped highlights, got %v and %v", c1, c2)
+	}
+	mapped := toneMap(img, ToneMapLinear)
+	if c1, c2 := mapped.RGBAAt(200, 0), mapped.RGBAAt(204, 0); c1.R <= c2.R || c1.R == 255 {
+		t.Fatalf("expected the detail of the highlights to be kept, got %v and %v", c1, c2)
+	}
+	if c := mapped.RGBAAt(0, 0); c.R == 0 {
+		t.Fatalf("expected the shadows to be kept, got %v", c)
+	}
+
+	settings := DefaultCropSettings()
+	settings.ToneMap = ToneMapLinear
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ResultAnalyzer)
+	if _, err := analyzer.FindBestResult(img, 100, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	settings.ToneMap = "aces"
+	analyzer = NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ResultAnalyzer)
+	if _, err := analyzer.FindBestResult(img, 100, 100); err == nil {
+		t.Fatal("expected an error for an unknown tone mapping")
+	}
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+/*
+Package hugo brings smart cropping to static sites built with Hugo, without
+running a server: Generate crops the images of a site for the aspect ratios
+its pages declare, and writes a data file templates look the crops up in.
+
+Pages declare the aspect ratios they need in their front matter, in YAML,
+TOML or JSON:
+
+	---
+	title: Gophers
+	smartcrop: ["16:9", "1:1"]
+	---
+
+Each image below static/images gets cropped for all of them, and the crops
+get written to static/smartcrop. data/smartcrop.json maps the path of each
+image, relative to static/images, and a ratio to its crop:
+
+	{{ with index site.Data.smartcrop "gopher.jpg" "16:9" }}
+	  <img src="{{ .url }}" width="{{ .width }}" height="{{ .height }}">
+	{{ end }}
+
+Run it before building the site, e.g. with `smartcrop hugo`.
+*/
+package hugo
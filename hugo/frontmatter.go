@@ -0,0 +1,181 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package hugo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// frontMatterKey is the key of the front matter declaring the aspect ratios
+// of a page.
+const frontMatterKey = "smartcrop"
+
+// Ratio is an aspect ratio, e.g. 16:9.
+type Ratio struct {
+	Width, Height int
+}
+
+// ParseRatio parses an aspect ratio given as "16:9", "16x9" or "16/9".
+func ParseRatio(s string) (Ratio, error) {
+	i := strings.IndexAny(s, ":x/")
+	if i < 0 {
+		return Ratio{}, fmt.Errorf("hugo: invalid aspect ratio %q", s)
+	}
+	w, err := strconv.Atoi(strings.TrimSpace(s[:i]))
+	if err != nil || w <= 0 {
+		return Ratio{}, fmt.Errorf("hugo: invalid aspect ratio %q", s)
+	}
+	h, err := strconv.Atoi(strings.TrimSpace(s[i+1:]))
+	if err != nil || h <= 0 {
+		return Ratio{}, fmt.Errorf("hugo: invalid aspect ratio %q", s)
+	}
+	return Ratio{Width: w, Height: h}, nil
+}
+
+// String returns the ratio as it's keyed in the data file, e.g. "16:9".
+func (r Ratio) String() string {
+	return strconv.Itoa(r.Width) + ":" + strconv.Itoa(r.Height)
+}
+
+// frontMatterRatios returns the aspect ratios declared in the front matter of
+// the page content. Pages without front matter or without the key declare
+// none.
+func frontMatterRatios(content []byte) ([]Ratio, error) {
+	var values []string
+	var err error
+	switch {
+	case bytes.HasPrefix(content, []byte("---")):
+		values, err = frontMatterValues(content, "---", ":")
+	case bytes.HasPrefix(content, []byte("+++")):
+		values, err = frontMatterValues(content, "+++", "=")
+	case bytes.HasPrefix(bytes.TrimLeft(content, " \t\r\n"), []byte("{")):
+		values, err = jsonFrontMatterValues(content)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ratios []Ratio
+	for _, v := range values {
+		r, err := ParseRatio(v)
+		if err != nil {
+			return nil, err
+		}
+		ratios = append(ratios, r)
+	}
+	return ratios, nil
+}
+
+// frontMatterValues returns the values of the key in YAML or TOML front
+// matter, delimited by delim and assigning values with sep. Only the subset
+// needed for a list of strings is supported: a flow sequence or array on the
+// line of the key, or a YAML block sequence below it.
+func frontMatterValues(content []byte, delim, sep string) ([]string, error) {
+	s := bufio.NewScanner(bytes.NewReader(content))
+	s.Scan() // the opening delimiter
+
+	var values []string
+	inKey := false
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), " \t\r")
+		if line == delim {
+			return values, nil
+		}
+
+		text := strings.TrimSpace(line)
+		if inKey {
+			if strings.HasPrefix(text, "- ") {
+				values = append(values, unquote(strings.TrimSpace(text[2:])))
+				continue
+			}
+			inKey = false
+		}
+		if line != text {
+			// nested below another key
+			continue
+		}
+
+		i := strings.Index(text, sep)
+		if i < 0 || strings.TrimSpace(text[:i]) != frontMatterKey {
+			continue
+		}
+		v := strings.TrimSpace(text[i+len(sep):])
+		switch {
+		case v == "":
+			inKey = true
+		case strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]"):
+			for _, item := range strings.Split(v[1:len(v)-1], ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					values = append(values, unquote(item))
+				}
+			}
+		default:
+			values = append(values, unquote(v))
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("hugo: front matter isn't closed by %s", delim)
+}
+
+// jsonFrontMatterValues returns the values of the key in JSON front matter,
+// which is a JSON object at the start of the page.
+func jsonFrontMatterValues(content []byte) ([]string, error) {
+	var fm map[string]json.RawMessage
+	if err := json.NewDecoder(bytes.NewReader(content)).Decode(&fm); err != nil {
+		return nil, fmt.Errorf("hugo: invalid front matter: %v", err)
+	}
+	raw, ok := fm[frontMatterKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var values []string
+	if err := json.Unmarshal(raw, &values); err != nil {
+		var v string
+		if json.Unmarshal(raw, &v) != nil {
+			return nil, fmt.Errorf("hugo: %s must be a list of aspect ratios", frontMatterKey)
+		}
+		values = []string{v}
+	}
+	return values, nil
+}
+
+// unquote strips the quotes around a YAML or TOML string.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
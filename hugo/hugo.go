@@ -0,0 +1,305 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package hugo
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+	"github.com/muesli/smartcrop/options"
+
+	_ "image/gif" // register the GIF decoder
+)
+
+// Options configures Generate. The directories and the data file are
+// relative to the root of the site.
+type Options struct {
+	// ContentDir holds the pages declaring aspect ratios in their front
+	// matter, defaulting to "content".
+	ContentDir string
+	// ImageDir holds the images to crop, defaulting to "static/images".
+	ImageDir string
+	// OutputDir is where the crops get written to, defaulting to
+	// "static/smartcrop", and URL is the URL it's served at, defaulting to
+	// "/smartcrop".
+	OutputDir string
+	URL       string
+	// DataFile is the file the crops get listed in, defaulting to
+	// "data/smartcrop.json".
+	DataFile string
+
+	// Ratios are aspect ratios to crop all images for, besides the ones
+	// declared by the pages.
+	Ratios []Ratio
+
+	// Width is the width the crops get scaled down to, if they're wider.
+	// They keep the size they were cut out at if it's 0.
+	Width int
+	// Quality is the quality of the JPEG crops, defaulting to 85.
+	Quality int
+
+	// Settings are the settings of the analysis, defaulting to
+	// smartcrop.DefaultCropSettings, and Resizer scales the images,
+	// defaulting to nfnt.
+	Settings *smartcrop.CropSettings
+	Resizer  options.Resizer
+}
+
+// Crop is a cropped image in the data file.
+type Crop struct {
+	// URL is the URL the crop is served at.
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+
+	// Result is the analysis the crop has been cut out by, with its region
+	// in the original image.
+	Result smartcrop.Result `json:"result"`
+}
+
+// Data is the content of the data file: the crops by the path of their image
+// relative to Options.ImageDir, with forward slashes, and by aspect ratio.
+type Data map[string]map[string]Crop
+
+// Generate crops the images of the site at root for all aspect ratios its
+// pages declare, writes the crops and the data file, and returns the data.
+func Generate(root string, opts Options) (Data, error) {
+	opts = opts.withDefaults()
+
+	ratios, err := pageRatios(filepath.Join(root, opts.ContentDir), opts.Ratios)
+	if err != nil {
+		return nil, err
+	}
+	images, err := listImages(filepath.Join(root, opts.ImageDir))
+	if err != nil {
+		return nil, err
+	}
+
+	analyzer := smartcrop.NewAnalyzerWithSettings(opts.Resizer, smartcrop.Logger{}, *opts.Settings).(smartcrop.ResultAnalyzer)
+	data := make(Data)
+	for _, name := range images {
+		crops, err := opts.crop(root, name, ratios, analyzer)
+		if err != nil {
+			return nil, err
+		}
+		data[name] = crops
+	}
+
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	dataFile := filepath.Join(root, opts.DataFile)
+	if err := os.MkdirAll(filepath.Dir(dataFile), 0755); err != nil {
+		return nil, err
+	}
+	return data, ioutil.WriteFile(dataFile, append(b, '\n'), 0644)
+}
+
+// withDefaults returns o with the defaults of its missing fields.
+func (o Options) withDefaults() Options {
+	if o.ContentDir == "" {
+		o.ContentDir = "content"
+	}
+	if o.ImageDir == "" {
+		o.ImageDir = filepath.Join("static", "images")
+	}
+	if o.OutputDir == "" {
+		o.OutputDir = filepath.Join("static", "smartcrop")
+	}
+	if o.URL == "" {
+		o.URL = "/smartcrop"
+	}
+	if o.DataFile == "" {
+		o.DataFile = filepath.Join("data", "smartcrop.json")
+	}
+	if o.Quality <= 0 {
+		o.Quality = 85
+	}
+	if o.Settings == nil {
+		settings := smartcrop.DefaultCropSettings()
+		o.Settings = &settings
+	}
+	if o.Resizer == nil {
+		o.Resizer = nfnt.NewDefaultResizer()
+	}
+	return o
+}
+
+// crop crops the image at name, relative to the image directory below root,
+// for ratios and writes the crops.
+func (o Options) crop(root, name string, ratios []Ratio, analyzer smartcrop.ResultAnalyzer) (map[string]Crop, error) {
+	f, err := os.Open(filepath.Join(root, o.ImageDir, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, err
+	}
+	img, format, err := smartcrop.Decode(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	type SubImager interface {
+		SubImage(r image.Rectangle) image.Image
+	}
+
+	crops := make(map[string]Crop)
+	for _, r := range ratios {
+		res, err := analyzer.FindBestResult(img, r.Width, r.Height)
+		if err != nil {
+			return nil, err
+		}
+
+		out := img.(SubImager).SubImage(res.Crop.Rectangle)
+		if o.Width > 0 && out.Bounds().Dx() > o.Width {
+			h := int(math.Max(1, math.Round(float64(o.Width*r.Height)/float64(r.Width))))
+			out = o.Resizer.Resize(out, uint(o.Width), uint(h))
+		}
+
+		ext := ".jpg"
+		if format == "png" {
+			ext = ".png"
+		}
+		base := strings.TrimSuffix(name, path.Ext(name)) + "_" + strconv.Itoa(r.Width) + "x" + strconv.Itoa(r.Height) + ext
+		if err := o.write(filepath.Join(root, o.OutputDir, filepath.FromSlash(base)), out); err != nil {
+			return nil, err
+		}
+
+		crops[r.String()] = Crop{
+			URL:    strings.TrimSuffix(o.URL, "/") + "/" + base,
+			Width:  out.Bounds().Dx(),
+			Height: out.Bounds().Dy(),
+			Result: res,
+		}
+	}
+	return crops, nil
+}
+
+// write encodes img as a JPEG or PNG file at path, by its extension.
+func (o Options) write(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if filepath.Ext(path) == ".png" {
+		err = png.Encode(f, img)
+	} else {
+		err = jpeg.Encode(f, img, &jpeg.Options{Quality: o.Quality})
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// pageRatios returns ratios and the aspect ratios declared by the pages in
+// dir, without duplicates. A missing content directory declares none.
+func pageRatios(dir string, ratios []Ratio) ([]Ratio, error) {
+	seen := make(map[Ratio]bool)
+	var res []Ratio
+	add := func(r Ratio) {
+		if !seen[r] {
+			seen[r] = true
+			res = append(res, r)
+		}
+	}
+	for _, r := range ratios {
+		add(r)
+	}
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && p == dir {
+			return filepath.SkipDir
+		}
+		if err != nil || info.IsDir() {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".md", ".markdown", ".html", ".adoc", ".org", ".rst":
+		default:
+			return nil
+		}
+
+		content, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		declared, err := frontMatterRatios(content)
+		if err != nil {
+			return fmt.Errorf("%s: %v", p, err)
+		}
+		for _, r := range declared {
+			add(r)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// listImages returns the paths of the JPEG, PNG and GIF images below dir,
+// relative to it with forward slashes, in lexical order.
+func listImages(dir string) ([]string, error) {
+	var images []string
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".jpg", ".jpeg", ".png", ".gif":
+		default:
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		images = append(images, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(images)
+	return images, err
+}
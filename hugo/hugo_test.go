@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package hugo
+
+import (
+	"encoding/json"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testFile = "../examples/gopher.jpg"
+
+func TestFrontMatterRatios(t *testing.T) {
+	tests := map[string][]Ratio{
+		"---\ntitle: Gophers\nsmartcrop: [\"16:9\", '1:1']\n---\ntext":                {{16, 9}, {1, 1}},
+		"---\nsmartcrop:\n  - \"4:3\"\n  - 3x2\ntags: [a]\n---\n":                     {{4, 3}, {3, 2}},
+		"---\nparams:\n  smartcrop: [\"2:1\"]\nsmartcrop: 21/9\n---\n":                {{21, 9}},
+		"+++\ntitle = \"Gophers\"\nsmartcrop = [\"16:9\"]\n+++\n":                     {{16, 9}},
+		"{\n  \"title\": \"Gophers\",\n  \"smartcrop\": [\"1:1\", \"9:16\"]\n}\ntext": {{1, 1}, {9, 16}},
+		"---\ntitle: Gophers\n---\n":                                                  nil,
+		"no front matter":                                                             nil,
+	}
+	for content, want := range tests {
+		ratios, err := frontMatterRatios([]byte(content))
+		if err != nil {
+			t.Errorf("%q: %v", content, err)
+			continue
+		}
+		if !reflect.DeepEqual(ratios, want) {
+			t.Errorf("expected %v for %q, got %v", want, content, ratios)
+		}
+	}
+
+	for _, content := range []string{"---\nsmartcrop: [\"wide\"]\n---\n", "---\nsmartcrop: [\"0:1\"]\n---\n", "---\nsmartcrop: [\"1:1\"]\n"} {
+		if _, err := frontMatterRatios([]byte(content)); err == nil {
+			t.Errorf("expected an error for %q", content)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	root, err := ioutil.TempDir("", "smartcrop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"content/_index.md":       "---\ntitle: Home\nsmartcrop: [\"16:9\"]\n---\n",
+		"content/posts/gopher.md": "+++\nsmartcrop = [\"1:1\", \"16x9\"]\n+++\n",
+	}
+	img, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["static/images/animals/gopher.jpg"] = string(img)
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := Generate(root, Options{Width: 200, Ratios: []Ratio{{4, 3}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	crops := data["animals/gopher.jpg"]
+	if len(data) != 1 || len(crops) != 3 {
+		t.Fatalf("expected the crops of gopher.jpg for 3 ratios, got %v", data)
+	}
+	for ratio, size := range map[string][2]int{"4:3": {200, 150}, "16:9": {200, 113}, "1:1": {200, 200}} {
+		c := crops[ratio]
+		if c.Width != size[0] || c.Height != size[1] {
+			t.Errorf("expected a %dx%d crop for %s, got %dx%d", size[0], size[1], ratio, c.Width, c.Height)
+		}
+	}
+	if url := crops["16:9"].URL; url != "/smartcrop/animals/gopher_16x9.jpg" {
+		t.Errorf("expected the crop to be served at /smartcrop/animals/gopher_16x9.jpg, got %s", url)
+	}
+
+	f, err := os.Open(filepath.Join(root, "static", "smartcrop", "animals", "gopher_1x1.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := jpeg.DecodeConfig(f)
+	f.Close()
+	if err != nil || c.Width != 200 || c.Height != 200 {
+		t.Errorf("expected a 200x200 crop, got %dx%d (%v)", c.Width, c.Height, err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(root, "data", "smartcrop.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written Data
+	if err := json.Unmarshal(b, &written); err != nil {
+		t.Fatal(err)
+	}
+	if written["animals/gopher.jpg"]["1:1"].URL != crops["1:1"].URL {
+		t.Errorf("expected the data file to list the crops, got %s", b)
+	}
+}
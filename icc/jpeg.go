@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package icc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// iccMarker starts the APP2 segments of a JPEG containing an ICC profile.
+var iccMarker = []byte("ICC_PROFILE\x00")
+
+// FromJPEG returns the ICC profile embedded in the JPEG read from r. It
+// returns nil without an error if the JPEG doesn't embed any. Only the
+// headers of the JPEG get read.
+func FromJPEG(r io.Reader) ([]byte, error) {
+	br := bufio.NewReader(r)
+	var soi [2]byte
+	if _, err := io.ReadFull(br, soi[:]); err != nil {
+		return nil, err
+	}
+	if soi != [2]byte{0xff, 0xd8} {
+		return nil, ErrInvalidProfile
+	}
+
+	// a profile may get split across multiple segments
+	chunks := map[byte][]byte{}
+	count := 0
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b != 0xff {
+			return nil, ErrInvalidProfile
+		}
+		marker := byte(0xff)
+		for marker == 0xff {
+			if marker, err = br.ReadByte(); err != nil {
+				return nil, err
+			}
+		}
+
+		// the image data starts after the headers
+		if marker == 0xd9 || marker == 0xda {
+			break
+		}
+		// standalone markers don't have a payload
+		if marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7) {
+			continue
+		}
+
+		var l [2]byte
+		if _, err := io.ReadFull(br, l[:]); err != nil {
+			return nil, err
+		}
+		n := int(binary.BigEndian.Uint16(l[:])) - 2
+		if n < 0 {
+			return nil, ErrInvalidProfile
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return nil, err
+		}
+
+		if marker == 0xe2 && len(payload) >= 14 && bytes.HasPrefix(payload, iccMarker) {
+			chunks[payload[12]] = payload[14:]
+			count = int(payload[13])
+		}
+	}
+
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+	var data []byte
+	for i := 1; i <= count; i++ {
+		chunk, ok := chunks[byte(i)]
+		if !ok {
+			return nil, ErrInvalidProfile
+		}
+		data = append(data, chunk...)
+	}
+	return data, nil
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package icc
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+func TestFromJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := FromJPEG(bytes.NewReader(buf.Bytes()))
+	if err != nil || data != nil {
+		t.Fatalf("expected no profile, got %v, %v", data, err)
+	}
+
+	// embed the profile split into two segments, in reverse order
+	profile := buildProfile(p3Colorants)
+	half := len(profile) / 2
+	var embedded []byte
+	embedded = append(embedded, buf.Bytes()[:2]...)
+	for _, seq := range []int{2, 1} {
+		chunk := profile[:half]
+		if seq == 2 {
+			chunk = profile[half:]
+		}
+		n := 2 + len(iccMarker) + 2 + len(chunk)
+		embedded = append(embedded, 0xff, 0xe2, byte(n>>8), byte(n))
+		embedded = append(embedded, iccMarker...)
+		embedded = append(embedded, byte(seq), 2)
+		embedded = append(embedded, chunk...)
+	}
+	embedded = append(embedded, buf.Bytes()[2:]...)
+
+	data, err = FromJPEG(bytes.NewReader(embedded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, profile) {
+		t.Fatalf("expected the embedded profile, got %d bytes", len(data))
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(embedded)); err != nil {
+		t.Fatalf("expected the JPEG to stay valid: %v", err)
+	}
+}
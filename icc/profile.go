@@ -0,0 +1,308 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+/*
+Package icc converts images with an embedded ICC profile, e.g. Display P3 or
+Adobe RGB photos, to sRGB, which the detectors of smartcrop expect. Only
+matrix/TRC based RGB profiles are supported, which covers the profiles cameras
+and phones embed; LUT based profiles are rejected.
+
+Pass the profile of a JPEG along with a smartcrop.Request:
+
+	data, err := icc.FromJPEG(f)
+	...
+	res, err := smartcrop.FindCrop(img, smartcrop.Request{
+		Width: 250, Height: 250,
+		Resizer:    nfnt.NewDefaultResizer(),
+		ICCProfile: data,
+	})
+*/
+package icc
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"math"
+)
+
+var (
+	// ErrInvalidProfile gets returned when the data isn't an ICC profile.
+	ErrInvalidProfile = errors.New("Invalid ICC profile")
+	// ErrUnsupportedProfile gets returned for profiles that aren't matrix/TRC
+	// based RGB profiles.
+	ErrUnsupportedProfile = errors.New("Only matrix/TRC based RGB profiles are supported")
+)
+
+// xyzToSRGB maps the D50 adapted XYZ connection space of ICC profiles to
+// linear sRGB.
+var xyzToSRGB = [3][3]float64{
+	{3.1338561, -1.6168667, -0.4906146},
+	{-0.9787684, 1.9161415, 0.0334540},
+	{0.0719453, -0.2289914, 1.4052427},
+}
+
+// encodeSize is the number of entries of the table encoding linear sRGB.
+const encodeSize = 4096
+
+var encodeTable = func() [encodeSize]uint8 {
+	var t [encodeSize]uint8
+	for i := range t {
+		v := float64(i) / (encodeSize - 1)
+		if v <= 0.0031308 {
+			v *= 12.92
+		} else {
+			v = 1.055*math.Pow(v, 1.0/2.4) - 0.055
+		}
+		t[i] = uint8(math.Round(v * 255.0))
+	}
+	return t
+}()
+
+// encode returns the 8-bit sRGB value of the linear value v.
+func encode(v float64) uint8 {
+	return encodeTable[int(math.Min(math.Max(v, 0.0), 1.0)*(encodeSize-1)+0.5)]
+}
+
+// Profile converts images from the color space of an ICC profile to sRGB.
+type Profile struct {
+	matrix   [3][3]float64
+	decode   [3][256]float64
+	identity bool
+}
+
+// Parse parses an ICC profile.
+func Parse(data []byte) (*Profile, error) {
+	if len(data) < 132 || string(data[36:40]) != "acsp" {
+		return nil, ErrInvalidProfile
+	}
+	if string(data[16:20]) != "RGB " || string(data[20:24]) != "XYZ " {
+		return nil, ErrUnsupportedProfile
+	}
+
+	tags := map[string][]byte{}
+	count := int(binary.BigEndian.Uint32(data[128:]))
+	for i := 0; i < count; i++ {
+		e := 132 + 12*i
+		if e+12 > len(data) {
+			return nil, ErrInvalidProfile
+		}
+		off := int(binary.BigEndian.Uint32(data[e+4:]))
+		size := int(binary.BigEndian.Uint32(data[e+8:]))
+		if off < 0 || size < 0 || off+size > len(data) || off+size < off {
+			return nil, ErrInvalidProfile
+		}
+		tags[string(data[e:e+4])] = data[off : off+size]
+	}
+
+	// the colorants are the columns of the matrix mapping the linear RGB of
+	// the profile to XYZ
+	var m [3][3]float64
+	for c, sig := range []string{"rXYZ", "gXYZ", "bXYZ"} {
+		xyz, err := parseXYZ(tags[sig])
+		if err != nil {
+			return nil, err
+		}
+		for r := 0; r < 3; r++ {
+			m[r][c] = xyz[r]
+		}
+	}
+
+	p := &Profile{}
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			for k := 0; k < 3; k++ {
+				p.matrix[r][c] += xyzToSRGB[r][k] * m[k][c]
+			}
+		}
+	}
+
+	for c, sig := range []string{"rTRC", "gTRC", "bTRC"} {
+		curve, err := parseCurve(tags[sig])
+		if err != nil {
+			return nil, err
+		}
+		for i := range p.decode[c] {
+			p.decode[c][i] = curve(float64(i) / 255.0)
+		}
+	}
+
+	p.identity = p.isSRGB()
+	return p, nil
+}
+
+// isSRGB reports whether p converts to sRGB without any visible change.
+func (p *Profile) isSRGB() bool {
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			e := 0.0
+			if r == c {
+				e = 1.0
+			}
+			if math.Abs(p.matrix[r][c]-e) > 0.005 {
+				return false
+			}
+		}
+	}
+	for c := 0; c < 3; c++ {
+		for i, v := range p.decode[c] {
+			if encode(v) != uint8(i) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func parseXYZ(tag []byte) ([3]float64, error) {
+	var xyz [3]float64
+	if len(tag) < 20 {
+		return xyz, ErrUnsupportedProfile
+	}
+	if string(tag[:4]) != "XYZ " {
+		return xyz, ErrInvalidProfile
+	}
+	for i := range xyz {
+		xyz[i] = s15Fixed16(tag[8+4*i:])
+	}
+	return xyz, nil
+}
+
+// parseCurve returns the function a curv or para tag describes, mapping
+// encoded values to linear ones, both ranging from 0 to 1.
+func parseCurve(tag []byte) (func(float64) float64, error) {
+	if len(tag) < 12 {
+		return nil, ErrUnsupportedProfile
+	}
+
+	switch string(tag[:4]) {
+	case "curv":
+		n := int(binary.BigEndian.Uint32(tag[8:]))
+		if n < 0 || len(tag) < 12+2*n {
+			return nil, ErrInvalidProfile
+		}
+		switch n {
+		case 0:
+			return func(x float64) float64 { return x }, nil
+		case 1:
+			g := float64(binary.BigEndian.Uint16(tag[12:])) / 256.0
+			return func(x float64) float64 { return math.Pow(x, g) }, nil
+		}
+		table := make([]float64, n)
+		for i := range table {
+			table[i] = float64(binary.BigEndian.Uint16(tag[12+2*i:])) / 65535.0
+		}
+		return func(x float64) float64 {
+			f := x * float64(n-1)
+			i := int(f)
+			if i >= n-1 {
+				return table[n-1]
+			}
+			return table[i] + (table[i+1]-table[i])*(f-float64(i))
+		}, nil
+
+	case "para":
+		params := []int{1, 3, 4, 5, 7}
+		fn := int(binary.BigEndian.Uint16(tag[8:]))
+		if fn >= len(params) || len(tag) < 12+4*params[fn] {
+			return nil, ErrInvalidProfile
+		}
+		var v [7]float64
+		for i := 0; i < params[fn]; i++ {
+			v[i] = s15Fixed16(tag[12+4*i:])
+		}
+
+		g, a, b, c, d, e, f := v[0], v[1], v[2], v[3], v[4], v[5], v[6]
+		// map the simpler function types onto the most general one
+		switch fn {
+		case 0:
+			return func(x float64) float64 { return math.Pow(x, g) }, nil
+		case 1, 2:
+			if a == 0 {
+				return nil, ErrInvalidProfile
+			}
+			if fn == 2 {
+				e, f = c, c
+			}
+			c, d = 0, -b/a
+		}
+		return func(x float64) float64 {
+			if x >= d {
+				return math.Pow(a*x+b, g) + e
+			}
+			return c*x + f
+		}, nil
+	}
+
+	return nil, ErrUnsupportedProfile
+}
+
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536.0
+}
+
+// Transform returns a copy of img converted from the color space of p to
+// sRGB. Colors outside of the sRGB gamut get clipped. If p is equivalent to
+// sRGB, img itself gets returned.
+func (p *Profile) Transform(img *image.RGBA) *image.RGBA {
+	if p.identity {
+		return img
+	}
+
+	b := img.Bounds()
+	out := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		i := img.PixOffset(b.Min.X, y)
+		o := out.PixOffset(b.Min.X, y)
+		for x := b.Min.X; x < b.Max.X; x, i, o = x+1, i+4, o+4 {
+			a := img.Pix[i+3]
+			out.Pix[o+3] = a
+			if a == 0 {
+				continue
+			}
+
+			var lin [3]float64
+			for c := 0; c < 3; c++ {
+				// the pixels are alpha-premultiplied
+				v := img.Pix[i+c]
+				if a < 255 {
+					v = uint8(math.Min(float64(v)*255.0/float64(a), 255.0))
+				}
+				lin[c] = p.decode[c][v]
+			}
+			for c := 0; c < 3; c++ {
+				m := p.matrix[c]
+				e := encode(m[0]*lin[0] + m[1]*lin[1] + m[2]*lin[2])
+				if a < 255 {
+					e = uint8(uint16(e) * uint16(a) / 255)
+				}
+				out.Pix[o+c] = e
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package icc
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// the D50 adapted colorants of sRGB and Display P3
+var (
+	srgbColorants = [3][3]float64{{0.4361, 0.2225, 0.0139}, {0.3851, 0.7169, 0.0971}, {0.1431, 0.0606, 0.7141}}
+	p3Colorants   = [3][3]float64{{0.5151, 0.2412, -0.0011}, {0.2919, 0.6922, 0.0419}, {0.1572, 0.0666, 0.7841}}
+)
+
+func fixed(v float64) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(int32(v*65536.0)))
+	return b
+}
+
+// buildProfile returns a matrix/TRC profile with the given colorants, using
+// the transfer function of sRGB.
+func buildProfile(colorants [3][3]float64) []byte {
+	trc := append([]byte("para\x00\x00\x00\x00\x00\x03\x00\x00"), fixed(2.4)...)
+	for _, v := range []float64{1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045} {
+		trc = append(trc, fixed(v)...)
+	}
+
+	type tag struct {
+		sig  string
+		data []byte
+	}
+	var tags []tag
+	for i, sig := range []string{"rXYZ", "gXYZ", "bXYZ"} {
+		data := []byte("XYZ \x00\x00\x00\x00")
+		for _, v := range colorants[i] {
+			data = append(data, fixed(v)...)
+		}
+		tags = append(tags, tag{sig, data})
+	}
+	for _, sig := range []string{"rTRC", "gTRC", "bTRC"} {
+		tags = append(tags, tag{sig, trc})
+	}
+
+	data := make([]byte, 132+12*len(tags))
+	copy(data[16:], "RGB XYZ ")
+	copy(data[36:], "acsp")
+	binary.BigEndian.PutUint32(data[128:], uint32(len(tags)))
+	for i, t := range tags {
+		e := 132 + 12*i
+		copy(data[e:], t.sig)
+		binary.BigEndian.PutUint32(data[e+4:], uint32(len(data)))
+		binary.BigEndian.PutUint32(data[e+8:], uint32(len(t.data)))
+		data = append(data, t.data...)
+	}
+	binary.BigEndian.PutUint32(data, uint32(len(data)))
+	return data
+}
+
+func TestTransform(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.SetRGBA(0, 0, color.RGBA{200, 100, 90, 255})
+	img.SetRGBA(1, 0, color.RGBA{128, 128, 128, 255})
+
+	p, err := Parse(buildProfile(srgbColorants))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := p.Transform(img); out != img {
+		t.Fatalf("expected an sRGB profile to leave the image alone")
+	}
+
+	p, err = Parse(buildProfile(p3Colorants))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := p.Transform(img)
+
+	// the same values describe a more saturated color in Display P3
+	c := out.RGBAAt(0, 0)
+	if int(c.R)-int(c.B) <= 200-90 {
+		t.Errorf("expected a more saturated color, got %v", c)
+	}
+	if g := out.RGBAAt(1, 0); g.R != g.G || g.G != g.B || absDiff(g.R, 128) > 1 {
+		t.Errorf("expected gray to stay gray, got %v", g)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := Parse([]byte("not a profile")); err != ErrInvalidProfile {
+		t.Fatalf("expected ErrInvalidProfile, got %v", err)
+	}
+
+	data := buildProfile(p3Colorants)
+	copy(data[16:], "CMYK")
+	if _, err := Parse(data); err != ErrUnsupportedProfile {
+		t.Fatalf("expected ErrUnsupportedProfile, got %v", err)
+	}
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
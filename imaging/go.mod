@@ -0,0 +1,15 @@
+module github.com/muesli/smartcrop/imaging
+
+go 1.18
+
+require (
+	github.com/disintegration/imaging v1.6.2
+	github.com/muesli/smartcrop v0.0.0-20261016125610-7709603b20e0
+)
+
+require (
+	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646 // indirect
+	golang.org/x/image v0.18.0 // indirect
+)
+
+replace github.com/muesli/smartcrop => ../
@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package imaging
+
+import (
+	"image"
+
+	"github.com/disintegration/imaging"
+	"github.com/muesli/smartcrop/options"
+)
+
+type imagingResizer struct {
+	filter imaging.ResampleFilter
+}
+
+// Resize scales img to the given dimensions using imaging. If either width or
+// height is 0, it gets derived from the other one, preserving the aspect ratio
+// of img.
+func (r imagingResizer) Resize(img image.Image, width, height uint) image.Image {
+	if width == 0 && height == 0 {
+		return img
+	}
+	return imaging.Resize(img, int(width), int(height), r.filter)
+}
+
+// NewResizer creates a new Resizer with the given resampling filter.
+func NewResizer(filter imaging.ResampleFilter) options.Resizer {
+	return imagingResizer{filter: filter}
+}
+
+// NewDefaultResizer creates a new Resizer with the Lanczos filter.
+func NewDefaultResizer() options.Resizer {
+	return NewResizer(imaging.Lanczos)
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package imaging
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+	"github.com/muesli/smartcrop/options"
+)
+
+// Fill returns a transform cropping images to their best crop of the given
+// aspect ratio and resizing them to width×height, using the default Analyzer
+// and Resizer.
+func Fill(width, height int) func(image.Image) *image.NRGBA {
+	resizer := nfnt.NewDefaultResizer()
+	return FillWith(smartcrop.NewAnalyzer(resizer), resizer, width, height)
+}
+
+// FillWith is like Fill, using the given Analyzer and Resizer.
+func FillWith(analyzer smartcrop.Analyzer, resizer options.Resizer, width, height int) func(image.Image) *image.NRGBA {
+	crop := CropWith(analyzer, width, height)
+	return func(img image.Image) *image.NRGBA {
+		cropped := crop(img)
+		if cropped.Rect.Empty() {
+			return cropped
+		}
+		return toNRGBA(resizer.Resize(cropped, uint(width), uint(height)))
+	}
+}
+
+// Crop returns a transform cropping images to their best crop for the given
+// dimensions, without resizing them, using the default Analyzer.
+func Crop(width, height int) func(image.Image) *image.NRGBA {
+	return CropWith(smartcrop.NewAnalyzer(nfnt.NewDefaultResizer()), width, height)
+}
+
+// CropWith is like Crop, using the given Analyzer. Like the transforms of
+// imaging, it returns an empty image if img can't be cropped.
+func CropWith(analyzer smartcrop.Analyzer, width, height int) func(image.Image) *image.NRGBA {
+	return func(img image.Image) *image.NRGBA {
+		if img == nil {
+			return &image.NRGBA{}
+		}
+		r, err := analyzer.FindBestCrop(img, width, height)
+		if err != nil || r.Empty() {
+			return &image.NRGBA{}
+		}
+
+		dst := image.NewNRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+		draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+		return dst
+	}
+}
+
+// toNRGBA returns img as an *image.NRGBA with its origin at 0, 0.
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok && n.Rect.Min == (image.Point{}) {
+		return n
+	}
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
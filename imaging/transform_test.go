@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package imaging
+
+import (
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+var testFile = "../examples/gopher.jpg"
+
+func TestFill(t *testing.T) {
+	fi, err := os.Open(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := Fill(100, 50)(img)
+	if dst.Bounds() != image.Rect(0, 0, 100, 50) {
+		t.Fatalf("expected a 100x50 image, got %v", dst.Bounds())
+	}
+
+	analyzer := smartcrop.NewAnalyzer(nfnt.NewDefaultResizer())
+	expected, err := analyzer.FindBestCrop(img, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cropped := CropWith(analyzer, 250, 250)(img)
+	if cropped.Bounds().Size() != expected.Size() {
+		t.Fatalf("expected a crop of %v, got %v", expected.Size(), cropped.Bounds().Size())
+	}
+	if c1, c2 := cropped.NRGBAAt(0, 0), color.NRGBAModel.Convert(img.At(expected.Min.X, expected.Min.Y)); c1 != c2 {
+		t.Fatalf("expected the crop to start at %v", expected.Min)
+	}
+
+	if dst := Fill(-1, 50)(img); !dst.Bounds().Empty() {
+		t.Fatalf("expected an empty image for invalid dimensions, got %v", dst.Bounds())
+	}
+}
@@ -0,0 +1,176 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"errors"
+	"image"
+	"math"
+
+	"golang.org/x/image/draw"
+)
+
+// updateMargin is the number of pixels of the prescaled image around a dirty
+// region which get recomputed, covering the support of the resampling kernel
+// and the neighborhood of the edge detection.
+const updateMargin = 4
+
+// ErrBoundsChanged gets returned when updating an Analysis with an image of
+// different bounds.
+var ErrBoundsChanged = errors.New("Image bounds changed")
+
+// Update returns the Analysis of img, an edited version of the analysed image
+// which only differs within the dirty rectangle, in coordinates of the
+// source image. Only the planes around that rectangle get recomputed, e.g. to
+// preview crops in an editor after a localized retouch. The result matches a
+// full analysis up to resampling differences at the border of the rectangle.
+//
+// Settings whose detection depends on the whole image, like Equalize,
+// SkinBlobs, SkySuppression, Detectors or a Classifier, as well as hooks of
+// the prescale and detect stages, fall back to a full analysis.
+func (a *Analysis) Update(img image.Image, dirty image.Rectangle) (res *Analysis, err error) {
+	defer recoverAnalysis(&err, img, 0, 0, a.analyzer.settings)
+
+	if img == nil {
+		return nil, ErrNilImage
+	}
+	b := img.Bounds()
+	if b != a.state.Source.Bounds() {
+		return nil, ErrBoundsChanged
+	}
+	if !a.incremental() {
+		return a.analyzer.Analyze(img)
+	}
+
+	dirty = dirty.Intersect(b)
+	st := a.state
+	st.Source = img
+	if dirty.Empty() {
+		return &Analysis{analyzer: a.analyzer, state: st}, nil
+	}
+
+	// the region of the prescaled image affected by the edit
+	pb := st.Prescaled.Bounds()
+	fx := float64(pb.Dx()) / float64(b.Dx())
+	fy := float64(pb.Dy()) / float64(b.Dy())
+	affected := image.Rect(
+		int(math.Floor(float64(dirty.Min.X-b.Min.X)*fx))-updateMargin,
+		int(math.Floor(float64(dirty.Min.Y-b.Min.Y)*fy))-updateMargin,
+		int(math.Ceil(float64(dirty.Max.X-b.Min.X)*fx))+updateMargin,
+		int(math.Ceil(float64(dirty.Max.Y-b.Min.Y)*fy))+updateMargin,
+	).Intersect(image.Rect(0, 0, pb.Dx(), pb.Dy()))
+
+	st.Prescaled = a.represcale(img, affected)
+	st.Detected = cloneRGBA(st.Detected)
+
+	// detect on the affected region plus the neighborhood of its edges
+	region := affected.Inset(-1).Intersect(image.Rect(0, 0, pb.Dx(), pb.Dy()))
+	in := image.NewRGBA(image.Rect(0, 0, region.Dx(), region.Dy()))
+	draw.Copy(in, image.Point{}, st.Prescaled, region.Add(pb.Min), draw.Src, nil)
+	out := image.NewRGBA(in.Bounds())
+	edgeDetect(in, out)
+	s := &a.analyzer.settings
+	if !s.SkipSkin {
+		skinDetect(s, in, out)
+	}
+	if !s.SkipSaturation {
+		saturationDetect(s, in, out)
+	}
+
+	// the edges of the region lack their neighbors, unless they are the edges
+	// of the image
+	ob := st.Detected.Bounds()
+	draw.Copy(st.Detected, ob.Min.Add(affected.Min), out, affected.Sub(region.Min), draw.Src, nil)
+
+	return &Analysis{analyzer: a.analyzer, state: st}, nil
+}
+
+// incremental reports whether the planes of a can be updated locally.
+func (a *Analysis) incremental() bool {
+	s := a.analyzer.settings
+	for _, stage := range []Stage{StagePrescale, StageDetect} {
+		if len(s.Hooks.Before[stage]) > 0 || len(s.Hooks.After[stage]) > 0 {
+			return false
+		}
+	}
+	return a.state.Reduction <= 1 &&
+		s.ToneMap == "" && s.Denoise == "" && s.Equalize == "" &&
+		s.EdgeLevels <= 1 && !s.SkinBlobs && s.SkySuppression <= 0 &&
+		len(s.Detectors) == 0 && s.Classifier == nil
+}
+
+// represcale returns a copy of the prescaled image of a with the region r, in
+// coordinates relative to its origin, prescaled anew from img.
+func (a *Analysis) represcale(img image.Image, r image.Rectangle) *image.RGBA {
+	prescaled := cloneRGBA(a.state.Prescaled)
+	pb := prescaled.Bounds()
+	b := img.Bounds()
+
+	if !a.analyzer.settings.Prescale {
+		draw.Copy(prescaled, pb.Min.Add(r.Min), img, r.Add(b.Min), draw.Src, nil)
+		return prescaled
+	}
+
+	// resample enough of the source for the kernels of the region
+	fx := float64(pb.Dx()) / float64(b.Dx())
+	fy := float64(pb.Dy()) / float64(b.Dy())
+	big := r.Inset(-updateMargin).Intersect(image.Rect(0, 0, pb.Dx(), pb.Dy()))
+	src := image.Rect(
+		int(math.Floor(float64(big.Min.X)/fx)), int(math.Floor(float64(big.Min.Y)/fy)),
+		int(math.Ceil(float64(big.Max.X)/fx)), int(math.Ceil(float64(big.Max.Y)/fy)),
+	).Add(b.Min).Intersect(b)
+
+	type SubImager interface {
+		SubImage(r image.Rectangle) image.Image
+	}
+	var sub image.Image
+	if si, ok := img.(SubImager); ok {
+		sub = si.SubImage(src)
+	} else {
+		sub = toRGBA(img).SubImage(src)
+	}
+	w := int(math.Round(float64(src.Dx()) * fx))
+	h := int(math.Round(float64(src.Dy()) * fy))
+	resized := toRGBA(a.analyzer.Resizer.Resize(sub, uint(maxInt(w, 1)), uint(maxInt(h, 1))))
+
+	// the resized pixel at 0, 0 corresponds to this one of the prescaled image
+	origin := image.Pt(
+		int(math.Round(float64(src.Min.X-b.Min.X)*fx)),
+		int(math.Round(float64(src.Min.Y-b.Min.Y)*fy)),
+	)
+	rb := resized.Bounds()
+	draw.Copy(prescaled, pb.Min.Add(r.Min), resized, r.Sub(origin).Add(rb.Min), draw.Src, nil)
+	return prescaled
+}
+
+// cloneRGBA returns a copy of img.
+func cloneRGBA(img *image.RGBA) *image.RGBA {
+	c := *img
+	c.Pix = append([]uint8(nil), img.Pix...)
+	return &c
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestAnalysisUpdate(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// large enough to get prescaled
+	orig := toRGBA(nfnt.NewDefaultResizer().Resize(img, 1800, 0))
+
+	// retouch a region with a red square
+	edited := image.NewRGBA(orig.Bounds())
+	copy(edited.Pix, orig.Pix)
+	dirty := image.Rect(200, 100, 400, 300)
+	draw.Draw(edited, dirty, image.NewUniform(color.RGBA{255, 0, 0, 255}), image.Point{}, draw.Src)
+
+	for _, prescale := range []bool{true, false} {
+		settings := DefaultCropSettings()
+		settings.Prescale = prescale
+		analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ImageAnalyzer)
+
+		a, err := analyzer.Analyze(orig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		updated, err := a.Update(edited, dirty)
+		if err != nil {
+			t.Fatal(err)
+		}
+		full, err := analyzer.Analyze(edited)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// allow for resampling differences at the border of the region
+		u, f := updated.state.Detected, full.state.Detected
+		diffs := 0
+		for i := range u.Pix {
+			if d := int(u.Pix[i]) - int(f.Pix[i]); d > 2 || d < -2 {
+				diffs++
+			}
+		}
+		if diffs > len(u.Pix)/200 {
+			t.Fatalf("expected the updated planes to match a full analysis with prescale %v, %d of %d values differ", prescale, diffs, len(u.Pix))
+		}
+		if string(a.state.Detected.Pix) == string(u.Pix) {
+			t.Fatalf("expected the planes to change with prescale %v", prescale)
+		}
+
+		res, err := updated.FindBestResult(250, 250)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := full.FindBestResult(250, 250)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Crop.Rectangle != expected.Crop.Rectangle {
+			t.Fatalf("expected crop %v, got %v", expected.Crop.Rectangle, res.Crop.Rectangle)
+		}
+	}
+
+	a, _ := NewAnalyzer(nfnt.NewDefaultResizer()).(ImageAnalyzer).Analyze(orig)
+	if _, err := a.Update(image.NewRGBA(image.Rect(0, 0, 10, 10)), dirty); err != ErrBoundsChanged {
+		t.Fatalf("expected ErrBoundsChanged, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"errors"
+	"image"
+	"math"
+	"time"
+)
+
+const (
+	// kenBurnsRate is how much a Ken Burns effect zooms in per second.
+	kenBurnsRate = 0.05
+	// kenBurnsMaxZoom limits the zoom of long Ken Burns effects.
+	kenBurnsMaxZoom = 1.5
+)
+
+// ErrInvalidDuration gets returned when planning a Ken Burns effect without a
+// positive duration.
+var ErrInvalidDuration = errors.New("Expect a positive duration")
+
+// KenBurns describes a pan and zoom from the Start to the End crop over the
+// given Duration.
+type KenBurns struct {
+	Result   Result          `json:"result"`
+	Duration time.Duration   `json:"duration"`
+	Start    image.Rectangle `json:"start"`
+	End      image.Rectangle `json:"end"`
+}
+
+// PlanKenBurns analyzes img for the given aspect ratio (width / height) and
+// plans a Ken Burns effect which starts at the widest crop of that ratio and
+// moves towards the focal point of the best crop, zooming in further the
+// longer it lasts.
+func PlanKenBurns(analyzer ResultAnalyzer, img image.Image, ratio float64, duration time.Duration) (KenBurns, error) {
+	if ratio <= 0 || math.IsNaN(ratio) || math.IsInf(ratio, 0) {
+		return KenBurns{}, ErrInvalidDimensions
+	}
+	if duration <= 0 {
+		return KenBurns{}, ErrInvalidDuration
+	}
+
+	zoom := math.Min(1.0+kenBurnsRate*duration.Seconds(), kenBurnsMaxZoom)
+	w, h := 1000, int(math.Max(1.0, math.Round(1000.0/ratio)))
+	z, err := PlanZoom(analyzer, img, w, h, 2, zoom)
+	if err != nil {
+		return KenBurns{}, err
+	}
+
+	return KenBurns{
+		Result:   z.Result,
+		Duration: duration,
+		Start:    z.Crops[0],
+		End:      z.Crops[1],
+	}, nil
+}
+
+// At returns the crop at time t of the effect, interpolating linearly between
+// Start and End.
+func (k KenBurns) At(t time.Duration) image.Rectangle {
+	f := 1.0
+	if k.Duration > 0 {
+		f = math.Min(math.Max(float64(t)/float64(k.Duration), 0.0), 1.0)
+	}
+
+	lerp := func(a, b int) int {
+		return int(math.Round(float64(a) + (float64(b)-float64(a))*f))
+	}
+	return image.Rect(
+		lerp(k.Start.Min.X, k.End.Min.X), lerp(k.Start.Min.Y, k.End.Min.Y),
+		lerp(k.Start.Max.X, k.End.Max.X), lerp(k.Start.Max.Y, k.End.Max.Y),
+	)
+}
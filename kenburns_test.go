@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestPlanKenBurns(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer := NewAnalyzer(nfnt.NewDefaultResizer()).(ResultAnalyzer)
+	kb, err := PlanKenBurns(analyzer, img, 1.0, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kb.End.Dx() >= kb.Start.Dx() {
+		t.Fatalf("expected the effect to zoom in, got %v to %v", kb.Start, kb.End)
+	}
+	if kb.At(0) != kb.Start || kb.At(kb.Duration) != kb.End {
+		t.Fatalf("expected the effect to run from %v to %v, got %v to %v", kb.Start, kb.End, kb.At(0), kb.At(kb.Duration))
+	}
+	if mid := kb.At(kb.Duration / 2); !mid.In(kb.Start.Union(kb.End)) {
+		t.Fatalf("expected %v between %v and %v", mid, kb.Start, kb.End)
+	}
+
+	if _, err := PlanKenBurns(analyzer, img, 1.0, 0); err != ErrInvalidDuration {
+		t.Fatalf("expected ErrInvalidDuration, got %v", err)
+	}
+}
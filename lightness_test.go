@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestLightnessRow(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n <= 40; n++ {
+		pix := make([]uint8, n*4)
+		r.Read(pix)
+		if n > 0 {
+			// the extremes
+			copy(pix, []uint8{255, 255, 255, 255})
+			copy(pix[len(pix)-4:], []uint8{0, 0, 0, 0})
+		}
+
+		got := make([]float64, n)
+		lightnessRow(got, pix)
+		for i := range got {
+			expected := cie(color.RGBA{pix[i*4], pix[i*4+1], pix[i*4+2], pix[i*4+3]})
+			// fused multiply-adds may round differently
+			if math.Abs(got[i]-expected) > 1e-9 {
+				t.Fatalf("expected lightness %v for pixel %d of %d, got %v", expected, i, n, got[i])
+			}
+		}
+	}
+}
+
+func BenchmarkLightnessRow(b *testing.B) {
+	pix := make([]uint8, 1024*4)
+	rand.New(rand.NewSource(1)).Read(pix)
+	dst := make([]float64, 1024)
+	b.SetBytes(int64(len(pix)))
+	for i := 0; i < b.N; i++ {
+		lightnessRow(dst, pix)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image/color"
+	"math"
+	"sync"
+)
+
+// colorTables contains lookup tables replacing most of the per-pixel float
+// math of the skin and saturation detectors. They yield exactly the same
+// results as cie, skinCol and saturation.
+type colorTables struct {
+	// cieR, cieG and cieB contain the weighted contribution of each channel
+	// to the lightness of a pixel.
+	cieR, cieG, cieB [256]float64
+
+	// sq contains the squares of all channel values.
+	sq [256]float64
+
+	// sat contains the saturation of every combination of the maximum (high
+	// byte) and minimum (low byte) channel value of a pixel.
+	sat [256 * 256]float64
+}
+
+var (
+	colorTablesOnce sync.Once
+	colorTablesLUT  *colorTables
+)
+
+// getColorTables returns the lookup tables, building them on first use.
+func getColorTables() *colorTables {
+	colorTablesOnce.Do(func() {
+		t := &colorTables{}
+		for v := 0; v < 256; v++ {
+			f := float64(v)
+			t.cieR[v] = 0.0722 * f
+			t.cieG[v] = 0.7152 * f
+			t.cieB[v] = 0.5126 * f
+			t.sq[v] = f * f
+
+			for min := 0; min <= v; min++ {
+				t.sat[v<<8|min] = saturation(color.RGBA{uint8(v), uint8(min), uint8(min), 255})
+			}
+		}
+		colorTablesLUT = t
+	})
+	return colorTablesLUT
+}
+
+// cie is the lookup table equivalent of the cie function.
+func (t *colorTables) cie(r, g, b uint8) float64 {
+	return t.cieB[b] + t.cieG[g] + t.cieR[r]
+}
+
+// skin is the lookup table equivalent of the skinCol function.
+func (t *colorTables) skin(r, g, b uint8) float64 {
+	mag := math.Sqrt(t.sq[r] + t.sq[g] + t.sq[b])
+	rd := float64(r)/mag - skinColor[0]
+	gd := float64(g)/mag - skinColor[1]
+	bd := float64(b)/mag - skinColor[2]
+
+	d := math.Sqrt(rd*rd + gd*gd + bd*bd)
+	return 1.0 - d
+}
+
+// saturation is the lookup table equivalent of the saturation function.
+func (t *colorTables) saturation(r, g, b uint8) float64 {
+	max, min := r, r
+	if g > max {
+		max = g
+	} else if g < min {
+		min = g
+	}
+	if b > max {
+		max = b
+	} else if b < min {
+		min = b
+	}
+	return t.sat[int(max)<<8|int(min)]
+}
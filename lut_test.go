@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestColorTables(t *testing.T) {
+	lut := getColorTables()
+	for r := 0; r < 256; r += 3 {
+		for g := 0; g < 256; g += 5 {
+			for b := 0; b < 256; b += 7 {
+				c := color.RGBA{uint8(r), uint8(g), uint8(b), 255}
+				if v, e := lut.cie(c.R, c.G, c.B), cie(c); v != e {
+					t.Fatalf("cie of %v: expected %v, got %v", c, e, v)
+				}
+				if v, e := lut.skin(c.R, c.G, c.B), skinCol(c); v != e && !(math.IsNaN(v) && math.IsNaN(e)) {
+					t.Fatalf("skin of %v: expected %v, got %v", c, e, v)
+				}
+				if v, e := lut.saturation(c.R, c.G, c.B), saturation(c); v != e {
+					t.Fatalf("saturation of %v: expected %v, got %v", c, e, v)
+				}
+			}
+		}
+	}
+}
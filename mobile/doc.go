@@ -0,0 +1,46 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+/*
+Package mobile provides bindings of smartcrop for Android and iOS apps,
+e.g. to frame the previews of uploads on the device. It only uses types
+gomobile can bind: images get passed as the bytes of their files, and crops
+get returned as a struct or as the JSON of a smartcrop.Result. It doesn't
+touch the file system.
+
+The analysis works on a prescaled copy with reduced detector planes, so it
+needs little memory beyond the decoded image, and images with more than
+Options.MaxPixels pixels get rejected before decoding them:
+
+	gomobile bind -target android github.com/muesli/smartcrop/mobile
+	gomobile bind -target ios github.com/muesli/smartcrop/mobile
+
+From Kotlin, for example:
+
+	val crop = Mobile.findCrop(bytes, 250, 250)
+*/
+package mobile
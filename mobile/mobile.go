@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package mobile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+
+	// decoders for the formats of the images to analyse
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+// defaultMaxPixels is the default of Options.MaxPixels, a 24 megapixel
+// image taking about 96 MB decoded.
+const defaultMaxPixels = 24000000
+
+// ErrTooLarge gets returned for images with more pixels than
+// Options.MaxPixels.
+var ErrTooLarge = errors.New("Image has too many pixels")
+
+// Crop is the best crop of an image, in its pixel coordinates.
+type Crop struct {
+	X, Y, Width, Height int
+	// Strategy is the name of the strategy the crop was found with, and
+	// Confidence how confident it was about the crop, ranging from 0 to 1.
+	Strategy   string
+	Confidence float64
+}
+
+// Options configures the analysis.
+type Options struct {
+	// MaxPixels is the number of pixels of the largest image to analyse,
+	// which bounds the memory needed for decoding it.
+	MaxPixels int
+
+	// SettingsJSON are the CropSettings in JSON, by the names they have in
+	// JSON, to change on top of the defaults, e.g. {"skinWeight": 2.0}.
+	SettingsJSON string
+}
+
+// NewOptions returns the default Options.
+func NewOptions() *Options {
+	return &Options{MaxPixels: defaultMaxPixels}
+}
+
+// settings returns the settings of the analysis: the defaults of smartcrop,
+// with the memory-saving ones enabled, changed by SettingsJSON.
+func (o *Options) settings() (smartcrop.CropSettings, error) {
+	s := smartcrop.DefaultCropSettings()
+	s.Prescale = true
+	s.ReducedPlanes = true
+	if o.SettingsJSON != "" {
+		if err := json.Unmarshal([]byte(o.SettingsJSON), &s); err != nil {
+			return smartcrop.CropSettings{}, err
+		}
+	}
+	return s, s.Validate()
+}
+
+// FindCrop returns the best crop of the given dimensions of the image
+// encoded in data, with the default Options.
+func FindCrop(data []byte, width, height int) (*Crop, error) {
+	return FindCropWithOptions(data, width, height, NewOptions())
+}
+
+// FindCropWithOptions returns the best crop of the given dimensions of the
+// image encoded in data.
+func FindCropWithOptions(data []byte, width, height int, opts *Options) (*Crop, error) {
+	res, err := find(data, width, height, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Crop{
+		X:          res.Crop.Min.X,
+		Y:          res.Crop.Min.Y,
+		Width:      res.Crop.Dx(),
+		Height:     res.Crop.Dy(),
+		Strategy:   res.Strategy,
+		Confidence: res.Confidence,
+	}, nil
+}
+
+// FindResultJSON returns the smartcrop.Result for the best crop of the given
+// dimensions of the image encoded in data as JSON, for everything Crop
+// doesn't contain, like the attention points.
+func FindResultJSON(data []byte, width, height int, opts *Options) (string, error) {
+	res, err := find(data, width, height, opts)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(res)
+	return string(b), err
+}
+
+func find(data []byte, width, height int, opts *Options) (smartcrop.Result, error) {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	settings, err := opts.settings()
+	if err != nil {
+		return smartcrop.Result{}, err
+	}
+
+	// the header tells the size of the image, without decoding it
+	// and 32-bit ints overflow on the pixels of large images
+	if c, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil && opts.MaxPixels > 0 && int64(c.Width)*int64(c.Height) > int64(opts.MaxPixels) {
+		return smartcrop.Result{}, ErrTooLarge
+	}
+	img, _, err := smartcrop.Decode(bytes.NewReader(data))
+	if err != nil {
+		return smartcrop.Result{}, err
+	}
+
+	return smartcrop.FindCrop(img, smartcrop.Request{
+		Width:    width,
+		Height:   height,
+		Settings: &settings,
+		Resizer:  nfnt.NewDefaultResizer(),
+	})
+}
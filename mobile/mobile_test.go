@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package mobile
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/muesli/smartcrop"
+)
+
+const testFile = "../examples/gopher.jpg"
+
+func TestFindCrop(t *testing.T) {
+	data, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crop, err := FindCrop(data, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crop.Width != crop.Height || crop.X+crop.Width > 900 || crop.Y+crop.Height > 284 || crop.Strategy != smartcrop.StrategySmart {
+		t.Fatalf("expected a square smart crop within the image, got %+v", crop)
+	}
+
+	opts := NewOptions()
+	opts.SettingsJSON = `{"strategies": ["center"]}`
+	s, err := FindResultJSON(data, 250, 250, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res smartcrop.Result
+	if err := json.Unmarshal([]byte(s), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Strategy != smartcrop.StrategyCenter {
+		t.Fatalf("expected the center strategy set in the settings, got %q", res.Strategy)
+	}
+
+	opts.MaxPixels = 900*284 - 1
+	if _, err := FindCropWithOptions(data, 250, 250, opts); err != ErrTooLarge {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+
+	opts = NewOptions()
+	opts.SettingsJSON = `{"step": 0}`
+	if _, err := FindCropWithOptions(data, 250, 250, opts); err == nil {
+		t.Fatal("expected invalid settings to be rejected")
+	}
+}
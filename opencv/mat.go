@@ -0,0 +1,121 @@
+//go:build gocv
+// +build gocv
+
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package opencv
+
+import (
+	"errors"
+	"image"
+
+	"github.com/muesli/smartcrop"
+	"gocv.io/x/gocv"
+)
+
+// ErrUnsupportedMat gets returned for Mats which aren't 8-bit grayscale, BGR
+// or BGRA images.
+var ErrUnsupportedMat = errors.New("Expect an 8-bit grayscale, BGR or BGRA Mat")
+
+// ToRGBA converts an 8-bit grayscale, BGR or BGRA Mat to an image.
+func ToRGBA(m gocv.Mat) (*image.RGBA, error) {
+	if m.Empty() {
+		return nil, smartcrop.ErrEmptyImage
+	}
+
+	var channels int
+	switch m.Type() {
+	case gocv.MatTypeCV8UC1:
+		channels = 1
+	case gocv.MatTypeCV8UC3:
+		channels = 3
+	case gocv.MatTypeCV8UC4:
+		channels = 4
+	default:
+		return nil, ErrUnsupportedMat
+	}
+
+	if !m.IsContinuous() {
+		m = m.Clone()
+		defer m.Close()
+	}
+	data := m.ToBytes()
+	w, h := m.Cols(), m.Rows()
+	if len(data) < w*h*channels {
+		return nil, ErrUnsupportedMat
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i, j := 0, 0; i < w*h*channels; i, j = i+channels, j+4 {
+		switch channels {
+		case 1:
+			img.Pix[j], img.Pix[j+1], img.Pix[j+2] = data[i], data[i], data[i]
+			img.Pix[j+3] = 255
+		case 3:
+			img.Pix[j], img.Pix[j+1], img.Pix[j+2] = data[i+2], data[i+1], data[i]
+			img.Pix[j+3] = 255
+		case 4:
+			// image.RGBA is alpha-premultiplied, Mats aren't
+			a := uint16(data[i+3])
+			img.Pix[j] = uint8(uint16(data[i+2]) * a / 255)
+			img.Pix[j+1] = uint8(uint16(data[i+1]) * a / 255)
+			img.Pix[j+2] = uint8(uint16(data[i]) * a / 255)
+			img.Pix[j+3] = data[i+3]
+		}
+	}
+	return img, nil
+}
+
+// FindBestCrop returns the best crop of m for the given dimensions, in
+// coordinates of m.
+func FindBestCrop(analyzer smartcrop.Analyzer, m gocv.Mat, width, height int) (image.Rectangle, error) {
+	img, err := ToRGBA(m)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	return analyzer.FindBestCrop(img, width, height)
+}
+
+// FindBestResult returns the full Result of the analysis of m.
+func FindBestResult(analyzer smartcrop.ResultAnalyzer, m gocv.Mat, width, height int) (smartcrop.Result, error) {
+	img, err := ToRGBA(m)
+	if err != nil {
+		return smartcrop.Result{}, err
+	}
+	return analyzer.FindBestResult(img, width, height)
+}
+
+// Crop returns the region of m within the best crop for the given dimensions.
+// Like any region of a Mat, it shares the data of m and needs to be closed.
+func Crop(analyzer smartcrop.Analyzer, m gocv.Mat, width, height int) (gocv.Mat, error) {
+	r, err := FindBestCrop(analyzer, m, width, height)
+	if err != nil {
+		return gocv.Mat{}, err
+	}
+	return m.Region(r), nil
+}
@@ -0,0 +1,130 @@
+//go:build gocv
+// +build gocv
+
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package opencv
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+	"gocv.io/x/gocv"
+)
+
+const testFile = "../examples/gopher.jpg"
+
+func TestToRGBA(t *testing.T) {
+	tests := []struct {
+		typ    gocv.MatType
+		data   []byte
+		expect color.RGBA
+	}{
+		{gocv.MatTypeCV8UC1, []byte{10, 20}, color.RGBA{20, 20, 20, 255}},
+		{gocv.MatTypeCV8UC3, []byte{1, 2, 3, 10, 20, 30}, color.RGBA{30, 20, 10, 255}},
+		// the color gets premultiplied by the alpha of the Mat
+		{gocv.MatTypeCV8UC4, []byte{1, 2, 3, 4, 0, 102, 255, 51}, color.RGBA{51, 20, 0, 51}},
+	}
+
+	for _, test := range tests {
+		m, err := gocv.NewMatFromBytes(1, 2, test.typ, test.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := ToRGBA(m)
+		m.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.Bounds() != image.Rect(0, 0, 2, 1) {
+			t.Fatalf("expected bounds %v, got %v", image.Rect(0, 0, 2, 1), img.Bounds())
+		}
+		if c := img.RGBAAt(1, 0); c != test.expect {
+			t.Fatalf("expected %v for Mat type %v, got %v", test.expect, test.typ, c)
+		}
+	}
+}
+
+func TestToRGBARegion(t *testing.T) {
+	// a region isn't continuous, so it gets copied before converting it
+	m := gocv.Zeros(4, 4, gocv.MatTypeCV8UC3)
+	defer m.Close()
+	m.SetUCharAt(1, 2*3+2, 200)
+	r := m.Region(image.Rect(1, 1, 3, 3))
+	defer r.Close()
+
+	img, err := ToRGBA(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 2, 2) {
+		t.Fatalf("expected bounds %v, got %v", image.Rect(0, 0, 2, 2), img.Bounds())
+	}
+	if c := img.RGBAAt(1, 0); c != (color.RGBA{200, 0, 0, 255}) {
+		t.Fatalf("expected the red pixel of the region, got %v", c)
+	}
+}
+
+func TestToRGBAUnsupported(t *testing.T) {
+	m := gocv.NewMatWithSize(2, 2, gocv.MatTypeCV32F)
+	defer m.Close()
+	if _, err := ToRGBA(m); err != ErrUnsupportedMat {
+		t.Fatalf("expected ErrUnsupportedMat, got %v", err)
+	}
+
+	empty := gocv.NewMat()
+	defer empty.Close()
+	if _, err := ToRGBA(empty); err != smartcrop.ErrEmptyImage {
+		t.Fatalf("expected ErrEmptyImage, got %v", err)
+	}
+}
+
+func TestCrop(t *testing.T) {
+	m := gocv.IMRead(testFile, gocv.IMReadColor)
+	if m.Empty() {
+		t.Fatalf("can't read %s", testFile)
+	}
+	defer m.Close()
+
+	analyzer := smartcrop.NewAnalyzer(nfnt.NewDefaultResizer())
+	r, err := FindBestCrop(analyzer, m, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cropped, err := Crop(analyzer, m, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cropped.Close()
+	if cropped.Cols() != r.Dx() || cropped.Rows() != r.Dy() {
+		t.Fatalf("expected a %dx%d Mat, got %dx%d", r.Dx(), r.Dy(), cropped.Cols(), cropped.Rows())
+	}
+}
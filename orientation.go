@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"errors"
+	"image"
+	"math"
+	"sort"
+)
+
+// ErrNoRatios gets returned when ranking aspect ratios without any valid ones.
+var ErrNoRatios = errors.New("Expect at least one aspect ratio")
+
+// Orientation is the outcome of analysing an image for a single aspect ratio
+// (width / height).
+type Orientation struct {
+	Ratio  float64 `json:"ratio"`
+	Result Result  `json:"result"`
+}
+
+// RankRatios analyzes img for each of the given aspect ratios and returns the
+// outcomes ordered from the best to the worst supported ratio, according to
+// the total score of their best crop. This helps layout engines to pick e.g.
+// between a portrait and a landscape card for an image.
+func RankRatios(analyzer ResultAnalyzer, img image.Image, ratios []float64) ([]Orientation, error) {
+	var res []Orientation
+	for _, ratio := range ratios {
+		if ratio <= 0 || math.IsNaN(ratio) || math.IsInf(ratio, 0) {
+			return nil, ErrInvalidDimensions
+		}
+
+		// only the ratio of the requested dimensions matters
+		w, h := 1000, int(math.Max(1.0, math.Round(1000.0/ratio)))
+		r, err := analyzer.FindBestResult(img, w, h)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, Orientation{Ratio: ratio, Result: r})
+	}
+	if len(res) == 0 {
+		return nil, ErrNoRatios
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Result.Crop.Score.Total > res[j].Result.Crop.Score.Total
+	})
+	return res, nil
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import "context"
+
+// Override changes the settings of an analysis, e.g. to enable an
+// experimental detector. Overrides get carried by the context of a request,
+// see ContextWithOverrides, so middleware can toggle features per request,
+// e.g. for a fraction of the traffic, without changing the call sites of
+// FindCrop.
+type Override func(s *CropSettings)
+
+type overridesKey struct{}
+
+// ContextWithOverrides returns a copy of ctx carrying the given overrides,
+// which get applied after the ones ctx carries already.
+func ContextWithOverrides(ctx context.Context, overrides ...Override) context.Context {
+	prev := OverridesFromContext(ctx)
+	all := make([]Override, 0, len(prev)+len(overrides))
+	all = append(append(all, prev...), overrides...)
+	return context.WithValue(ctx, overridesKey{}, all)
+}
+
+// OverridesFromContext returns the overrides carried by ctx, in the order
+// they get applied.
+func OverridesFromContext(ctx context.Context) []Override {
+	overrides, _ := ctx.Value(overridesKey{}).([]Override)
+	return overrides
+}
+
+// ApplyOverrides returns s with the overrides carried by ctx applied. The
+// maps and slices of s are copied before, so the overrides may modify them
+// in place without affecting s. FindCrop applies the overrides carried by
+// Request.Context.
+func ApplyOverrides(ctx context.Context, s CropSettings) CropSettings {
+	overrides := OverridesFromContext(ctx)
+	if len(overrides) == 0 {
+		return s
+	}
+
+	s.Detectors = copyWeights(s.Detectors)
+	s.Ensemble = copyWeights(s.Ensemble)
+	s.Strategies = append([]string(nil), s.Strategies...)
+	for _, o := range overrides {
+		o(&s)
+	}
+	return s
+}
+
+// EnableDetector returns an Override enabling the registered Detector of the
+// given name with the given weight, see CropSettings.Detectors.
+func EnableDetector(name string, weight float64) Override {
+	return func(s *CropSettings) {
+		if s.Detectors == nil {
+			s.Detectors = map[string]float64{}
+		}
+		s.Detectors[name] = weight
+	}
+}
+
+// DisableDetector returns an Override disabling the Detector of the given
+// name.
+func DisableDetector(name string) Override {
+	return func(s *CropSettings) {
+		delete(s.Detectors, name)
+	}
+}
+
+// copyWeights returns a copy of a map of names to weights, or nil if it's
+// nil.
+func copyWeights(weights map[string]float64) map[string]float64 {
+	if weights == nil {
+		return nil
+	}
+	c := make(map[string]float64, len(weights))
+	for name, weight := range weights {
+		c[name] = weight
+	}
+	return c
+}
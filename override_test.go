@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"context"
+	"image"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	s := DefaultCropSettings()
+	s.Detectors = map[string]float64{"text": 0.5}
+	if o := ApplyOverrides(context.Background(), s); !reflect.DeepEqual(o, s) {
+		t.Fatalf("expected the settings unchanged without overrides, got %+v", o)
+	}
+
+	ctx := ContextWithOverrides(context.Background(), EnableDetector("faces", 1), DisableDetector("text"))
+	ctx = ContextWithOverrides(ctx, func(s *CropSettings) { s.Detectors["faces"] = 2 })
+	if n := len(OverridesFromContext(ctx)); n != 3 {
+		t.Fatalf("expected 3 overrides, got %d", n)
+	}
+
+	o := ApplyOverrides(ctx, s)
+	if !reflect.DeepEqual(o.Detectors, map[string]float64{"faces": 2}) {
+		t.Fatalf("expected the overrides to be applied in order, got %v", o.Detectors)
+	}
+	if !reflect.DeepEqual(s.Detectors, map[string]float64{"text": 0.5}) {
+		t.Fatalf("expected the original settings unchanged, got %v", s.Detectors)
+	}
+}
+
+func TestFindCropOverrides(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := DefaultCropSettings()
+	ctx := ContextWithOverrides(context.Background(), func(s *CropSettings) {
+		s.Strategies = []string{StrategyCenter}
+	})
+	res, err := FindCrop(img, Request{Width: 250, Height: 250, Settings: &s, Context: ctx, Resizer: nfnt.NewDefaultResizer()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Strategy != StrategyCenter || res.ParamsHash == s.Fingerprint() {
+		t.Fatalf("expected the center strategy with the overridden settings, got %q with %s", res.Strategy, res.ParamsHash)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"fmt"
+	"image"
+	"runtime/debug"
+)
+
+// PanicError is returned if an analysis panicked, e.g. on a pathological
+// image, instead of taking down the calling process. It contains everything
+// needed to reproduce the failure.
+type PanicError struct {
+	// Value is the value the analysis panicked with, Stack the stack trace
+	// of the panic.
+	Value interface{}
+	Stack []byte
+
+	// Bounds are the bounds of the analysed image, Width and Height the
+	// requested dimensions of the crop.
+	Bounds        image.Rectangle
+	Width, Height int
+	Settings      CropSettings
+	// Fingerprint is the Fingerprint of Settings, computed when the panic got
+	// recovered, so Error doesn't run any more code on them.
+	Fingerprint string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("smartcrop: analysis of %v image for %dx%d crop (settings %s) panicked: %v",
+		e.Bounds, e.Width, e.Height, e.Fingerprint, e.Value)
+}
+
+// recoverAnalysis turns a panic of the analysis of img into a PanicError
+// stored in err. It must be deferred directly.
+func recoverAnalysis(err *error, img image.Image, width, height int, settings CropSettings) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	e := &PanicError{
+		Value:       v,
+		Stack:       debug.Stack(),
+		Width:       width,
+		Height:      height,
+		Settings:    settings,
+		Fingerprint: settings.Fingerprint(),
+	}
+	if img != nil {
+		e.Bounds = img.Bounds()
+	}
+	*err = e
+}
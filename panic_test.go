@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestPanicError(t *testing.T) {
+	settings := DefaultCropSettings()
+	settings.Hooks.AddBefore(StageScore, func(stage Stage, st *State) error {
+		panic("boom")
+	})
+
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings)
+	_, err := analyzer.FindBestCrop(img, 10, 10)
+
+	perr, ok := err.(*PanicError)
+	if !ok {
+		t.Fatalf("expected a PanicError, got %v", err)
+	}
+	if perr.Value != "boom" || perr.Bounds != img.Bounds() || perr.Width != 10 || perr.Height != 10 || len(perr.Stack) == 0 {
+		t.Fatalf("expected the diagnostics of the panic, got %+v", perr)
+	}
+	if !strings.Contains(err.Error(), "boom") || !strings.Contains(err.Error(), settings.Fingerprint()) {
+		t.Fatalf("expected the panic value and fingerprint in the message, got %q", err)
+	}
+
+	// settings which can't be encoded as JSON still get a message
+	perr.Settings.BlobPenalty = math.NaN()
+	if !strings.Contains(perr.Error(), "boom") {
+		t.Fatalf("expected the panic value in the message, got %q", perr)
+	}
+}
@@ -0,0 +1,633 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"time"
+
+	"github.com/muesli/smartcrop/options"
+)
+
+// Stage identifies a step of the analysis pipeline.
+type Stage int
+
+// The stages of the analysis pipeline, in the order they run in.
+const (
+	// StagePrescale scales the source image down for faster processing.
+	StagePrescale Stage = iota
+	// StageDetect computes the detector planes of the prescaled image.
+	StageDetect
+	// StageCandidates determines the dimensions of the crop candidates.
+	StageCandidates
+	// StageScore scores all candidates and keeps the best one.
+	StageScore
+	// StageSelect refines the best candidate and maps it back onto the
+	// source image.
+	StageSelect
+)
+
+var stageNames = []string{"prescale", "detect", "candidates", "score", "select"}
+
+func (s Stage) String() string {
+	if s < 0 || int(s) >= len(stageNames) {
+		return "unknown"
+	}
+	return stageNames[s]
+}
+
+// State contains the intermediate data of an analysis. Hooks may inspect and
+// modify it. If a hook running before StagePrescale or StageDetect already
+// provides Prescaled or Detected, e.g. from a cache, the stage gets skipped.
+type State struct {
+	// Source is the image being analysed, Width and Height the requested
+	// dimensions of the crop.
+	Source        image.Image
+	Width, Height int
+
+	// Area restricts the crop to a rectangle of the source image, in its
+	// coordinates, while the detectors still see the whole image. The crop
+	// may lie anywhere in the image if Area is empty.
+	Area image.Rectangle
+
+	// PrescaleFactor is the factor the source image has been scaled by,
+	// Prescaled the resulting image.
+	PrescaleFactor float64
+	Prescaled      *image.RGBA
+
+	// Detected contains the skin, edge and saturation planes of the prescaled
+	// image in its red, green and blue channels. Boost contains the combined
+	// output of the additional Detectors enabled in the settings, if any.
+	// Both are scaled down by the factor Reduction compared to the prescaled
+	// image, which is 1 unless ReducedPlanes is enabled in the settings.
+	Detected  *image.RGBA
+	Boost     *image.Gray
+	Reduction int
+
+	// Regions are the regions flagged by the Classifier of the settings, in
+	// coordinates of the prescaled image. Only freshly computed planes get
+	// down-weighted, planes provided by a hook are expected to be already.
+	Regions []Region
+
+	// CropWidth and CropHeight are the dimensions of the largest candidate,
+	// MinScale the factor the smallest candidate is scaled by. Candidates for
+	// which Filter returns false are skipped.
+	CropWidth, CropHeight, MinScale float64
+	Filter                          func(Crop) bool
+
+	// Candidates is the number of candidates scored, Best the best of them, in
+	// coordinates of the prescaled image.
+	Candidates int
+	Best       Crop
+
+	// Result is the outcome of the analysis.
+	Result Result
+
+	// Context aborts the scoring of the candidates once it's done. The
+	// analysis then fails with its error, unless Partial is set and a
+	// candidate has been scored already: the best crop found so far is
+	// returned with Result.Partial set instead.
+	Context context.Context
+	Partial bool
+
+	// deadline is the time the scoring gets cut short at, see MaxDuration,
+	// and truncated reports that it has been. partial reports that the
+	// scoring has been cancelled by Context.
+	deadline  time.Time
+	truncated bool
+	partial   bool
+
+	trace *Trace
+}
+
+// Hook gets called before or after a stage of the pipeline. Returning an error
+// aborts the analysis.
+type Hook func(stage Stage, state *State) error
+
+// Hooks contains the functions to call before and after stages.
+type Hooks struct {
+	Before map[Stage][]Hook
+	After  map[Stage][]Hook
+}
+
+// AddBefore adds a hook to call before the given stage.
+func (h *Hooks) AddBefore(stage Stage, hook Hook) {
+	if h.Before == nil {
+		h.Before = map[Stage][]Hook{}
+	}
+	h.Before[stage] = append(h.Before[stage], hook)
+}
+
+// AddAfter adds a hook to call after the given stage.
+func (h *Hooks) AddAfter(stage Stage, hook Hook) {
+	if h.After == nil {
+		h.After = map[Stage][]Hook{}
+	}
+	h.After[stage] = append(h.After[stage], hook)
+}
+
+// clone returns a copy of h which can be added to without affecting h.
+func (h Hooks) clone() Hooks {
+	return h.from(StagePrescale)
+}
+
+// from returns a copy of h without the hooks of the stages before first.
+func (h Hooks) from(first Stage) Hooks {
+	c := Hooks{}
+	for stage, hooks := range h.Before {
+		for _, hook := range hooks {
+			if stage >= first {
+				c.AddBefore(stage, hook)
+			}
+		}
+	}
+	for stage, hooks := range h.After {
+		for _, hook := range hooks {
+			if stage >= first {
+				c.AddAfter(stage, hook)
+			}
+		}
+	}
+	return c
+}
+
+// pipeline runs the stages of an analysis.
+type pipeline struct {
+	logger   Logger
+	settings *CropSettings
+	resizer  options.Resizer
+}
+
+// runStages runs the stages from first to last, inclusively.
+func (p pipeline) runStages(st *State, first, last Stage) error {
+	stages := []func(*State) error{
+		p.prescale,
+		p.detect,
+		p.candidates,
+		p.score,
+		p.selectBest,
+	}
+
+	for stage := first; stage <= last; stage++ {
+		fn := stages[stage]
+		for _, hook := range p.settings.Hooks.Before[stage] {
+			if err := hook(stage, st); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		if err := fn(st); err != nil {
+			return err
+		}
+		elapsed := time.Since(now)
+		p.logger.Log.Printf("Time elapsed %s: %v\n", stage, elapsed)
+		st.trace.addStage(stage, elapsed)
+
+		for _, hook := range p.settings.Hooks.After[stage] {
+			if err := hook(stage, st); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p pipeline) prescale(st *State) error {
+	img := st.Source
+	if _, err := st.area(); err != nil {
+		return err
+	}
+	st.PrescaleFactor = p.settings.prescaleFactor(img.Bounds().Dx(), img.Bounds().Dy())
+	if st.Prescaled != nil {
+		return nil
+	}
+
+	switch p.settings.ToneMap {
+	case "":
+	case ToneMapLinear, ToneMapPQ, ToneMapHLG:
+		now := time.Now()
+		img = toneMap(img, p.settings.ToneMap)
+		p.logger.Log.Println("Time elapsed tone mapping:", time.Since(now))
+	default:
+		return fmt.Errorf("smartcrop: unknown tone mapping %q", p.settings.ToneMap)
+	}
+
+	if p.settings.Prescale {
+		p.logger.Log.Println(st.PrescaleFactor)
+
+		smallimg := p.resizer.Resize(
+			img,
+			uint(float64(img.Bounds().Dx())*st.PrescaleFactor),
+			0)
+
+		st.Prescaled = toRGBA(smallimg)
+	} else {
+		st.Prescaled = toRGBA(img)
+	}
+
+	debugOutput(p.logger, st.Prescaled, "prescale")
+	return nil
+}
+
+func (p pipeline) detect(st *State) error {
+	if st.Regions == nil && p.settings.Classifier != nil {
+		now := time.Now()
+		regions, err := classifyRegions(p.settings, st.Prescaled)
+		if err != nil {
+			return err
+		}
+		st.Regions = regions
+		p.logger.Log.Println("Time elapsed classifier:", time.Since(now))
+	}
+
+	st.Reduction = p.reduction()
+	if st.Boost == nil && len(p.settings.Detectors) > 0 {
+		now := time.Now()
+		boost, err := boostDetect(p.settings, st.Prescaled)
+		if err != nil {
+			return err
+		}
+		if st.Reduction > 1 {
+			boost = reduceGray(boost, st.Reduction)
+		}
+		suppressGray(boost, st.Regions, st.Reduction)
+		st.Boost = boost
+		p.logger.Log.Println("Time elapsed detectors:", time.Since(now))
+	}
+
+	if st.Detected != nil {
+		return nil
+	}
+
+	img, err := p.preprocess(st.Prescaled)
+	if err != nil {
+		return err
+	}
+	if st.Reduction > 1 {
+		return p.detectReduced(st, img)
+	}
+
+	o := image.NewRGBA(img.Bounds())
+	st.Detected = o
+	defer suppressRGBA(o, st.Regions, 1)
+
+	now := time.Now()
+	c := p.concurrency()
+	c.inTiles(img, func(y0, y1 int) { edgeDetectRows(img, o, y0, y1) })
+	p.edgeLevels(img, o, 1)
+	p.logger.Log.Println("Time elapsed edge:", time.Since(now))
+	debugOutput(p.logger, o, "edge")
+
+	if !p.settings.SkipSkin {
+		now = time.Now()
+		c.inTiles(img, func(y0, y1 int) { skinDetect(p.settings, rowsOf(img, y0, y1), rowsOf(o, y0, y1)) })
+		p.skinBlobs(o)
+		p.logger.Log.Println("Time elapsed skin:", time.Since(now))
+		debugOutput(p.logger, o, "skin")
+	}
+
+	if !p.settings.SkipSaturation {
+		now = time.Now()
+		c.inTiles(img, func(y0, y1 int) { saturationDetect(p.settings, rowsOf(img, y0, y1), rowsOf(o, y0, y1)) })
+		p.logger.Log.Println("Time elapsed sat:", time.Since(now))
+		debugOutput(p.logger, o, "saturation")
+	}
+
+	p.suppressSky(img, o, 1)
+	return nil
+}
+
+// preprocess returns the image the detector planes get computed on.
+func (p pipeline) preprocess(img *image.RGBA) (*image.RGBA, error) {
+	switch p.settings.Denoise {
+	case "":
+	case DenoiseMedian:
+		img = medianFilter(img)
+	case DenoiseBilateral:
+		img = bilateralFilter(img)
+	default:
+		return nil, fmt.Errorf("smartcrop: unknown denoise filter %q", p.settings.Denoise)
+	}
+
+	switch p.settings.Equalize {
+	case "":
+	case EqualizeGlobal, EqualizeCLAHE:
+		img = equalize(img, p.settings.Equalize)
+	default:
+		return nil, fmt.Errorf("smartcrop: unknown contrast normalization %q", p.settings.Equalize)
+	}
+
+	if p.settings.Denoise != "" || p.settings.Equalize != "" {
+		debugOutput(p.logger, img, "preprocessed")
+	}
+	return img, nil
+}
+
+// detectReduced computes the detector planes of img scaled down by
+// st.Reduction.
+func (p pipeline) detectReduced(st *State, img *image.RGBA) error {
+	o := image.NewRGBA(reducedBounds(img.Bounds(), st.Reduction))
+
+	now := time.Now()
+	reducedDetect(p.settings, img, o, st.Reduction)
+	p.logger.Log.Println("Time elapsed reduced:", time.Since(now))
+
+	p.edgeLevels(img, o, st.Reduction)
+	p.skinBlobs(o)
+	p.suppressSky(img, o, st.Reduction)
+	suppressRGBA(o, st.Regions, st.Reduction)
+	st.Detected = o
+	return nil
+}
+
+func (p pipeline) candidates(st *State) error {
+	img := st.Source
+	area, _ := st.area()
+	scale := math.Min(float64(area.Dx())/float64(st.Width), float64(area.Dy())/float64(st.Height))
+
+	st.CropWidth = chop(float64(st.Width) * scale * st.PrescaleFactor)
+	st.CropHeight = chop(float64(st.Height) * scale * st.PrescaleFactor)
+	st.MinScale = p.settings.realMinScale(scale)
+
+	p.logger.Log.Printf("original resolution: %dx%d\n", img.Bounds().Dx(), img.Bounds().Dy())
+	p.logger.Log.Printf("scale: %f, cropw: %f, croph: %f, minscale: %f\n", scale, st.CropWidth, st.CropHeight, st.MinScale)
+	return nil
+}
+
+// cutShort reports whether the scoring of st has been cut short, so the best
+// crop found isn't worth refining.
+func (st *State) cutShort() bool {
+	return st.truncated || st.partial
+}
+
+func (p pipeline) score(st *State) error {
+	s := p.settings
+	o := st.Detected
+
+	var textLum float64
+	if s.TextZone != nil {
+		var err error
+		if textLum, err = s.textLuminance(); err != nil {
+			return err
+		}
+	}
+
+	var tree *quadtree
+	if s.Quadtree {
+		now := time.Now()
+		tree = buildQuadtree(s, o, st.Boost, st.Reduction)
+		p.logger.Log.Println("Time elapsed quadtree:", time.Since(now))
+	}
+
+	var blobs *blobCuts
+	if s.BlobPenalty > 0 {
+		blobs = newBlobCuts(newSaliencyMap(s, o, st.Boost, st.Reduction))
+	}
+
+	// scoreCrop is safe to call concurrently: it only reads the state
+	scoreCrop := func(crop Crop, table *importanceTable) Score {
+		nowIn := time.Now()
+		var sc Score
+		if tree != nil {
+			sc = tree.score(s, crop)
+		} else if s.FixedPoint {
+			sc = scoreFixed(s, o, st.Boost, crop, table, st.Reduction)
+		} else {
+			sc = score(s, o, st.Boost, crop, st.Reduction)
+		}
+		crop.Score = sc
+		sc.Total = crop.totalScore(s)
+		if blobs != nil {
+			blobs.penalize(s, newRect(crop.Rectangle), &sc)
+		}
+		if s.TextZone != nil {
+			sc.Contrast = zoneContrast(s, st.Prescaled, s.textZone(newRect(crop.Rectangle)), textLum)
+		}
+		p.logger.Log.Println("Time elapsed single-score:", time.Since(nowIn))
+		return sc
+	}
+
+	topScore := -1.0
+	topReadable := false
+	take := func(crop Crop) {
+		better := crop.Score.Total > topScore
+		if s.TextZone != nil {
+			// crops text is readable on always beat the ones it isn't
+			readable := s.readable(crop.Score)
+			better = (readable && !topReadable) || (readable == topReadable && better)
+			if better {
+				topReadable = readable
+			}
+		}
+		st.trace.addCrop(crop)
+		if better {
+			st.Best = crop
+			topScore = crop.Score.Total
+		}
+		st.Candidates++
+	}
+
+	// with several workers, the candidates get scored concurrently in
+	// batches, and taken in order, so the crop doesn't depend on how the
+	// work was split up
+	c := p.concurrency()
+	var batch []Crop
+	var batchTables []*importanceTable
+	flush := func() {
+		parallel(c.Workers, len(batch), func(i int) {
+			batch[i].Score = scoreCrop(batch[i], batchTables[i])
+		})
+		for _, crop := range batch {
+			take(crop)
+		}
+		batch, batchTables = batch[:0], batchTables[:0]
+	}
+
+	tables := importanceTables{}
+	vetoes := 0
+	var cancelled error
+	crops(s, st.PrescaledArea(), st.CropWidth, st.CropHeight, st.MinScale, func(crop Crop) bool {
+		scored := st.Candidates + len(batch)
+		if st.Context != nil {
+			if err := st.Context.Err(); err != nil {
+				if st.Partial && scored > 0 {
+					st.partial = true
+				} else {
+					cancelled = err
+				}
+				return false
+			}
+		}
+		if scored > 0 && !st.deadline.IsZero() && time.Now().After(st.deadline) {
+			st.truncated = true
+			return false
+		}
+		if st.Filter != nil && !st.Filter(crop) {
+			return true
+		}
+		if vetoed(newRect(crop.Rectangle), st.Regions) {
+			vetoes++
+			return true
+		}
+
+		// the tables get built here, as they're cached for all candidates
+		var table *importanceTable
+		if tree == nil && s.FixedPoint {
+			table = tables.get(s, crop.Dx(), crop.Dy())
+		}
+		if c.Workers <= 1 {
+			crop.Score = scoreCrop(crop, table)
+			take(crop)
+			return true
+		}
+
+		batch, batchTables = append(batch, crop), append(batchTables, table)
+		if len(batch) >= c.ScoreBatch {
+			flush()
+		}
+		return true
+	})
+	if cancelled != nil {
+		return cancelled
+	}
+	flush()
+	p.logger.Log.Println("Candidates scored:", st.Candidates)
+
+	if st.Candidates == 0 && vetoes > 0 {
+		return ErrVetoed
+	}
+	if s.jittersRatio() && st.Candidates > 0 && !st.cutShort() {
+		st.Best = p.jitterRatio(st, st.Best)
+	}
+	return nil
+}
+
+func (p pipeline) selectBest(st *State) error {
+	s := p.settings
+	o := st.Detected
+	topCrop := st.Best
+
+	// the debug output draws onto the planes, so build the saliency map first
+	saliency := newSaliencyMap(s, o, st.Boost, st.Reduction)
+
+	r := newRect(topCrop.Rectangle)
+	if s.Refine && st.Candidates > 0 && !st.cutShort() {
+		now := time.Now()
+		area := st.PrescaledArea()
+		cw := st.CropWidth
+		if cw == 0.0 {
+			cw = math.Min(float64(area.Dx()), float64(area.Dy()))
+		}
+		// refining must neither move the crop into a vetoed region, nor onto
+		// one the filter would have skipped
+		rr, sc := refine(s, o, st.Boost, st.Reduction, r, newRect(area), cw*st.MinScale, cw*s.MaxScale)
+		ok := !vetoed(rr, st.Regions) && (st.Filter == nil || st.Filter(Crop{Rectangle: rr.bounds()}))
+		if s.TextZone != nil {
+			// nor make text less readable than before
+			textLum, err := s.textLuminance()
+			if err != nil {
+				return err
+			}
+			sc.Contrast = zoneContrast(s, st.Prescaled, s.textZone(rr), textLum)
+			ok = ok && (s.readable(sc) || !s.readable(topCrop.Score))
+		}
+		if s.BlobPenalty > 0 {
+			// nor cut deeper into salient blobs
+			newBlobCuts(saliency).penalize(s, rr, &sc)
+			ok = ok && sc.Cut <= topCrop.Score.Cut
+		}
+		if ok {
+			r, topCrop.Score = rr, sc
+		}
+		p.logger.Log.Println("Time elapsed refine:", time.Since(now))
+	}
+
+	if p.logger.DebugMode {
+		drawDebugCrop(s, topCrop, o)
+		debugOutput(p.logger, o, "final")
+	}
+
+	// the normalized crop isn't affected by any rounding of the dimensions, so
+	// map it back onto the source image instead of the prescaled crop
+	lowimg := st.Prescaled
+	lw, lh := float64(lowimg.Bounds().Dx()), float64(lowimg.Bounds().Dy())
+	norm := NormalizedRect{
+		X:      r.x / lw,
+		Y:      r.y / lh,
+		Width:  r.w / lw,
+		Height: r.h / lh,
+	}
+
+	if s.Margin > 0 {
+		// the margin must not reach into a vetoed region either
+		expanded := norm.expandWithin(s.Margin, st.normalizedArea())
+		if !vetoed(rect{expanded.X * lw, expanded.Y * lh, expanded.Width * lw, expanded.Height * lh}, st.Regions) {
+			norm = expanded
+		}
+	}
+
+	st.Result = p.result(st, topCrop, norm)
+	st.Result.SubjectCut = saliency.cuts(rect{norm.X * lw, norm.Y * lh, norm.Width * lw, norm.Height * lh})
+	st.Result.Confidence = saliency.confidence(r)
+	st.Result.AttentionPoints = saliency.attentionPoints(s.AttentionPoints)
+	if s.Samples > 0 {
+		st.Result.Seed = s.Seed
+	}
+	st.Result.Truncated = st.truncated
+	st.Result.Partial = st.partial
+	if s.jittersRatio() {
+		st.Result.Ratio = float64(st.Result.Crop.Dx()) / float64(st.Result.Crop.Dy())
+	}
+	return nil
+}
+
+// result maps the crop norm onto the source image and returns its Result.
+func (p pipeline) result(st *State, crop Crop, norm NormalizedRect) Result {
+	bounds := st.Source.Bounds()
+	ratio := st.ratio()
+	if p.settings.jittersRatio() {
+		// keep the ratio of the crop found
+		ratio = 0
+	}
+	crop.Rectangle = norm.Rect(bounds, ratio)
+	if p.settings.ExactRatio {
+		area, err := st.area()
+		if err != nil {
+			area = bounds
+		}
+		crop.Rectangle = snapRatio(crop.Rectangle, area, st.Width, st.Height)
+		// the snapped crop is what gets returned, so describe it everywhere
+		norm = normalize(crop.Rectangle, bounds)
+	}
+	return newResult(crop, norm, bounds, *p.settings)
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"errors"
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestPipelineHooks(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stages []Stage
+	record := func(stage Stage, st *State) error {
+		stages = append(stages, stage)
+		return nil
+	}
+
+	settings := DefaultCropSettings()
+	for stage := StagePrescale; stage <= StageSelect; stage++ {
+		settings.Hooks.AddAfter(stage, record)
+	}
+	// only allow candidates in the left half of the image
+	settings.Hooks.AddBefore(StageScore, func(stage Stage, st *State) error {
+		half := st.Prescaled.Bounds().Dx() / 2
+		st.Filter = func(c Crop) bool {
+			return c.Max.X <= half
+		}
+		return nil
+	})
+
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings)
+	topCrop, err := analyzer.FindBestCrop(img, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stages) != 5 {
+		t.Fatalf("expected hooks for 5 stages, got %v", stages)
+	}
+	for i, stage := range stages {
+		if stage != Stage(i) {
+			t.Fatalf("expected stage %s, got %s", Stage(i), stage)
+		}
+	}
+	if topCrop.Max.X > img.Bounds().Dx()/2+1 {
+		t.Fatalf("expected crop in the left half of the image, got %v", topCrop)
+	}
+
+	errAbort := errors.New("abort")
+	settings.Hooks.AddBefore(StageDetect, func(stage Stage, st *State) error {
+		return errAbort
+	})
+	analyzer = NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings)
+	if _, err := analyzer.FindBestCrop(img, 250, 250); err != errAbort {
+		t.Fatalf("expected hook to abort the analysis, got %v", err)
+	}
+}
+
+func TestSkipDetectors(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, reduced := range []bool{false, true} {
+		settings := DefaultCropSettings()
+		settings.ReducedPlanes = reduced
+		full, err := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ImageAnalyzer).Analyze(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		settings.SkipSkin = true
+		settings.SkipSaturation = true
+		skipped, err := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ImageAnalyzer).Analyze(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for name, plane := range map[string]*image.Gray{"skin": skipped.SkinMap(), "saturation": skipped.SaturationMap()} {
+			for _, v := range plane.Pix {
+				if v != 0 {
+					t.Fatalf("expected an empty %s map with reduced planes %v", name, reduced)
+				}
+			}
+		}
+		if d1, d2 := full.DetailMap(), skipped.DetailMap(); string(d1.Pix) != string(d2.Pix) {
+			t.Fatalf("expected the detail map not to change with reduced planes %v", reduced)
+		}
+	}
+
+	settings := DefaultCropSettings()
+	e1, _ := EstimateCost(900, 284, 250, 250, settings)
+	settings.SkipSkin = true
+	e2, _ := EstimateCost(900, 284, 250, 250, settings)
+	if e2.DetectorOps*3 != e1.DetectorOps*2 {
+		t.Fatalf("expected skipping skin detection to save a third of the detector ops, got %d instead of %d", e2.DetectorOps, e1.DetectorOps)
+	}
+}
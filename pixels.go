@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import "image"
+
+// rgbaRows gives direct access to the pixels of an *image.RGBA row by row, in
+// coordinates relative to its bounds, so sub-images and padded strides work
+// the same as images at the origin. It replaces RGBAAt and SetRGBA in the
+// loops over the detector planes: every row gets bounds-checked once when
+// it's taken, and its pixels are plain slice indexes from then on.
+type rgbaRows struct {
+	pix           []uint8
+	stride        int
+	width, height int
+}
+
+func newRGBARows(img *image.RGBA) rgbaRows {
+	b := img.Bounds()
+	if b.Empty() {
+		return rgbaRows{}
+	}
+	return rgbaRows{
+		pix:    img.Pix[img.PixOffset(b.Min.X, b.Min.Y):],
+		stride: img.Stride,
+		width:  b.Dx(),
+		height: b.Dy(),
+	}
+}
+
+// row returns the 4 bytes per pixel of row y.
+func (r rgbaRows) row(y int) []uint8 {
+	off := y * r.stride
+	return r.pix[off : off+r.width*4 : off+r.width*4]
+}
+
+// grayRows is the equivalent of rgbaRows for an *image.Gray.
+type grayRows struct {
+	pix           []uint8
+	stride        int
+	width, height int
+}
+
+func newGrayRows(img *image.Gray) grayRows {
+	b := img.Bounds()
+	if b.Empty() {
+		return grayRows{}
+	}
+	return grayRows{
+		pix:    img.Pix[img.PixOffset(b.Min.X, b.Min.Y):],
+		stride: img.Stride,
+		width:  b.Dx(),
+		height: b.Dy(),
+	}
+}
+
+// row returns the byte per pixel of row y.
+func (g grayRows) row(y int) []uint8 {
+	off := y * g.stride
+	return g.pix[off : off+g.width : off+g.width]
+}
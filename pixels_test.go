@@ -0,0 +1,99 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"math/rand"
+	"testing"
+)
+
+// randomSubImage returns a sub-image of a random image of 37x23 pixels, whose
+// stride doesn't match its width, and a copy of it at the origin.
+func randomSubImage(r *rand.Rand) (*image.RGBA, *image.RGBA) {
+	img := image.NewRGBA(image.Rect(-3, 2, 34, 25))
+	r.Read(img.Pix)
+	sub := img.SubImage(image.Rect(1, 5, 30, 22)).(*image.RGBA)
+
+	cp := image.NewRGBA(image.Rect(0, 0, sub.Bounds().Dx(), sub.Bounds().Dy()))
+	draw.Draw(cp, cp.Bounds(), sub, sub.Bounds().Min, draw.Src)
+	return sub, cp
+}
+
+func TestRGBARows(t *testing.T) {
+	sub, _ := randomSubImage(rand.New(rand.NewSource(1)))
+	rows := newRGBARows(sub)
+	b := sub.Bounds()
+	if rows.width != b.Dx() || rows.height != b.Dy() {
+		t.Fatalf("expected %dx%d rows, got %dx%d", b.Dx(), b.Dy(), rows.width, rows.height)
+	}
+	for y := 0; y < rows.height; y++ {
+		row := rows.row(y)
+		for x := 0; x < rows.width; x++ {
+			c := sub.RGBAAt(b.Min.X+x, b.Min.Y+y)
+			if p := row[x*4 : x*4+4]; p[0] != c.R || p[1] != c.G || p[2] != c.B || p[3] != c.A {
+				t.Fatalf("expected %v at %d,%d, got %v", c, x, y, p)
+			}
+		}
+	}
+
+	if rows := newRGBARows(image.NewRGBA(image.Rect(3, 3, 3, 8))); rows.width != 0 {
+		t.Fatalf("expected no rows for an empty image, got %d pixels wide ones", rows.width)
+	}
+
+	gray := image.NewGray(image.Rect(0, 0, 9, 4))
+	gray.Pix[gray.PixOffset(5, 3)] = 42
+	if v := newGrayRows(gray.SubImage(image.Rect(2, 1, 7, 4)).(*image.Gray)).row(2)[3]; v != 42 {
+		t.Fatalf("expected 42 at 3,2 of the gray sub-image, got %d", v)
+	}
+}
+
+func TestDetectSubImage(t *testing.T) {
+	s := DefaultCropSettings()
+	sub, cp := randomSubImage(rand.New(rand.NewSource(2)))
+
+	detect := func(i *image.RGBA) *image.RGBA {
+		o := image.NewRGBA(i.Bounds())
+		edgeDetect(i, o)
+		skinDetect(&s, i, o)
+		saturationDetect(&s, i, o)
+		return o
+	}
+	o, expected := detect(sub), detect(cp)
+	if !bytes.Equal(o.Pix, expected.Pix) {
+		t.Fatal("expected the planes of the sub-image to match the ones of its copy")
+	}
+
+	crop := Crop{Rectangle: image.Rect(2, 2, 20, 12)}
+	s.ScoreDownSample = 1
+	if a, b := score(&s, o, nil, crop, 1), score(&s, expected, nil, crop, 1); a != b {
+		t.Fatalf("expected the same score for the sub-image and its copy, got %+v and %+v", a, b)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// PoolStats are counters of the scratch buffers analyses borrow from a pool
+// instead of allocating them, like the lightness plane of the edge detection.
+// They are shared by all analyses of the process.
+//
+// A steady load should mostly hit the pool. Many misses mean the buffers
+// get collected between analyses, e.g. because analyses are rare, or that
+// the images vary a lot in size: a pooled buffer only gets reused for images
+// of at most its size, so PeakBytes tells the memory to reckon with per
+// concurrent analysis.
+type PoolStats struct {
+	// Hits is the number of buffers reused from the pool, Misses the number
+	// of buffers allocated because the pool had none large enough.
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	// PeakBytes is the size of the largest buffer requested.
+	PeakBytes int64 `json:"peakBytes"`
+}
+
+// HitRate returns the share of buffers reused from the pool, ranging from 0
+// to 1.
+func (ps PoolStats) HitRate() float64 {
+	if ps.Hits+ps.Misses == 0 {
+		return 0
+	}
+	return float64(ps.Hits) / float64(ps.Hits+ps.Misses)
+}
+
+var (
+	floatBuffers sync.Pool
+	poolStats    PoolStats
+)
+
+// ReadPoolStats returns the current PoolStats.
+func ReadPoolStats() PoolStats {
+	return PoolStats{
+		Hits:      atomic.LoadInt64(&poolStats.Hits),
+		Misses:    atomic.LoadInt64(&poolStats.Misses),
+		PeakBytes: atomic.LoadInt64(&poolStats.PeakBytes),
+	}
+}
+
+// getFloats returns a zeroed buffer of n float64s, reused from the pool if
+// possible. It should be returned with putFloats once it's not needed
+// anymore.
+func getFloats(n int) []float64 {
+	size := int64(n) * 8
+	for {
+		peak := atomic.LoadInt64(&poolStats.PeakBytes)
+		if size <= peak || atomic.CompareAndSwapInt64(&poolStats.PeakBytes, peak, size) {
+			break
+		}
+	}
+
+	if buf, ok := floatBuffers.Get().(*[]float64); ok && cap(*buf) >= n {
+		atomic.AddInt64(&poolStats.Hits, 1)
+		b := (*buf)[:n]
+		for i := range b {
+			b[i] = 0
+		}
+		return b
+	}
+	atomic.AddInt64(&poolStats.Misses, 1)
+	return make([]float64, n)
+}
+
+// putFloats returns buf to the pool.
+func putFloats(buf []float64) {
+	floatBuffers.Put(&buf)
+}
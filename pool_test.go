@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestPool(t *testing.T) {
+	before := ReadPoolStats()
+	buf := getFloats(100)
+	buf[0] = 1
+	putFloats(buf)
+
+	// the pool may drop buffers at any time, so only a reused one must be
+	// zeroed
+	buf = getFloats(50)
+	if len(buf) != 50 || buf[0] != 0 {
+		t.Fatalf("expected a zeroed buffer of 50 floats, got %d with %v", len(buf), buf[0])
+	}
+	putFloats(buf)
+
+	stats := ReadPoolStats()
+	if stats.Hits+stats.Misses != before.Hits+before.Misses+2 {
+		t.Fatalf("expected 2 more buffers to be requested, got %+v after %+v", stats, before)
+	}
+	if stats.PeakBytes < 800 {
+		t.Fatalf("expected a peak of at least 800 bytes, got %d", stats.PeakBytes)
+	}
+	if r := stats.HitRate(); r < 0 || r > 1 {
+		t.Fatalf("expected a hit rate between 0 and 1, got %v", r)
+	}
+}
+
+func TestPoolAnalysis(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer := NewAnalyzer(nfnt.NewDefaultResizer())
+	before := ReadPoolStats()
+	for i := 0; i < 3; i++ {
+		if _, err := analyzer.FindBestCrop(img, 250, 250); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := ReadPoolStats()
+	if stats.Hits+stats.Misses < before.Hits+before.Misses+3 {
+		t.Fatalf("expected every analysis to borrow a buffer, got %+v after %+v", stats, before)
+	}
+}
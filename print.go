@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+)
+
+// MillimetersPerInch converts physical dimensions given in millimeters to the
+// inches a PrintTarget expects.
+const MillimetersPerInch = 25.4
+
+// PrintTarget describes a physical print, e.g. 6×4 inches at 300 dpi.
+type PrintTarget struct {
+	// Width and Height are the dimensions of the print in inches.
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	// DPI is the pixel density the print requires.
+	DPI float64 `json:"dpi"`
+}
+
+// Pixels returns the dimensions of the print in pixels at its DPI.
+func (t PrintTarget) Pixels() (int, int) {
+	return int(math.Round(t.Width * t.DPI)), int(math.Round(t.Height * t.DPI))
+}
+
+func (t PrintTarget) valid() bool {
+	for _, v := range []float64{t.Width, t.Height, t.DPI} {
+		if v <= 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	w, h := t.Pixels()
+	return w > 0 && h > 0
+}
+
+// PrintCrop is the crop of an image for a PrintTarget.
+type PrintCrop struct {
+	Result Result      `json:"result"`
+	Target PrintTarget `json:"target"`
+	// DPI is the pixel density the crop achieves when printed at the
+	// dimensions of the target.
+	DPI float64 `json:"dpi"`
+	// Sufficient reports whether DPI reaches the density of the target.
+	Sufficient bool `json:"sufficient"`
+}
+
+// FindPrintCrop finds the best crop of img for the given print target and
+// reports the pixel density it achieves. A crop with too few pixels for the
+// target still gets returned, but isn't flagged as Sufficient.
+func FindPrintCrop(analyzer ResultAnalyzer, img image.Image, target PrintTarget) (PrintCrop, error) {
+	if !target.valid() {
+		return PrintCrop{}, ErrInvalidDimensions
+	}
+
+	width, height := target.Pixels()
+	res, err := analyzer.FindBestResult(img, width, height)
+	if err != nil {
+		return PrintCrop{}, err
+	}
+
+	c := res.Crop.Rectangle
+	dpi := math.Min(float64(c.Dx())/target.Width, float64(c.Dy())/target.Height)
+	return PrintCrop{
+		Result: res,
+		Target: target,
+		DPI:    dpi,
+		// tolerate the rounding of the crop to whole pixels
+		Sufficient: c.Dx() >= width-1 && c.Dy() >= height-1,
+	}, nil
+}
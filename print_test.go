@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestFindPrintCrop(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer := NewAnalyzer(nfnt.NewDefaultResizer()).(ResultAnalyzer)
+	for _, tc := range []struct {
+		target     PrintTarget
+		sufficient bool
+	}{
+		{PrintTarget{Width: 2, Height: 2, DPI: 100}, true},
+		{PrintTarget{Width: 6, Height: 4, DPI: 300}, false},
+	} {
+		pc, err := FindPrintCrop(analyzer, img, tc.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := pc.Result.Crop.Rectangle
+		expected := math.Min(float64(c.Dx())/tc.target.Width, float64(c.Dy())/tc.target.Height)
+		if pc.DPI != expected {
+			t.Errorf("expected %v dpi, got %v", expected, pc.DPI)
+		}
+		if pc.Sufficient != tc.sufficient {
+			t.Errorf("expected %v to be sufficient: %v, got %v dpi", tc.target, tc.sufficient, pc.DPI)
+		}
+	}
+
+	if _, err := FindPrintCrop(analyzer, img, PrintTarget{Width: 6, Height: 4}); err != ErrInvalidDimensions {
+		t.Fatalf("expected ErrInvalidDimensions, got %v", err)
+	}
+}
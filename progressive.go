@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+)
+
+// previewPrescale is the size the shorter side of an image gets prescaled
+// to for a preview, see FindProgressive.
+const previewPrescale = 100.0
+
+// FindProgressive finds the best crop of img like analyzer.FindBestResult,
+// calling preview with a quick estimate of it first, so UIs can show the
+// estimate instantly and snap to the final crop once it returns. The
+// estimate is found on a tiny prescaled image with a coarse grid of
+// candidates, while the final crop is searched for concurrently. The
+// preview is skipped if the final crop is found first, or if analyzer is
+// none of the Analyzers returned by NewAnalyzer and its variants.
+func FindProgressive(analyzer ResultAnalyzer, img image.Image, width, height int, preview func(Result)) (Result, error) {
+	type outcome struct {
+		res Result
+		err error
+	}
+	final := make(chan outcome, 1)
+	go func() {
+		res, err := analyzer.FindBestResult(img, width, height)
+		final <- outcome{res, err}
+	}()
+
+	if o, ok := analyzer.(*smartcropAnalyzer); ok && preview != nil {
+		p := *o
+		p.settings = previewSettings(o.settings)
+		p.logger.DebugMode = false
+		if res, err := p.FindBestResult(img, width, height); err == nil {
+			select {
+			case f := <-final:
+				return f.res, f.err
+			default:
+				preview(res)
+			}
+		}
+	}
+
+	f := <-final
+	return f.res, f.err
+}
+
+// previewSettings returns the coarsest settings of s, for a quick estimate of
+// the crop.
+func previewSettings(s CropSettings) CropSettings {
+	s = degrade(s, maxDegradation)
+	s.Prescale = true
+	s.PrescaleMin = math.Min(s.PrescaleMin, previewPrescale)
+	s.Budget = 0
+	s.Refine = false
+	s.OnResult = nil
+	s.OnTrace = nil
+	return s
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestFindProgressive(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer := NewAnalyzer(nfnt.NewDefaultResizer()).(ResultAnalyzer)
+	expected, err := analyzer.FindBestResult(img, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var previews []Result
+	res, err := FindProgressive(analyzer, img, 250, 250, func(res Result) {
+		previews = append(previews, res)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected %v, got %v", expected, res)
+	}
+	t.Logf("previews: %d", len(previews))
+	if len(previews) > 1 {
+		t.Fatalf("expected at most one preview, got %d", len(previews))
+	}
+	for _, p := range previews {
+		if p.Crop.Dx() != p.Crop.Dy() || !p.Crop.In(img.Bounds()) {
+			t.Fatalf("expected a square preview within the image, got %v", p.Crop.Rectangle)
+		}
+	}
+
+	// the preview settings are cheaper to analyse with
+	s := DefaultCropSettings()
+	b := img.Bounds()
+	full, _ := EstimateCost(b.Dx(), b.Dy(), 250, 250, s)
+	quick, _ := EstimateCost(b.Dx(), b.Dy(), 250, 250, previewSettings(s))
+	if quick.ScoringOps*10 > full.ScoringOps {
+		t.Fatalf("expected the preview to score at most a tenth of %d ops, got %d", full.ScoringOps, quick.ScoringOps)
+	}
+}
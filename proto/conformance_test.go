@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcroppb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// The golden messages in testdata are encoded from the text format files next
+// to them by the protobuf reference implementation, which keeps the map
+// entries in the order given:
+//
+//	protoc --encode=smartcrop.v1.Settings smartcrop.proto < testdata/settings.txtpb > testdata/settings.binpb
+
+var goldenResult = &Result{
+	SchemaVersion:   1,
+	AnalyzerVersion: "smartcrop/2.0",
+	ParamsHash:      "c0ffee",
+	ImageWidth:      900,
+	ImageHeight:     284,
+	Crop: &Crop{X: 464, Y: 29, Width: 255, Height: 255, Score: &Score{
+		Detail:     0.25,
+		Saturation: 0.125,
+		Skin:       -0.5,
+		Boost:      1e-9,
+		Total:      3.75,
+		Contrast:   4.5,
+		Cut:        0.0625,
+	}},
+	FloatCrop:  &Rect{X: 464.5, Y: 29.25, Width: 255, Height: 254.75},
+	Normalized: &Rect{X: 0.515625, Y: 0.1, Width: 0.28, Height: 0.89},
+	FocalPoint: &FocalPoint{X: 0.65, Y: 0.55},
+	SubjectCut: true,
+	AttentionPoints: []*AttentionPoint{
+		{X: 0.5, Y: 0.25, Weight: 2},
+		{X: 0.75},
+	},
+	Degradation: 2,
+	Strategy:    "faces",
+	Confidence:  0.875,
+	Reused:      true,
+	Seed:        -42,
+	Ratio:       1.5,
+	Truncated:   true,
+	Partial:     true,
+}
+
+var goldenSettings = &Settings{
+	DetailWeight:            0.2,
+	SkinBias:                0.01,
+	SkinBrightnessMin:       0.2,
+	SkinBrightnessMax:       1,
+	SkinThreshold:           0.8,
+	SkinWeight:              1.8,
+	SaturationBrightnessMin: 0.05,
+	SaturationBrightnessMax: 0.9,
+	SaturationThreshold:     0.4,
+	SaturationBias:          0.2,
+	SaturationWeight:        0.1,
+	BoostWeight:             100,
+	ScoreDownSample:         8,
+	Step:                    8,
+	ScaleStep:               0.1,
+	MinScale:                0.9,
+	MaxScale:                1,
+	EdgeRadius:              0.4,
+	EdgeWeight:              -20,
+	OutsideImportance:       -0.5,
+	RuleOfThirds:            true,
+	Prescale:                true,
+	PrescaleMin:             400,
+	BlobPenalty:             0.5,
+	SkipSkin:                true,
+	SkipSaturation:          true,
+	ToneMap:                 "pq",
+	Denoise:                 "median",
+	Equalize:                "clahe",
+	BudgetNanos:             50000000,
+	FixedPoint:              true,
+	EdgeLevels:              -1,
+	SkinBlobs:               true,
+	SkySuppression:          0.75,
+	ReducedPlanes:           true,
+	Quadtree:                true,
+	Refine:                  true,
+	ExactRatio:              true,
+	Margin:                  0.05,
+	Detectors:               map[string]float64{"faces": 1, "text": 0},
+	AttentionPoints:         3,
+	Strategies:              []string{"faces", ""},
+	MinConfidence:           0.6,
+	TextZone:                &Rect{Y: 0.7, Width: 0.8, Height: 0.2},
+	TextColor:               "#ffffff",
+	MinContrast:             4.5,
+	Samples:                 500,
+	Seed:                    -1 << 63,
+	StepFraction:            0.04,
+	RatioTolerance:          0.03,
+	DedupEpsilon:            3,
+	MaxDurationNanos:        1000000000,
+	Workers:                 -1,
+	Ensemble:                map[string]float64{"": 0.5, "smart": 1},
+	EnsembleMode:            "blend",
+}
+
+func TestConformance(t *testing.T) {
+	tests := []struct {
+		file     string
+		expected proto.Message
+		decoded  proto.Message
+	}{
+		{"testdata/result.binpb", goldenResult, &Result{}},
+		{"testdata/settings.binpb", goldenSettings, &Settings{}},
+	}
+
+	for _, test := range tests {
+		golden, err := ioutil.ReadFile(test.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := proto.Unmarshal(golden, test.decoded); err != nil {
+			t.Fatalf("can't decode %s: %v", test.file, err)
+		}
+		if !proto.Equal(test.decoded, test.expected) {
+			t.Fatalf("expected %s to decode to %+v, got %+v", test.file, test.expected, test.decoded)
+		}
+
+		b, err := proto.MarshalOptions{Deterministic: true}.Marshal(test.expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, golden) {
+			t.Fatalf("expected the encoding of %s\n%x\ngot\n%x", test.file, golden, b)
+		}
+	}
+}
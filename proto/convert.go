@@ -0,0 +1,282 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcroppb
+
+import (
+	"image"
+	"time"
+
+	"github.com/muesli/smartcrop"
+)
+
+// FromResult returns the message of a Result.
+func FromResult(r smartcrop.Result) *Result {
+	m := &Result{
+		SchemaVersion:   int64(r.SchemaVersion),
+		AnalyzerVersion: r.AnalyzerVersion,
+		ParamsHash:      r.ParamsHash,
+		ImageWidth:      int64(r.ImageWidth),
+		ImageHeight:     int64(r.ImageHeight),
+		Crop: &Crop{
+			X:      int64(r.Crop.Min.X),
+			Y:      int64(r.Crop.Min.Y),
+			Width:  int64(r.Crop.Dx()),
+			Height: int64(r.Crop.Dy()),
+			Score:  fromScore(r.Crop.Score),
+		},
+		FloatCrop:   &Rect{X: r.FloatCrop.X, Y: r.FloatCrop.Y, Width: r.FloatCrop.Width, Height: r.FloatCrop.Height},
+		Normalized:  fromNormalized(r.Normalized),
+		FocalPoint:  &FocalPoint{X: r.FocalPoint.X, Y: r.FocalPoint.Y},
+		SubjectCut:  r.SubjectCut,
+		Degradation: int64(r.Degradation),
+		Strategy:    r.Strategy,
+		Confidence:  r.Confidence,
+		Reused:      r.Reused,
+		Seed:        r.Seed,
+		Ratio:       r.Ratio,
+		Truncated:   r.Truncated,
+		Partial:     r.Partial,
+	}
+	for _, p := range r.AttentionPoints {
+		m.AttentionPoints = append(m.AttentionPoints, &AttentionPoint{X: p.X, Y: p.Y, Weight: p.Weight})
+	}
+	return m
+}
+
+// ToResult returns the Result of m. Missing fields are left at their zero
+// values.
+func (m *Result) ToResult() smartcrop.Result {
+	r := smartcrop.Result{
+		SchemaVersion:   int(m.SchemaVersion),
+		AnalyzerVersion: m.AnalyzerVersion,
+		ParamsHash:      m.ParamsHash,
+		ImageWidth:      int(m.ImageWidth),
+		ImageHeight:     int(m.ImageHeight),
+		SubjectCut:      m.SubjectCut,
+		Degradation:     int(m.Degradation),
+		Strategy:        m.Strategy,
+		Confidence:      m.Confidence,
+		Reused:          m.Reused,
+		Seed:            m.Seed,
+		Ratio:           m.Ratio,
+		Truncated:       m.Truncated,
+		Partial:         m.Partial,
+	}
+	if c := m.Crop; c != nil {
+		r.Crop.Rectangle = image.Rect(int(c.X), int(c.Y), int(c.X+c.Width), int(c.Y+c.Height))
+		if s := c.Score; s != nil {
+			r.Crop.Score = smartcrop.Score{
+				Detail:     s.Detail,
+				Saturation: s.Saturation,
+				Skin:       s.Skin,
+				Boost:      s.Boost,
+				Total:      s.Total,
+				Contrast:   s.Contrast,
+				Cut:        s.Cut,
+			}
+		}
+	}
+	if f := m.FloatCrop; f != nil {
+		r.FloatCrop = smartcrop.FloatRect{X: f.X, Y: f.Y, Width: f.Width, Height: f.Height}
+	}
+	if n := m.Normalized; n != nil {
+		r.Normalized = toNormalized(n)
+	}
+	if f := m.FocalPoint; f != nil {
+		r.FocalPoint = smartcrop.FocalPoint{X: f.X, Y: f.Y}
+	}
+	for _, p := range m.AttentionPoints {
+		if p != nil {
+			r.AttentionPoints = append(r.AttentionPoints, smartcrop.AttentionPoint{X: p.X, Y: p.Y, Weight: p.Weight})
+		}
+	}
+	return r
+}
+
+// FromSettings returns the message of CropSettings. Callbacks, Hooks and the
+// Classifier can't be exchanged and get dropped.
+func FromSettings(s smartcrop.CropSettings) *Settings {
+	m := &Settings{
+		DetailWeight:            s.DetailWeight,
+		SkinBias:                s.SkinBias,
+		SkinBrightnessMin:       s.SkinBrightnessMin,
+		SkinBrightnessMax:       s.SkinBrightnessMax,
+		SkinThreshold:           s.SkinThreshold,
+		SkinWeight:              s.SkinWeight,
+		SaturationBrightnessMin: s.SaturationBrightnessMin,
+		SaturationBrightnessMax: s.SaturationBrightnessMax,
+		SaturationThreshold:     s.SaturationThreshold,
+		SaturationBias:          s.SaturationBias,
+		SaturationWeight:        s.SaturationWeight,
+		BoostWeight:             s.BoostWeight,
+		ScoreDownSample:         int64(s.ScoreDownSample),
+		Step:                    int64(s.Step),
+		ScaleStep:               s.ScaleStep,
+		MinScale:                s.MinScale,
+		MaxScale:                s.MaxScale,
+		EdgeRadius:              s.EdgeRadius,
+		EdgeWeight:              s.EdgeWeight,
+		OutsideImportance:       s.OutsideImportance,
+		RuleOfThirds:            s.RuleOfThirds,
+		Prescale:                s.Prescale,
+		PrescaleMin:             s.PrescaleMin,
+		BlobPenalty:             s.BlobPenalty,
+		SkipSkin:                s.SkipSkin,
+		SkipSaturation:          s.SkipSaturation,
+		ToneMap:                 s.ToneMap,
+		Denoise:                 s.Denoise,
+		Equalize:                s.Equalize,
+		BudgetNanos:             int64(s.Budget),
+		FixedPoint:              s.FixedPoint,
+		EdgeLevels:              int64(s.EdgeLevels),
+		SkinBlobs:               s.SkinBlobs,
+		SkySuppression:          s.SkySuppression,
+		ReducedPlanes:           s.ReducedPlanes,
+		Quadtree:                s.Quadtree,
+		Refine:                  s.Refine,
+		ExactRatio:              s.ExactRatio,
+		Margin:                  s.Margin,
+		AttentionPoints:         int64(s.AttentionPoints),
+		Strategies:              append([]string(nil), s.Strategies...),
+		MinConfidence:           s.MinConfidence,
+		TextColor:               s.TextColor,
+		MinContrast:             s.MinContrast,
+		Samples:                 int64(s.Samples),
+		Seed:                    s.Seed,
+		StepFraction:            s.StepFraction,
+		RatioTolerance:          s.RatioTolerance,
+		DedupEpsilon:            int64(s.DedupEpsilon),
+		MaxDurationNanos:        int64(s.MaxDuration),
+		Workers:                 int64(s.Workers),
+		Detectors:               copyWeights(s.Detectors),
+		Ensemble:                copyWeights(s.Ensemble),
+		EnsembleMode:            s.EnsembleMode,
+	}
+	if s.TextZone != nil {
+		m.TextZone = fromNormalized(*s.TextZone)
+	}
+	return m
+}
+
+// ToSettings returns the CropSettings of m.
+func (m *Settings) ToSettings() smartcrop.CropSettings {
+	s := smartcrop.CropSettings{
+		DetailWeight:            m.DetailWeight,
+		SkinBias:                m.SkinBias,
+		SkinBrightnessMin:       m.SkinBrightnessMin,
+		SkinBrightnessMax:       m.SkinBrightnessMax,
+		SkinThreshold:           m.SkinThreshold,
+		SkinWeight:              m.SkinWeight,
+		SaturationBrightnessMin: m.SaturationBrightnessMin,
+		SaturationBrightnessMax: m.SaturationBrightnessMax,
+		SaturationThreshold:     m.SaturationThreshold,
+		SaturationBias:          m.SaturationBias,
+		SaturationWeight:        m.SaturationWeight,
+		BoostWeight:             m.BoostWeight,
+		ScoreDownSample:         int(m.ScoreDownSample),
+		Step:                    int(m.Step),
+		ScaleStep:               m.ScaleStep,
+		MinScale:                m.MinScale,
+		MaxScale:                m.MaxScale,
+		EdgeRadius:              m.EdgeRadius,
+		EdgeWeight:              m.EdgeWeight,
+		OutsideImportance:       m.OutsideImportance,
+		RuleOfThirds:            m.RuleOfThirds,
+		Prescale:                m.Prescale,
+		PrescaleMin:             m.PrescaleMin,
+		BlobPenalty:             m.BlobPenalty,
+		SkipSkin:                m.SkipSkin,
+		SkipSaturation:          m.SkipSaturation,
+		ToneMap:                 m.ToneMap,
+		Denoise:                 m.Denoise,
+		Equalize:                m.Equalize,
+		Budget:                  time.Duration(m.BudgetNanos),
+		FixedPoint:              m.FixedPoint,
+		EdgeLevels:              int(m.EdgeLevels),
+		SkinBlobs:               m.SkinBlobs,
+		SkySuppression:          m.SkySuppression,
+		ReducedPlanes:           m.ReducedPlanes,
+		Quadtree:                m.Quadtree,
+		Refine:                  m.Refine,
+		ExactRatio:              m.ExactRatio,
+		Margin:                  m.Margin,
+		AttentionPoints:         int(m.AttentionPoints),
+		Strategies:              append([]string(nil), m.Strategies...),
+		MinConfidence:           m.MinConfidence,
+		TextColor:               m.TextColor,
+		MinContrast:             m.MinContrast,
+		Samples:                 int(m.Samples),
+		Seed:                    m.Seed,
+		StepFraction:            m.StepFraction,
+		RatioTolerance:          m.RatioTolerance,
+		DedupEpsilon:            int(m.DedupEpsilon),
+		MaxDuration:             time.Duration(m.MaxDurationNanos),
+		Workers:                 int(m.Workers),
+		Detectors:               copyWeights(m.Detectors),
+		Ensemble:                copyWeights(m.Ensemble),
+		EnsembleMode:            m.EnsembleMode,
+	}
+	if m.TextZone != nil {
+		n := toNormalized(m.TextZone)
+		s.TextZone = &n
+	}
+	return s
+}
+
+func fromScore(s smartcrop.Score) *Score {
+	return &Score{
+		Detail:     s.Detail,
+		Saturation: s.Saturation,
+		Skin:       s.Skin,
+		Boost:      s.Boost,
+		Total:      s.Total,
+		Contrast:   s.Contrast,
+		Cut:        s.Cut,
+	}
+}
+
+func fromNormalized(n smartcrop.NormalizedRect) *Rect {
+	return &Rect{X: n.X, Y: n.Y, Width: n.Width, Height: n.Height}
+}
+
+func toNormalized(m *Rect) smartcrop.NormalizedRect {
+	return smartcrop.NormalizedRect{X: m.X, Y: m.Y, Width: m.Width, Height: m.Height}
+}
+
+// copyWeights returns a copy of a map of names to weights, or nil if it's
+// empty.
+func copyWeights(weights map[string]float64) map[string]float64 {
+	if len(weights) == 0 {
+		return nil
+	}
+	c := make(map[string]float64, len(weights))
+	for name, weight := range weights {
+		c[name] = weight
+	}
+	return c
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+/*
+Package smartcroppb provides the canonical protobuf messages of smartcrop
+results and analysis settings, so services in other languages can exchange
+crop decisions with a stable schema.
+
+The schema is published in smartcrop.proto, to generate code for other
+languages from. The Go types of this package are generated from it by
+protoc-gen-go, and get encoded with the protobuf runtime:
+
+	b, err := proto.Marshal(smartcroppb.FromResult(res))
+	// ...
+	var m smartcroppb.Result
+	err = proto.Unmarshal(b, &m)
+	res = m.ToResult()
+
+Unknown fields are kept when decoding, so older readers accept messages
+written by newer versions of the schema.
+*/
+package smartcroppb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative smartcrop.proto
@@ -0,0 +1,15 @@
+module github.com/muesli/smartcrop/proto
+
+go 1.23
+
+require (
+	github.com/muesli/smartcrop v0.0.0-20261016130252-779c7a143dc5
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646 // indirect
+	golang.org/x/image v0.0.0-20190802002840-cff245a6509b // indirect
+)
+
+replace github.com/muesli/smartcrop => ../
@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcroppb
+
+import (
+	"bytes"
+	"image"
+	_ "image/jpeg"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+	"google.golang.org/protobuf/proto"
+)
+
+var testFile = "../examples/gopher.jpg"
+
+func TestResultRoundTrip(t *testing.T) {
+	fi, err := os.Open(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := smartcrop.DefaultCropSettings()
+	s.AttentionPoints = 3
+	s.RatioTolerance = 0.03
+	analyzer := smartcrop.NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), smartcrop.Logger{}, s).(smartcrop.ResultAnalyzer)
+	res, err := analyzer.FindBestResult(img, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := proto.Marshal(FromResult(res))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m Result
+	if err := proto.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.ToResult(); !reflect.DeepEqual(got, res) {
+		t.Fatalf("expected %+v, got %+v", res, got)
+	}
+}
+
+func TestSettingsRoundTrip(t *testing.T) {
+	s := smartcrop.DefaultCropSettings()
+	s.Budget = 50 * time.Millisecond
+	s.Strategies = []string{"faces", ""}
+	s.Detectors = map[string]float64{"text": 0.5, "faces": 1}
+	s.TextZone = &smartcrop.NormalizedRect{X: 0.1, Y: 0.7, Width: 0.8, Height: 0.2}
+	s.EdgeLevels = -1
+	s.Samples = 500
+	s.Seed = -42
+	s.StepFraction = 0.04
+	s.RatioTolerance = 0.03
+	s.DedupEpsilon = 3
+	s.MaxDuration = time.Second
+	s.Workers = smartcrop.AutoWorkers
+	s.Ensemble = map[string]float64{"smart": 1, "entropy": 0.5}
+	s.EnsembleMode = smartcrop.EnsembleBlend
+
+	deterministic := proto.MarshalOptions{Deterministic: true}
+	b, err := deterministic.Marshal(FromSettings(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m Settings
+	if err := proto.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.ToSettings(); !reflect.DeepEqual(got, s) {
+		t.Fatalf("expected %+v, got %+v", s, got)
+	}
+
+	// the detectors are sorted, so the encoding is stable
+	b2, _ := deterministic.Marshal(FromSettings(s))
+	if !bytes.Equal(b, b2) {
+		t.Fatal("expected equal settings to encode to equal bytes")
+	}
+}
@@ -0,0 +1,1255 @@
+// Canonical schema of smartcrop results and analysis settings, for services
+// exchanging crop decisions across languages.
+//
+// Fields may be added in later versions, but existing field numbers never
+// change meaning.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: smartcrop.proto
+
+package smartcroppb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Score is the score of a crop candidate.
+type Score struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Detail        float64                `protobuf:"fixed64,1,opt,name=detail,proto3" json:"detail,omitempty"`
+	Saturation    float64                `protobuf:"fixed64,2,opt,name=saturation,proto3" json:"saturation,omitempty"`
+	Skin          float64                `protobuf:"fixed64,3,opt,name=skin,proto3" json:"skin,omitempty"`
+	Boost         float64                `protobuf:"fixed64,4,opt,name=boost,proto3" json:"boost,omitempty"`
+	Total         float64                `protobuf:"fixed64,5,opt,name=total,proto3" json:"total,omitempty"`
+	Contrast      float64                `protobuf:"fixed64,6,opt,name=contrast,proto3" json:"contrast,omitempty"`
+	Cut           float64                `protobuf:"fixed64,7,opt,name=cut,proto3" json:"cut,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Score) Reset() {
+	*x = Score{}
+	mi := &file_smartcrop_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Score) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Score) ProtoMessage() {}
+
+func (x *Score) ProtoReflect() protoreflect.Message {
+	mi := &file_smartcrop_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Score.ProtoReflect.Descriptor instead.
+func (*Score) Descriptor() ([]byte, []int) {
+	return file_smartcrop_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Score) GetDetail() float64 {
+	if x != nil {
+		return x.Detail
+	}
+	return 0
+}
+
+func (x *Score) GetSaturation() float64 {
+	if x != nil {
+		return x.Saturation
+	}
+	return 0
+}
+
+func (x *Score) GetSkin() float64 {
+	if x != nil {
+		return x.Skin
+	}
+	return 0
+}
+
+func (x *Score) GetBoost() float64 {
+	if x != nil {
+		return x.Boost
+	}
+	return 0
+}
+
+func (x *Score) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *Score) GetContrast() float64 {
+	if x != nil {
+		return x.Contrast
+	}
+	return 0
+}
+
+func (x *Score) GetCut() float64 {
+	if x != nil {
+		return x.Cut
+	}
+	return 0
+}
+
+// Crop is a crop in pixels of the source image.
+type Crop struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             int64                  `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             int64                  `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	Width         int64                  `protobuf:"varint,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height        int64                  `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	Score         *Score                 `protobuf:"bytes,5,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Crop) Reset() {
+	*x = Crop{}
+	mi := &file_smartcrop_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Crop) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Crop) ProtoMessage() {}
+
+func (x *Crop) ProtoReflect() protoreflect.Message {
+	mi := &file_smartcrop_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Crop.ProtoReflect.Descriptor instead.
+func (*Crop) Descriptor() ([]byte, []int) {
+	return file_smartcrop_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Crop) GetX() int64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Crop) GetY() int64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *Crop) GetWidth() int64 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Crop) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Crop) GetScore() *Score {
+	if x != nil {
+		return x.Score
+	}
+	return nil
+}
+
+// Rect is a rectangle in fractional pixels, or relative to the dimensions of
+// the source image.
+type Rect struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             float64                `protobuf:"fixed64,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             float64                `protobuf:"fixed64,2,opt,name=y,proto3" json:"y,omitempty"`
+	Width         float64                `protobuf:"fixed64,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height        float64                `protobuf:"fixed64,4,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rect) Reset() {
+	*x = Rect{}
+	mi := &file_smartcrop_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rect) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rect) ProtoMessage() {}
+
+func (x *Rect) ProtoReflect() protoreflect.Message {
+	mi := &file_smartcrop_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rect.ProtoReflect.Descriptor instead.
+func (*Rect) Descriptor() ([]byte, []int) {
+	return file_smartcrop_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Rect) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Rect) GetY() float64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *Rect) GetWidth() float64 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Rect) GetHeight() float64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+// FocalPoint is the center of a crop, relative to the dimensions of the
+// source image.
+type FocalPoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             float64                `protobuf:"fixed64,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             float64                `protobuf:"fixed64,2,opt,name=y,proto3" json:"y,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FocalPoint) Reset() {
+	*x = FocalPoint{}
+	mi := &file_smartcrop_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FocalPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FocalPoint) ProtoMessage() {}
+
+func (x *FocalPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_smartcrop_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FocalPoint.ProtoReflect.Descriptor instead.
+func (*FocalPoint) Descriptor() ([]byte, []int) {
+	return file_smartcrop_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FocalPoint) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *FocalPoint) GetY() float64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+// AttentionPoint is the centroid of a salient region, relative to the
+// dimensions of the source image.
+type AttentionPoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             float64                `protobuf:"fixed64,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             float64                `protobuf:"fixed64,2,opt,name=y,proto3" json:"y,omitempty"`
+	Weight        float64                `protobuf:"fixed64,3,opt,name=weight,proto3" json:"weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttentionPoint) Reset() {
+	*x = AttentionPoint{}
+	mi := &file_smartcrop_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttentionPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttentionPoint) ProtoMessage() {}
+
+func (x *AttentionPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_smartcrop_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttentionPoint.ProtoReflect.Descriptor instead.
+func (*AttentionPoint) Descriptor() ([]byte, []int) {
+	return file_smartcrop_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AttentionPoint) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *AttentionPoint) GetY() float64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *AttentionPoint) GetWeight() float64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+// Result is the outcome of an analysis.
+type Result struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	SchemaVersion   int64                  `protobuf:"varint,1,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	AnalyzerVersion string                 `protobuf:"bytes,2,opt,name=analyzer_version,json=analyzerVersion,proto3" json:"analyzer_version,omitempty"`
+	ParamsHash      string                 `protobuf:"bytes,3,opt,name=params_hash,json=paramsHash,proto3" json:"params_hash,omitempty"`
+	ImageWidth      int64                  `protobuf:"varint,4,opt,name=image_width,json=imageWidth,proto3" json:"image_width,omitempty"`
+	ImageHeight     int64                  `protobuf:"varint,5,opt,name=image_height,json=imageHeight,proto3" json:"image_height,omitempty"`
+	Crop            *Crop                  `protobuf:"bytes,6,opt,name=crop,proto3" json:"crop,omitempty"`
+	FloatCrop       *Rect                  `protobuf:"bytes,7,opt,name=float_crop,json=floatCrop,proto3" json:"float_crop,omitempty"`
+	Normalized      *Rect                  `protobuf:"bytes,8,opt,name=normalized,proto3" json:"normalized,omitempty"`
+	FocalPoint      *FocalPoint            `protobuf:"bytes,9,opt,name=focal_point,json=focalPoint,proto3" json:"focal_point,omitempty"`
+	SubjectCut      bool                   `protobuf:"varint,10,opt,name=subject_cut,json=subjectCut,proto3" json:"subject_cut,omitempty"`
+	AttentionPoints []*AttentionPoint      `protobuf:"bytes,11,rep,name=attention_points,json=attentionPoints,proto3" json:"attention_points,omitempty"`
+	Degradation     int64                  `protobuf:"varint,12,opt,name=degradation,proto3" json:"degradation,omitempty"`
+	Strategy        string                 `protobuf:"bytes,13,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	Confidence      float64                `protobuf:"fixed64,14,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	Reused          bool                   `protobuf:"varint,15,opt,name=reused,proto3" json:"reused,omitempty"`
+	Seed            int64                  `protobuf:"varint,16,opt,name=seed,proto3" json:"seed,omitempty"`
+	Ratio           float64                `protobuf:"fixed64,17,opt,name=ratio,proto3" json:"ratio,omitempty"`
+	Truncated       bool                   `protobuf:"varint,18,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	Partial         bool                   `protobuf:"varint,19,opt,name=partial,proto3" json:"partial,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_smartcrop_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_smartcrop_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_smartcrop_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Result) GetSchemaVersion() int64 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *Result) GetAnalyzerVersion() string {
+	if x != nil {
+		return x.AnalyzerVersion
+	}
+	return ""
+}
+
+func (x *Result) GetParamsHash() string {
+	if x != nil {
+		return x.ParamsHash
+	}
+	return ""
+}
+
+func (x *Result) GetImageWidth() int64 {
+	if x != nil {
+		return x.ImageWidth
+	}
+	return 0
+}
+
+func (x *Result) GetImageHeight() int64 {
+	if x != nil {
+		return x.ImageHeight
+	}
+	return 0
+}
+
+func (x *Result) GetCrop() *Crop {
+	if x != nil {
+		return x.Crop
+	}
+	return nil
+}
+
+func (x *Result) GetFloatCrop() *Rect {
+	if x != nil {
+		return x.FloatCrop
+	}
+	return nil
+}
+
+func (x *Result) GetNormalized() *Rect {
+	if x != nil {
+		return x.Normalized
+	}
+	return nil
+}
+
+func (x *Result) GetFocalPoint() *FocalPoint {
+	if x != nil {
+		return x.FocalPoint
+	}
+	return nil
+}
+
+func (x *Result) GetSubjectCut() bool {
+	if x != nil {
+		return x.SubjectCut
+	}
+	return false
+}
+
+func (x *Result) GetAttentionPoints() []*AttentionPoint {
+	if x != nil {
+		return x.AttentionPoints
+	}
+	return nil
+}
+
+func (x *Result) GetDegradation() int64 {
+	if x != nil {
+		return x.Degradation
+	}
+	return 0
+}
+
+func (x *Result) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
+	}
+	return ""
+}
+
+func (x *Result) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *Result) GetReused() bool {
+	if x != nil {
+		return x.Reused
+	}
+	return false
+}
+
+func (x *Result) GetSeed() int64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+func (x *Result) GetRatio() float64 {
+	if x != nil {
+		return x.Ratio
+	}
+	return 0
+}
+
+func (x *Result) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+func (x *Result) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+// Settings are the options of an analysis. Callbacks, hooks and classifiers
+// can't be exchanged and have no counterpart.
+type Settings struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	DetailWeight            float64                `protobuf:"fixed64,1,opt,name=detail_weight,json=detailWeight,proto3" json:"detail_weight,omitempty"`
+	SkinBias                float64                `protobuf:"fixed64,2,opt,name=skin_bias,json=skinBias,proto3" json:"skin_bias,omitempty"`
+	SkinBrightnessMin       float64                `protobuf:"fixed64,3,opt,name=skin_brightness_min,json=skinBrightnessMin,proto3" json:"skin_brightness_min,omitempty"`
+	SkinBrightnessMax       float64                `protobuf:"fixed64,4,opt,name=skin_brightness_max,json=skinBrightnessMax,proto3" json:"skin_brightness_max,omitempty"`
+	SkinThreshold           float64                `protobuf:"fixed64,5,opt,name=skin_threshold,json=skinThreshold,proto3" json:"skin_threshold,omitempty"`
+	SkinWeight              float64                `protobuf:"fixed64,6,opt,name=skin_weight,json=skinWeight,proto3" json:"skin_weight,omitempty"`
+	SaturationBrightnessMin float64                `protobuf:"fixed64,7,opt,name=saturation_brightness_min,json=saturationBrightnessMin,proto3" json:"saturation_brightness_min,omitempty"`
+	SaturationBrightnessMax float64                `protobuf:"fixed64,8,opt,name=saturation_brightness_max,json=saturationBrightnessMax,proto3" json:"saturation_brightness_max,omitempty"`
+	SaturationThreshold     float64                `protobuf:"fixed64,9,opt,name=saturation_threshold,json=saturationThreshold,proto3" json:"saturation_threshold,omitempty"`
+	SaturationBias          float64                `protobuf:"fixed64,10,opt,name=saturation_bias,json=saturationBias,proto3" json:"saturation_bias,omitempty"`
+	SaturationWeight        float64                `protobuf:"fixed64,11,opt,name=saturation_weight,json=saturationWeight,proto3" json:"saturation_weight,omitempty"`
+	BoostWeight             float64                `protobuf:"fixed64,12,opt,name=boost_weight,json=boostWeight,proto3" json:"boost_weight,omitempty"`
+	ScoreDownSample         int64                  `protobuf:"varint,13,opt,name=score_down_sample,json=scoreDownSample,proto3" json:"score_down_sample,omitempty"`
+	Step                    int64                  `protobuf:"varint,14,opt,name=step,proto3" json:"step,omitempty"`
+	ScaleStep               float64                `protobuf:"fixed64,15,opt,name=scale_step,json=scaleStep,proto3" json:"scale_step,omitempty"`
+	MinScale                float64                `protobuf:"fixed64,16,opt,name=min_scale,json=minScale,proto3" json:"min_scale,omitempty"`
+	MaxScale                float64                `protobuf:"fixed64,17,opt,name=max_scale,json=maxScale,proto3" json:"max_scale,omitempty"`
+	EdgeRadius              float64                `protobuf:"fixed64,18,opt,name=edge_radius,json=edgeRadius,proto3" json:"edge_radius,omitempty"`
+	EdgeWeight              float64                `protobuf:"fixed64,19,opt,name=edge_weight,json=edgeWeight,proto3" json:"edge_weight,omitempty"`
+	OutsideImportance       float64                `protobuf:"fixed64,20,opt,name=outside_importance,json=outsideImportance,proto3" json:"outside_importance,omitempty"`
+	RuleOfThirds            bool                   `protobuf:"varint,21,opt,name=rule_of_thirds,json=ruleOfThirds,proto3" json:"rule_of_thirds,omitempty"`
+	Prescale                bool                   `protobuf:"varint,22,opt,name=prescale,proto3" json:"prescale,omitempty"`
+	PrescaleMin             float64                `protobuf:"fixed64,23,opt,name=prescale_min,json=prescaleMin,proto3" json:"prescale_min,omitempty"`
+	BlobPenalty             float64                `protobuf:"fixed64,24,opt,name=blob_penalty,json=blobPenalty,proto3" json:"blob_penalty,omitempty"`
+	SkipSkin                bool                   `protobuf:"varint,25,opt,name=skip_skin,json=skipSkin,proto3" json:"skip_skin,omitempty"`
+	SkipSaturation          bool                   `protobuf:"varint,26,opt,name=skip_saturation,json=skipSaturation,proto3" json:"skip_saturation,omitempty"`
+	ToneMap                 string                 `protobuf:"bytes,27,opt,name=tone_map,json=toneMap,proto3" json:"tone_map,omitempty"`
+	Denoise                 string                 `protobuf:"bytes,28,opt,name=denoise,proto3" json:"denoise,omitempty"`
+	Equalize                string                 `protobuf:"bytes,29,opt,name=equalize,proto3" json:"equalize,omitempty"`
+	BudgetNanos             int64                  `protobuf:"varint,30,opt,name=budget_nanos,json=budgetNanos,proto3" json:"budget_nanos,omitempty"`
+	FixedPoint              bool                   `protobuf:"varint,32,opt,name=fixed_point,json=fixedPoint,proto3" json:"fixed_point,omitempty"`
+	EdgeLevels              int64                  `protobuf:"varint,33,opt,name=edge_levels,json=edgeLevels,proto3" json:"edge_levels,omitempty"`
+	SkinBlobs               bool                   `protobuf:"varint,34,opt,name=skin_blobs,json=skinBlobs,proto3" json:"skin_blobs,omitempty"`
+	SkySuppression          float64                `protobuf:"fixed64,35,opt,name=sky_suppression,json=skySuppression,proto3" json:"sky_suppression,omitempty"`
+	ReducedPlanes           bool                   `protobuf:"varint,36,opt,name=reduced_planes,json=reducedPlanes,proto3" json:"reduced_planes,omitempty"`
+	Quadtree                bool                   `protobuf:"varint,37,opt,name=quadtree,proto3" json:"quadtree,omitempty"`
+	Refine                  bool                   `protobuf:"varint,38,opt,name=refine,proto3" json:"refine,omitempty"`
+	ExactRatio              bool                   `protobuf:"varint,39,opt,name=exact_ratio,json=exactRatio,proto3" json:"exact_ratio,omitempty"`
+	Margin                  float64                `protobuf:"fixed64,40,opt,name=margin,proto3" json:"margin,omitempty"`
+	Detectors               map[string]float64     `protobuf:"bytes,41,rep,name=detectors,proto3" json:"detectors,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	AttentionPoints         int64                  `protobuf:"varint,42,opt,name=attention_points,json=attentionPoints,proto3" json:"attention_points,omitempty"`
+	Strategies              []string               `protobuf:"bytes,43,rep,name=strategies,proto3" json:"strategies,omitempty"`
+	MinConfidence           float64                `protobuf:"fixed64,44,opt,name=min_confidence,json=minConfidence,proto3" json:"min_confidence,omitempty"`
+	TextZone                *Rect                  `protobuf:"bytes,45,opt,name=text_zone,json=textZone,proto3" json:"text_zone,omitempty"`
+	TextColor               string                 `protobuf:"bytes,46,opt,name=text_color,json=textColor,proto3" json:"text_color,omitempty"`
+	MinContrast             float64                `protobuf:"fixed64,47,opt,name=min_contrast,json=minContrast,proto3" json:"min_contrast,omitempty"`
+	Samples                 int64                  `protobuf:"varint,48,opt,name=samples,proto3" json:"samples,omitempty"`
+	Seed                    int64                  `protobuf:"varint,49,opt,name=seed,proto3" json:"seed,omitempty"`
+	StepFraction            float64                `protobuf:"fixed64,50,opt,name=step_fraction,json=stepFraction,proto3" json:"step_fraction,omitempty"`
+	RatioTolerance          float64                `protobuf:"fixed64,51,opt,name=ratio_tolerance,json=ratioTolerance,proto3" json:"ratio_tolerance,omitempty"`
+	DedupEpsilon            int64                  `protobuf:"varint,52,opt,name=dedup_epsilon,json=dedupEpsilon,proto3" json:"dedup_epsilon,omitempty"`
+	MaxDurationNanos        int64                  `protobuf:"varint,53,opt,name=max_duration_nanos,json=maxDurationNanos,proto3" json:"max_duration_nanos,omitempty"`
+	Workers                 int64                  `protobuf:"varint,54,opt,name=workers,proto3" json:"workers,omitempty"`
+	Ensemble                map[string]float64     `protobuf:"bytes,55,rep,name=ensemble,proto3" json:"ensemble,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	EnsembleMode            string                 `protobuf:"bytes,56,opt,name=ensemble_mode,json=ensembleMode,proto3" json:"ensemble_mode,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *Settings) Reset() {
+	*x = Settings{}
+	mi := &file_smartcrop_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Settings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Settings) ProtoMessage() {}
+
+func (x *Settings) ProtoReflect() protoreflect.Message {
+	mi := &file_smartcrop_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Settings.ProtoReflect.Descriptor instead.
+func (*Settings) Descriptor() ([]byte, []int) {
+	return file_smartcrop_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Settings) GetDetailWeight() float64 {
+	if x != nil {
+		return x.DetailWeight
+	}
+	return 0
+}
+
+func (x *Settings) GetSkinBias() float64 {
+	if x != nil {
+		return x.SkinBias
+	}
+	return 0
+}
+
+func (x *Settings) GetSkinBrightnessMin() float64 {
+	if x != nil {
+		return x.SkinBrightnessMin
+	}
+	return 0
+}
+
+func (x *Settings) GetSkinBrightnessMax() float64 {
+	if x != nil {
+		return x.SkinBrightnessMax
+	}
+	return 0
+}
+
+func (x *Settings) GetSkinThreshold() float64 {
+	if x != nil {
+		return x.SkinThreshold
+	}
+	return 0
+}
+
+func (x *Settings) GetSkinWeight() float64 {
+	if x != nil {
+		return x.SkinWeight
+	}
+	return 0
+}
+
+func (x *Settings) GetSaturationBrightnessMin() float64 {
+	if x != nil {
+		return x.SaturationBrightnessMin
+	}
+	return 0
+}
+
+func (x *Settings) GetSaturationBrightnessMax() float64 {
+	if x != nil {
+		return x.SaturationBrightnessMax
+	}
+	return 0
+}
+
+func (x *Settings) GetSaturationThreshold() float64 {
+	if x != nil {
+		return x.SaturationThreshold
+	}
+	return 0
+}
+
+func (x *Settings) GetSaturationBias() float64 {
+	if x != nil {
+		return x.SaturationBias
+	}
+	return 0
+}
+
+func (x *Settings) GetSaturationWeight() float64 {
+	if x != nil {
+		return x.SaturationWeight
+	}
+	return 0
+}
+
+func (x *Settings) GetBoostWeight() float64 {
+	if x != nil {
+		return x.BoostWeight
+	}
+	return 0
+}
+
+func (x *Settings) GetScoreDownSample() int64 {
+	if x != nil {
+		return x.ScoreDownSample
+	}
+	return 0
+}
+
+func (x *Settings) GetStep() int64 {
+	if x != nil {
+		return x.Step
+	}
+	return 0
+}
+
+func (x *Settings) GetScaleStep() float64 {
+	if x != nil {
+		return x.ScaleStep
+	}
+	return 0
+}
+
+func (x *Settings) GetMinScale() float64 {
+	if x != nil {
+		return x.MinScale
+	}
+	return 0
+}
+
+func (x *Settings) GetMaxScale() float64 {
+	if x != nil {
+		return x.MaxScale
+	}
+	return 0
+}
+
+func (x *Settings) GetEdgeRadius() float64 {
+	if x != nil {
+		return x.EdgeRadius
+	}
+	return 0
+}
+
+func (x *Settings) GetEdgeWeight() float64 {
+	if x != nil {
+		return x.EdgeWeight
+	}
+	return 0
+}
+
+func (x *Settings) GetOutsideImportance() float64 {
+	if x != nil {
+		return x.OutsideImportance
+	}
+	return 0
+}
+
+func (x *Settings) GetRuleOfThirds() bool {
+	if x != nil {
+		return x.RuleOfThirds
+	}
+	return false
+}
+
+func (x *Settings) GetPrescale() bool {
+	if x != nil {
+		return x.Prescale
+	}
+	return false
+}
+
+func (x *Settings) GetPrescaleMin() float64 {
+	if x != nil {
+		return x.PrescaleMin
+	}
+	return 0
+}
+
+func (x *Settings) GetBlobPenalty() float64 {
+	if x != nil {
+		return x.BlobPenalty
+	}
+	return 0
+}
+
+func (x *Settings) GetSkipSkin() bool {
+	if x != nil {
+		return x.SkipSkin
+	}
+	return false
+}
+
+func (x *Settings) GetSkipSaturation() bool {
+	if x != nil {
+		return x.SkipSaturation
+	}
+	return false
+}
+
+func (x *Settings) GetToneMap() string {
+	if x != nil {
+		return x.ToneMap
+	}
+	return ""
+}
+
+func (x *Settings) GetDenoise() string {
+	if x != nil {
+		return x.Denoise
+	}
+	return ""
+}
+
+func (x *Settings) GetEqualize() string {
+	if x != nil {
+		return x.Equalize
+	}
+	return ""
+}
+
+func (x *Settings) GetBudgetNanos() int64 {
+	if x != nil {
+		return x.BudgetNanos
+	}
+	return 0
+}
+
+func (x *Settings) GetFixedPoint() bool {
+	if x != nil {
+		return x.FixedPoint
+	}
+	return false
+}
+
+func (x *Settings) GetEdgeLevels() int64 {
+	if x != nil {
+		return x.EdgeLevels
+	}
+	return 0
+}
+
+func (x *Settings) GetSkinBlobs() bool {
+	if x != nil {
+		return x.SkinBlobs
+	}
+	return false
+}
+
+func (x *Settings) GetSkySuppression() float64 {
+	if x != nil {
+		return x.SkySuppression
+	}
+	return 0
+}
+
+func (x *Settings) GetReducedPlanes() bool {
+	if x != nil {
+		return x.ReducedPlanes
+	}
+	return false
+}
+
+func (x *Settings) GetQuadtree() bool {
+	if x != nil {
+		return x.Quadtree
+	}
+	return false
+}
+
+func (x *Settings) GetRefine() bool {
+	if x != nil {
+		return x.Refine
+	}
+	return false
+}
+
+func (x *Settings) GetExactRatio() bool {
+	if x != nil {
+		return x.ExactRatio
+	}
+	return false
+}
+
+func (x *Settings) GetMargin() float64 {
+	if x != nil {
+		return x.Margin
+	}
+	return 0
+}
+
+func (x *Settings) GetDetectors() map[string]float64 {
+	if x != nil {
+		return x.Detectors
+	}
+	return nil
+}
+
+func (x *Settings) GetAttentionPoints() int64 {
+	if x != nil {
+		return x.AttentionPoints
+	}
+	return 0
+}
+
+func (x *Settings) GetStrategies() []string {
+	if x != nil {
+		return x.Strategies
+	}
+	return nil
+}
+
+func (x *Settings) GetMinConfidence() float64 {
+	if x != nil {
+		return x.MinConfidence
+	}
+	return 0
+}
+
+func (x *Settings) GetTextZone() *Rect {
+	if x != nil {
+		return x.TextZone
+	}
+	return nil
+}
+
+func (x *Settings) GetTextColor() string {
+	if x != nil {
+		return x.TextColor
+	}
+	return ""
+}
+
+func (x *Settings) GetMinContrast() float64 {
+	if x != nil {
+		return x.MinContrast
+	}
+	return 0
+}
+
+func (x *Settings) GetSamples() int64 {
+	if x != nil {
+		return x.Samples
+	}
+	return 0
+}
+
+func (x *Settings) GetSeed() int64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+func (x *Settings) GetStepFraction() float64 {
+	if x != nil {
+		return x.StepFraction
+	}
+	return 0
+}
+
+func (x *Settings) GetRatioTolerance() float64 {
+	if x != nil {
+		return x.RatioTolerance
+	}
+	return 0
+}
+
+func (x *Settings) GetDedupEpsilon() int64 {
+	if x != nil {
+		return x.DedupEpsilon
+	}
+	return 0
+}
+
+func (x *Settings) GetMaxDurationNanos() int64 {
+	if x != nil {
+		return x.MaxDurationNanos
+	}
+	return 0
+}
+
+func (x *Settings) GetWorkers() int64 {
+	if x != nil {
+		return x.Workers
+	}
+	return 0
+}
+
+func (x *Settings) GetEnsemble() map[string]float64 {
+	if x != nil {
+		return x.Ensemble
+	}
+	return nil
+}
+
+func (x *Settings) GetEnsembleMode() string {
+	if x != nil {
+		return x.EnsembleMode
+	}
+	return ""
+}
+
+var File_smartcrop_proto protoreflect.FileDescriptor
+
+const file_smartcrop_proto_rawDesc = "" +
+	"\n" +
+	"\x0fsmartcrop.proto\x12\fsmartcrop.v1\"\xad\x01\n" +
+	"\x05Score\x12\x16\n" +
+	"\x06detail\x18\x01 \x01(\x01R\x06detail\x12\x1e\n" +
+	"\n" +
+	"saturation\x18\x02 \x01(\x01R\n" +
+	"saturation\x12\x12\n" +
+	"\x04skin\x18\x03 \x01(\x01R\x04skin\x12\x14\n" +
+	"\x05boost\x18\x04 \x01(\x01R\x05boost\x12\x14\n" +
+	"\x05total\x18\x05 \x01(\x01R\x05total\x12\x1a\n" +
+	"\bcontrast\x18\x06 \x01(\x01R\bcontrast\x12\x10\n" +
+	"\x03cut\x18\a \x01(\x01R\x03cut\"{\n" +
+	"\x04Crop\x12\f\n" +
+	"\x01x\x18\x01 \x01(\x03R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\x03R\x01y\x12\x14\n" +
+	"\x05width\x18\x03 \x01(\x03R\x05width\x12\x16\n" +
+	"\x06height\x18\x04 \x01(\x03R\x06height\x12)\n" +
+	"\x05score\x18\x05 \x01(\v2\x13.smartcrop.v1.ScoreR\x05score\"P\n" +
+	"\x04Rect\x12\f\n" +
+	"\x01x\x18\x01 \x01(\x01R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\x01R\x01y\x12\x14\n" +
+	"\x05width\x18\x03 \x01(\x01R\x05width\x12\x16\n" +
+	"\x06height\x18\x04 \x01(\x01R\x06height\"(\n" +
+	"\n" +
+	"FocalPoint\x12\f\n" +
+	"\x01x\x18\x01 \x01(\x01R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\x01R\x01y\"D\n" +
+	"\x0eAttentionPoint\x12\f\n" +
+	"\x01x\x18\x01 \x01(\x01R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\x01R\x01y\x12\x16\n" +
+	"\x06weight\x18\x03 \x01(\x01R\x06weight\"\xcb\x05\n" +
+	"\x06Result\x12%\n" +
+	"\x0eschema_version\x18\x01 \x01(\x03R\rschemaVersion\x12)\n" +
+	"\x10analyzer_version\x18\x02 \x01(\tR\x0fanalyzerVersion\x12\x1f\n" +
+	"\vparams_hash\x18\x03 \x01(\tR\n" +
+	"paramsHash\x12\x1f\n" +
+	"\vimage_width\x18\x04 \x01(\x03R\n" +
+	"imageWidth\x12!\n" +
+	"\fimage_height\x18\x05 \x01(\x03R\vimageHeight\x12&\n" +
+	"\x04crop\x18\x06 \x01(\v2\x12.smartcrop.v1.CropR\x04crop\x121\n" +
+	"\n" +
+	"float_crop\x18\a \x01(\v2\x12.smartcrop.v1.RectR\tfloatCrop\x122\n" +
+	"\n" +
+	"normalized\x18\b \x01(\v2\x12.smartcrop.v1.RectR\n" +
+	"normalized\x129\n" +
+	"\vfocal_point\x18\t \x01(\v2\x18.smartcrop.v1.FocalPointR\n" +
+	"focalPoint\x12\x1f\n" +
+	"\vsubject_cut\x18\n" +
+	" \x01(\bR\n" +
+	"subjectCut\x12G\n" +
+	"\x10attention_points\x18\v \x03(\v2\x1c.smartcrop.v1.AttentionPointR\x0fattentionPoints\x12 \n" +
+	"\vdegradation\x18\f \x01(\x03R\vdegradation\x12\x1a\n" +
+	"\bstrategy\x18\r \x01(\tR\bstrategy\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x0e \x01(\x01R\n" +
+	"confidence\x12\x16\n" +
+	"\x06reused\x18\x0f \x01(\bR\x06reused\x12\x12\n" +
+	"\x04seed\x18\x10 \x01(\x03R\x04seed\x12\x14\n" +
+	"\x05ratio\x18\x11 \x01(\x01R\x05ratio\x12\x1c\n" +
+	"\ttruncated\x18\x12 \x01(\bR\ttruncated\x12\x18\n" +
+	"\apartial\x18\x13 \x01(\bR\apartial\"\x86\x11\n" +
+	"\bSettings\x12#\n" +
+	"\rdetail_weight\x18\x01 \x01(\x01R\fdetailWeight\x12\x1b\n" +
+	"\tskin_bias\x18\x02 \x01(\x01R\bskinBias\x12.\n" +
+	"\x13skin_brightness_min\x18\x03 \x01(\x01R\x11skinBrightnessMin\x12.\n" +
+	"\x13skin_brightness_max\x18\x04 \x01(\x01R\x11skinBrightnessMax\x12%\n" +
+	"\x0eskin_threshold\x18\x05 \x01(\x01R\rskinThreshold\x12\x1f\n" +
+	"\vskin_weight\x18\x06 \x01(\x01R\n" +
+	"skinWeight\x12:\n" +
+	"\x19saturation_brightness_min\x18\a \x01(\x01R\x17saturationBrightnessMin\x12:\n" +
+	"\x19saturation_brightness_max\x18\b \x01(\x01R\x17saturationBrightnessMax\x121\n" +
+	"\x14saturation_threshold\x18\t \x01(\x01R\x13saturationThreshold\x12'\n" +
+	"\x0fsaturation_bias\x18\n" +
+	" \x01(\x01R\x0esaturationBias\x12+\n" +
+	"\x11saturation_weight\x18\v \x01(\x01R\x10saturationWeight\x12!\n" +
+	"\fboost_weight\x18\f \x01(\x01R\vboostWeight\x12*\n" +
+	"\x11score_down_sample\x18\r \x01(\x03R\x0fscoreDownSample\x12\x12\n" +
+	"\x04step\x18\x0e \x01(\x03R\x04step\x12\x1d\n" +
+	"\n" +
+	"scale_step\x18\x0f \x01(\x01R\tscaleStep\x12\x1b\n" +
+	"\tmin_scale\x18\x10 \x01(\x01R\bminScale\x12\x1b\n" +
+	"\tmax_scale\x18\x11 \x01(\x01R\bmaxScale\x12\x1f\n" +
+	"\vedge_radius\x18\x12 \x01(\x01R\n" +
+	"edgeRadius\x12\x1f\n" +
+	"\vedge_weight\x18\x13 \x01(\x01R\n" +
+	"edgeWeight\x12-\n" +
+	"\x12outside_importance\x18\x14 \x01(\x01R\x11outsideImportance\x12$\n" +
+	"\x0erule_of_thirds\x18\x15 \x01(\bR\fruleOfThirds\x12\x1a\n" +
+	"\bprescale\x18\x16 \x01(\bR\bprescale\x12!\n" +
+	"\fprescale_min\x18\x17 \x01(\x01R\vprescaleMin\x12!\n" +
+	"\fblob_penalty\x18\x18 \x01(\x01R\vblobPenalty\x12\x1b\n" +
+	"\tskip_skin\x18\x19 \x01(\bR\bskipSkin\x12'\n" +
+	"\x0fskip_saturation\x18\x1a \x01(\bR\x0eskipSaturation\x12\x19\n" +
+	"\btone_map\x18\x1b \x01(\tR\atoneMap\x12\x18\n" +
+	"\adenoise\x18\x1c \x01(\tR\adenoise\x12\x1a\n" +
+	"\bequalize\x18\x1d \x01(\tR\bequalize\x12!\n" +
+	"\fbudget_nanos\x18\x1e \x01(\x03R\vbudgetNanos\x12\x1f\n" +
+	"\vfixed_point\x18  \x01(\bR\n" +
+	"fixedPoint\x12\x1f\n" +
+	"\vedge_levels\x18! \x01(\x03R\n" +
+	"edgeLevels\x12\x1d\n" +
+	"\n" +
+	"skin_blobs\x18\" \x01(\bR\tskinBlobs\x12'\n" +
+	"\x0fsky_suppression\x18# \x01(\x01R\x0eskySuppression\x12%\n" +
+	"\x0ereduced_planes\x18$ \x01(\bR\rreducedPlanes\x12\x1a\n" +
+	"\bquadtree\x18% \x01(\bR\bquadtree\x12\x16\n" +
+	"\x06refine\x18& \x01(\bR\x06refine\x12\x1f\n" +
+	"\vexact_ratio\x18' \x01(\bR\n" +
+	"exactRatio\x12\x16\n" +
+	"\x06margin\x18( \x01(\x01R\x06margin\x12C\n" +
+	"\tdetectors\x18) \x03(\v2%.smartcrop.v1.Settings.DetectorsEntryR\tdetectors\x12)\n" +
+	"\x10attention_points\x18* \x01(\x03R\x0fattentionPoints\x12\x1e\n" +
+	"\n" +
+	"strategies\x18+ \x03(\tR\n" +
+	"strategies\x12%\n" +
+	"\x0emin_confidence\x18, \x01(\x01R\rminConfidence\x12/\n" +
+	"\ttext_zone\x18- \x01(\v2\x12.smartcrop.v1.RectR\btextZone\x12\x1d\n" +
+	"\n" +
+	"text_color\x18. \x01(\tR\ttextColor\x12!\n" +
+	"\fmin_contrast\x18/ \x01(\x01R\vminContrast\x12\x18\n" +
+	"\asamples\x180 \x01(\x03R\asamples\x12\x12\n" +
+	"\x04seed\x181 \x01(\x03R\x04seed\x12#\n" +
+	"\rstep_fraction\x182 \x01(\x01R\fstepFraction\x12'\n" +
+	"\x0fratio_tolerance\x183 \x01(\x01R\x0eratioTolerance\x12#\n" +
+	"\rdedup_epsilon\x184 \x01(\x03R\fdedupEpsilon\x12,\n" +
+	"\x12max_duration_nanos\x185 \x01(\x03R\x10maxDurationNanos\x12\x18\n" +
+	"\aworkers\x186 \x01(\x03R\aworkers\x12@\n" +
+	"\bensemble\x187 \x03(\v2$.smartcrop.v1.Settings.EnsembleEntryR\bensemble\x12#\n" +
+	"\rensemble_mode\x188 \x01(\tR\fensembleMode\x1a<\n" +
+	"\x0eDetectorsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\x1a;\n" +
+	"\rEnsembleEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01J\x04\b\x1f\x10 R\abackendB/Z-github.com/muesli/smartcrop/proto;smartcroppbb\x06proto3"
+
+var (
+	file_smartcrop_proto_rawDescOnce sync.Once
+	file_smartcrop_proto_rawDescData []byte
+)
+
+func file_smartcrop_proto_rawDescGZIP() []byte {
+	file_smartcrop_proto_rawDescOnce.Do(func() {
+		file_smartcrop_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_smartcrop_proto_rawDesc), len(file_smartcrop_proto_rawDesc)))
+	})
+	return file_smartcrop_proto_rawDescData
+}
+
+var file_smartcrop_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_smartcrop_proto_goTypes = []any{
+	(*Score)(nil),          // 0: smartcrop.v1.Score
+	(*Crop)(nil),           // 1: smartcrop.v1.Crop
+	(*Rect)(nil),           // 2: smartcrop.v1.Rect
+	(*FocalPoint)(nil),     // 3: smartcrop.v1.FocalPoint
+	(*AttentionPoint)(nil), // 4: smartcrop.v1.AttentionPoint
+	(*Result)(nil),         // 5: smartcrop.v1.Result
+	(*Settings)(nil),       // 6: smartcrop.v1.Settings
+	nil,                    // 7: smartcrop.v1.Settings.DetectorsEntry
+	nil,                    // 8: smartcrop.v1.Settings.EnsembleEntry
+}
+var file_smartcrop_proto_depIdxs = []int32{
+	0, // 0: smartcrop.v1.Crop.score:type_name -> smartcrop.v1.Score
+	1, // 1: smartcrop.v1.Result.crop:type_name -> smartcrop.v1.Crop
+	2, // 2: smartcrop.v1.Result.float_crop:type_name -> smartcrop.v1.Rect
+	2, // 3: smartcrop.v1.Result.normalized:type_name -> smartcrop.v1.Rect
+	3, // 4: smartcrop.v1.Result.focal_point:type_name -> smartcrop.v1.FocalPoint
+	4, // 5: smartcrop.v1.Result.attention_points:type_name -> smartcrop.v1.AttentionPoint
+	7, // 6: smartcrop.v1.Settings.detectors:type_name -> smartcrop.v1.Settings.DetectorsEntry
+	2, // 7: smartcrop.v1.Settings.text_zone:type_name -> smartcrop.v1.Rect
+	8, // 8: smartcrop.v1.Settings.ensemble:type_name -> smartcrop.v1.Settings.EnsembleEntry
+	9, // [9:9] is the sub-list for method output_type
+	9, // [9:9] is the sub-list for method input_type
+	9, // [9:9] is the sub-list for extension type_name
+	9, // [9:9] is the sub-list for extension extendee
+	0, // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_smartcrop_proto_init() }
+func file_smartcrop_proto_init() {
+	if File_smartcrop_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_smartcrop_proto_rawDesc), len(file_smartcrop_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_smartcrop_proto_goTypes,
+		DependencyIndexes: file_smartcrop_proto_depIdxs,
+		MessageInfos:      file_smartcrop_proto_msgTypes,
+	}.Build()
+	File_smartcrop_proto = out.File
+	file_smartcrop_proto_goTypes = nil
+	file_smartcrop_proto_depIdxs = nil
+}
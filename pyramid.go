@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+)
+
+// edgeLevels adds the edges of coarser levels of a Gaussian pyramid of the
+// lightness of i to the edge plane in the green channel of o, if enabled in
+// the settings. o may be scaled down by the factor reduction. Level l gets
+// weighted by exp(-l²/2), so fine texture still counts as before, while
+// large soft edges, like those of an out-of-focus foreground object,
+// contribute as well.
+func (p pipeline) edgeLevels(i *image.RGBA, o *image.RGBA, reduction int) {
+	levels := p.settings.EdgeLevels
+	if levels <= 1 {
+		return
+	}
+
+	width, height := i.Bounds().Dx(), i.Bounds().Dy()
+	lum := makeCies(i)
+
+	ob := o.Bounds()
+	sum := make([]float64, ob.Dx()*ob.Dy())
+	for oy := 0; oy < ob.Dy(); oy++ {
+		for ox := 0; ox < ob.Dx(); ox++ {
+			sum[oy*ob.Dx()+ox] = float64(o.Pix[o.PixOffset(ob.Min.X+ox, ob.Min.Y+oy)+1])
+		}
+	}
+
+	for l := 1; l < levels; l++ {
+		// the edge detector needs at least 3x3 pixels
+		if width < 6 || height < 6 {
+			break
+		}
+		lum, width, height = pyrDown(lum, width, height)
+		edges := laplacian(lum, width, height)
+
+		w := math.Exp(-float64(l*l) / 2.0)
+		for oy := 0; oy < ob.Dy(); oy++ {
+			y := minInt((oy*reduction)>>uint(l), height-1)
+			for ox := 0; ox < ob.Dx(); ox++ {
+				x := minInt((ox*reduction)>>uint(l), width-1)
+				sum[oy*ob.Dx()+ox] += w * edges[y*width+x]
+			}
+		}
+	}
+
+	for oy := 0; oy < ob.Dy(); oy++ {
+		for ox := 0; ox < ob.Dx(); ox++ {
+			o.Pix[o.PixOffset(ob.Min.X+ox, ob.Min.Y+oy)+1] = uint8(bounds(sum[oy*ob.Dx()+ox]))
+		}
+	}
+}
+
+// pyrDown blurs the plane v with a 1-2-1 binomial filter and drops every
+// other row and column of it.
+func pyrDown(v []float64, width, height int) ([]float64, int, int) {
+	at := func(x, y int) float64 {
+		return v[minInt(maxInt(y, 0), height-1)*width+minInt(maxInt(x, 0), width-1)]
+	}
+
+	w, h := (width+1)/2, (height+1)/2
+	d := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx, sy := 2*x, 2*y
+			sum := 0.0
+			for dy := -1; dy <= 1; dy++ {
+				row := at(sx-1, sy+dy) + 2.0*at(sx, sy+dy) + at(sx+1, sy+dy)
+				if dy == 0 {
+					row *= 2.0
+				}
+				sum += row
+			}
+			d[y*w+x] = sum / 16.0
+		}
+	}
+	return d, w, h
+}
+
+// laplacian returns the same edges edgeDetect finds for the lightness plane
+// v, clamped to 0..255.
+func laplacian(v []float64, width, height int) []float64 {
+	e := make([]float64, width*height)
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			e[y*width+x] = bounds(v[y*width+x]*4.0 -
+				v[x+(y-1)*width] -
+				v[x-1+y*width] -
+				v[x+1+y*width] -
+				v[x+(y+1)*width])
+		}
+	}
+	return e
+}
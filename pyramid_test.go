@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestEdgeLevels(t *testing.T) {
+	// a soft-edged blob on the left, too blurry for a single-scale detector
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			d2 := float64((x-50)*(x-50) + (y-50)*(y-50))
+			v := uint8(255.0 * math.Exp(-d2/(2.0*20.0*20.0)))
+			img.SetRGBA(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+
+	edges := func(levels int) (left, right int) {
+		o := image.NewRGBA(img.Bounds())
+		edgeDetect(img, o)
+		settings := DefaultCropSettings()
+		settings.EdgeLevels = levels
+		pipeline{settings: &settings}.edgeLevels(img, o, 1)
+
+		for y := 0; y < 100; y++ {
+			for x := 0; x < 200; x++ {
+				if v := int(o.Pix[o.PixOffset(x, y)+1]); x < 100 {
+					left += v
+				} else {
+					right += v
+				}
+			}
+		}
+		return left, right
+	}
+
+	single, _ := edges(1)
+	left, right := edges(3)
+	if left <= 2*single || left <= 10*right {
+		t.Fatalf("expected the pyramid to pick up the blob, got %d on it (%d single-scale) and %d elsewhere", left, single, right)
+	}
+}
@@ -0,0 +1,228 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+)
+
+// quadTolerance is the maximum difference between the values of a detector
+// plane within a region for the region to be considered homogeneous.
+const quadTolerance = 8
+
+// quadNode is a node of a quadtree over the detector planes. It contains the
+// sums of the per-pixel score contributions within its region, in coordinates
+// of the prescaled image.
+type quadNode struct {
+	r                               image.Rectangle
+	detail, skin, saturation, boost float64
+	min, max                        [4]uint8
+	children                        []*quadNode
+	kids                            [4]*quadNode
+}
+
+// quadtree aggregates the detector planes, so a crop can be scored by only
+// visiting as many regions as needed: regions outside the crop and
+// homogeneous ones get scored as a whole, detailed ones get subdivided down to
+// half of the ScoreDownSample stride.
+type quadtree struct {
+	root *quadNode
+	// norm scales the sums to the magnitude of the sampled scores.
+	norm float64
+	// leaf is the stride of the sampled scorer. Regions of up to twice its
+	// square in prescaled pixels get scored as a whole, even if they aren't
+	// homogeneous.
+	leaf int
+}
+
+// buildQuadtree builds a quadtree over the detector planes, which are scaled
+// down by the factor reduction compared to the prescaled image.
+func buildQuadtree(s *CropSettings, output *image.RGBA, boost *image.Gray, reduction int) *quadtree {
+	minSize := s.ScoreDownSample / (2 * reduction)
+	if minSize < 1 {
+		minSize = 1
+	}
+
+	// allocate the nodes in chunks, there are a lot of them
+	var chunk []quadNode
+	var build func(r image.Rectangle) *quadNode
+	build = func(r image.Rectangle) *quadNode {
+		if len(chunk) == cap(chunk) {
+			chunk = make([]quadNode, 0, 1024)
+		}
+		chunk = append(chunk, quadNode{r: image.Rectangle{Min: r.Min.Mul(reduction), Max: r.Max.Mul(reduction)}})
+		n := &chunk[len(chunk)-1]
+		for i := range n.min {
+			n.min[i] = 255
+		}
+
+		if r.Dx() <= minSize && r.Dy() <= minSize {
+			n.sum(s, output, boost, r)
+			return n
+		}
+
+		cx, cy := r.Min.X+(r.Dx()+1)/2, r.Min.Y+(r.Dy()+1)/2
+		homogeneous := true
+		kids := 0
+		for _, c := range [4]image.Rectangle{
+			image.Rect(r.Min.X, r.Min.Y, cx, cy),
+			image.Rect(cx, r.Min.Y, r.Max.X, cy),
+			image.Rect(r.Min.X, cy, cx, r.Max.Y),
+			image.Rect(cx, cy, r.Max.X, r.Max.Y),
+		} {
+			if c.Empty() {
+				continue
+			}
+			child := build(c)
+			n.merge(child)
+			n.kids[kids] = child
+			kids++
+			homogeneous = homogeneous && child.children == nil
+		}
+		n.children = n.kids[:kids]
+		for i := range n.min {
+			homogeneous = homogeneous && n.max[i]-n.min[i] <= quadTolerance
+		}
+		if homogeneous {
+			n.children = nil
+		}
+
+		return n
+	}
+
+	ds := float64(s.ScoreDownSample)
+	r := float64(reduction)
+	return &quadtree{
+		root: build(output.Bounds()),
+		norm: r * r / (ds * ds),
+		leaf: s.ScoreDownSample,
+	}
+}
+
+// sum sets the sums and ranges of n to the ones of the pixels of the planes
+// within r, using integer math for speed.
+func (n *quadNode) sum(s *CropSettings, output *image.RGBA, boost *image.Gray, r image.Rectangle) {
+	var sr, sg, sb, srg, sbg, sboost int64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			p := output.Pix[output.PixOffset(x, y):]
+			var v [4]uint8
+			v[0], v[1], v[2] = p[0], p[1], p[2]
+			if boost != nil {
+				v[3] = boost.Pix[boost.PixOffset(x, y)]
+			}
+
+			r8, g8, b8 := int64(v[0]), int64(v[1]), int64(v[2])
+			sr += r8
+			sg += g8
+			sb += b8
+			srg += r8 * g8
+			sbg += b8 * g8
+			sboost += int64(v[3])
+
+			for i := range v {
+				if v[i] < n.min[i] {
+					n.min[i] = v[i]
+				}
+				if v[i] > n.max[i] {
+					n.max[i] = v[i]
+				}
+			}
+		}
+	}
+
+	n.detail = float64(sg) / 255.0
+	n.skin = float64(srg)/(255.0*255.0) + s.SkinBias*float64(sr)/255.0
+	n.saturation = float64(sbg)/(255.0*255.0) + s.SaturationBias*float64(sb)/255.0
+	n.boost = float64(sboost) / 255.0
+}
+
+// merge adds the sums and ranges of child to n.
+func (n *quadNode) merge(child *quadNode) {
+	n.detail += child.detail
+	n.skin += child.skin
+	n.saturation += child.saturation
+	n.boost += child.boost
+	for i := range n.min {
+		if child.min[i] < n.min[i] {
+			n.min[i] = child.min[i]
+		}
+		if child.max[i] > n.max[i] {
+			n.max[i] = child.max[i]
+		}
+	}
+}
+
+// score is the quadtree equivalent of score.
+func (t *quadtree) score(s *CropSettings, crop Crop) Score {
+	sc := Score{}
+	t.root.score(s, crop.Rectangle, t.leaf, &sc)
+
+	sc.Detail *= t.norm
+	sc.Skin *= t.norm
+	sc.Saturation *= t.norm
+	sc.Boost *= t.norm
+	return sc
+}
+
+// score adds the contributions of n to sc.
+func (n *quadNode) score(s *CropSettings, crop image.Rectangle, leaf int, sc *Score) {
+	in := n.r.Intersect(crop)
+	switch {
+	case in.Empty():
+		n.addTo(sc, s.OutsideImportance, 1.0)
+
+	case n.children == nil, n.r.Dx()*n.r.Dy() <= 2*leaf*leaf:
+		// the importance of the region is approximated by the one at the
+		// center of its part within the crop
+		area := float64(n.r.Dx() * n.r.Dy())
+		inside := float64(in.Dx()*in.Dy()) / area
+		xf := (float64(in.Min.X+in.Max.X-1)/2.0 - float64(crop.Min.X)) / float64(crop.Dx())
+		yf := (float64(in.Min.Y+in.Max.Y-1)/2.0 - float64(crop.Min.Y)) / float64(crop.Dy())
+		n.addTo(sc, relativeImportance(s, xf, yf), inside)
+		n.addTo(sc, s.OutsideImportance, 1.0-inside)
+
+	default:
+		for _, c := range n.children {
+			c.score(s, crop, leaf, sc)
+		}
+	}
+}
+
+// addTo adds the given fraction of the sums of n, weighted by imp, to sc.
+func (n *quadNode) addTo(sc *Score, imp, fraction float64) {
+	if fraction <= 0.0 || math.IsNaN(fraction) {
+		return
+	}
+	f := imp * fraction
+	sc.Detail += n.detail * f
+	sc.Skin += n.skin * f
+	sc.Saturation += n.saturation * f
+	sc.Boost += n.boost * f
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestQuadtree(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := DefaultCropSettings()
+	i := toRGBA(img)
+	o := image.NewRGBA(i.Bounds())
+	edgeDetect(i, o)
+	skinDetect(&s, i, o)
+	saturationDetect(&s, i, o)
+	tree := buildQuadtree(&s, o, nil, 1)
+
+	// score every pixel for reference, scaled to the magnitude of sampling
+	// every ScoreDownSample-th one
+	dense := s
+	dense.ScoreDownSample = 1
+	norm := 1.0 / float64(s.ScoreDownSample*s.ScoreDownSample)
+
+	// a coarse grid of candidates is enough and keeps the test fast
+	grid := s
+	grid.Step = 32
+	var treeErr, sampledErr float64
+	crops(&grid, o, 200, 200, 1.0, func(crop Crop) bool {
+		ref := score(&dense, o, nil, crop, 1)
+		want := (ref.Detail*s.DetailWeight + ref.Skin*s.SkinWeight + ref.Saturation*s.SaturationWeight) * norm
+
+		sc := tree.score(&s, crop)
+		treeErr += math.Abs(sc.Detail*s.DetailWeight + sc.Skin*s.SkinWeight + sc.Saturation*s.SaturationWeight - want)
+		sc = score(&s, o, nil, crop, 1)
+		sampledErr += math.Abs(sc.Detail*s.DetailWeight + sc.Skin*s.SkinWeight + sc.Saturation*s.SaturationWeight - want)
+		return true
+	})
+	t.Logf("quadtree error: %v, sampling error: %v", treeErr, sampledErr)
+	if treeErr >= sampledErr {
+		t.Fatalf("expected the quadtree to be closer to the dense scores than sampling: %v vs %v", treeErr, sampledErr)
+	}
+
+	settings := DefaultCropSettings()
+	settings.Quadtree = true
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings)
+	if _, err := analyzer.FindBestCrop(img, 250, 250); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkQuadtree(b *testing.B) {
+	fi, err := os.Open(testFile)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	settings := DefaultCropSettings()
+	settings.Quadtree = true
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := analyzer.FindBestCrop(img, 250, 250); err != nil {
+			b.Error(err)
+		}
+	}
+}
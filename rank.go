@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"errors"
+	"image"
+	"sort"
+)
+
+// ErrNoImages gets returned when ranking no images at all.
+var ErrNoImages = errors.New("Expect at least one image")
+
+// RankedImage is an image ranked by RankImages.
+type RankedImage struct {
+	// Index is the index of the image in the slice passed to RankImages.
+	Index  int    `json:"index"`
+	Result Result `json:"result"`
+	// Score is the total score of the best crop, which is normalized by its
+	// area and so comparable across images.
+	Score float64 `json:"score"`
+	// Upscaled reports that the crop is smaller than the slot.
+	Upscaled bool `json:"upscaled"`
+}
+
+// RankImages finds the best crop of each of imgs for a slot of the given
+// dimensions, e.g. to pick a hero image, and ranks them by how well they crop
+// into it: images which fill the slot without upscaling first, then by the
+// score of their best crop, highest first.
+func RankImages(analyzer ResultAnalyzer, imgs []image.Image, width, height int) ([]RankedImage, error) {
+	if len(imgs) == 0 {
+		return nil, ErrNoImages
+	}
+
+	ranking := make([]RankedImage, 0, len(imgs))
+	for i, img := range imgs {
+		res, err := analyzer.FindBestResult(img, width, height)
+		if err != nil {
+			return nil, err
+		}
+
+		c := res.Crop.Rectangle
+		ranking = append(ranking, RankedImage{
+			Index:    i,
+			Result:   res,
+			Score:    res.Crop.Score.Total,
+			Upscaled: (width > 0 && c.Dx() < width) || (height > 0 && c.Dy() < height),
+		})
+	}
+
+	sort.SliceStable(ranking, func(i, j int) bool {
+		if ranking[i].Upscaled != ranking[j].Upscaled {
+			return !ranking[i].Upscaled
+		}
+		return ranking[i].Score > ranking[j].Score
+	})
+	return ranking, nil
+}
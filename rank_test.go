@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestRankImages(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a flat image without anything to crop to
+	flat := image.NewRGBA(img.Bounds())
+	for i := range flat.Pix {
+		flat.Pix[i] = 128
+	}
+	// and one too small for the slot
+	small := nfnt.NewDefaultResizer().Resize(img, 100, 0)
+
+	analyzer := NewAnalyzer(nfnt.NewDefaultResizer()).(ResultAnalyzer)
+	ranking, err := RankImages(analyzer, []image.Image{flat, small, img}, 200, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []int{2, 0, 1}
+	for i, r := range ranking {
+		if r.Index != expected[i] {
+			t.Fatalf("expected ranking %v, got image %d at %d", expected, r.Index, i)
+		}
+	}
+	if !ranking[2].Upscaled {
+		t.Fatal("expected the small image to be upscaled")
+	}
+
+	if _, err := RankImages(analyzer, []image.Image{img, nil}, 200, 200); err != ErrNilImage {
+		t.Fatalf("expected ErrNilImage, got %v", err)
+	}
+	if _, err := RankImages(analyzer, nil, 200, 200); err != ErrNoImages {
+		t.Fatalf("expected ErrNoImages, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	// ratioGain is how much better than the best crop of the requested
+	// aspect ratio a crop of a deviating one has to score to replace it.
+	ratioGain = 0.1
+	// ratioSteps is the number of deviating ratios tried on either side of
+	// the requested one.
+	ratioSteps = 3
+)
+
+// jitterRatio looks for a crop around best whose aspect ratio deviates from
+// the requested one by up to RatioTolerance and which scores at least
+// ratioGain better. Each deviating crop keeps the center and area of best as
+// far as the area of interest permits. It returns best if there is none.
+func (p pipeline) jitterRatio(st *State, best Crop) Crop {
+	s := p.settings
+	o := st.Detected
+	bounds := st.PrescaledArea()
+
+	var blobs *blobCuts
+	if s.BlobPenalty > 0 {
+		blobs = newBlobCuts(newSaliencyMap(s, o, st.Boost, st.Reduction))
+	}
+	rate := func(c Crop) Score {
+		sc := score(s, o, st.Boost, c, st.Reduction)
+		c.Score = sc
+		sc.Total = c.totalScore(s)
+		if blobs != nil {
+			blobs.penalize(s, newRect(c.Rectangle), &sc)
+		}
+		return sc
+	}
+
+	// rescore best, so all crops compare by the same measure
+	exact := rate(best)
+	target := exact.Total + ratioGain*math.Abs(exact.Total)
+	top, topScore := best, exact
+	cx := float64(best.Min.X+best.Max.X) / 2.0
+	cy := float64(best.Min.Y+best.Max.Y) / 2.0
+	for k := -ratioSteps; k <= ratioSteps; k++ {
+		if k == 0 {
+			continue
+		}
+		f := math.Sqrt(1.0 + s.RatioTolerance*float64(k)/ratioSteps)
+		w := math.Min(float64(best.Dx())*f, float64(bounds.Dx()))
+		h := math.Min(float64(best.Dy())/f, float64(bounds.Dy()))
+		if w < 1 || h < 1 {
+			continue
+		}
+
+		// keep the crop within the area of interest
+		x := math.Max(float64(bounds.Min.X), math.Min(cx-w/2.0, float64(bounds.Max.X)-w))
+		y := math.Max(float64(bounds.Min.Y), math.Min(cy-h/2.0, float64(bounds.Max.Y)-h))
+		c := Crop{Rectangle: image.Rect(int(x), int(y), int(x)+int(w), int(y)+int(h))}
+		if (st.Filter != nil && !st.Filter(c)) || vetoed(newRect(c.Rectangle), st.Regions) {
+			continue
+		}
+
+		if sc := rate(c); sc.Total >= target && sc.Total > topScore.Total {
+			top, topScore = c, sc
+		}
+	}
+
+	if top.Rectangle == best.Rectangle {
+		return best
+	}
+	top.Score = topScore
+	return top
+}
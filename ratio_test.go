@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestJitterRatio(t *testing.T) {
+	s := DefaultCropSettings()
+	s.RatioTolerance = 0.06
+
+	// a detailed band slightly wider than the requested square crop
+	o := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 50; x < 155; x++ {
+			o.Pix[o.PixOffset(x, y)+1] = 255
+		}
+	}
+	st := &State{Source: o, Prescaled: o, Detected: o, Reduction: 1, PrescaleFactor: 1, Width: 100, Height: 100}
+	best := Crop{Rectangle: image.Rect(52, 0, 152, 100)}
+	best.Score = score(&s, o, nil, best, 1)
+	exact := best.totalScore(&s)
+
+	c := pipeline{settings: &s}.jitterRatio(st, best)
+	ratio := float64(c.Dx()) / float64(c.Dy())
+	if ratio == 1 || math.Abs(ratio-1) > s.RatioTolerance+0.02 {
+		t.Fatalf("expected a ratio within the tolerance, got %v", c.Rectangle)
+	}
+	if c.Score.Total < exact*(1+ratioGain) {
+		t.Fatalf("expected a score at least %v better than %v, got %v", ratioGain, exact, c.Score.Total)
+	}
+
+	s.RatioTolerance = 0.001
+	if c := (pipeline{settings: &s}).jitterRatio(st, best); c.Rectangle != best.Rectangle {
+		t.Fatalf("expected the crop to keep its ratio within a tiny tolerance, got %v", c.Rectangle)
+	}
+}
+
+func TestRatioTolerance(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := DefaultCropSettings()
+	s.RatioTolerance = 0.03
+	res, err := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, s).(ResultAnalyzer).FindBestResult(img, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Ratio == 0 || math.Abs(res.Ratio-1) > 0.04 {
+		t.Fatalf("expected the ratio of the crop within the tolerance, got %v", res.Ratio)
+	}
+
+	s.ExactRatio = true
+	res, err = NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, s).(ResultAnalyzer).FindBestResult(img, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Ratio != 0 || res.Crop.Dx() != res.Crop.Dy() {
+		t.Fatalf("expected ExactRatio to take precedence, got %v", res.Crop.Rectangle)
+	}
+
+	// snapping 333x250 moves the crop, which the other fields must follow
+	res, err = NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, s).(ResultAnalyzer).FindBestResult(img, 333, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Crop.Dx()*250 != res.Crop.Dy()*333 {
+		t.Fatalf("expected a crop of exactly 333:250, got %v", res.Crop.Rectangle)
+	}
+	f := res.FloatCrop
+	if image.Rect(int(math.Round(f.X)), int(math.Round(f.Y)), int(math.Round(f.X+f.Width)), int(math.Round(f.Y+f.Height))) != res.Crop.Rectangle {
+		t.Fatalf("expected the float crop %+v to match the snapped crop %v", f, res.Crop.Rectangle)
+	}
+	if n := res.Normalized; math.Abs(n.Width*float64(res.ImageWidth)-float64(res.Crop.Dx())) > 1e-9 {
+		t.Fatalf("expected the normalized crop %+v to match the snapped crop %v", n, res.Crop.Rectangle)
+	}
+}
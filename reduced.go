@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+)
+
+// reduction returns the factor the detector planes are scaled down by,
+// compared to the prescaled image. Debug output always uses full-resolution
+// planes.
+func (p pipeline) reduction() int {
+	if p.settings.ReducedPlanes && !p.logger.DebugMode && p.settings.ScoreDownSample > 1 {
+		return p.settings.ScoreDownSample
+	}
+	return 1
+}
+
+// reducedBounds returns the bounds of the detector planes of an image with the
+// given bounds, scaled down by reduction.
+func reducedBounds(r image.Rectangle, reduction int) image.Rectangle {
+	return image.Rect(0, 0, r.Dx()/reduction, r.Dy()/reduction)
+}
+
+// reduceRect returns the pixels of a plane scaled down by reduction which
+// correspond to pixels within r.
+func reduceRect(r image.Rectangle, reduction int) image.Rectangle {
+	ceil := func(v int) int {
+		if v <= 0 {
+			return v / reduction
+		}
+		return (v + reduction - 1) / reduction
+	}
+	return image.Rect(ceil(r.Min.X), ceil(r.Min.Y), ceil(r.Max.X), ceil(r.Max.Y))
+}
+
+// reducedDetect computes the edge, skin and saturation planes of i, but only
+// for every reduction-th pixel, as sampled by the scorer. The values are the
+// same as those of the full-resolution planes.
+func reducedDetect(s *CropSettings, i *image.RGBA, o *image.RGBA, reduction int) {
+	t := getColorTables()
+	ib, ob := i.Bounds(), o.Bounds()
+	width := ib.Dx()
+	height := ib.Dy()
+	skinK := 255.0 / (1.0 - s.SkinThreshold)
+	satK := 255.0 / (1.0 - s.SaturationThreshold)
+
+	// x and y are relative to the bounds of i, which need not start at 0,0
+	lightness := func(x, y int) float64 {
+		p := i.PixOffset(ib.Min.X+x, ib.Min.Y+y)
+		return t.cie(i.Pix[p], i.Pix[p+1], i.Pix[p+2])
+	}
+
+	for oy := 0; oy < ob.Dy(); oy++ {
+		for ox := 0; ox < ob.Dx(); ox++ {
+			x, y := ox*reduction, oy*reduction
+			ii, oi := i.PixOffset(ib.Min.X+x, ib.Min.Y+y), o.PixOffset(ob.Min.X+ox, ob.Min.Y+oy)
+			r8, g8, b8 := i.Pix[ii], i.Pix[ii+1], i.Pix[ii+2]
+			cie := t.cie(r8, g8, b8)
+
+			edge := 0.0
+			if x > 0 && x < width-1 && y > 0 && y < height-1 {
+				edge = cie*4.0 -
+					lightness(x, y-1) -
+					lightness(x-1, y) -
+					lightness(x+1, y) -
+					lightness(x, y+1)
+			}
+
+			skin, sat := 0.0, 0.0
+			l := cie / 255.0
+			if !s.SkipSkin && l >= s.SkinBrightnessMin && l <= s.SkinBrightnessMax {
+				if v := t.skin(r8, g8, b8); v > s.SkinThreshold {
+					skin = bounds((v - s.SkinThreshold) * skinK)
+				}
+			}
+			if !s.SkipSaturation {
+				if v := t.saturation(r8, g8, b8); v > s.SaturationThreshold {
+					if l >= s.SaturationBrightnessMin && l <= s.SaturationBrightnessMax {
+						sat = bounds((v - s.SaturationThreshold) * satK)
+					}
+				}
+			}
+
+			o.Pix[oi] = uint8(skin)
+			o.Pix[oi+1] = uint8(bounds(edge))
+			o.Pix[oi+2] = uint8(sat)
+			o.Pix[oi+3] = 255
+		}
+	}
+}
+
+// reduceGray returns every reduction-th pixel of the full-resolution plane g.
+func reduceGray(g *image.Gray, reduction int) *image.Gray {
+	gb := g.Bounds()
+	r := image.NewGray(reducedBounds(gb, reduction))
+	for y := 0; y < r.Bounds().Dy(); y++ {
+		for x := 0; x < r.Bounds().Dx(); x++ {
+			r.Pix[r.PixOffset(x, y)] = g.Pix[g.PixOffset(gb.Min.X+x*reduction, gb.Min.Y+y*reduction)]
+		}
+	}
+	return r
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestReducedPlanes(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := DefaultCropSettings()
+	full := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ResultAnalyzer)
+	settings.ReducedPlanes = true
+	reduced := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ResultAnalyzer)
+
+	// the reduced planes contain exactly the pixels sampled by the scorer
+	for _, size := range []image.Point{{250, 250}, {100, 200}, {400, 100}} {
+		a, err := full.FindBestResult(img, size.X, size.Y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := reduced.FindBestResult(img, size.X, size.Y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Crop != b.Crop {
+			t.Fatalf("expected %+v with reduced planes, got %+v", a.Crop, b.Crop)
+		}
+	}
+
+	e, _ := EstimateCost(4000, 3000, 250, 250, DefaultCropSettings())
+	r, _ := EstimateCost(4000, 3000, 250, 250, settings)
+	if r.DetectorOps >= e.DetectorOps || r.WorkingMemory >= e.WorkingMemory {
+		t.Fatalf("expected reduced planes to be cheaper: %+v vs %+v", r, e)
+	}
+}
+
+func TestReducedPlanesSubImage(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rgba := toRGBA(img)
+	sub := rgba.SubImage(image.Rect(100, 100, 400, 300))
+
+	for _, prescale := range []bool{true, false} {
+		settings := DefaultCropSettings()
+		settings.Prescale = prescale
+		full := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ResultAnalyzer)
+		settings.ReducedPlanes = true
+		reduced := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ResultAnalyzer)
+
+		a, err := full.FindBestResult(sub, 100, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := reduced.FindBestResult(sub, 100, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Crop != b.Crop {
+			t.Fatalf("prescale %v: expected %+v with reduced planes, got %+v", prescale, a.Crop, b.Crop)
+		}
+		if !b.Crop.In(sub.Bounds()) {
+			t.Fatalf("prescale %v: expected the crop within %v, got %v", prescale, sub.Bounds(), b.Crop)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+)
+
+// rect is a rectangle with fractional coordinates.
+type rect struct {
+	x, y, w, h float64
+}
+
+func newRect(r image.Rectangle) rect {
+	return rect{float64(r.Min.X), float64(r.Min.Y), float64(r.Dx()), float64(r.Dy())}
+}
+
+// bounds returns the smallest rectangle of whole pixels containing r.
+func (r rect) bounds() image.Rectangle {
+	return image.Rect(int(math.Floor(r.x)), int(math.Floor(r.y)), int(math.Ceil(r.x+r.w)), int(math.Ceil(r.y+r.h)))
+}
+
+func (r rect) scale(f float64) rect {
+	return rect{r.x * f, r.y * f, r.w * f, r.h * f}
+}
+
+// rectScore is the equivalent of score for a crop with fractional coordinates.
+func rectScore(s *CropSettings, output *image.RGBA, boost *image.Gray, reduction int, r rect) Score {
+	width := output.Bounds().Dx()
+	height := output.Bounds().Dy()
+	step := s.ScoreDownSample / reduction
+	score := Score{}
+	rows := newRGBARows(output)
+	var boostRows grayRows
+	if boost != nil {
+		boostRows = newGrayRows(boost)
+	}
+
+	for y := 0; y <= height-step; y += step {
+		row := rows.row(y)
+		var boostRow []uint8
+		if boost != nil {
+			boostRow = boostRows.row(y)
+		}
+		for x := 0; x <= width-step; x += step {
+			p := row[x*4 : x*4+3 : x*4+3]
+			r8 := float64(p[0])
+			g8 := float64(p[1])
+			b8 := float64(p[2])
+
+			imp := s.OutsideImportance
+			if xf, yf := float64(x*reduction), float64(y*reduction); xf >= r.x && xf < r.x+r.w && yf >= r.y && yf < r.y+r.h {
+				imp = relativeImportance(s, (xf-r.x)/r.w, (yf-r.y)/r.h)
+			}
+			det := g8 / 255.0
+
+			score.Skin += r8 / 255.0 * (det + s.SkinBias) * imp
+			score.Detail += det * imp
+			score.Saturation += b8 / 255.0 * (det + s.SaturationBias) * imp
+			if boost != nil {
+				score.Boost += float64(boostRow[x]) / 255.0 * imp
+			}
+		}
+	}
+
+	score.Total = (score.Detail*s.DetailWeight + score.Skin*s.SkinWeight + score.Saturation*s.SaturationWeight + score.Boost*s.BoostWeight) / r.w / r.h
+	return score
+}
+
+// refineSteps limits the number of steps taken by refine.
+const refineSteps = 100
+
+// tableCells is the number of cells per side of a crop, within which
+// scoreTable treats the importance as constant.
+const tableCells = 16
+
+// scoreTable is a summed-area table of the weighted detector planes, sampled
+// the same way as by rectScore. It scores crops with fractional coordinates in
+// constant time, approximating the importance within each cell of a
+// tableCells x tableCells grid over the crop by its value at the center.
+type scoreTable struct {
+	s          *CropSettings
+	sums       []float64 // (cols+1) x (rows+1) prefix sums
+	cols, rows int
+	cell       float64 // size of a sample in pixels of the prescaled image
+	weights    [tableCells * tableCells]float64
+}
+
+func newScoreTable(s *CropSettings, output *image.RGBA, boost *image.Gray, reduction int) *scoreTable {
+	width := output.Bounds().Dx()
+	height := output.Bounds().Dy()
+	step := s.ScoreDownSample / reduction
+	t := &scoreTable{s: s, cell: float64(step * reduction)}
+	if width >= step {
+		t.cols = (width-step)/step + 1
+	}
+	if height >= step {
+		t.rows = (height-step)/step + 1
+	}
+
+	rows := newRGBARows(output)
+	var boostRows grayRows
+	if boost != nil {
+		boostRows = newGrayRows(boost)
+	}
+
+	w := t.cols + 1
+	t.sums = make([]float64, w*(t.rows+1))
+	for j := 0; j < t.rows; j++ {
+		row := rows.row(j * step)
+		var boostRow []uint8
+		if boost != nil {
+			boostRow = boostRows.row(j * step)
+		}
+		acc := 0.0
+		for i := 0; i < t.cols; i++ {
+			x := i * step
+			p := row[x*4 : x*4+3 : x*4+3]
+			det := float64(p[1]) / 255.0
+			v := det*s.DetailWeight +
+				float64(p[0])/255.0*(det+s.SkinBias)*s.SkinWeight +
+				float64(p[2])/255.0*(det+s.SaturationBias)*s.SaturationWeight
+			if boost != nil {
+				v += float64(boostRow[x]) / 255.0 * s.BoostWeight
+			}
+			acc += v
+			t.sums[(j+1)*w+i+1] = t.sums[j*w+i+1] + acc
+		}
+	}
+
+	for j := 0; j < tableCells; j++ {
+		for i := 0; i < tableCells; i++ {
+			t.weights[j*tableCells+i] = relativeImportance(s, (float64(i)+0.5)/tableCells, (float64(j)+0.5)/tableCells)
+		}
+	}
+	return t
+}
+
+// at returns the sum of all samples above and to the left of (x, y), treating
+// each sample as spread evenly over a cell centered on its position.
+func (t *scoreTable) at(x, y float64) float64 {
+	if t.cols == 0 || t.rows == 0 {
+		return 0
+	}
+	u := math.Max(0, math.Min(x/t.cell+0.5, float64(t.cols)))
+	v := math.Max(0, math.Min(y/t.cell+0.5, float64(t.rows)))
+	i, j := int(u), int(v)
+	if i == t.cols {
+		i--
+	}
+	if j == t.rows {
+		j--
+	}
+	fu, fv := u-float64(i), v-float64(j)
+
+	w := t.cols + 1
+	return t.sums[j*w+i]*(1-fu)*(1-fv) + t.sums[j*w+i+1]*fu*(1-fv) +
+		t.sums[(j+1)*w+i]*(1-fu)*fv + t.sums[(j+1)*w+i+1]*fu*fv
+}
+
+// sum returns the sum of the samples within the given rectangle.
+func (t *scoreTable) sum(x0, y0, x1, y1 float64) float64 {
+	return t.at(x1, y1) - t.at(x0, y1) - t.at(x1, y0) + t.at(x0, y0)
+}
+
+// total approximates rectScore(...).Total for r.
+func (t *scoreTable) total(r rect) float64 {
+	// the cells share their corners
+	var corners [(tableCells + 1) * (tableCells + 1)]float64
+	cw, ch := r.w/tableCells, r.h/tableCells
+	for j := 0; j <= tableCells; j++ {
+		for i := 0; i <= tableCells; i++ {
+			corners[j*(tableCells+1)+i] = t.at(r.x+float64(i)*cw, r.y+float64(j)*ch)
+		}
+	}
+
+	inside := 0.0
+	for j := 0; j < tableCells; j++ {
+		c := corners[j*(tableCells+1):]
+		for i := 0; i < tableCells; i++ {
+			sum := c[i+tableCells+2] - c[i+tableCells+1] - c[i+1] + c[i]
+			inside += t.weights[j*tableCells+i] * sum
+		}
+	}
+
+	all := t.sums[len(t.sums)-1]
+	outside := all - (corners[len(corners)-1] - corners[tableCells*(tableCells+1)] - corners[tableCells] + corners[0])
+	return (inside + outside*t.s.OutsideImportance) / r.w / r.h
+}
+
+// refine searches the neighborhood of r for a better placement, moving and
+// scaling it by fractions of a pixel. It runs a gradient ascent on a
+// summed-area table of the planes, so the planes only get scanned twice, no
+// matter how many steps it takes. The width of the crop is kept between
+// minWidth and maxWidth, its aspect ratio stays the same, and it stays within
+// bounds. With reduced planes, the crop may not reach the last few pixels of
+// the right and bottom edges.
+func refine(s *CropSettings, output *image.RGBA, boost *image.Gray, reduction int, r, bounds rect, minWidth, maxWidth float64) (rect, Score) {
+	best := rectScore(s, output, boost, reduction, r)
+
+	// the crop must stay within both the planes and bounds
+	x0 := math.Max(bounds.x, 0)
+	y0 := math.Max(bounds.y, 0)
+	x1 := math.Min(bounds.x+bounds.w, float64(output.Bounds().Dx()*reduction))
+	y1 := math.Min(bounds.y+bounds.h, float64(output.Bounds().Dy()*reduction))
+	ratio := r.h / r.w
+	lo := math.Min(minWidth, r.w)
+	hi := math.Min(math.Max(maxWidth, r.w), math.Min(x1-x0, (y1-y0)/ratio))
+	if hi < lo {
+		return r, best
+	}
+
+	// the crop is described by its center and width, so changing the width
+	// doesn't move it
+	type point [3]float64
+	toRect := func(p point) rect {
+		return rect{p[0] - p[2]/2.0, p[1] - p[2]*ratio/2.0, p[2], p[2] * ratio}
+	}
+	project := func(p point) point {
+		p[2] = math.Max(lo, math.Min(hi, p[2]))
+		w, h := p[2], p[2]*ratio
+		p[0] = math.Max(x0+w/2.0, math.Min(x1-w/2.0, p[0]))
+		p[1] = math.Max(y0+h/2.0, math.Min(y1-h/2.0, p[1]))
+		return p
+	}
+
+	t := newScoreTable(s, output, boost, reduction)
+	p := point{r.x + r.w/2.0, r.y + r.h/2.0, r.w}
+	current := t.total(r)
+	d := float64(s.Step) / 2.0
+	for i := 0; i < refineSteps && d >= 0.25; i++ {
+		// estimate the gradient by central differences, within the constraints
+		var g point
+		norm := 0.0
+		for k := range g {
+			a, b := p, p
+			a[k] -= 0.5
+			b[k] += 0.5
+			a, b = project(a), project(b)
+			if b[k] > a[k] {
+				g[k] = (t.total(toRect(b)) - t.total(toRect(a))) / (b[k] - a[k])
+				norm += g[k] * g[k]
+			}
+		}
+		if norm == 0.0 {
+			break
+		}
+		norm = math.Sqrt(norm)
+
+		c := p
+		for k := range c {
+			c[k] += d * g[k] / norm
+		}
+		c = project(c)
+		if sc := t.total(toRect(c)); sc > current && c != p {
+			p, current = c, sc
+			d *= 1.5
+		} else {
+			d /= 2.0
+		}
+	}
+
+	if c := toRect(p); c != r {
+		if sc := rectScore(s, output, boost, reduction, c); sc.Total > best.Total {
+			return c, sc
+		}
+	}
+	return r, best
+}
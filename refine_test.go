@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestRefine(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := DefaultCropSettings()
+	rgba := toRGBA(nfnt.NewDefaultResizer().Resize(img, 400, 0))
+	o := image.NewRGBA(rgba.Bounds())
+	edgeDetect(rgba, o)
+	skinDetect(&s, rgba, o)
+	saturationDetect(&s, rgba, o)
+
+	r := newRect(image.Rect(40, 10, 140, 110))
+	before := rectScore(&s, o, nil, 1, r)
+	refined, after := refine(&s, o, nil, 1, r, newRect(o.Bounds()), 90, 100)
+
+	if after.Total < before.Total {
+		t.Fatalf("expected refined score %g to be at least %g", after.Total, before.Total)
+	}
+	if refined.x < 0 || refined.y < 0 || refined.x+refined.w > float64(o.Bounds().Dx()) || refined.y+refined.h > float64(o.Bounds().Dy()) ||
+		refined.w < 90 || refined.w > 100 {
+		t.Fatalf("refined crop %+v violates its constraints", refined)
+	}
+	if ratio := refined.h / refined.w; ratio < 0.999 || ratio > 1.001 {
+		t.Fatalf("expected refined crop to keep its ratio, got %f", ratio)
+	}
+}
+
+func TestScoreTable(t *testing.T) {
+	s := DefaultCropSettings()
+	s.ScoreDownSample = 1
+	o := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	for i := range o.Pix {
+		o.Pix[i] = uint8(i * 7)
+	}
+	table := newScoreTable(&s, o, nil, 1)
+
+	// the samples are spread over cells centered on them, so rectangles
+	// aligned to those cells sum them up exactly
+	want := 0.0
+	for y := 2; y < 8; y++ {
+		for x := 3; x < 11; x++ {
+			c := o.RGBAAt(x, y)
+			det := float64(c.G) / 255.0
+			want += det*s.DetailWeight +
+				float64(c.R)/255.0*(det+s.SkinBias)*s.SkinWeight +
+				float64(c.B)/255.0*(det+s.SaturationBias)*s.SaturationWeight
+		}
+	}
+	if got := table.sum(2.5, 1.5, 10.5, 7.5); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("expected sum %g, got %g", want, got)
+	}
+
+	// splitting a sample counts it in proportion
+	if got, half := table.sum(2.5, 1.5, 3, 2.5), table.sum(2.5, 1.5, 3.5, 2.5)/2.0; math.Abs(got-half) > 1e-9 {
+		t.Fatalf("expected half a sample to sum to %g, got %g", half, got)
+	}
+}
+
+func TestScoreTableTotal(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := DefaultCropSettings()
+	rgba := toRGBA(nfnt.NewDefaultResizer().Resize(img, 400, 0))
+	o := image.NewRGBA(rgba.Bounds())
+	edgeDetect(rgba, o)
+	skinDetect(&s, rgba, o)
+	saturationDetect(&s, rgba, o)
+	table := newScoreTable(&s, o, nil, 1)
+
+	for _, r := range []rect{{40, 10, 100, 100}, {120.5, 3.25, 110, 110}, {0, 0, 126, 126}} {
+		exact := rectScore(&s, o, nil, 1, r).Total
+		if got := table.total(r); math.Abs(got-exact) > 0.1*math.Abs(exact) {
+			t.Errorf("expected a score close to %g for %+v, got %g", exact, r, got)
+		}
+	}
+}
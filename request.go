@@ -0,0 +1,176 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"context"
+	"errors"
+	"image"
+	"math"
+
+	"github.com/muesli/smartcrop/icc"
+	"github.com/muesli/smartcrop/options"
+)
+
+// ErrNoResizer gets returned by FindCrop when the image needs to be prescaled,
+// but the Request lacks a Resizer.
+var ErrNoResizer = errors.New("Expect a Resizer to prescale the image")
+
+// Boost marks a region of the source image as important, e.g. a face found by
+// an external detector. Weight ranges from 0 to 1.
+type Boost struct {
+	image.Rectangle
+	Weight float64
+}
+
+// Request contains everything FindCrop needs to know about a crop.
+type Request struct {
+	// Width and Height are the requested dimensions of the crop.
+	Width, Height int
+
+	// Settings contains the weights of the detectors and constraints like
+	// MinScale, Margin or ExactRatio. It defaults to DefaultCropSettings.
+	Settings *CropSettings
+
+	// Boosts are regions of the source image to prefer.
+	Boosts []Boost
+
+	// Area restricts the crop to a region of interest of the source image,
+	// e.g. to leave out a sidebar already cropped away. The detectors still
+	// see the surrounding image. The whole image is used if Area is empty.
+	Area image.Rectangle
+
+	// ICCProfile is the ICC profile embedded in the source image, if any,
+	// e.g. as returned by icc.FromJPEG. The prescaled image gets converted
+	// from it to sRGB, which the detectors expect.
+	ICCProfile []byte
+
+	// Resizer is used for prescaling the image, e.g. nfnt.NewDefaultResizer().
+	// It is required unless Settings disables Prescale.
+	Resizer options.Resizer
+
+	// Logger defaults to a Logger discarding all output.
+	Logger Logger
+
+	// Context cancels the analysis. It fails with the error of Context once
+	// it's done, unless Partial is set and some candidates have been scored
+	// already: the best of them is returned with Result.Partial set instead,
+	// which is usually preferable to no thumbnail at all. The Overrides
+	// carried by Context get applied to Settings.
+	Context context.Context
+	Partial bool
+}
+
+// FindCrop is a stateless alternative to the Analyzer interface: it finds the
+// best crop of img for everything specified in req at once.
+func FindCrop(img image.Image, req Request) (Result, error) {
+	settings := DefaultCropSettings()
+	if req.Settings != nil {
+		settings = *req.Settings
+	}
+	if req.Context != nil {
+		settings = ApplyOverrides(req.Context, settings)
+	}
+	if settings.Prescale && req.Resizer == nil {
+		return Result{}, ErrNoResizer
+	}
+	if len(req.ICCProfile) > 0 {
+		profile, err := icc.Parse(req.ICCProfile)
+		if err != nil {
+			return Result{}, err
+		}
+		settings.Hooks = settings.Hooks.clone()
+		settings.Hooks.AddAfter(StagePrescale, func(stage Stage, st *State) error {
+			st.Prescaled = profile.Transform(st.Prescaled)
+			return nil
+		})
+	}
+	if !req.Area.Empty() {
+		area := req.Area
+		settings.Hooks = settings.Hooks.clone()
+		settings.Hooks.AddBefore(StagePrescale, func(stage Stage, st *State) error {
+			st.Area = area
+			return nil
+		})
+	}
+	if req.Context != nil {
+		// a partial result must not be discarded before the selection
+		ctx, partial := req.Context, req.Partial
+		last := StageSelect
+		if partial {
+			last = StageScore
+		}
+		settings.Hooks = settings.Hooks.clone()
+		for stage := StagePrescale; stage <= last; stage++ {
+			settings.Hooks.AddBefore(stage, func(stage Stage, st *State) error {
+				st.Context, st.Partial = ctx, partial
+				return ctx.Err()
+			})
+		}
+	}
+	if len(req.Boosts) > 0 {
+		boosts := req.Boosts
+		settings.Hooks = settings.Hooks.clone()
+		settings.Hooks.AddAfter(StageDetect, func(stage Stage, st *State) error {
+			st.Boost = applyBoosts(st, boosts)
+			return nil
+		})
+	}
+
+	analyzer := NewAnalyzerWithSettings(req.Resizer, req.Logger, settings)
+	return analyzer.(ResultAnalyzer).FindBestResult(img, req.Width, req.Height)
+}
+
+// applyBoosts returns a copy of the boost plane of st, with the boosts mapped
+// onto it.
+func applyBoosts(st *State, boosts []Boost) *image.Gray {
+	pb := st.Detected.Bounds()
+	plane := image.NewGray(pb)
+	if st.Boost != nil {
+		copy(plane.Pix, st.Boost.Pix)
+	}
+
+	origin := st.Source.Bounds().Min
+	f := st.PrescaleFactor
+	for _, b := range boosts {
+		r := b.Sub(origin)
+		r = reduceRect(image.Rect(
+			int(float64(r.Min.X)*f), int(float64(r.Min.Y)*f),
+			int(math.Ceil(float64(r.Max.X)*f)), int(math.Ceil(float64(r.Max.Y)*f)),
+		), st.Reduction).Add(pb.Min).Intersect(pb)
+
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				off := plane.PixOffset(x, y)
+				plane.Pix[off] = uint8(bounds(float64(plane.Pix[off]) + b.Weight*255.0))
+			}
+		}
+	}
+
+	return plane
+}
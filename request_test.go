@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"context"
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/icc"
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestFindCrop(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := FindCrop(img, Request{Width: 250, Height: 250, Resizer: nfnt.NewDefaultResizer()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := smartCrop(img, 250, 250)
+	if res.Crop.Rectangle != expected {
+		t.Fatalf("expected %v, got %v", expected, res.Crop.Rectangle)
+	}
+
+	left := image.Rect(0, 0, img.Bounds().Dx()/4, img.Bounds().Dy())
+	res, err = FindCrop(img, Request{
+		Resizer: nfnt.NewDefaultResizer(),
+		Width:   250,
+		Height:  250,
+		Boosts:  []Boost{{Rectangle: left, Weight: 1.0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Crop.Overlaps(left) {
+		t.Fatalf("expected crop to include the boosted region, got %v", res.Crop.Rectangle)
+	}
+
+	_, err = FindCrop(img, Request{Width: 250, Height: 250, ICCProfile: []byte("not a profile"), Resizer: nfnt.NewDefaultResizer()})
+	if err != icc.ErrInvalidProfile {
+		t.Fatalf("expected icc.ErrInvalidProfile, got %v", err)
+	}
+
+	_, err = FindCrop(img, Request{Width: 250, Height: 250})
+	if err != ErrNoResizer {
+		t.Fatalf("expected ErrNoResizer, got %v", err)
+	}
+	settings := DefaultCropSettings()
+	settings.Prescale = false
+	if _, err := FindCrop(img, Request{Width: 250, Height: 250, Settings: &settings}); err != nil {
+		t.Fatalf("expected no Resizer to be needed without prescaling, got %v", err)
+	}
+}
+
+func TestFindCropCancelled(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// cancel the context while the fifth candidate is being scored
+	find := func(partial bool) (Result, error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		settings := DefaultCropSettings()
+		settings.Refine = true
+		settings.Hooks.AddBefore(StageScore, func(stage Stage, st *State) error {
+			n := 0
+			st.Filter = func(Crop) bool {
+				if n++; n == 5 {
+					cancel()
+				}
+				return true
+			}
+			return nil
+		})
+		return FindCrop(img, Request{Width: 250, Height: 250, Settings: &settings, Context: ctx, Partial: partial, Resizer: nfnt.NewDefaultResizer()})
+	}
+
+	if _, err := find(false); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	res, err := find(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Partial || res.Crop.Dx() == 0 || res.Crop.Dx() != res.Crop.Dy() {
+		t.Fatalf("expected a partial square crop, got %v (partial %v)", res.Crop.Rectangle, res.Partial)
+	}
+
+	// nothing has been scored yet
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := FindCrop(img, Request{Width: 250, Height: 250, Context: ctx, Partial: true, Resizer: nfnt.NewDefaultResizer()}); err != context.Canceled {
+		t.Fatalf("expected context.Canceled before scoring, got %v", err)
+	}
+}
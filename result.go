@@ -0,0 +1,330 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"encoding/json"
+	"image"
+	"math"
+)
+
+// ResultSchemaVersion is the version of the JSON schema used by Result. It gets
+// bumped whenever a field is removed or changes its meaning.
+const ResultSchemaVersion = 1
+
+// Version is the version of the cropping algorithm. It changes whenever an
+// update may yield a different crop for the same input and parameters.
+const Version = "1.1.0"
+
+// ResultAnalyzer is implemented by Analyzers which can report the full Result
+// of an analysis instead of just the crop rectangle. The Analyzers returned by
+// NewAnalyzer, NewAnalyzerWithLogger and NewAnalyzerWithSettings implement it.
+type ResultAnalyzer interface {
+	Analyzer
+	FindBestResult(img image.Image, width, height int) (Result, error)
+}
+
+// NormalizedRect is a rectangle in coordinates relative to the dimensions of
+// the source image, ranging from 0 to 1. It is independent of the resolution
+// of the image the crop has been found on.
+type NormalizedRect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Rect maps n onto an image with the given bounds. The returned rectangle is
+// guaranteed to lie within bounds and keeps its center as close as possible to
+// the center of n. Its aspect ratio is kept as close to ratio as rounding to
+// whole pixels permits. If ratio is 0, the aspect ratio of n is used.
+func (n NormalizedRect) Rect(bounds image.Rectangle, ratio float64) image.Rectangle {
+	bw, bh := float64(bounds.Dx()), float64(bounds.Dy())
+	if bw <= 0 || bh <= 0 {
+		return image.Rectangle{Min: bounds.Min, Max: bounds.Min}
+	}
+	if ratio <= 0 || math.IsNaN(ratio) || math.IsInf(ratio, 0) {
+		ratio = (n.Width * bw) / (n.Height * bh)
+		if ratio <= 0 || math.IsNaN(ratio) || math.IsInf(ratio, 0) {
+			ratio = bw / bh
+		}
+	}
+
+	// derive the height from the width, so the ratio survives the rounding
+	w := math.Max(1.0, math.Round(n.Width*bw))
+	h := math.Max(1.0, math.Round(w/ratio))
+	if w > bw {
+		w = bw
+		h = math.Max(1.0, math.Round(w/ratio))
+	}
+	if h > bh {
+		h = bh
+		w = math.Min(bw, math.Max(1.0, math.Round(h*ratio)))
+	}
+
+	cx := (n.X + n.Width/2.0) * bw
+	cy := (n.Y + n.Height/2.0) * bh
+	x := math.Min(math.Max(math.Round(cx-w/2.0), 0.0), bw-w)
+	y := math.Min(math.Max(math.Round(cy-h/2.0), 0.0), bh-h)
+
+	return image.Rect(int(x), int(y), int(x+w), int(y+h)).Add(bounds.Min)
+}
+
+// normalize returns r relative to an image with the given bounds.
+func normalize(r, bounds image.Rectangle) NormalizedRect {
+	bw, bh := float64(bounds.Dx()), float64(bounds.Dy())
+	return NormalizedRect{
+		X:      float64(r.Min.X-bounds.Min.X) / bw,
+		Y:      float64(r.Min.Y-bounds.Min.Y) / bh,
+		Width:  float64(r.Dx()) / bw,
+		Height: float64(r.Dy()) / bh,
+	}
+}
+
+// expand grows n by margin times its dimensions on each side, keeping its
+// center and aspect ratio. It gets shifted and, if necessary, shrunk to stay
+// within the image.
+func (n NormalizedRect) expand(margin float64) NormalizedRect {
+	return n.expandWithin(margin, NormalizedRect{Width: 1.0, Height: 1.0})
+}
+
+// expandWithin is like expand, but keeps n within bounds instead of the image.
+func (n NormalizedRect) expandWithin(margin float64, bounds NormalizedRect) NormalizedRect {
+	w := n.Width * (1.0 + 2.0*margin)
+	h := n.Height * (1.0 + 2.0*margin)
+	if f := math.Min(bounds.Width/w, bounds.Height/h); f < 1.0 {
+		w *= f
+		h *= f
+	}
+
+	cx := n.X + n.Width/2.0
+	cy := n.Y + n.Height/2.0
+	return NormalizedRect{
+		X:      math.Min(math.Max(cx-w/2.0, bounds.X), bounds.X+bounds.Width-w),
+		Y:      math.Min(math.Max(cy-h/2.0, bounds.Y), bounds.Y+bounds.Height-h),
+		Width:  w,
+		Height: h,
+	}
+}
+
+// snapRatio adjusts r to have exactly the aspect ratio width:height, keeping
+// its center and staying within bounds. r is returned unchanged if that ratio
+// can't be achieved within bounds.
+func snapRatio(r, bounds image.Rectangle, width, height int) image.Rectangle {
+	if width <= 0 || height <= 0 {
+		return r
+	}
+
+	g := gcd(width, height)
+	a, b := width/g, height/g
+	k := int(math.Round(float64(r.Dx()) / float64(a)))
+	if k*a > bounds.Dx() {
+		k = bounds.Dx() / a
+	}
+	if k*b > bounds.Dy() {
+		k = bounds.Dy() / b
+	}
+	if k < 1 {
+		return r
+	}
+
+	w, h := k*a, k*b
+	c := r.Min.Add(r.Max).Div(2)
+	x := minInt(maxInt(c.X-w/2, bounds.Min.X), bounds.Max.X-w)
+	y := minInt(maxInt(c.Y-h/2, bounds.Min.Y), bounds.Max.Y-h)
+	return image.Rect(x, y, x+w, y+h)
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// FloatRect is a rectangle in fractional pixel coordinates of the source image.
+type FloatRect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// FocalPoint is the center of a crop, relative to the dimensions of the
+// source image.
+type FocalPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Result contains the outcome of an analysis in a form suitable for storing it
+// alongside the image it belongs to. ParamsHash is the Fingerprint of the
+// settings the crop was found with.
+//
+// Crop contains the best crop rounded to whole pixels, whereas FloatCrop and
+// Normalized describe the same crop without any rounding applied.
+type Result struct {
+	SchemaVersion   int            `json:"schemaVersion"`
+	AnalyzerVersion string         `json:"analyzerVersion"`
+	ParamsHash      string         `json:"paramsHash"`
+	ImageWidth      int            `json:"imageWidth"`
+	ImageHeight     int            `json:"imageHeight"`
+	Crop            Crop           `json:"crop"`
+	FloatCrop       FloatRect      `json:"floatCrop"`
+	Normalized      NormalizedRect `json:"normalized"`
+	FocalPoint      FocalPoint     `json:"focalPoint"`
+
+	// SubjectCut warns that the crop cuts through a salient region, e.g.
+	// because the subject doesn't fit into any crop of the requested aspect
+	// ratio. Such images may need a human review.
+	SubjectCut bool `json:"subjectCut,omitempty"`
+
+	// AttentionPoints are the centroids of the most salient regions of the
+	// image, heaviest first, as requested by CropSettings.AttentionPoints.
+	AttentionPoints []AttentionPoint `json:"attentionPoints,omitempty"`
+
+	// Degradation is the level the analysis has been coarsened to, to finish
+	// within CropSettings.Budget, ranging from 0 for none to 5.
+	Degradation int `json:"degradation,omitempty"`
+
+	// Strategy is the name of the CropStrategy the crop was found with, and
+	// Confidence how confident it was about the crop, ranging from 0 to 1.
+	Strategy   string  `json:"strategy,omitempty"`
+	Confidence float64 `json:"confidence"`
+
+	// Reused reports that the crop has been taken over from a near-identical
+	// image by a BurstCache instead of analysing the image.
+	Reused bool `json:"reused,omitempty"`
+
+	// Seed is the seed the random candidates have been drawn with, if
+	// CropSettings.Samples is enabled, to reproduce the crop with.
+	Seed int64 `json:"seed,omitempty"`
+
+	// Ratio is the aspect ratio of the crop, which may deviate from the
+	// requested one if CropSettings.RatioTolerance is enabled.
+	Ratio float64 `json:"ratio,omitempty"`
+
+	// Truncated reports that the analysis has been cut short after
+	// CropSettings.MaxDuration, so the crop is the best of the candidates
+	// scored until then.
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Partial reports that the analysis has been cancelled while scoring the
+	// candidates, so the crop is the best of the candidates scored until
+	// then, see Request.Partial.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// clone returns a copy of res which doesn't share its AttentionPoints.
+func (res Result) clone() Result {
+	if res.AttentionPoints != nil {
+		res.AttentionPoints = append([]AttentionPoint(nil), res.AttentionPoints...)
+	}
+	return res
+}
+
+// jsonCrop is the JSON representation of a Crop.
+type jsonCrop struct {
+	X      int   `json:"x"`
+	Y      int   `json:"y"`
+	Width  int   `json:"width"`
+	Height int   `json:"height"`
+	Score  Score `json:"score"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c Crop) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonCrop{
+		X:      c.Min.X,
+		Y:      c.Min.Y,
+		Width:  c.Dx(),
+		Height: c.Dy(),
+		Score:  c.Score,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Crop) UnmarshalJSON(data []byte) error {
+	var jc jsonCrop
+	if err := json.Unmarshal(data, &jc); err != nil {
+		return err
+	}
+
+	c.Rectangle = image.Rect(jc.X, jc.Y, jc.X+jc.Width, jc.Y+jc.Height)
+	c.Score = jc.Score
+	return nil
+}
+
+// newResult returns the Result for crop, which has been found on an image with
+// the given bounds using the given settings. norm is the crop in normalized
+// coordinates, before it got rounded.
+func newResult(crop Crop, norm NormalizedRect, bounds image.Rectangle, settings CropSettings) Result {
+	w := float64(bounds.Dx())
+	h := float64(bounds.Dy())
+
+	return Result{
+		SchemaVersion:   ResultSchemaVersion,
+		AnalyzerVersion: Version,
+		ParamsHash:      settings.Fingerprint(),
+		ImageWidth:      bounds.Dx(),
+		ImageHeight:     bounds.Dy(),
+		Crop:            crop,
+		FloatCrop: FloatRect{
+			X:      float64(bounds.Min.X) + norm.X*w,
+			Y:      float64(bounds.Min.Y) + norm.Y*h,
+			Width:  norm.Width * w,
+			Height: norm.Height * h,
+		},
+		Normalized: norm,
+		FocalPoint: FocalPoint{
+			X: norm.X + norm.Width/2.0,
+			Y: norm.Y + norm.Height/2.0,
+		},
+	}
+}
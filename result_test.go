@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"encoding/json"
+	"image"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestResultJSON(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer := NewAnalyzer(nfnt.NewDefaultResizer()).(ResultAnalyzer)
+	res, err := analyzer.FindBestResult(img, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.SchemaVersion != ResultSchemaVersion || res.AnalyzerVersion != Version || res.ParamsHash == "" {
+		t.Fatalf("unexpected result header: %+v", res)
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dec Result
+	if err := json.Unmarshal(b, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dec, res) {
+		t.Fatalf("expected %+v, got %+v", res, dec)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := DefaultCropSettings()
+	b := DefaultCropSettings()
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Fatal("expected identical settings to have the same fingerprint")
+	}
+
+	b.SkinWeight += 0.1
+	if a.Fingerprint() == b.Fingerprint() {
+		t.Fatal("expected different settings to have different fingerprints")
+	}
+
+	// callbacks aren't part of it, and the detectors are in a stable order
+	b = DefaultCropSettings()
+	b.OnResult = func(Decision) {}
+	b.Detectors = map[string]float64{}
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Fatal("expected callbacks and empty detectors not to change the fingerprint")
+	}
+	a.Detectors = map[string]float64{"faces": 1, "text": 0.5, "saliency": 2}
+	for i := 0; i < 10; i++ {
+		b.Detectors = map[string]float64{"saliency": 2, "text": 0.5, "faces": 1}
+		if a.Fingerprint() != b.Fingerprint() {
+			t.Fatal("expected equal detectors to have the same fingerprint")
+		}
+	}
+
+	// settings Validate rejects get fingerprints too, as servers key their
+	// caches by them before validating
+	seen := map[string]bool{a.Fingerprint(): true}
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		for _, modify := range []func(*CropSettings){
+			func(s *CropSettings) { s.BlobPenalty = v },
+			func(s *CropSettings) { s.Detectors = map[string]float64{"faces": v} },
+			func(s *CropSettings) { s.TextZone = &NormalizedRect{Width: v} },
+		} {
+			s := DefaultCropSettings()
+			modify(&s)
+			fp := s.Fingerprint()
+			if seen[fp] {
+				t.Fatalf("expected a distinct fingerprint for %v, got %s again", v, fp)
+			}
+			seen[fp] = true
+		}
+	}
+}
+
+func TestNormalizedRect(t *testing.T) {
+	bounds := image.Rect(10, 20, 1034, 789)
+	for _, n := range []NormalizedRect{
+		{0, 0, 1, 1},
+		{0.1, 0.2, 0.3, 0.4},
+		{0.71, 0.5, 0.29, 0.5},
+		{0.9, 0.9, 0.2, 0.2},
+	} {
+		for _, ratio := range []float64{0, 1, 16.0 / 9.0, 3.0 / 4.0, 2.35} {
+			r := n.Rect(bounds, ratio)
+			if !r.In(bounds) || r.Empty() {
+				t.Fatalf("%+v at ratio %f: %v is not within %v", n, ratio, r, bounds)
+			}
+			if ratio > 0 && r.Dx() < bounds.Dx() && r.Dy() < bounds.Dy() {
+				if d := math.Abs(float64(r.Dx())/ratio - float64(r.Dy())); d > 0.5 {
+					t.Fatalf("%+v at ratio %f: %v is off by %f pixels", n, ratio, r, d)
+				}
+			}
+
+			// mapping the rectangle again must yield the same rectangle
+			rn := NormalizedRect{
+				X:      float64(r.Min.X-bounds.Min.X) / float64(bounds.Dx()),
+				Y:      float64(r.Min.Y-bounds.Min.Y) / float64(bounds.Dy()),
+				Width:  float64(r.Dx()) / float64(bounds.Dx()),
+				Height: float64(r.Dy()) / float64(bounds.Dy()),
+			}
+			if rr := rn.Rect(bounds, ratio); rr != r {
+				t.Fatalf("%+v at ratio %f: expected %v to round-trip, got %v", n, ratio, r, rr)
+			}
+		}
+	}
+}
+
+func TestSnapRatio(t *testing.T) {
+	bounds := image.Rect(0, 0, 1000, 700)
+	for _, tc := range []struct {
+		r             image.Rectangle
+		width, height int
+	}{
+		{image.Rect(100, 100, 501, 327), 16, 9},
+		{image.Rect(0, 0, 999, 700), 4, 3},
+		{image.Rect(950, 650, 1000, 700), 250, 250},
+		{image.Rect(10, 10, 300, 400), 1920, 1080},
+	} {
+		r := snapRatio(tc.r, bounds, tc.width, tc.height)
+		if !r.In(bounds) {
+			t.Fatalf("%v is not within %v", r, bounds)
+		}
+		if r.Dx()*tc.height != r.Dy()*tc.width {
+			t.Fatalf("expected %v to have a ratio of %d:%d", r, tc.width, tc.height)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	for _, tc := range []struct {
+		n        NormalizedRect
+		margin   float64
+		expected NormalizedRect
+	}{
+		{NormalizedRect{0.4, 0.4, 0.2, 0.2}, 0.5, NormalizedRect{0.3, 0.3, 0.4, 0.4}},
+		{NormalizedRect{0, 0, 0.5, 0.25}, 0.5, NormalizedRect{0, 0, 1, 0.5}},
+		{NormalizedRect{0, 0, 0.8, 0.4}, 0.5, NormalizedRect{0, 0, 1, 0.5}},
+	} {
+		n := tc.n.expand(tc.margin)
+		if math.Abs(n.X-tc.expected.X) > 1e-9 || math.Abs(n.Y-tc.expected.Y) > 1e-9 ||
+			math.Abs(n.Width-tc.expected.Width) > 1e-9 || math.Abs(n.Height-tc.expected.Height) > 1e-9 {
+			t.Fatalf("expected %+v, got %+v", tc.expected, n)
+		}
+	}
+}
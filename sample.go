@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math/rand"
+)
+
+// sampleCrops calls fn for s.Samples random crop candidates within an image
+// of the given dimensions, at scales between realMinScale and s.MaxScale.
+// It stops as soon as fn returns false.
+func sampleCrops(s *CropSettings, width, height int, cropW, cropH, realMinScale float64, origin image.Point, fn func(Crop) bool) {
+	rng := rand.New(rand.NewSource(s.Seed))
+	for n := 0; n < s.Samples; n++ {
+		scale := realMinScale + rng.Float64()*(s.MaxScale-realMinScale)
+		w, h := int(cropW*scale), int(cropH*scale)
+		if w <= 0 || h <= 0 || w > width || h > height {
+			continue
+		}
+
+		x, y := rng.Intn(width-w+1), rng.Intn(height-h+1)
+		crop := Crop{
+			Rectangle: image.Rect(x, y, x+w, y+h).Add(origin),
+		}
+		if !fn(crop) {
+			return
+		}
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestSampleCrops(t *testing.T) {
+	s := DefaultCropSettings()
+	s.Samples = 100
+	s.Seed = 7
+
+	var first []image.Rectangle
+	crops(&s, image.Rect(10, 20, 410, 320), 200, 200, 0.5, func(crop Crop) bool {
+		if !crop.In(image.Rect(10, 20, 410, 320)) {
+			t.Fatalf("expected the candidates within the image, got %v", crop.Rectangle)
+		}
+		if crop.Dx() != crop.Dy() || crop.Dx() < 100 || crop.Dx() > 200 {
+			t.Fatalf("expected square candidates between the scales, got %v", crop.Rectangle)
+		}
+		first = append(first, crop.Rectangle)
+		return true
+	})
+	if len(first) != s.Samples {
+		t.Fatalf("expected %d candidates, got %d", s.Samples, len(first))
+	}
+
+	i := 0
+	crops(&s, image.Rect(10, 20, 410, 320), 200, 200, 0.5, func(crop Crop) bool {
+		if crop.Rectangle != first[i] {
+			t.Fatalf("expected the same candidates for the same seed, got %v instead of %v", crop.Rectangle, first[i])
+		}
+		i++
+		return true
+	})
+}
+
+func TestSampledResult(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := DefaultCropSettings()
+	s.Samples = 200
+	s.Seed = 42
+	var candidates int
+	s.Hooks.AddAfter(StageScore, func(stage Stage, st *State) error {
+		candidates = st.Candidates
+		return nil
+	})
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, s).(ResultAnalyzer)
+
+	res, err := analyzer.FindBestResult(img, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if candidates != s.Samples {
+		t.Fatalf("expected %d candidates, got %d", s.Samples, candidates)
+	}
+	if res.Seed != s.Seed {
+		t.Fatalf("expected the seed %d in the result, got %d", s.Seed, res.Seed)
+	}
+
+	again, err := analyzer.FindBestResult(img, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Crop != res.Crop {
+		t.Fatalf("expected the same crop for the same seed, got %v and %v", res.Crop, again.Crop)
+	}
+}
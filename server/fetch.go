@@ -0,0 +1,209 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrSchemeNotAllowed gets returned for URLs with a scheme not allowed by
+	// the FetchOptions, also when redirected to.
+	ErrSchemeNotAllowed = errors.New("URL scheme not allowed")
+	// ErrAddressNotAllowed gets returned for hosts resolving to an address
+	// which isn't public, unless the FetchOptions allow them.
+	ErrAddressNotAllowed = errors.New("Address not allowed")
+)
+
+// FetchOptions configures fetching images by their URL.
+type FetchOptions struct {
+	// Schemes are the URL schemes allowed, defaulting to https and http.
+	Schemes []string
+	// AllowPrivate allows fetching from loopback, private, link-local and
+	// other addresses which aren't publicly routable. It should only be
+	// enabled if the service can't reach anything worth protecting.
+	AllowPrivate bool
+	// Timeout bounds the time a fetch takes, defaulting to 10 seconds.
+	Timeout time.Duration
+}
+
+// nonPublic are the networks which aren't publicly routable, next to the
+// ones the methods of net.IP report.
+var nonPublic = parseCIDRs(
+	"0.0.0.0/8",       // this network
+	"100.64.0.0/10",   // carrier-grade NAT
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // documentation
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // documentation
+	"203.0.113.0/24",  // documentation
+	"240.0.0.0/4",     // reserved, and broadcast
+	"64:ff9b::/96",    // NAT64, which may map onto private IPv4 addresses
+	"64:ff9b:1::/48",  // local NAT64
+	"2001:db8::/32",   // documentation
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
+// public reports whether ip is publicly routable.
+func public(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, n := range nonPublic {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// fetcher fetches images, guarding against server-side request forgery.
+type fetcher struct {
+	opts     FetchOptions
+	maxBytes int64
+	client   *http.Client
+}
+
+// newFetcher returns a fetcher for the given FetchOptions, reading images up
+// to maxBytes.
+func newFetcher(opts FetchOptions, maxBytes int64) *fetcher {
+	if len(opts.Schemes) == 0 {
+		opts.Schemes = []string{"https", "http"}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	f := &fetcher{opts: opts, maxBytes: maxBytes}
+	// the address gets checked when connecting, after resolving the host, so
+	// it can't be swapped for another one by a second DNS lookup
+	dialer := &net.Dialer{Timeout: opts.Timeout, Control: f.control}
+	f.client = &http.Client{
+		// no proxy, which would be connected to instead of the host
+		Transport: &http.Transport{
+			DialContext:            dialer.DialContext,
+			TLSHandshakeTimeout:    opts.Timeout,
+			ResponseHeaderTimeout:  opts.Timeout,
+			MaxResponseHeaderBytes: 1 << 20,
+		},
+		Timeout:       opts.Timeout,
+		CheckRedirect: f.checkRedirect,
+	}
+	return f
+}
+
+// control rejects connections to addresses which aren't allowed.
+func (f *fetcher) control(network, address string, c syscall.RawConn) error {
+	if f.opts.AllowPrivate {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !public(ip) {
+		return ErrAddressNotAllowed
+	}
+	return nil
+}
+
+// checkScheme returns ErrSchemeNotAllowed if the scheme of u isn't allowed.
+func (f *fetcher) checkScheme(u *url.URL) error {
+	for _, scheme := range f.opts.Schemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return nil
+		}
+	}
+	return ErrSchemeNotAllowed
+}
+
+func (f *fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 5 {
+		return errors.New("too many redirects")
+	}
+	return f.checkScheme(req.URL)
+}
+
+// fetch returns the image at rawurl. On failure, it returns the HTTP status
+// code to respond with.
+func (f *fetcher) fetch(ctx context.Context, rawurl string) ([]byte, int, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid url: %v", err)
+	}
+	if err := f.checkScheme(u); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid url: %v", err)
+	}
+	resp, err := f.client.Do(req)
+	if errors.Is(err, ErrSchemeNotAllowed) || errors.Is(err, ErrAddressNotAllowed) {
+		return nil, http.StatusBadRequest, fmt.Errorf("can't fetch image: %v", err)
+	}
+	if err != nil {
+		return nil, http.StatusBadGateway, fmt.Errorf("can't fetch image: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, http.StatusBadGateway, fmt.Errorf("can't fetch image: %s", resp.Status)
+	}
+
+	buf, err := ioutil.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return nil, http.StatusBadGateway, fmt.Errorf("can't fetch image: %v", err)
+	}
+	if int64(len(buf)) > f.maxBytes {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("can't fetch image: larger than %d bytes", f.maxBytes)
+	}
+	return buf, http.StatusOK, nil
+}
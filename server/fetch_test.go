@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPublic(t *testing.T) {
+	for addr, expected := range map[string]bool{
+		"8.8.8.8":         true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"255.255.255.255": false,
+		"::1":             false,
+		"fe80::1":         false,
+		"fc00::1":         false,
+		"::ffff:10.0.0.1": false,
+		"64:ff9b::a00:1":  false,
+	} {
+		if public(net.ParseIP(addr)) != expected {
+			t.Errorf("expected %s to be public: %v", addr, expected)
+		}
+	}
+}
+
+// serveImage serves the test image, and redirects /redirect to the location
+// given by its query.
+func serveImage(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+			return
+		}
+		http.ServeFile(w, r, testFile)
+	}))
+}
+
+func cropURL(u string) string {
+	return "/crop?width=250&height=250&url=" + url.QueryEscape(u)
+}
+
+func TestFetch(t *testing.T) {
+	ts := serveImage(t)
+	defer ts.Close()
+
+	s := New(Options{Fetch: &FetchOptions{AllowPrivate: true}})
+	if w := post(t, s, cropURL(ts.URL+"/gopher.jpg")); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body)
+	}
+
+	// the imaginary API fetches with GET
+	s = New(Options{Fetch: &FetchOptions{AllowPrivate: true}, Imaginary: true})
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/smartcrop?width=100&height=100&url="+url.QueryEscape(ts.URL), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 from the imaginary API, got %d: %s", w.Code, w.Body)
+	}
+
+	// without the fetch option, images must be posted
+	if w := post(t, New(Options{}), cropURL(ts.URL)); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without fetching, got %d", w.Code)
+	}
+}
+
+func TestFetchForgery(t *testing.T) {
+	ts := serveImage(t)
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(ts.URL, "http://"))
+
+	s := New(Options{Fetch: &FetchOptions{}})
+	for _, u := range []string{
+		// the test server is listening on the loopback interface
+		ts.URL,
+		// which a host name resolving to it doesn't get past
+		"http://localhost:" + port,
+		"http://[::ffff:127.0.0.1]:" + port,
+		"file:///etc/passwd",
+		"gopher://localhost:" + port,
+	} {
+		w := post(t, s, cropURL(u))
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "not allowed") {
+			t.Errorf("expected status 400 fetching %s, got %d: %s", u, w.Code, w.Body)
+		}
+	}
+
+	// redirects must stay within the allowed schemes
+	s = New(Options{Fetch: &FetchOptions{AllowPrivate: true}})
+	w := post(t, s, cropURL(ts.URL+"/redirect?to="+url.QueryEscape("ftp://example.com/gopher.jpg")))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), ErrSchemeNotAllowed.Error()) {
+		t.Errorf("expected status 400 redirecting to another scheme, got %d: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/muesli/smartcrop"
+)
+
+// selfTestImage is the PNG the health checks run through the pipeline: 32x24
+// pixels of a gradient with a skin-toned disc right of the centre.
+var selfTestImage = []byte{
+	0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d, 0x49, 0x48, 0x44, 0x52,
+	0x00, 0x00, 0x00, 0x20, 0x00, 0x00, 0x00, 0x18, 0x08, 0x02, 0x00, 0x00, 0x00, 0x14, 0x31, 0x68,
+	0x63, 0x00, 0x00, 0x00, 0x7d, 0x49, 0x44, 0x41, 0x54, 0x78, 0xda, 0x62, 0xd1, 0xb0, 0xe9, 0x61,
+	0x62, 0x60, 0xa0, 0x1d, 0x62, 0x61, 0x60, 0x66, 0xa0, 0x29, 0x18, 0xb5, 0x60, 0x28, 0x5b, 0xd0,
+	0x17, 0xf4, 0x0d, 0x53, 0xb0, 0x61, 0x1d, 0x17, 0x9a, 0x08, 0x13, 0x03, 0x33, 0x03, 0x19, 0x68,
+	0x02, 0x36, 0xd3, 0x19, 0x18, 0x18, 0x1a, 0x82, 0xbe, 0xa1, 0xa9, 0x24, 0xc7, 0x82, 0x49, 0xfe,
+	0xdf, 0xf0, 0xf8, 0xac, 0xce, 0x1f, 0xc5, 0x0e, 0xda, 0xc4, 0x01, 0x33, 0xad, 0x23, 0x79, 0xd4,
+	0x82, 0x41, 0x65, 0x01, 0x39, 0xc9, 0x34, 0xef, 0x24, 0x17, 0x1e, 0xc3, 0x9b, 0x4e, 0x72, 0x51,
+	0x9a, 0x0f, 0x18, 0x98, 0x19, 0x0a, 0xce, 0x60, 0xb7, 0xa3, 0xe1, 0x0c, 0x17, 0x9a, 0x4a, 0xf2,
+	0x83, 0xa8, 0xe8, 0x3c, 0x17, 0x96, 0x1a, 0x86, 0x79, 0xb4, 0x34, 0x1d, 0xb5, 0x80, 0xfa, 0x00,
+	0x30, 0x00, 0x54, 0x28, 0x13, 0xb0, 0x55, 0x6e, 0xc6, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x49, 0x45,
+	0x4e, 0x44, 0xae, 0x42, 0x60, 0x82,
+}
+
+// health is the response of the health checks.
+type health struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// handleHealthz serves /healthz, reporting whether the pipeline works with
+// the default settings of the Server.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, s.selfTest(r, s.defaultSettings()))
+}
+
+// handleReadyz serves /readyz, reporting whether the Server is ready to take
+// requests: the detectors and strategies named by the settings of
+// every profile are registered, the pipeline works with them, the limits
+// allow the self-test image, and the AuditLog, if any, can be written.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, s.ready(r))
+}
+
+// ready runs the checks of /readyz.
+func (s *Server) ready(r *http.Request) error {
+	if int64(len(selfTestImage)) > s.opts.MaxImageSize {
+		return fmt.Errorf("MaxImageSize of %d bytes rejects any image", s.opts.MaxImageSize)
+	}
+	if s.opts.AuditLog != nil {
+		if err := s.opts.AuditLog.Err(); err != nil {
+			return fmt.Errorf("can't write audit log: %v", err)
+		}
+	}
+	if err := registered(s.defaultSettings()); err != nil {
+		return err
+	}
+	if err := s.selfTest(r, s.defaultSettings()); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(s.opts.Profiles))
+	for name := range s.opts.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := registered(s.opts.Profiles[name]); err != nil {
+			return fmt.Errorf("profile %q: %v", name, err)
+		}
+		if err := s.selfTest(r, s.opts.Profiles[name]); err != nil {
+			return fmt.Errorf("profile %q: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// registered checks that the Detectors and the strategies named by settings
+// are registered. The self-test alone doesn't catch strategies it doesn't get
+// to.
+func registered(settings smartcrop.CropSettings) error {
+	for _, name := range sortedKeys(settings.Detectors) {
+		if !contains(smartcrop.Detectors(), name) {
+			return fmt.Errorf("detector %q isn't registered", name)
+		}
+	}
+	for _, name := range append(append([]string(nil), settings.Strategies...), sortedKeys(settings.Ensemble)...) {
+		if !contains(smartcrop.Strategies(), name) {
+			return fmt.Errorf("strategy %q isn't registered", name)
+		}
+	}
+	return nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// selfTest decodes the self-test image and finds its best crop with
+// settings, like for a request. The callbacks of the settings are left out,
+// so the probes don't show up as crop decisions, e.g. in an AuditLog.
+func (s *Server) selfTest(r *http.Request, settings smartcrop.CropSettings) error {
+	settings.OnResult, settings.OnTrace = nil, nil
+	img, _, err := smartcrop.Decode(bytes.NewReader(selfTestImage))
+	if err != nil {
+		return fmt.Errorf("can't decode the self-test image: %v", err)
+	}
+	res, err := smartcrop.FindCrop(img, smartcrop.Request{
+		Width:    16,
+		Height:   16,
+		Settings: &settings,
+		Resizer:  s.opts.Resizer,
+		Logger:   s.opts.Logger,
+		Context:  r.Context(),
+	})
+	if err != nil {
+		return fmt.Errorf("self-test failed: %v", err)
+	}
+	if res.Crop.Empty() || !res.Crop.In(img.Bounds()) {
+		return fmt.Errorf("self-test failed: invalid crop %v", res.Crop.Rectangle)
+	}
+	return nil
+}
+
+func writeHealth(w http.ResponseWriter, err error) {
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		writeJSON(w, health{Status: "failing", Error: err.Error()})
+		return
+	}
+	writeJSON(w, health{Status: "ok"})
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/muesli/smartcrop"
+)
+
+func TestHealth(t *testing.T) {
+	check := func(s http.Handler, url string, code int, contains string) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		var h health
+		if err := json.NewDecoder(w.Body).Decode(&h); err != nil {
+			t.Fatalf("%s: %v", url, err)
+		}
+		if w.Code != code || !strings.Contains(h.Status+" "+h.Error, contains) {
+			t.Errorf("%s: expected status %d with %q, got %d: %+v", url, code, contains, w.Code, h)
+		}
+	}
+
+	s := New(Options{})
+	check(s, "/healthz", http.StatusOK, "ok")
+	check(s, "/readyz", http.StatusOK, "ok")
+
+	// a profile using a detector which isn't registered
+	broken := smartcrop.DefaultCropSettings()
+	broken.Detectors = map[string]float64{"missing": 1}
+	s = New(Options{Profiles: map[string]smartcrop.CropSettings{"broken": broken}, Imaginary: true})
+	check(s, "/healthz", http.StatusOK, "ok")
+	check(s, "/readyz", http.StatusServiceUnavailable, `profile "broken": detector "missing" isn't registered`)
+
+	// strategies it wouldn't get to
+	for _, modify := range []func(*smartcrop.CropSettings){
+		func(s *smartcrop.CropSettings) { s.Strategies = []string{smartcrop.StrategySmart, "missing"} },
+		func(s *smartcrop.CropSettings) {
+			s.Ensemble = map[string]float64{smartcrop.StrategyCenter: 1, "missing": 1}
+		},
+	} {
+		settings := smartcrop.DefaultCropSettings()
+		modify(&settings)
+		s = New(Options{Profiles: map[string]smartcrop.CropSettings{"broken": settings}})
+		check(s, "/readyz", http.StatusServiceUnavailable, `"missing" isn't registered`)
+	}
+	settings := smartcrop.DefaultCropSettings()
+	settings.Strategies = []string{"missing"}
+	check(New(Options{Settings: &settings}), "/readyz", http.StatusServiceUnavailable, `strategy "missing"`)
+
+	s = New(Options{MaxImageSize: 100})
+	check(s, "/readyz", http.StatusServiceUnavailable, "MaxImageSize")
+}
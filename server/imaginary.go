@@ -0,0 +1,405 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/muesli/smartcrop"
+)
+
+// defaultImaginaryQuality is the JPEG quality of the images returned by the
+// imaginary API, unless requested otherwise.
+const defaultImaginaryQuality = 80
+
+// imaginaryParams are the parameters of an operation of the imaginary API.
+type imaginaryParams struct {
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Gravity string `json:"gravity"`
+	Quality int    `json:"quality"`
+	Type    string `json:"type"`
+}
+
+// imaginaryOperation is an operation of a pipeline of the imaginary API.
+type imaginaryOperation struct {
+	Operation string          `json:"operation"`
+	Params    imaginaryParams `json:"params"`
+}
+
+// imaginaryError is the body of the error responses of the imaginary API.
+type imaginaryError struct {
+	Message string `json:"message"`
+	Status  int    `json:"status"`
+}
+
+// handleImaginary serves /smartcrop and /crop of the imaginary API, which
+// return the cropped image. gravity is the gravity of the crop, or empty to
+// take it from the query, defaulting to the centre.
+func (s *Server) handleImaginary(gravity string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.serveImaginary(w, r, func(q imaginaryParams) ([]imaginaryOperation, error) {
+			if gravity != "" {
+				q.Gravity = gravity
+			}
+			return []imaginaryOperation{{Operation: "crop", Params: q}}, nil
+		})
+	}
+}
+
+// handlePipeline serves /pipeline of the imaginary API, which applies the
+// operations given as JSON by the operations query parameter in order. The
+// smartcrop, crop and resize operations are supported.
+func (s *Server) handlePipeline(w http.ResponseWriter, r *http.Request) {
+	s.serveImaginary(w, r, func(imaginaryParams) ([]imaginaryOperation, error) {
+		var ops []imaginaryOperation
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("operations")), &ops); err != nil {
+			return nil, fmt.Errorf("invalid operations: %v", err)
+		}
+		if len(ops) == 0 {
+			return nil, fmt.Errorf("missing operations")
+		}
+		return ops, nil
+	})
+}
+
+// serveImaginary serves a request of the imaginary API, running the
+// operations returned by parse for the parameters of the query.
+func (s *Server) serveImaginary(w http.ResponseWriter, r *http.Request, parse func(imaginaryParams) ([]imaginaryOperation, error)) {
+	now := time.Now()
+	buf, contentType, status, err := s.imaginary(r, parse)
+	if status == http.StatusTooManyRequests {
+		s.metrics.rejected.Add(1)
+		w.Header().Set("Retry-After", "1")
+		writeImaginaryError(w, status, err)
+		return
+	}
+	s.metrics.observe(time.Since(now), err != nil)
+	if err != nil {
+		writeImaginaryError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(buf)))
+	w.Write(buf)
+}
+
+// imaginary runs the operations of the request r of the imaginary API and
+// returns the encoded image along with its content type. On failure, it
+// returns the HTTP status code to respond with, http.StatusTooManyRequests if
+// the server is overloaded.
+func (s *Server) imaginary(r *http.Request, parse func(imaginaryParams) ([]imaginaryOperation, error)) ([]byte, string, int, error) {
+	u := r.URL.Query().Get("url")
+	if r.Method != http.MethodPost && (r.Method != http.MethodGet || u == "") {
+		return nil, "", http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method)
+	}
+	if r.URL.Query().Get("file") != "" {
+		return nil, "", http.StatusBadRequest, fmt.Errorf("the file parameter isn't supported")
+	}
+	q, err := imaginaryQuery(r)
+	if err != nil {
+		return nil, "", http.StatusBadRequest, err
+	}
+	ops, err := parse(q)
+	if err != nil {
+		return nil, "", http.StatusBadRequest, err
+	}
+
+	var buf []byte
+	if u != "" {
+		var status int
+		if buf, status, err = s.fetch(r, u); err != nil {
+			return nil, "", status, err
+		}
+	} else if buf, err = s.readImaginaryImage(r); err != nil {
+		return nil, "", http.StatusRequestEntityTooLarge, fmt.Errorf("can't read image: %v", err)
+	}
+
+	// a smart crop of the posted image gets found like /crop finds it, from
+	// the cache or by an analysis taking a slot of its own, before the slot
+	// for decoding and scaling the image gets taken
+	var smart image.Rectangle
+	if first := ops[0]; first.smart() {
+		if err := first.Params.validate(*s.opts.Limits); err != nil {
+			return nil, "", http.StatusBadRequest, err
+		}
+		res, status, err := s.findCrop(r, buf, first.Params.Width, first.Params.Height)
+		if err != nil {
+			return nil, "", status, err
+		}
+		smart = res.Crop.Rectangle
+	}
+
+	// as for crops, the slot is only taken once the image has been read
+	if err := s.limiter.acquire(r.Context()); err != nil {
+		return nil, "", acquireStatus(err), err
+	}
+	defer s.limiter.release()
+	img, format, status, err := s.decode(buf)
+	if err != nil {
+		return nil, "", status, err
+	}
+
+	ctx, cancel := s.withTimeout(r.Context())
+	defer cancel()
+	r = r.WithContext(ctx)
+
+	for i, op := range ops {
+		var crop image.Rectangle
+		if i == 0 {
+			crop = smart
+		}
+		if img, err = s.imaginaryOperation(r, img, op, crop); err == context.DeadlineExceeded {
+			return nil, "", http.StatusServiceUnavailable, err
+		} else if err != nil {
+			return nil, "", http.StatusBadRequest, err
+		}
+	}
+
+	// the encoding is taken from the last operation, or the query
+	last := ops[len(ops)-1].Params
+	if last.Type == "" {
+		last.Type = q.Type
+	}
+	if last.Quality == 0 {
+		last.Quality = q.Quality
+	}
+	return encodeImaginary(img, format, last)
+}
+
+// imaginaryQuery returns the parameters of the query of r.
+func imaginaryQuery(r *http.Request) (imaginaryParams, error) {
+	q := r.URL.Query()
+	p := imaginaryParams{Gravity: q.Get("gravity"), Type: q.Get("type")}
+	for name, v := range map[string]*int{"width": &p.Width, "height": &p.Height, "quality": &p.Quality} {
+		if s := q.Get(name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				return p, fmt.Errorf("invalid %s: %q", name, s)
+			}
+			*v = n
+		}
+	}
+	return p, nil
+}
+
+// readImaginaryImage reads the image posted with r, either as the body or as
+// the file field of a multipart form.
+func (s *Server) readImaginaryImage(r *http.Request) ([]byte, error) {
+	body := http.MaxBytesReader(nil, r.Body, s.opts.MaxImageSize)
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return ioutil.ReadAll(body)
+	}
+
+	r.Body = body
+	f, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(io.LimitReader(f, s.opts.MaxImageSize))
+}
+
+// smart reports whether op is a smart crop.
+func (op imaginaryOperation) smart() bool {
+	return op.Operation == "smartcrop" || op.Operation == "crop" && op.Params.Gravity == "smart"
+}
+
+// validate checks the dimensions requested by p, which must not exceed
+// limits.
+func (p imaginaryParams) validate(limits smartcrop.Limits) error {
+	if p.Width < 0 || p.Height < 0 {
+		return fmt.Errorf("invalid dimensions %dx%d", p.Width, p.Height)
+	}
+	if p.Width == 0 && p.Height == 0 {
+		return fmt.Errorf("missing width and height")
+	}
+	return limits.Check(p.Width, p.Height)
+}
+
+// imaginaryOperation applies op to img. If op is a smart crop, smart is the
+// crop found for it already, if any.
+func (s *Server) imaginaryOperation(r *http.Request, img image.Image, op imaginaryOperation, smart image.Rectangle) (image.Image, error) {
+	p := op.Params
+	if err := p.validate(*s.opts.Limits); err != nil {
+		return nil, err
+	}
+
+	b := img.Bounds()
+	width, height := p.Width, p.Height
+	if width == 0 {
+		width = int(math.Max(1, math.Round(float64(b.Dx()*height)/float64(b.Dy()))))
+	}
+	if height == 0 {
+		height = int(math.Max(1, math.Round(float64(b.Dy()*width)/float64(b.Dx()))))
+	}
+	// the image gets scaled to them, so they're bound like decoded images
+	if err := s.opts.Limits.Check(width, height); err != nil {
+		return nil, err
+	}
+
+	var crop image.Rectangle
+	switch op.Operation {
+	case "resize":
+		crop = b
+	case "smartcrop":
+		p.Gravity = "smart"
+		fallthrough
+	case "crop":
+		if p.Gravity == "smart" && !smart.Empty() {
+			crop = smart
+			break
+		}
+		var err error
+		if crop, err = s.imaginaryCrop(r, img, width, height, p.Gravity); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported operation %q", op.Operation)
+	}
+
+	type SubImager interface {
+		SubImage(r image.Rectangle) image.Image
+	}
+	out := img
+	if crop != b {
+		if sub, ok := img.(SubImager); ok {
+			out = sub.SubImage(crop)
+		} else {
+			return nil, fmt.Errorf("can't crop images of type %T", img)
+		}
+	}
+	if out.Bounds().Dx() != width || out.Bounds().Dy() != height {
+		out = s.opts.Resizer.Resize(out, uint(width), uint(height))
+	}
+	return out, nil
+}
+
+// imaginaryCrop returns the region of img to scale to width and height for
+// the given gravity: the best crop for smart, or the largest region of the
+// aspect ratio at the centre or at the given side of img.
+func (s *Server) imaginaryCrop(r *http.Request, img image.Image, width, height int, gravity string) (image.Rectangle, error) {
+	if gravity == "smart" {
+		settings, err := s.settings(r)
+		if err != nil {
+			return image.Rectangle{}, err
+		}
+		res, err := smartcrop.FindCrop(img, smartcrop.Request{
+			Width:    width,
+			Height:   height,
+			Settings: &settings,
+			Resizer:  s.opts.Resizer,
+			Logger:   s.opts.Logger,
+			Context:  r.Context(),
+		})
+		if err != nil {
+			return image.Rectangle{}, err
+		}
+		d := decision{res: res}
+		if s.opts.AuditLog != nil {
+			d.source = smartcrop.SourceHash(img)
+		}
+		s.audit(d, width, height, settings)
+		return res.Crop.Rectangle, nil
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), int(math.Round(float64(b.Dx()*height)/float64(width)))
+	if h > b.Dy() {
+		w, h = int(math.Round(float64(b.Dy()*width)/float64(height))), b.Dy()
+	}
+	x, y := b.Min.X+(b.Dx()-w)/2, b.Min.Y+(b.Dy()-h)/2
+	switch gravity {
+	case "", "centre", "center":
+	case "north":
+		y = b.Min.Y
+	case "south":
+		y = b.Max.Y - h
+	case "west":
+		x = b.Min.X
+	case "east":
+		x = b.Max.X - w
+	default:
+		return image.Rectangle{}, fmt.Errorf("unsupported gravity %q", gravity)
+	}
+	return image.Rect(x, y, x+w, y+h), nil
+}
+
+// encodeImaginary encodes img, decoded from format, as the type requested by
+// p, defaulting to the format it was decoded from if it's JPEG or PNG.
+func encodeImaginary(img image.Image, format string, p imaginaryParams) ([]byte, string, int, error) {
+	t := p.Type
+	if t == "" || t == "auto" {
+		t = "jpeg"
+		if format == "png" {
+			t = "png"
+		}
+	}
+	quality := p.Quality
+	if quality <= 0 {
+		quality = defaultImaginaryQuality
+	}
+
+	var buf bytes.Buffer
+	var err error
+	var contentType string
+	switch t {
+	case "png":
+		contentType = "image/png"
+		err = png.Encode(&buf, img)
+	case "jpeg", "jpg":
+		contentType = "image/jpeg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	default:
+		return nil, "", http.StatusBadRequest, fmt.Errorf("unsupported type %q", p.Type)
+	}
+	if err != nil {
+		return nil, "", http.StatusInternalServerError, err
+	}
+	return buf.Bytes(), contentType, http.StatusOK, nil
+}
+
+func writeImaginaryError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(imaginaryError{Message: err.Error(), Status: status})
+}
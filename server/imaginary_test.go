@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/muesli/smartcrop"
+)
+
+func TestImaginary(t *testing.T) {
+	s := New(Options{Imaginary: true})
+
+	for _, tc := range []struct {
+		url           string
+		width, height int
+	}{
+		{"/smartcrop?width=100&height=100", 100, 100},
+		{"/crop?width=200&height=50&gravity=north&type=png", 200, 50},
+		{"/crop?width=300", 300, 95},
+		{"/pipeline?operations=" + url.QueryEscape(`[{"operation":"smartcrop","params":{"width":400,"height":200}},{"operation":"resize","params":{"width":100}}]`), 100, 50},
+	} {
+		w := post(t, s, tc.url)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d: %s", tc.url, w.Code, w.Body)
+			continue
+		}
+		c, format, err := image.DecodeConfig(w.Body)
+		if err != nil {
+			t.Errorf("%s: %v", tc.url, err)
+			continue
+		}
+		if c.Width != tc.width || c.Height != tc.height || "image/"+format != w.Header().Get("Content-Type") {
+			t.Errorf("%s: expected a %dx%d image, got a %dx%d %s image", tc.url, tc.width, tc.height, c.Width, c.Height, format)
+		}
+	}
+
+	for _, u := range []string{
+		"/smartcrop",
+		"/smartcrop?width=100&height=100&url=http://localhost/a.jpg",
+		"/crop?width=100&height=100&gravity=diagonal",
+		"/pipeline?operations=" + url.QueryEscape(`[{"operation":"blur","params":{"width":100}}]`),
+	} {
+		w := post(t, s, u)
+		var e imaginaryError
+		if err := json.NewDecoder(w.Body).Decode(&e); err != nil || w.Code != http.StatusBadRequest || e.Status != http.StatusBadRequest || e.Message == "" {
+			t.Errorf("%s: expected an imaginary error with status 400, got %d: %+v (%v)", u, w.Code, e, err)
+		}
+	}
+}
+
+func TestImaginaryLimits(t *testing.T) {
+	// the source image has 255600 pixels
+	s := New(Options{Imaginary: true, Limits: &smartcrop.Limits{MaxPixels: 300000, MaxDimension: 2000}})
+	if w := post(t, s, "/crop?width=600&height=400"); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 within the limits, got %d: %s", w.Code, w.Body)
+	}
+
+	for _, u := range []string{
+		"/crop?width=1000&height=1000",
+		"/smartcrop?width=3000&height=10",
+		// the width follows from the aspect ratio of the image
+		"/crop?height=1000",
+		"/pipeline?operations=" + url.QueryEscape(`[{"operation":"smartcrop","params":{"width":100,"height":100}},{"operation":"resize","params":{"width":1000}}]`),
+	} {
+		w := post(t, s, u)
+		var e imaginaryError
+		if err := json.NewDecoder(w.Body).Decode(&e); err != nil || w.Code != http.StatusBadRequest || !strings.Contains(e.Message, "exceeds") {
+			t.Errorf("%s: expected an imaginary error with status 400, got %d: %+v (%v)", u, w.Code, e, err)
+		}
+	}
+}
+
+func TestImaginaryMultipart(t *testing.T) {
+	f, err := os.Open(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "gopher.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(fw, f); err != nil {
+		t.Fatal(err)
+	}
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/smartcrop?width=120&height=80", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	New(Options{Imaginary: true}).ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body)
+	}
+	c, _, err := image.DecodeConfig(w.Body)
+	if err != nil || c.Width != 120 || c.Height != 80 {
+		t.Errorf("expected a 120x80 image, got %dx%d (%v)", c.Width, c.Height, err)
+	}
+}
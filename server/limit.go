@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrOverloaded gets returned if a request can't be served because too many
+// are in progress already.
+var ErrOverloaded = errors.New("Too many requests")
+
+// limiter caps the number of concurrent analyses. Requests exceeding the cap
+// wait in a queue of limited length for a limited time.
+type limiter struct {
+	slots   chan struct{}
+	timeout time.Duration
+
+	mu       sync.Mutex
+	queued   int
+	maxQueue int
+}
+
+// newLimiter returns a limiter for the given Options, or nil if they don't
+// limit the concurrency.
+func newLimiter(opts Options) *limiter {
+	if opts.MaxConcurrent <= 0 {
+		return nil
+	}
+	return &limiter{
+		slots:    make(chan struct{}, opts.MaxConcurrent),
+		timeout:  opts.QueueTimeout,
+		maxQueue: opts.MaxQueue,
+	}
+}
+
+// acquire waits for a free slot, which has to be released afterwards. It
+// returns ErrOverloaded if the queue is full or the wait times out, and the
+// error of ctx if it gets cancelled.
+func (l *limiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	l.mu.Lock()
+	if l.queued >= l.maxQueue {
+		l.mu.Unlock()
+		return ErrOverloaded
+	}
+	l.queued++
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.queued--
+		l.mu.Unlock()
+	}()
+
+	var timeout <-chan time.Time
+	if l.timeout > 0 {
+		t := time.NewTimer(l.timeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timeout:
+		return ErrOverloaded
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// acquireStatus returns the HTTP status code to respond with if acquire failed
+// with err: 429 Too Many Requests if the server is overloaded, or 503 Service
+// Unavailable if the request got cancelled or its deadline passed while it
+// waited, which isn't the server rejecting it.
+func acquireStatus(err error) int {
+	if isContextError(err) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusTooManyRequests
+}
+
+// isContextError reports whether err is the error of a cancelled or expired
+// context, as acquire returns it.
+func isContextError(err error) bool {
+	return err == context.Canceled || err == context.DeadlineExceeded
+}
+
+// release frees a slot acquired before.
+func (l *limiter) release() {
+	if l != nil {
+		<-l.slots
+	}
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	if l := newLimiter(Options{}); l != nil {
+		t.Fatal("expected no limiter without a concurrency cap")
+	}
+
+	ctx := context.Background()
+	l := newLimiter(Options{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: 10 * time.Millisecond})
+	if err := l.acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the queued request times out
+	if err := l.acquire(ctx); err != ErrOverloaded {
+		t.Fatalf("expected %v, got %v", ErrOverloaded, err)
+	}
+
+	// or waits for the slot to be released, without a timeout
+	l = newLimiter(Options{MaxConcurrent: 1, MaxQueue: 1})
+	if err := l.acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() {
+		done <- l.acquire(ctx)
+	}()
+	for {
+		l.mu.Lock()
+		queued := l.queued
+		l.mu.Unlock()
+		if queued == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// while the queue is full, further requests get rejected right away
+	if err := l.acquire(ctx); err != ErrOverloaded {
+		t.Fatalf("expected %v with a full queue, got %v", ErrOverloaded, err)
+	}
+
+	l.release()
+	if err := <-done; err != nil {
+		t.Fatalf("expected the queued request to get the slot, got %v", err)
+	}
+}
+
+func TestSlowUpload(t *testing.T) {
+	s := New(Options{MaxConcurrent: 1})
+
+	// a client still uploading doesn't hold the only slot
+	pr, pw := io.Pipe()
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/crop?width=250&height=250", pr))
+		done <- w.Code
+	}()
+	if _, err := pw.Write([]byte{0xff, 0xd8}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := post(t, s, "/crop?width=250&height=250"); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 during a slow upload, got %d: %s", w.Code, w.Body)
+	}
+	pw.Close()
+	if code := <-done; code == http.StatusTooManyRequests {
+		t.Fatal("expected the slow upload to get the slot once read")
+	}
+}
+
+// waitFor polls cond until it's true.
+func waitFor(cond func() bool) {
+	for !cond() {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSlotAfterCache(t *testing.T) {
+	s := New(Options{MaxConcurrent: 1, CacheSize: 1})
+	if w := post(t, s, "/crop?width=250&height=250"); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body)
+	}
+
+	// with the only slot taken, the cached crop is still served, while the
+	// analysis of another one gets rejected
+	if err := s.limiter.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer s.limiter.release()
+	if w := post(t, s, "/crop?width=250&height=250"); w.Code != http.StatusOK {
+		t.Fatalf("expected the cached crop with status 200, got %d: %s", w.Code, w.Body)
+	}
+	if w := post(t, s, "/crop?width=100&height=100"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status 429, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestSlotCoalesced(t *testing.T) {
+	s := New(Options{MaxConcurrent: 1, MaxQueue: 1})
+	if err := s.limiter.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	queued := func(n int) func() bool {
+		return func() bool {
+			s.limiter.mu.Lock()
+			defer s.limiter.mu.Unlock()
+			return s.limiter.queued == n
+		}
+	}
+	waiting := func() bool {
+		s.flights.mu.Lock()
+		defer s.flights.mu.Unlock()
+		for _, c := range s.flights.calls {
+			return c.dups == 1
+		}
+		return false
+	}
+
+	serve := func(ctx context.Context) chan int {
+		done := make(chan int, 1)
+		f, err := os.Open(testFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			defer f.Close()
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/crop?width=250&height=250", f).WithContext(ctx))
+			done <- w.Code
+		}()
+		return done
+	}
+
+	// the first request queues for the slot, the second one waits for its
+	// analysis without taking the queue's only place
+	ctx, cancel := context.WithCancel(context.Background())
+	first := serve(ctx)
+	waitFor(queued(1))
+	second := serve(context.Background())
+	waitFor(waiting)
+
+	// once the first client is gone, the second request queues instead,
+	// and the first one doesn't count as rejected
+	rejected := s.metrics.rejected.Value()
+	cancel()
+	if code := <-first; code != http.StatusServiceUnavailable {
+		t.Fatalf("expected the cancelled request to get status 503, got %d", code)
+	}
+	if n := s.metrics.rejected.Value() - rejected; n != 0 {
+		t.Fatalf("expected no rejected requests, got %d", n)
+	}
+	waitFor(queued(1))
+	s.limiter.release()
+	if code := <-second; code != http.StatusOK {
+		t.Fatalf("expected the coalesced request to get status 200, got %d", code)
+	}
+}
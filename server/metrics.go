@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package server
+
+import (
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/muesli/smartcrop"
+)
+
+// latencyBuckets are the upper bounds of the buckets of the latency
+// histogram.
+var latencyBuckets = []time.Duration{
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// metrics counts the crop requests of all Servers of the process, as expvar
+// variables can only be published once.
+type metrics struct {
+	requests *expvar.Int
+	errors   *expvar.Int
+	// coalesced counts the requests answered by the analysis of another
+	// request.
+	coalesced *expvar.Int
+	// rejected counts the requests rejected because of the concurrency cap.
+	rejected *expvar.Int
+	// cached counts the requests answered from the cache of Results.
+	cached *expvar.Int
+	// latency is a cumulative histogram: every bucket, named after its
+	// upper bound, counts the requests which took at most that long.
+	latency *expvar.Map
+	vars    *expvar.Map
+}
+
+var (
+	serverMetrics = newMetrics()
+	publishOnce   sync.Once
+)
+
+func newMetrics() *metrics {
+	m := &metrics{
+		requests:  new(expvar.Int),
+		errors:    new(expvar.Int),
+		coalesced: new(expvar.Int),
+		rejected:  new(expvar.Int),
+		cached:    new(expvar.Int),
+		latency:   new(expvar.Map).Init(),
+		vars:      new(expvar.Map).Init(),
+	}
+	for _, b := range latencyBuckets {
+		m.latency.Set(b.String(), new(expvar.Int))
+	}
+	m.latency.Set("+Inf", new(expvar.Int))
+
+	m.vars.Set("requests", m.requests)
+	m.vars.Set("errors", m.errors)
+	m.vars.Set("coalesced", m.coalesced)
+	m.vars.Set("rejected", m.rejected)
+	m.vars.Set("cached", m.cached)
+	m.vars.Set("latency", m.latency)
+	m.vars.Set("pool", expvar.Func(func() interface{} {
+		return smartcrop.ReadPoolStats()
+	}))
+	return m
+}
+
+// observe counts a request which took d.
+func (m *metrics) observe(d time.Duration, failed bool) {
+	m.requests.Add(1)
+	if failed {
+		m.errors.Add(1)
+	}
+	for _, b := range latencyBuckets {
+		if d <= b {
+			m.latency.Add(b.String(), 1)
+		}
+	}
+	m.latency.Add("+Inf", 1)
+}
+
+// publishMetrics publishes the metrics as the expvar variable "smartcrop".
+func publishMetrics() {
+	publishOnce.Do(func() {
+		expvar.Publish("smartcrop", serverMetrics.vars)
+	})
+}
@@ -0,0 +1,246 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package server
+
+import "net/http"
+
+// OpenAPI is the OpenAPI 3 document describing the endpoints of a Server. It
+// gets served at /openapi.json.
+const OpenAPI = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "smartcrop",
+    "description": "Finds the best crops of uploaded images.",
+    "version": "1"
+  },
+  "paths": {
+    "/crop": {
+      "post": {
+        "operationId": "crop",
+        "summary": "Find the best crop of an image",
+        "parameters": [
+          {
+            "name": "width",
+            "in": "query",
+            "required": true,
+            "description": "Width of the requested crop. Only its ratio to the height matters, unless the image is smaller.",
+            "schema": {"type": "integer"}
+          },
+          {
+            "name": "height",
+            "in": "query",
+            "required": true,
+            "description": "Height of the requested crop.",
+            "schema": {"type": "integer"}
+          },
+          {
+            "name": "profile",
+            "in": "query",
+            "required": false,
+            "description": "Name of the profile of settings to analyse the image with, instead of the default settings.",
+            "schema": {"type": "string"}
+          },
+          {
+            "name": "url",
+            "in": "query",
+            "required": false,
+            "description": "URL to fetch the image from instead of posting it, if the server enables fetching. Only public hosts can be fetched from.",
+            "schema": {"type": "string", "format": "uri"}
+          }
+        ],
+        "requestBody": {
+          "required": false,
+          "description": "The image, encoded as JPEG, PNG or GIF, unless the url parameter is given.",
+          "content": {
+            "image/*": {
+              "schema": {"type": "string", "format": "binary"}
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "The best crop of the image.",
+            "content": {
+              "application/json": {
+                "schema": {"$ref": "#/components/schemas/Result"}
+              }
+            }
+          },
+          "400": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/Error"},
+          "413": {"$ref": "#/components/responses/Error"},
+          "415": {"$ref": "#/components/responses/Error"},
+          "422": {"$ref": "#/components/responses/Error"},
+          "502": {"$ref": "#/components/responses/Error"},
+          "429": {
+            "description": "Too many requests are being analysed, retry later.",
+            "headers": {
+              "Retry-After": {"schema": {"type": "integer"}}
+            },
+            "content": {
+              "text/plain": {"schema": {"type": "string"}}
+            }
+          },
+          "503": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/healthz": {
+      "get": {
+        "operationId": "healthz",
+        "summary": "Check that the pipeline works with the default settings",
+        "responses": {
+          "200": {"$ref": "#/components/responses/Health"},
+          "503": {"$ref": "#/components/responses/Health"}
+        }
+      }
+    },
+    "/readyz": {
+      "get": {
+        "operationId": "readyz",
+        "summary": "Check that the pipeline works with the settings of every profile and the limits",
+        "responses": {
+          "200": {"$ref": "#/components/responses/Health"},
+          "503": {"$ref": "#/components/responses/Health"}
+        }
+      }
+    }
+  },
+  "components": {
+    "responses": {
+      "Health": {
+        "description": "The outcome of running a built-in image through the pipeline.",
+        "content": {
+          "application/json": {
+            "schema": {
+              "type": "object",
+              "required": ["status"],
+              "properties": {
+                "status": {"type": "string", "enum": ["ok", "failing"]},
+                "error": {"type": "string"}
+              }
+            }
+          }
+        }
+      },
+      "Error": {
+        "description": "The request can't be served.",
+        "content": {
+          "text/plain": {"schema": {"type": "string"}}
+        }
+      }
+    },
+    "schemas": {
+      "Result": {
+        "type": "object",
+        "required": ["schemaVersion", "analyzerVersion", "paramsHash", "imageWidth", "imageHeight", "crop", "floatCrop", "normalized", "focalPoint", "confidence"],
+        "properties": {
+          "schemaVersion": {"type": "integer"},
+          "analyzerVersion": {"type": "string"},
+          "paramsHash": {"type": "string"},
+          "imageWidth": {"type": "integer"},
+          "imageHeight": {"type": "integer"},
+          "crop": {"$ref": "#/components/schemas/Crop"},
+          "floatCrop": {"$ref": "#/components/schemas/Rect"},
+          "normalized": {"$ref": "#/components/schemas/Rect"},
+          "focalPoint": {"$ref": "#/components/schemas/Point"},
+          "subjectCut": {"type": "boolean"},
+          "attentionPoints": {
+            "type": "array",
+            "items": {"$ref": "#/components/schemas/AttentionPoint"}
+          },
+          "degradation": {"type": "integer"},
+          "strategy": {"type": "string"},
+          "confidence": {"type": "number"},
+          "reused": {"type": "boolean"},
+          "seed": {"type": "integer"},
+          "ratio": {"type": "number"},
+          "truncated": {"type": "boolean"},
+          "partial": {"type": "boolean"}
+        }
+      },
+      "Crop": {
+        "type": "object",
+        "required": ["x", "y", "width", "height", "score"],
+        "properties": {
+          "x": {"type": "integer"},
+          "y": {"type": "integer"},
+          "width": {"type": "integer"},
+          "height": {"type": "integer"},
+          "score": {"$ref": "#/components/schemas/Score"}
+        }
+      },
+      "Score": {
+        "type": "object",
+        "required": ["detail", "saturation", "skin", "boost", "total"],
+        "properties": {
+          "detail": {"type": "number"},
+          "saturation": {"type": "number"},
+          "skin": {"type": "number"},
+          "boost": {"type": "number"},
+          "total": {"type": "number"},
+          "contrast": {"type": "number"},
+          "cut": {"type": "number"}
+        }
+      },
+      "Rect": {
+        "type": "object",
+        "required": ["x", "y", "width", "height"],
+        "properties": {
+          "x": {"type": "number"},
+          "y": {"type": "number"},
+          "width": {"type": "number"},
+          "height": {"type": "number"}
+        }
+      },
+      "Point": {
+        "type": "object",
+        "required": ["x", "y"],
+        "properties": {
+          "x": {"type": "number"},
+          "y": {"type": "number"}
+        }
+      },
+      "AttentionPoint": {
+        "type": "object",
+        "required": ["x", "y", "weight"],
+        "properties": {
+          "x": {"type": "number"},
+          "y": {"type": "number"},
+          "weight": {"type": "number"}
+        }
+      }
+    }
+  }
+}
+`
+
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(OpenAPI))
+}
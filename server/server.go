@@ -0,0 +1,439 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+/*
+Package server implements an HTTP service finding the best crops of uploaded
+images.
+
+Images get POSTed to /crop with the requested dimensions as the width and
+height query parameters, and the Result gets returned as JSON:
+
+	curl --data-binary @gopher.jpg 'http://localhost:8080/crop?width=250&height=250'
+
+Concurrent requests for the same image and dimensions get coalesced into a
+single analysis, so a burst of requests for a newly uploaded image doesn't
+multiply the CPU cost.
+
+The API is described by the OpenAPI document served at /openapi.json, and Go
+programs can use the Client of this package to call it.
+
+/healthz and /readyz run a tiny built-in image through the pipeline, with the
+default settings or with the ones of every profile respectively, and answer
+503 Service Unavailable if it fails, e.g. because a configured detector or
+strategy isn't registered.
+
+Operators can optionally expose expvar metrics at /debug/vars and the pprof
+endpoints at /debug/pprof/, see Options.
+
+By default, the service only analyzes images uploaded in the request body.
+With Options.Fetch, it fetches the image from the URL given by the url
+parameter instead, guarded against being turned into a proxy reaching hosts
+of the internal network: only the allowed schemes get fetched, also when
+redirected, and connections to loopback, private, link-local and other
+addresses which aren't publicly routable get refused. The address gets checked
+when connecting, after resolving the host, so a host resolving to another
+address on a second lookup can't get past the check.
+
+With Options.Imaginary, the Server speaks the API of imaginary instead, for
+existing clients of it: /smartcrop, /crop and /pipeline return the posted
+image cropped and scaled to the requested dimensions.
+*/
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"image"
+	_ "image/gif"  // register the GIF decoder
+	_ "image/jpeg" // register the JPEG decoder
+	_ "image/png"  // register the PNG decoder
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"time"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+	"github.com/muesli/smartcrop/options"
+)
+
+// defaultMaxImageSize is the largest image accepted by default, in bytes.
+const defaultMaxImageSize = 32 << 20
+
+// Options configures a Server.
+type Options struct {
+	// Settings are the CropSettings to analyse the images with. They
+	// default to DefaultCropSettings. Middleware may override them per
+	// request, see smartcrop.ContextWithOverrides.
+	Settings *smartcrop.CropSettings
+	// Profiles are named settings requests can pick with the profile query
+	// parameter instead, e.g. the profiles of a smartcrop.Config.
+	Profiles map[string]smartcrop.CropSettings
+	// Resizer is used for prescaling the images. It defaults to the nfnt
+	// Resizer.
+	Resizer options.Resizer
+	Logger  smartcrop.Logger
+
+	// MaxImageSize is the largest image accepted, in bytes, defaulting to
+	// 32 MiB.
+	MaxImageSize int64
+	// Limits bound the dimensions of the images decoded and the time spent
+	// analysing them. They default to smartcrop.DefaultLimits, with MaxBytes
+	// set to MaxImageSize. Images exceeding them get rejected with 413
+	// Request Entity Too Large, and images of the imaginary API to be scaled
+	// beyond them with 400 Bad Request.
+	Limits *smartcrop.Limits
+
+	// Metrics exposes the number of requests and errors, a latency histogram
+	// and the smartcrop.PoolStats at /debug/vars, next to the other published
+	// expvar variables.
+	Metrics bool
+	// Profiling exposes the pprof endpoints at /debug/pprof/. They reveal
+	// details about the process, so they should only be enabled on
+	// instances which aren't publicly reachable.
+	Profiling bool
+
+	// MaxConcurrent caps the number of requests analysed at a time, so a
+	// burst of requests can't exhaust the memory. Up to MaxQueue requests
+	// exceeding it wait for up to QueueTimeout, or as long as their client
+	// does if it's 0. Other requests get rejected with 429 Too Many Requests.
+	// The concurrency is unlimited if MaxConcurrent is 0.
+	MaxConcurrent int
+	MaxQueue      int
+	QueueTimeout  time.Duration
+
+	// CacheSize is the number of Results of recently requested images to
+	// keep, so requesting an image again doesn't analyse it again. Nothing
+	// gets cached if it's 0.
+	CacheSize int
+
+	// AuditLog records the crop decision of every response, including the
+	// ones served from the cache or shared by coalesced requests, which an
+	// AuditLog attached to the settings doesn't get to see. /readyz fails
+	// once it can't be written anymore.
+	AuditLog *smartcrop.AuditLog
+
+	// Fetch enables fetching images by the URL given with the url parameter
+	// instead of posting them, guarded by the FetchOptions against
+	// server-side request forgery. Images can only be posted if it's nil.
+	Fetch *FetchOptions
+
+	// Imaginary serves the API of imaginary instead of the JSON one, so
+	// clients of an imaginary deployment can be pointed at the Server: the
+	// images posted to /smartcrop, /crop and /pipeline, or fetched with GET
+	// and the url parameter, get returned cropped and scaled to the requested
+	// width and height.
+	Imaginary bool
+}
+
+// Server is an http.Handler serving crop requests.
+type Server struct {
+	opts    Options
+	mux     *http.ServeMux
+	metrics *metrics
+	flights flightGroup
+	limiter *limiter
+	cache   *resultCache
+	fetcher *fetcher
+}
+
+// New returns a new Server with the given Options.
+func New(opts Options) *Server {
+	if opts.MaxImageSize <= 0 {
+		opts.MaxImageSize = defaultMaxImageSize
+	}
+	if opts.Resizer == nil {
+		opts.Resizer = nfnt.NewDefaultResizer()
+	}
+	if opts.Limits == nil {
+		limits := smartcrop.DefaultLimits()
+		limits.MaxBytes = opts.MaxImageSize
+		opts.Limits = &limits
+	}
+
+	s := &Server{
+		opts:    opts,
+		mux:     http.NewServeMux(),
+		metrics: serverMetrics,
+		limiter: newLimiter(opts),
+		cache:   newResultCache(opts.CacheSize),
+	}
+	if opts.Fetch != nil {
+		s.fetcher = newFetcher(*opts.Fetch, opts.MaxImageSize)
+	}
+	if opts.Imaginary {
+		s.mux.HandleFunc("/smartcrop", s.handleImaginary("smart"))
+		s.mux.HandleFunc("/crop", s.handleImaginary(""))
+		s.mux.HandleFunc("/pipeline", s.handlePipeline)
+	} else {
+		s.mux.HandleFunc("/crop", s.handleCrop)
+		s.mux.HandleFunc("/openapi.json", handleOpenAPI)
+	}
+	s.mux.HandleFunc("/healthz", s.handleHealthz)
+	s.mux.HandleFunc("/readyz", s.handleReadyz)
+	if opts.Metrics {
+		publishMetrics()
+		s.mux.Handle("/debug/vars", expvar.Handler())
+	}
+	if opts.Profiling {
+		s.mux.HandleFunc("/debug/pprof/", pprof.Index)
+		s.mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		s.mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		s.mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		s.mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleCrop(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	res, status, err := s.crop(r)
+	if status == http.StatusTooManyRequests {
+		s.metrics.rejected.Add(1)
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, err.Error(), status)
+		return
+	}
+	s.metrics.observe(time.Since(now), err != nil)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, res)
+}
+
+// crop analyses the image posted with r, or the one at the URL it gives if
+// fetching is enabled. On failure, it returns the HTTP
+// status code to respond with, http.StatusTooManyRequests if the server is
+// overloaded.
+func (s *Server) crop(r *http.Request) (smartcrop.Result, int, error) {
+	if r.Method != http.MethodPost {
+		return smartcrop.Result{}, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method)
+	}
+
+	width, err := strconv.Atoi(r.URL.Query().Get("width"))
+	if err != nil {
+		return smartcrop.Result{}, http.StatusBadRequest, fmt.Errorf("invalid width: %v", err)
+	}
+	height, err := strconv.Atoi(r.URL.Query().Get("height"))
+	if err != nil {
+		return smartcrop.Result{}, http.StatusBadRequest, fmt.Errorf("invalid height: %v", err)
+	}
+
+	var buf []byte
+	if u := r.URL.Query().Get("url"); u != "" {
+		var status int
+		if buf, status, err = s.fetch(r, u); err != nil {
+			return smartcrop.Result{}, status, err
+		}
+	} else if buf, err = ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, s.opts.MaxImageSize)); err != nil {
+		return smartcrop.Result{}, http.StatusRequestEntityTooLarge, fmt.Errorf("can't read image: %v", err)
+	}
+
+	return s.findCrop(r, buf, width, height)
+}
+
+// fetch returns the image at the URL u given with r. On failure, it returns
+// the HTTP status code to respond with.
+func (s *Server) fetch(r *http.Request, u string) ([]byte, int, error) {
+	if s.fetcher == nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("images must be posted, fetching them by URL isn't enabled")
+	}
+	return s.fetcher.fetch(r.Context(), u)
+}
+
+// findCrop finds the best crop of the encoded image buf, posted with r. On
+// failure, it returns the HTTP status code to respond with,
+// http.StatusTooManyRequests if the server is overloaded.
+func (s *Server) findCrop(r *http.Request, buf []byte, width, height int) (smartcrop.Result, int, error) {
+	settings, err := s.settings(r)
+	if err != nil {
+		return smartcrop.Result{}, http.StatusBadRequest, err
+	}
+
+	sum := sha256.Sum256(buf)
+	key := fmt.Sprintf("%x/%dx%d/%s", sum, width, height, settings.Fingerprint())
+	if d, ok := s.cache.get(key); ok {
+		s.metrics.cached.Add(1)
+		s.audit(d, width, height, settings)
+		return d.res, http.StatusOK, nil
+	}
+
+	// identical requests in flight get answered by a single analysis, which
+	// only takes a slot once the image has been read and isn't cached, so
+	// slow clients, cache hits and coalesced requests don't hold one up
+	var d decision
+	var status int
+	var shared bool
+	for {
+		d, status, err, shared = s.flights.do(key, func() (decision, int, error) {
+			if err := s.limiter.acquire(r.Context()); err != nil {
+				return decision{}, acquireStatus(err), err
+			}
+			defer s.limiter.release()
+			return s.analyze(buf, width, height, &settings)
+		})
+		// the request whose analysis got shared may have gone away while
+		// waiting for a slot, which doesn't concern the others
+		if !shared || !isContextError(err) || r.Context().Err() != nil {
+			break
+		}
+	}
+	if shared {
+		s.metrics.coalesced.Add(1)
+	}
+	if err != nil {
+		return smartcrop.Result{}, status, err
+	}
+	s.cache.add(key, d)
+	s.audit(d, width, height, settings)
+	return d.res, status, nil
+}
+
+// decision is the outcome of an analysis: its Result, and the SourceHash of
+// the analysed image if the Server has an AuditLog, so responses served from
+// the cache don't need to decode the image again to record it.
+type decision struct {
+	res    smartcrop.Result
+	source string
+}
+
+// audit records d, the decision for the requested width and height found
+// with settings, in the AuditLog of s, if any.
+func (s *Server) audit(d decision, width, height int, settings smartcrop.CropSettings) {
+	if s.opts.AuditLog == nil {
+		return
+	}
+	rec := smartcrop.NewAuditRecord(smartcrop.Decision{
+		Width:    width,
+		Height:   height,
+		Settings: settings,
+		Result:   d.res,
+	})
+	rec.SourceHash = d.source
+	if err := s.opts.AuditLog.Write(rec); err != nil {
+		log.Println("can't write audit record:", err)
+	}
+}
+
+// settings returns the settings to analyse the image posted with r with:
+// the ones of the profile it requests, if any. Middleware may override them
+// per request, see smartcrop.ContextWithOverrides.
+func (s *Server) settings(r *http.Request) (smartcrop.CropSettings, error) {
+	settings := s.defaultSettings()
+	if name := r.URL.Query().Get("profile"); name != "" {
+		p, ok := s.opts.Profiles[name]
+		if !ok {
+			return smartcrop.CropSettings{}, fmt.Errorf("unknown profile %q", name)
+		}
+		settings = p
+	}
+	return smartcrop.ApplyOverrides(r.Context(), settings), nil
+}
+
+// defaultSettings returns the settings of requests which don't pick a
+// profile.
+func (s *Server) defaultSettings() smartcrop.CropSettings {
+	if s.opts.Settings != nil {
+		return *s.opts.Settings
+	}
+	return smartcrop.DefaultCropSettings()
+}
+
+// analyze decodes buf and finds its best crop. Its format is sniffed from
+// its content, regardless of the Content-Type of the request.
+func (s *Server) analyze(buf []byte, width, height int, settings *smartcrop.CropSettings) (decision, int, error) {
+	img, _, status, err := s.decode(buf)
+	if err != nil {
+		return decision{}, status, err
+	}
+
+	// the analysis may be shared by several requests, so it isn't bound to
+	// the context of any of them
+	ctx, cancel := s.withTimeout(context.Background())
+	defer cancel()
+	res, err := smartcrop.FindCrop(img, smartcrop.Request{
+		Width:    width,
+		Height:   height,
+		Settings: settings,
+		Resizer:  s.opts.Resizer,
+		Logger:   s.opts.Logger,
+		Context:  ctx,
+	})
+	if err == context.DeadlineExceeded {
+		return decision{}, http.StatusServiceUnavailable, err
+	}
+	if err != nil {
+		return decision{}, http.StatusUnprocessableEntity, err
+	}
+	d := decision{res: res}
+	if s.opts.AuditLog != nil {
+		d.source = smartcrop.SourceHash(img)
+	}
+	return d, http.StatusOK, nil
+}
+
+// decode decodes buf within the Limits of s. On failure, it returns the HTTP
+// status code to respond with.
+func (s *Server) decode(buf []byte) (image.Image, string, int, error) {
+	img, format, err := smartcrop.DecodeUntrusted(buf, *s.opts.Limits)
+	if e, ok := err.(*smartcrop.UnsupportedFormatError); ok && e.Format != "" {
+		return nil, "", http.StatusUnsupportedMediaType, err
+	}
+	if _, ok := err.(*smartcrop.LimitError); ok {
+		return nil, "", http.StatusRequestEntityTooLarge, err
+	}
+	if err != nil {
+		return nil, "", http.StatusBadRequest, fmt.Errorf("can't decode image: %v", err)
+	}
+	return img, format, http.StatusOK, nil
+}
+
+// withTimeout returns ctx bounded by the Timeout of the Limits of s, if any.
+func (s *Server) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.opts.Limits.Timeout > 0 {
+		return context.WithTimeout(ctx, s.opts.Limits.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("can't encode response:", err)
+	}
+}
@@ -0,0 +1,266 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/muesli/smartcrop"
+)
+
+const testFile = "../examples/gopher.jpg"
+
+func post(t *testing.T, s http.Handler, url string) *httptest.ResponseRecorder {
+	f, err := os.Open(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, url, f))
+	return w
+}
+
+func TestCrop(t *testing.T) {
+	s := New(Options{})
+	w := post(t, s, "/crop?width=250&height=250")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body)
+	}
+
+	var res smartcrop.Result
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Crop.Dx() != 255 || res.Crop.Dy() != 255 {
+		t.Fatalf("expected a 255x255 crop, got %v", res.Crop.Rectangle)
+	}
+
+	if w := post(t, s, "/crop?width=250"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without a height, got %d", w.Code)
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	s := New(Options{})
+	// middleware toggling a setting for some of the requests
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("experiment") != "" {
+			r = r.WithContext(smartcrop.ContextWithOverrides(r.Context(), func(s *smartcrop.CropSettings) {
+				s.Quadtree = true
+			}))
+		}
+		s.ServeHTTP(w, r)
+	})
+
+	hashes := map[string]bool{}
+	for _, url := range []string{"/crop?width=250&height=250", "/crop?width=250&height=250&experiment=1"} {
+		w := post(t, h, url)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body)
+		}
+		var res smartcrop.Result
+		if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		hashes[res.ParamsHash] = true
+	}
+	if len(hashes) != 2 {
+		t.Fatal("expected the overridden settings to yield a different params hash")
+	}
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/crop?width=250&height=250",
+		strings.NewReader("RIFF\x24\x00\x00\x00WEBPVP8 \x00\x00"))
+	r.Header.Set("Content-Type", "image/jpeg")
+	w := httptest.NewRecorder()
+	New(Options{}).ServeHTTP(w, r)
+	if w.Code != http.StatusUnsupportedMediaType || !strings.Contains(w.Body.String(), "webp") {
+		t.Fatalf("expected status 415 naming the webp format, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	get := func(s http.Handler, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	s := New(Options{})
+	if w := get(s, "/debug/vars"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected the metrics to be disabled, got %d", w.Code)
+	}
+	if w := get(s, "/debug/pprof/"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected profiling to be disabled, got %d", w.Code)
+	}
+
+	s = New(Options{Metrics: true, Profiling: true})
+	post(t, s, "/crop?width=100&height=100")
+
+	w := get(s, "/debug/vars")
+	var vars struct {
+		Smartcrop struct {
+			Requests int64               `json:"requests"`
+			Errors   int64               `json:"errors"`
+			Latency  map[string]int64    `json:"latency"`
+			Pool     smartcrop.PoolStats `json:"pool"`
+		} `json:"smartcrop"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&vars); err != nil {
+		t.Fatal(err)
+	}
+	if vars.Smartcrop.Requests == 0 || vars.Smartcrop.Latency["+Inf"] != vars.Smartcrop.Requests {
+		t.Fatalf("expected the requests to be counted, got %+v", vars.Smartcrop)
+	}
+	if pool := vars.Smartcrop.Pool; pool.Hits+pool.Misses == 0 || pool.PeakBytes == 0 {
+		t.Fatalf("expected the pool to be used, got %+v", pool)
+	}
+
+	if w := get(s, "/debug/pprof/"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine") {
+		t.Fatalf("expected the pprof index, got %d", w.Code)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	thumbnails := smartcrop.DefaultCropSettings()
+	thumbnails.MinScale = 1
+	s := New(Options{Profiles: map[string]smartcrop.CropSettings{"thumbnails": thumbnails}})
+
+	var res smartcrop.Result
+	w := post(t, s, "/crop?width=100&height=100&profile=thumbnails")
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.ParamsHash != thumbnails.Fingerprint() || res.Crop.Dy() != 284 {
+		t.Fatalf("expected the crop to be found with the profile, got %+v", res)
+	}
+
+	if w := post(t, s, "/crop?width=100&height=100&profile=missing"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an unknown profile, got %d", w.Code)
+	}
+}
+
+func TestCache(t *testing.T) {
+	s := New(Options{CacheSize: 1})
+	cached := s.metrics.cached.Value()
+	for _, url := range []string{"/crop?width=100&height=100", "/crop?width=100&height=100", "/crop?width=200&height=100", "/crop?width=100&height=100"} {
+		if w := post(t, s, url); w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body)
+		}
+	}
+	if n := s.metrics.cached.Value() - cached; n != 1 {
+		t.Fatalf("expected 1 request to be answered from the cache, got %d", n)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	decisions := 0
+	settings := smartcrop.DefaultCropSettings()
+	settings.OnResult = func(smartcrop.Decision) {
+		decisions++
+	}
+	s := New(Options{Settings: &settings, CacheSize: 1, AuditLog: smartcrop.NewAuditLog(&buf)})
+
+	// the probes don't make crop decisions
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusOK || decisions != 0 || buf.Len() != 0 {
+		t.Fatalf("expected /readyz to succeed without decisions, got %d with %d decisions: %s", w.Code, decisions, buf.String())
+	}
+
+	// the cached response gets recorded as well
+	for i := 0; i < 2; i++ {
+		if w := post(t, s, "/crop?width=100&height=100"); w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body)
+		}
+	}
+	if decisions != 1 {
+		t.Fatalf("expected a single analysis, got %d", decisions)
+	}
+	var records []smartcrop.AuditRecord
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var rec smartcrop.AuditRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Width != 100 || len(records[0].SourceHash) != 64 || records[1].SourceHash != records[0].SourceHash || records[1].Crop != records[0].Crop {
+		t.Errorf("expected the same decision twice, got %+v and %+v", records[0], records[1])
+	}
+
+	// /readyz fails once the log can't be written
+	s = New(Options{AuditLog: smartcrop.NewAuditLog(failingWriter{})})
+	if w := post(t, s, "/crop?width=100&height=100"); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body)
+	}
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "audit log") {
+		t.Errorf("expected status 503 for the audit log, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	limits := smartcrop.Limits{MaxDimension: 899}
+	for _, s := range []*Server{
+		New(Options{Limits: &limits}),
+		New(Options{Limits: &limits, Imaginary: true}),
+	} {
+		if w := post(t, s, "/crop?width=250&height=250"); w.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("expected status 413 for an image exceeding the limits, got %d: %s", w.Code, w.Body)
+		}
+	}
+
+	// the limits default to MaxImageSize
+	if w := post(t, New(Options{MaxImageSize: 1 << 10}), "/crop?width=250&height=250"); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status 413 for an image exceeding MaxImageSize, got %d", w.Code)
+	}
+}
@@ -0,0 +1,409 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CropSettings contains the parameters used by the analyzer. Use
+// DefaultCropSettings to get a set of sensible defaults and adjust them to
+// your needs.
+type CropSettings struct {
+	DetailWeight            float64 `json:"detailWeight"`
+	SkinBias                float64 `json:"skinBias"`
+	SkinBrightnessMin       float64 `json:"skinBrightnessMin"`
+	SkinBrightnessMax       float64 `json:"skinBrightnessMax"`
+	SkinThreshold           float64 `json:"skinThreshold"`
+	SkinWeight              float64 `json:"skinWeight"`
+	SaturationBrightnessMin float64 `json:"saturationBrightnessMin"`
+	SaturationBrightnessMax float64 `json:"saturationBrightnessMax"`
+	SaturationThreshold     float64 `json:"saturationThreshold"`
+	SaturationBias          float64 `json:"saturationBias"`
+	SaturationWeight        float64 `json:"saturationWeight"`
+	BoostWeight             float64 `json:"boostWeight"`
+	ScoreDownSample         int     `json:"scoreDownSample"`
+	Step                    int     `json:"step"`
+	ScaleStep               float64 `json:"scaleStep"`
+	MinScale                float64 `json:"minScale"`
+	MaxScale                float64 `json:"maxScale"`
+	EdgeRadius              float64 `json:"edgeRadius"`
+	EdgeWeight              float64 `json:"edgeWeight"`
+	OutsideImportance       float64 `json:"outsideImportance"`
+	RuleOfThirds            bool    `json:"ruleOfThirds"`
+	Prescale                bool    `json:"prescale"`
+	PrescaleMin             float64 `json:"prescaleMin"`
+
+	// StepFraction makes the distance between neighbouring candidates
+	// proportional to their dimensions, e.g. 0.04 for 4% of their width and
+	// height, instead of Step pixels. It keeps the search over big crops
+	// fast and the placement of small crops precise.
+	StepFraction float64 `json:"stepFraction,omitempty"`
+
+	// BlobPenalty lowers the total score of crops cutting through salient
+	// blobs, like half a face or half a product, by up to the given fraction,
+	// preferring crops which either fully include or fully exclude them.
+	BlobPenalty float64 `json:"blobPenalty,omitempty"`
+
+	// SkipSkin and SkipSaturation skip the skin and saturation detection, as
+	// if their weights were zero. For content like screenshots, maps or
+	// diagrams, they only add cost and noise.
+	SkipSkin       bool `json:"skipSkin,omitempty"`
+	SkipSaturation bool `json:"skipSaturation,omitempty"`
+
+	// ToneMap maps HDR images to SDR before prescaling them, so highlights
+	// above SDR white don't get clipped and lose their detail. It names the
+	// transfer function the image is encoded with, ToneMapPQ, ToneMapHLG or
+	// ToneMapLinear. Images are taken as SDR if empty.
+	ToneMap string `json:"toneMap,omitempty"`
+
+	// Denoise is the name of the filter to denoise the prescaled image with
+	// before detection, DenoiseMedian or DenoiseBilateral, which keeps the
+	// noise of high-ISO photos from being scored as detail. It isn't applied
+	// if empty.
+	Denoise string `json:"denoise,omitempty"`
+
+	// Equalize normalizes the contrast of the prescaled image before
+	// detection, either of the whole image with EqualizeGlobal or of tiles of
+	// it with EqualizeCLAHE. It recovers the saliency of low-contrast images
+	// like fog or backlit scenes. It isn't applied if empty.
+	Equalize string `json:"equalize,omitempty"`
+
+	// Budget is the time an analysis may take. If set, the analysis gets
+	// coarsened as far as necessary to finish within it, going by EstimateCost
+	// and the time analyses took recently. Result.Degradation reports how
+	// far.
+	Budget time.Duration `json:"budget,omitempty"`
+
+	// MaxDuration bounds the time an analysis takes, even if the caller has
+	// no means to cancel it. Once it's exceeded, no more candidates get
+	// scored, and the best crop found so far is returned with
+	// Result.Truncated set. At least one candidate is always scored.
+	MaxDuration time.Duration `json:"maxDuration,omitempty"`
+
+	// Workers is the number of goroutines the detectors and the scoring of
+	// an analysis get spread over, with 0 and 1 keeping to the calling
+	// goroutine. AutoWorkers picks it by the Calibration of the host, along
+	// with the sizes of the tiles and batches the work gets split into. The
+	// crop is the same either way.
+	Workers int `json:"workers,omitempty"`
+
+	// FixedPoint enables scoring with integer math, which is faster on devices
+	// with slow floating-point units. Its scores deviate slightly from the ones
+	// calculated with floating-point math.
+	FixedPoint bool `json:"fixedPoint,omitempty"`
+
+	// EdgeLevels is the number of levels of a Gaussian pyramid to detect
+	// edges on, with 2 or 3 levels picking up large soft-edged subjects too.
+	// 0 and 1 only detect edges at the resolution of the prescaled image.
+	EdgeLevels int `json:"edgeLevels,omitempty"`
+
+	// SkinBlobs scores skin by connected blobs instead of single pixels, so
+	// skin-colored texture like sand or wood grain doesn't attract the crop,
+	// whereas faces and hands get boosted as a whole.
+	SkinBlobs bool `json:"skinBlobs,omitempty"`
+
+	// SkySuppression scales the detector planes down within the sky, a smooth
+	// region at the top of the image, by the given fraction, ranging from 0
+	// to 1. It keeps vivid sunset skies from dragging the crop upwards if the
+	// subject is on the ground.
+	SkySuppression float64 `json:"skySuppression,omitempty"`
+
+	// ReducedPlanes computes the detector planes only for the pixels sampled
+	// by the scorer, i.e. at 1/ScoreDownSample of the resolution of the
+	// prescaled image, which makes detection much faster and leaner. It's
+	// ignored in debug mode, so the debug output stays at full resolution.
+	ReducedPlanes bool `json:"reducedPlanes,omitempty"`
+
+	// Quadtree scores crops using a quadtree over the detector planes instead
+	// of sampling every ScoreDownSample-th pixel. It skips over homogeneous
+	// regions and those outside of a crop, and samples detailed regions more
+	// densely. It takes precedence over FixedPoint.
+	Quadtree bool `json:"quadtree,omitempty"`
+
+	// Samples scores the given number of random candidates instead of the
+	// whole grid of candidates, a fast approximation for very large images.
+	// The candidates are drawn from a random number generator seeded with
+	// Seed, so the same seed always yields the same crop.
+	Samples int   `json:"samples,omitempty"`
+	Seed    int64 `json:"seed,omitempty"`
+
+	// DedupEpsilon skips candidates whose edges all lie less than the given
+	// number of pixels from the ones of a candidate already scored, e.g. the
+	// nearly identical candidates of adjacent scales.
+	DedupEpsilon int `json:"dedupEpsilon,omitempty"`
+
+	// Refine enables a local search for a better, fractional placement of the
+	// best crop found on the grid of candidates.
+	Refine bool `json:"refine,omitempty"`
+
+	// ExactRatio snaps the returned crop to exactly the requested aspect
+	// ratio, which matters if you crop without resizing afterwards. The crop
+	// might get slightly smaller or bigger to achieve that.
+	ExactRatio bool `json:"exactRatio,omitempty"`
+
+	// RatioTolerance lets the aspect ratio of the crop deviate from the
+	// requested one by up to the given fraction, e.g. 0.03 for 3%, if that
+	// improves its score considerably. This suits layouts which can absorb
+	// small differences, e.g. with CSS object-fit. The Result reports the
+	// ratio of the crop. It's ignored with ExactRatio or a TextZone.
+	RatioTolerance float64 `json:"ratioTolerance,omitempty"`
+
+	// Margin expands the best crop by the given fraction of its dimensions on
+	// each side, e.g. 0.05 for 5%, while keeping it within the image. This is
+	// useful for print bleed or later rotation.
+	Margin float64 `json:"margin,omitempty"`
+
+	// Detectors maps the names of registered Detectors to enable to the weight
+	// their output is mixed into the boost plane with.
+	Detectors map[string]float64 `json:"detectors,omitempty"`
+
+	// AttentionPoints is the number of salient regions to report in the
+	// Result, so clients can implement their own framing, e.g. by setting the
+	// CSS object-position per breakpoint.
+	AttentionPoints int `json:"attentionPoints,omitempty"`
+
+	// Strategies is the chain of CropStrategies to try, by the name they are
+	// registered with, defaulting to StrategySmart alone. The next strategy is
+	// tried as long as the confidence in the crop is below MinConfidence,
+	// ranging from 0 to 1, and the Result of the last one tried is returned.
+	Strategies    []string `json:"strategies,omitempty"`
+	MinConfidence float64  `json:"minConfidence,omitempty"`
+
+	// Ensemble maps the names of strategies to run all of instead of trying
+	// Strategies in order to their weights. EnsembleMode picks how their
+	// crops get combined, see EnsembleBest and EnsembleBlend.
+	Ensemble     map[string]float64 `json:"ensemble,omitempty"`
+	EnsembleMode string             `json:"ensembleMode,omitempty"`
+
+	// TextZone is the area of the crop, relative to its dimensions, text is
+	// going to be overlaid on. If set, crops on which text in TextColor, given
+	// as #rrggbb and defaulting to white, reaches a WCAG contrast ratio of
+	// MinContrast are preferred over the ones it doesn't. MinContrast defaults
+	// to 4.5, as required by level AA for normal text.
+	TextZone    *NormalizedRect `json:"textZone,omitempty"`
+	TextColor   string          `json:"textColor,omitempty"`
+	MinContrast float64         `json:"minContrast,omitempty"`
+
+	// Classifier flags regions to down-weight or avoid, e.g. NSFW content. It
+	// isn't part of the Fingerprint.
+	Classifier RegionClassifier `json:"-"`
+
+	// OnResult gets called with every crop decision. It isn't part of the
+	// Fingerprint.
+	OnResult func(Decision) `json:"-"`
+
+	// OnTrace gets called with the Trace of every analysis, including failed
+	// ones. It isn't part of the Fingerprint.
+	OnTrace func(Trace) `json:"-"`
+
+	// Hooks get called before and after the stages of the analysis. They
+	// aren't part of the Fingerprint.
+	Hooks Hooks `json:"-"`
+}
+
+// DefaultCropSettings returns the settings the analyzer uses unless told
+// otherwise.
+func DefaultCropSettings() CropSettings {
+	return CropSettings{
+		DetailWeight:            detailWeight,
+		SkinBias:                skinBias,
+		SkinBrightnessMin:       skinBrightnessMin,
+		SkinBrightnessMax:       skinBrightnessMax,
+		SkinThreshold:           skinThreshold,
+		SkinWeight:              skinWeight,
+		SaturationBrightnessMin: saturationBrightnessMin,
+		SaturationBrightnessMax: saturationBrightnessMax,
+		SaturationThreshold:     saturationThreshold,
+		SaturationBias:          saturationBias,
+		SaturationWeight:        saturationWeight,
+		BoostWeight:             boostWeight,
+		ScoreDownSample:         scoreDownSample,
+		Step:                    step,
+		ScaleStep:               scaleStep,
+		MinScale:                minScale,
+		MaxScale:                maxScale,
+		EdgeRadius:              edgeRadius,
+		EdgeWeight:              edgeWeight,
+		OutsideImportance:       outsideImportance,
+		RuleOfThirds:            ruleOfThirds,
+		Prescale:                prescale,
+		PrescaleMin:             prescaleMin,
+	}
+}
+
+// Fingerprint returns a deterministic hash of the algorithm version and the
+// settings. Two analyses with the same fingerprint yield the same crop for
+// the same input, so it can be used to invalidate cached crops. Settings
+// which don't pass Validate, e.g. with NaN weights, get a fingerprint as well.
+func (s CropSettings) Fingerprint() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%q:", Version)
+	writeFingerprint(h, reflect.ValueOf(s))
+
+	sum := h.Sum(nil)
+	return hex.EncodeToString(sum[:8])
+}
+
+// writeFingerprint writes v to w like JSON does, naming, omitting and ignoring
+// fields as their tags say, but it doesn't fail on NaN and infinite numbers.
+// Fields which are empty and omitted don't change the fingerprint, so adding
+// a setting doesn't invalidate the cached crops of settings not using it.
+func writeFingerprint(w io.Writer, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		io.WriteString(w, "{")
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := strings.Split(f.Tag.Get("json"), ",")
+			if f.PkgPath != "" || tag[0] == "-" {
+				continue
+			}
+			if len(tag) > 1 && tag[1] == "omitempty" && isEmptyValue(v.Field(i)) {
+				continue
+			}
+			name := tag[0]
+			if name == "" {
+				name = f.Name
+			}
+			fmt.Fprintf(w, "%q:", name)
+			writeFingerprint(w, v.Field(i))
+			io.WriteString(w, ",")
+		}
+		io.WriteString(w, "}")
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			io.WriteString(w, "null")
+			return
+		}
+		writeFingerprint(w, v.Elem())
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		io.WriteString(w, "{")
+		for _, k := range keys {
+			fmt.Fprintf(w, "%q:", k.String())
+			writeFingerprint(w, v.MapIndex(k))
+			io.WriteString(w, ",")
+		}
+		io.WriteString(w, "}")
+	case reflect.Slice, reflect.Array:
+		io.WriteString(w, "[")
+		for i := 0; i < v.Len(); i++ {
+			writeFingerprint(w, v.Index(i))
+			io.WriteString(w, ",")
+		}
+		io.WriteString(w, "]")
+	case reflect.Float32, reflect.Float64:
+		io.WriteString(w, strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.String:
+		io.WriteString(w, strconv.Quote(v.String()))
+	case reflect.Bool:
+		io.WriteString(w, strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		io.WriteString(w, strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		io.WriteString(w, strconv.FormatUint(v.Uint(), 10))
+	}
+}
+
+// isEmptyValue reports whether v is empty, as encoding/json defines it for
+// omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// detectionPasses returns the number of the built-in detectors which run.
+func (s CropSettings) detectionPasses() int {
+	passes := 3
+	if s.SkipSkin {
+		passes--
+	}
+	if s.SkipSaturation {
+		passes--
+	}
+	return passes
+}
+
+// prescaleFactor returns the factor an image with the given dimensions gets
+// scaled by before analysing it.
+func (s CropSettings) prescaleFactor(width, height int) float64 {
+	if !s.Prescale {
+		return 1.0
+	}
+
+	if f := s.PrescaleMin / math.Min(float64(width), float64(height)); f < 1.0 {
+		return f
+	}
+	return 1.0
+}
+
+// steps returns the horizontal and vertical distance between neighbouring
+// candidates of the given dimensions.
+func (s CropSettings) steps(cropW, cropH float64) (int, int) {
+	if s.StepFraction <= 0 {
+		return s.Step, s.Step
+	}
+	return maxInt(1, int(math.Round(s.StepFraction*cropW))), maxInt(1, int(math.Round(s.StepFraction*cropH)))
+}
+
+// jittersRatio reports whether the aspect ratio of the crop may deviate
+// from the requested one, see RatioTolerance.
+func (s CropSettings) jittersRatio() bool {
+	return s.RatioTolerance > 0 && !s.ExactRatio && s.TextZone == nil
+}
+
+// realMinScale returns the smallest scale of crop candidates, given the scale
+// between the image and the requested crop.
+func (s CropSettings) realMinScale(scale float64) float64 {
+	return math.Min(s.MaxScale, math.Max(1.0/scale, s.MinScale))
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// SidecarExt is appended to the path of an image to name its sidecar, e.g.
+// photo.jpg.smartcrop.json.
+const SidecarExt = ".smartcrop.json"
+
+// Sidecar contains the crops of an image for all the aspect ratios it has
+// been analyzed for, to be stored next to it as JSON, so static site
+// generators can pick up the crops when building a site instead of analyzing
+// the images themselves.
+type Sidecar struct {
+	AnalyzerVersion string `json:"analyzerVersion"`
+	ImageWidth      int    `json:"imageWidth"`
+	ImageHeight     int    `json:"imageHeight"`
+
+	// Settings are the settings the crops have been found with, if known.
+	// The ParamsHash of each crop identifies them either way.
+	Settings *CropSettings `json:"settings,omitempty"`
+
+	// Crops are the Results by their aspect ratio in lowest terms, e.g.
+	// "16:9".
+	Crops map[string]Result `json:"crops"`
+}
+
+// SidecarPath returns the path of the sidecar of the image at path.
+func SidecarPath(path string) string {
+	return path + SidecarExt
+}
+
+// RatioKey returns the key of the aspect ratio width:height in the Crops of a
+// Sidecar.
+func RatioKey(width, height int) string {
+	g := gcd(width, height)
+	return strconv.Itoa(width/g) + ":" + strconv.Itoa(height/g)
+}
+
+// Add records res, the crop for the dimensions width and height, replacing
+// the crop of the same aspect ratio. Missing dimensions are taken from the
+// crop, like FindBestResult does.
+func (s *Sidecar) Add(width, height int, res Result) {
+	if width <= 0 || height <= 0 {
+		width, height = res.Crop.Dx(), res.Crop.Dy()
+	}
+	if s.Crops == nil {
+		s.Crops = make(map[string]Result)
+	}
+
+	s.AnalyzerVersion = res.AnalyzerVersion
+	s.ImageWidth, s.ImageHeight = res.ImageWidth, res.ImageHeight
+	s.Crops[RatioKey(width, height)] = res
+}
+
+// ReadSidecar reads the sidecar of the image at path. It returns an empty
+// Sidecar if there is none yet.
+func ReadSidecar(path string) (Sidecar, error) {
+	var s Sidecar
+	b, err := ioutil.ReadFile(SidecarPath(path))
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(b, &s)
+	return s, err
+}
+
+// WriteSidecar writes s as the sidecar of the image at path. It's written to
+// a temporary file first, so readers never see it incomplete.
+func WriteSidecar(path string, s Sidecar) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path = SidecarPath(path)
+	f, err := ioutil.TempFile(filepath.Dir(path), ".smartcrop-")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSidecar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smartcrop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "photo.jpg")
+
+	s, err := ReadSidecar(path)
+	if err != nil || len(s.Crops) != 0 {
+		t.Fatalf("expected an empty sidecar, got %v (%v)", s, err)
+	}
+
+	settings := DefaultCropSettings()
+	res := newResult(Crop{Rectangle: image.Rect(10, 0, 210, 200)}, NormalizedRect{}, image.Rect(0, 0, 400, 200), settings)
+	s.Settings = &settings
+	s.Add(250, 250, res)
+	s.Add(1600, 900, res)
+	s.Add(0, 0, res)
+	if err := WriteSidecar(path, s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "photo.jpg.smartcrop.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = ReadSidecar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Crops) != 2 || s.Crops["1:1"].Crop.Rectangle != res.Crop.Rectangle || s.Crops["16:9"].ParamsHash != settings.Fingerprint() {
+		t.Errorf("expected the crops for 1:1 and 16:9, got %v", s.Crops)
+	}
+	if s.AnalyzerVersion != Version || s.ImageWidth != 400 || s.Settings == nil || s.Settings.Fingerprint() != settings.Fingerprint() {
+		t.Errorf("expected the version, dimensions and settings to be recorded, got %+v", s)
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+const (
+	// skinBlobMinArea is the smallest area of a skin blob to keep, relative
+	// to the area of the image.
+	skinBlobMinArea = 0.001
+	// skinBlobMaxAspect is the largest ratio between the long and the short
+	// side of the bounding box of a skin blob to keep. Streaks such as wood
+	// grain are longer.
+	skinBlobMaxAspect = 4.0
+	// skinBlobMinSolidity is the smallest share of its convex hull a skin
+	// blob to keep has to fill. Scattered pixels such as sand fill less.
+	skinBlobMinSolidity = 0.4
+)
+
+// skinBlobs replaces the per-pixel skin scores in the red channel of o by
+// blob-level ones, if enabled in the settings: connected skin pixels which
+// are too small, too elongated or too ragged to be a face or a hand get
+// dropped, whereas every pixel of the remaining blobs gets the mean score of
+// its blob.
+func (p pipeline) skinBlobs(o *image.RGBA) {
+	if !p.settings.SkinBlobs {
+		return
+	}
+
+	b := o.Bounds()
+	w, h := b.Dx(), b.Dy()
+	skin := func(i int) uint8 {
+		return o.Pix[o.PixOffset(b.Min.X+i%w, b.Min.Y+i/w)]
+	}
+	minArea := math.Max(skinBlobMinArea*float64(w*h), 2.0)
+
+	seen := make([]bool, w*h)
+	var blob, stack []int
+	for start := range seen {
+		if seen[start] || skin(start) == 0 {
+			continue
+		}
+
+		// collect the 8-connected blob
+		blob = blob[:0]
+		seen[start] = true
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			blob = append(blob, i)
+
+			x, y := i%w, i/w
+			for ny := maxInt(y-1, 0); ny <= minInt(y+1, h-1); ny++ {
+				for nx := maxInt(x-1, 0); nx <= minInt(x+1, w-1); nx++ {
+					if j := ny*w + nx; !seen[j] && skin(j) > 0 {
+						seen[j] = true
+						stack = append(stack, j)
+					}
+				}
+			}
+		}
+
+		value := uint8(0)
+		if keepSkinBlob(blob, w, minArea) {
+			sum := 0
+			for _, i := range blob {
+				sum += int(skin(i))
+			}
+			value = uint8((sum + len(blob)/2) / len(blob))
+		}
+		for _, i := range blob {
+			o.Pix[o.PixOffset(b.Min.X+i%w, b.Min.Y+i/w)] = value
+		}
+	}
+}
+
+// keepSkinBlob reports whether the blob of pixel indices into an image of
+// width w is shaped like a face or a hand.
+func keepSkinBlob(blob []int, w int, minArea float64) bool {
+	area := float64(len(blob))
+	if area < minArea {
+		return false
+	}
+
+	// the bounding box and the leftmost and rightmost pixel of every row,
+	// which are all it takes to find the convex hull
+	rows := map[int][2]int{}
+	minX, minY, maxX, maxY := w, math.MaxInt32, -1, -1
+	for _, i := range blob {
+		x, y := i%w, i/w
+		minX, maxX = minInt(minX, x), maxInt(maxX, x)
+		minY, maxY = minInt(minY, y), maxInt(maxY, y)
+		if r, ok := rows[y]; !ok {
+			rows[y] = [2]int{x, x}
+		} else {
+			rows[y] = [2]int{minInt(r[0], x), maxInt(r[1], x)}
+		}
+	}
+
+	bw, bh := float64(maxX-minX+1), float64(maxY-minY+1)
+	if math.Max(bw, bh)/math.Min(bw, bh) > skinBlobMaxAspect {
+		return false
+	}
+
+	points := make([]image.Point, 0, 2*len(rows))
+	for y, r := range rows {
+		points = append(points, image.Pt(r[0], y), image.Pt(r[1], y))
+	}
+	hullArea, perimeter := convexHull(points)
+	// the number of pixels covered by the hull, by Pick's theorem
+	return area/(hullArea+perimeter/2.0+1.0) >= skinBlobMinSolidity
+}
+
+// convexHull returns the area and the perimeter of the convex hull of points.
+func convexHull(points []image.Point) (float64, float64) {
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].X != points[j].X {
+			return points[i].X < points[j].X
+		}
+		return points[i].Y < points[j].Y
+	})
+
+	cross := func(o, a, b image.Point) int {
+		return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+	}
+
+	// Andrew's monotone chain
+	hull := make([]image.Point, 0, 2*len(points))
+	for _, p := range points {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	for i, lower := len(points)-2, len(hull)+1; i >= 0; i-- {
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], points[i]) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, points[i])
+	}
+	hull = hull[:len(hull)-1]
+
+	var area, perimeter float64
+	for i, p := range hull {
+		q := hull[(i+1)%len(hull)]
+		area += float64(p.X*q.Y - q.X*p.Y)
+		perimeter += math.Hypot(float64(q.X-p.X), float64(q.Y-p.Y))
+	}
+	return math.Abs(area) / 2.0, perimeter
+}
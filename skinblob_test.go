@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+)
+
+func TestSkinBlobs(t *testing.T) {
+	o := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	set := func(x, y int, v uint8) {
+		o.Pix[o.PixOffset(x, y)] = v
+	}
+
+	// a face
+	for y := 10; y < 30; y++ {
+		for x := 10; x < 25; x++ {
+			set(x, y, uint8(100+x+y))
+		}
+	}
+	// a streak of wood grain
+	for x := 40; x < 90; x++ {
+		set(x, 50, 200)
+		set(x, 51, 200)
+	}
+	// and scattered grains of sand
+	rnd := rand.New(rand.NewSource(1))
+	for y := 60; y < 100; y++ {
+		for x := 0; x < 40; x++ {
+			if rnd.Float64() < 0.3 {
+				set(x, y, 200)
+			}
+		}
+	}
+
+	settings := DefaultCropSettings()
+	settings.SkinBlobs = true
+	pipeline{settings: &settings}.skinBlobs(o)
+
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 200; x++ {
+			v := o.Pix[o.PixOffset(x, y)]
+			face := x >= 10 && x < 25 && y >= 10 && y < 30
+			if face && v != 137 {
+				t.Fatalf("expected the face to get its mean skin score of 137 at %d,%d, got %d", x, y, v)
+			}
+			if !face && v != 0 {
+				t.Fatalf("expected no skin outside of the face at %d,%d, got %d", x, y, v)
+			}
+		}
+	}
+}
+
+func TestConvexHull(t *testing.T) {
+	area, perimeter := convexHull([]image.Point{{0, 0}, {4, 0}, {2, 1}, {4, 3}, {0, 3}, {2, 3}})
+	if area != 12 || perimeter != 14 {
+		t.Fatalf("expected area 12 and perimeter 14, got %v and %v", area, perimeter)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import "image"
+
+const (
+	// skyTolerance is the largest difference of any channel between
+	// neighboring pixels of the sky. Skies are smooth gradients, whatever
+	// their color.
+	skyTolerance = 8
+	// skyMaxDepth is the share of the height of the image from the top the
+	// sky may extend to.
+	skyMaxDepth = 2.0 / 3.0
+)
+
+// skyMask returns which pixels of img belong to the sky, i.e. the featureless
+// region reachable from the top border via smooth transitions only.
+func skyMask(img *image.RGBA) []bool {
+	b := img.Bounds()
+	w, h := b.Dx(), int(float64(b.Dy())*skyMaxDepth)
+	mask := make([]bool, w*b.Dy())
+
+	smooth := func(x0, y0, x1, y1 int) bool {
+		i, j := img.PixOffset(b.Min.X+x0, b.Min.Y+y0), img.PixOffset(b.Min.X+x1, b.Min.Y+y1)
+		for c := 0; c < 3; c++ {
+			if absInt(int(img.Pix[i+c])-int(img.Pix[j+c])) > skyTolerance {
+				return false
+			}
+		}
+		return true
+	}
+
+	// the top row seeds the sky where it is smooth itself
+	var stack []int
+	for x := 0; x < w && h > 0; x++ {
+		if (x == 0 || smooth(x-1, 0, x, 0)) && (x == w-1 || smooth(x, 0, x+1, 0)) {
+			mask[x] = true
+			stack = append(stack, x)
+		}
+	}
+
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		x, y := i%w, i/w
+
+		for _, d := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			nx, ny := x+d[0], y+d[1]
+			if nx < 0 || ny < 0 || nx >= w || ny >= h {
+				continue
+			}
+			if j := ny*w + nx; !mask[j] && smooth(x, y, nx, ny) {
+				mask[j] = true
+				stack = append(stack, j)
+			}
+		}
+	}
+	return mask
+}
+
+// suppressSky scales the detector planes o, which are scaled down by the
+// factor reduction, down within the sky of img, if enabled in the settings.
+func (p pipeline) suppressSky(img *image.RGBA, o *image.RGBA, reduction int) {
+	if p.settings.SkySuppression <= 0 {
+		return
+	}
+
+	mask := skyMask(img)
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	f := 1.0 - p.settings.SkySuppression
+	if f < 0 {
+		f = 0
+	}
+
+	ob := o.Bounds()
+	for oy := 0; oy < ob.Dy(); oy++ {
+		y := minInt(oy*reduction, h-1)
+		for ox := 0; ox < ob.Dx(); ox++ {
+			if x := minInt(ox*reduction, w-1); !mask[y*w+x] {
+				continue
+			}
+			off := o.PixOffset(ob.Min.X+ox, ob.Min.Y+oy)
+			o.Pix[off] = uint8(float64(o.Pix[off]) * f)
+			o.Pix[off+1] = uint8(float64(o.Pix[off+1]) * f)
+			o.Pix[off+2] = uint8(float64(o.Pix[off+2]) * f)
+		}
+	}
+}
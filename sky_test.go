@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestSky(t *testing.T) {
+	// a vivid sunset sky above textured ground
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			c := color.RGBA{255, uint8(80 + y), 40, 255}
+			if y >= 50 {
+				v := uint8(60 + (x*7+y*13)%50)
+				c = color.RGBA{v, v, v, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	mask := skyMask(img)
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			if sky := y < 50; mask[y*100+x] != sky {
+				t.Fatalf("expected the sky to be %v at %d,%d, got %v", sky, x, y, mask[y*100+x])
+			}
+		}
+	}
+
+	settings := DefaultCropSettings()
+	settings.SkySuppression = 0.75
+	full := image.NewRGBA(img.Bounds())
+	saturationDetect(&settings, img, full)
+	o := image.NewRGBA(img.Bounds())
+	copy(o.Pix, full.Pix)
+	pipeline{settings: &settings}.suppressSky(img, o, 1)
+
+	if v := full.Pix[full.PixOffset(50, 10)+2]; v == 0 {
+		t.Fatal("expected the sky to be saturated")
+	}
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			v, expected := o.Pix[o.PixOffset(x, y)+2], full.Pix[full.PixOffset(x, y)+2]
+			if y < 50 {
+				expected = uint8(float64(expected) * 0.25)
+			}
+			if v != expected {
+				t.Fatalf("expected a saturation of %d at %d,%d, got %d", expected, x, y, v)
+			}
+		}
+	}
+}
@@ -38,7 +38,6 @@ import (
 	"io/ioutil"
 	"log"
 	"math"
-	"time"
 
 	"github.com/muesli/smartcrop/options"
 
@@ -64,6 +63,7 @@ const (
 	saturationThreshold     = 0.4
 	saturationBias          = 0.2
 	saturationWeight        = 0.3
+	boostWeight             = 100.0
 	scoreDownSample         = 8 // step * minscale rounded down to the next power of two should be good
 	step                    = 8
 	scaleStep               = 0.1
@@ -85,9 +85,19 @@ type Analyzer interface {
 
 // Score contains values that classify matches
 type Score struct {
-	Detail     float64
-	Saturation float64
-	Skin       float64
+	Detail     float64 `json:"detail"`
+	Saturation float64 `json:"saturation"`
+	Skin       float64 `json:"skin"`
+	Boost      float64 `json:"boost"`
+	Total      float64 `json:"total"`
+
+	// Contrast is the contrast ratio between the text color and the text zone
+	// of the crop, if one is configured in the settings.
+	Contrast float64 `json:"contrast,omitempty"`
+
+	// Cut rates how badly the crop cuts through salient blobs, from 0 to 1,
+	// if BlobPenalty is enabled in the settings.
+	Cut float64 `json:"cut,omitempty"`
 }
 
 // Crop contains results
@@ -100,10 +110,16 @@ type Crop struct {
 type Logger struct {
 	DebugMode bool
 	Log       *log.Logger
+
+	// DebugPrefix is prepended to the names of the debug images written in
+	// DebugMode, e.g. a directory and the name of the input image. It
+	// defaults to "./smartcrop_".
+	DebugPrefix string
 }
 
 type smartcropAnalyzer struct {
-	logger Logger
+	logger   Logger
+	settings CropSettings
 	options.Resizer
 }
 
@@ -118,68 +134,55 @@ func NewAnalyzer(resizer options.Resizer) Analyzer {
 
 // NewAnalyzerWithLogger returns a new analyzer with the given Resizer and Logger.
 func NewAnalyzerWithLogger(resizer options.Resizer, logger Logger) Analyzer {
+	return NewAnalyzerWithSettings(resizer, logger, DefaultCropSettings())
+}
+
+// NewAnalyzerWithSettings returns a new analyzer with the given Resizer, Logger
+// and CropSettings.
+func NewAnalyzerWithSettings(resizer options.Resizer, logger Logger, settings CropSettings) Analyzer {
 	if logger.Log == nil {
 		logger.Log = log.New(ioutil.Discard, "", 0)
 	}
-	return &smartcropAnalyzer{Resizer: resizer, logger: logger}
+	return &smartcropAnalyzer{Resizer: resizer, logger: logger, settings: settings}
 }
 
 func (o smartcropAnalyzer) FindBestCrop(img image.Image, width, height int) (image.Rectangle, error) {
-	if width == 0 && height == 0 {
-		return image.Rectangle{}, ErrInvalidDimensions
-	}
-
-	// resize image for faster processing
-	scale := math.Min(float64(img.Bounds().Dx())/float64(width), float64(img.Bounds().Dy())/float64(height))
-	var lowimg *image.RGBA
-	var prescalefactor = 1.0
-
-	if prescale {
-		// if f := 1.0 / scale / minScale; f < 1.0 {
-		// prescalefactor = f
-		// }
-		if f := prescaleMin / math.Min(float64(img.Bounds().Dx()), float64(img.Bounds().Dy())); f < 1.0 {
-			prescalefactor = f
-		}
-		o.logger.Log.Println(prescalefactor)
+	res, err := o.FindBestResult(img, width, height)
+	return res.Crop.Rectangle, err
+}
 
-		smallimg := o.Resize(
-			img,
-			uint(float64(img.Bounds().Dx())*prescalefactor),
-			0)
+// FindBestResult returns the full Result of the analysis, including the scores
+// of the best crop and the analyzer version it was found with.
+func (o smartcropAnalyzer) FindBestResult(img image.Image, width, height int) (res Result, err error) {
+	defer recoverAnalysis(&err, img, width, height, o.settings)
 
-		lowimg = toRGBA(smallimg)
-	} else {
-		lowimg = toRGBA(img)
+	if width, height, err = validateImage(img, width, height); err != nil {
+		return Result{}, err
 	}
-
-	if o.logger.DebugMode {
-		writeImage("png", lowimg, "./smartcrop_prescale.png")
+	if err := o.settings.Validate(); err != nil {
+		return Result{}, err
 	}
 
-	cropWidth, cropHeight := chop(float64(width)*scale*prescalefactor), chop(float64(height)*scale*prescalefactor)
-	realMinScale := math.Min(maxScale, math.Max(1.0/scale, minScale))
+	b := img.Bounds()
+	var degradation int
+	o.settings, degradation = o.settings.forBudget(b.Dx(), b.Dy(), width, height)
 
-	o.logger.Log.Printf("original resolution: %dx%d\n", img.Bounds().Dx(), img.Bounds().Dy())
-	o.logger.Log.Printf("scale: %f, cropw: %f, croph: %f, minscale: %f\n", scale, cropWidth, cropHeight, realMinScale)
-
-	topCrop, err := analyse(o.logger, lowimg, cropWidth, cropHeight, realMinScale)
+	st := &State{Source: img, Width: width, Height: height}
+	res, elapsed, err := o.find(st, degradation)
 	if err != nil {
-		return topCrop, err
+		return Result{}, err
 	}
 
-	if prescale == true {
-		topCrop.Min.X = int(chop(float64(topCrop.Min.X) / prescalefactor))
-		topCrop.Min.Y = int(chop(float64(topCrop.Min.Y) / prescalefactor))
-		topCrop.Max.X = int(chop(float64(topCrop.Max.X) / prescalefactor))
-		topCrop.Max.Y = int(chop(float64(topCrop.Max.Y) / prescalefactor))
+	if o.settings.Budget > 0 {
+		if e, err := EstimateCost(b.Dx(), b.Dy(), width, height, o.settings); err == nil {
+			observeOpCost(e, elapsed)
+		}
 	}
-
-	return topCrop.Canon(), nil
+	return res, nil
 }
 
-func (c Crop) totalScore() float64 {
-	return (c.Score.Detail*detailWeight + c.Score.Skin*skinWeight + c.Score.Saturation*saturationWeight) / float64(c.Dx()) / float64(c.Dy())
+func (c Crop) totalScore(s *CropSettings) float64 {
+	return (c.Score.Detail*s.DetailWeight + c.Score.Skin*s.SkinWeight + c.Score.Saturation*s.SaturationWeight + c.Score.Boost*s.BoostWeight) / float64(c.Dx()) / float64(c.Dy())
 }
 
 func chop(x float64) float64 {
@@ -198,101 +201,80 @@ func bounds(l float64) float64 {
 	return math.Min(math.Max(l, 0.0), 255)
 }
 
-func importance(crop Crop, x, y int) float64 {
+func importance(s *CropSettings, crop Crop, x, y int) float64 {
 	if crop.Min.X > x || x >= crop.Max.X || crop.Min.Y > y || y >= crop.Max.Y {
-		return outsideImportance
+		return s.OutsideImportance
 	}
 
 	xf := float64(x-crop.Min.X) / float64(crop.Dx())
 	yf := float64(y-crop.Min.Y) / float64(crop.Dy())
 
+	return relativeImportance(s, xf, yf)
+}
+
+// relativeImportance returns the importance of a point inside a crop, given
+// relative to the crop's dimensions.
+func relativeImportance(s *CropSettings, xf, yf float64) float64 {
 	px := math.Abs(0.5-xf) * 2.0
 	py := math.Abs(0.5-yf) * 2.0
 
-	dx := math.Max(px-1.0+edgeRadius, 0.0)
-	dy := math.Max(py-1.0+edgeRadius, 0.0)
-	d := (dx*dx + dy*dy) * edgeWeight
+	dx := math.Max(px-1.0+s.EdgeRadius, 0.0)
+	dy := math.Max(py-1.0+s.EdgeRadius, 0.0)
+	d := (dx*dx + dy*dy) * s.EdgeWeight
 
-	s := 1.41 - math.Sqrt(px*px+py*py)
-	if ruleOfThirds {
-		s += (math.Max(0.0, s+d+0.5) * 1.2) * (thirds(px) + thirds(py))
+	i := 1.41 - math.Sqrt(px*px+py*py)
+	if s.RuleOfThirds {
+		i += (math.Max(0.0, i+d+0.5) * 1.2) * (thirds(px) + thirds(py))
 	}
 
-	return s + d
+	return i + d
 }
 
-func score(output *image.RGBA, crop Crop) Score {
+// score samples the detector planes, which are scaled down by the factor
+// reduction compared to the prescaled image crop refers to.
+func score(s *CropSettings, output *image.RGBA, boost *image.Gray, crop Crop, reduction int) Score {
 	width := output.Bounds().Dx()
 	height := output.Bounds().Dy()
+	step := s.ScoreDownSample / reduction
 	score := Score{}
 
+	rows := newRGBARows(output)
+	var boostRows grayRows
+	if boost != nil {
+		boostRows = newGrayRows(boost)
+	}
+
 	// same loops but with downsampling
 	//for y := 0; y < height; y++ {
 	//for x := 0; x < width; x++ {
-	for y := 0; y <= height-scoreDownSample; y += scoreDownSample {
-		for x := 0; x <= width-scoreDownSample; x += scoreDownSample {
+	for y := 0; y <= height-step; y += step {
+		row := rows.row(y)
+		var boostRow []uint8
+		if boost != nil {
+			boostRow = boostRows.row(y)
+		}
+		for x := 0; x <= width-step; x += step {
 
-			c := output.RGBAAt(x, y)
-			r8 := float64(c.R)
-			g8 := float64(c.G)
-			b8 := float64(c.B)
+			p := row[x*4 : x*4+3 : x*4+3]
+			r8 := float64(p[0])
+			g8 := float64(p[1])
+			b8 := float64(p[2])
 
-			imp := importance(crop, int(x), int(y))
+			imp := importance(s, crop, x*reduction, y*reduction)
 			det := g8 / 255.0
 
-			score.Skin += r8 / 255.0 * (det + skinBias) * imp
+			score.Skin += r8 / 255.0 * (det + s.SkinBias) * imp
 			score.Detail += det * imp
-			score.Saturation += b8 / 255.0 * (det + saturationBias) * imp
+			score.Saturation += b8 / 255.0 * (det + s.SaturationBias) * imp
+			if boost != nil {
+				score.Boost += float64(boostRow[x]) / 255.0 * imp
+			}
 		}
 	}
 
 	return score
 }
 
-func analyse(logger Logger, img *image.RGBA, cropWidth, cropHeight, realMinScale float64) (image.Rectangle, error) {
-	o := image.NewRGBA(img.Bounds())
-
-	now := time.Now()
-	edgeDetect(img, o)
-	logger.Log.Println("Time elapsed edge:", time.Since(now))
-	debugOutput(logger.DebugMode, o, "edge")
-
-	now = time.Now()
-	skinDetect(img, o)
-	logger.Log.Println("Time elapsed skin:", time.Since(now))
-	debugOutput(logger.DebugMode, o, "skin")
-
-	now = time.Now()
-	saturationDetect(img, o)
-	logger.Log.Println("Time elapsed sat:", time.Since(now))
-	debugOutput(logger.DebugMode, o, "saturation")
-
-	now = time.Now()
-	var topCrop Crop
-	topScore := -1.0
-	cs := crops(o, cropWidth, cropHeight, realMinScale)
-	logger.Log.Println("Time elapsed crops:", time.Since(now), len(cs))
-
-	now = time.Now()
-	for _, crop := range cs {
-		nowIn := time.Now()
-		crop.Score = score(o, crop)
-		logger.Log.Println("Time elapsed single-score:", time.Since(nowIn))
-		if crop.totalScore() > topScore {
-			topCrop = crop
-			topScore = crop.totalScore()
-		}
-	}
-	logger.Log.Println("Time elapsed score:", time.Since(now))
-
-	if logger.DebugMode {
-		drawDebugCrop(topCrop, o)
-		debugOutput(true, o, "final")
-	}
-
-	return topCrop.Rectangle, nil
-}
-
 func saturation(c color.RGBA) float64 {
 	cMax, cMin := uint8(0), uint8(255)
 	if c.R > cMax {
@@ -347,91 +329,143 @@ func skinCol(c color.RGBA) float64 {
 }
 
 func makeCies(img *image.RGBA) []float64 {
-	width := img.Bounds().Dx()
-	height := img.Bounds().Dy()
-	cies := make([]float64, width*height, width*height)
-	i := 0
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			cies[i] = cie(img.RGBAAt(x, y))
-			i++
-		}
+	return fillCies(img, make([]float64, img.Bounds().Dx()*img.Bounds().Dy()))
+}
+
+// fillCies fills cies with the lightness of every pixel of img and returns
+// it.
+func fillCies(img *image.RGBA, cies []float64) []float64 {
+	rows := newRGBARows(img)
+	width := rows.width
+	for y := 0; y < rows.height; y++ {
+		lightnessRow(cies[y*width:(y+1)*width], rows.row(y))
 	}
 
 	return cies
 }
 
+// edgeDetect stores the edges of i in the green channel of o, clearing its
+// other channels.
+//
+// Only the lightness of the three rows around the current one is kept, in a
+// ring of rows, instead of the lightness of the whole image: each row of i
+// gets read and each row of o written once, while the rows being worked on
+// stay in the cache even for 4K images.
 func edgeDetect(i *image.RGBA, o *image.RGBA) {
-	width := i.Bounds().Dx()
-	height := i.Bounds().Dy()
-	cies := makeCies(i)
-
-	var lightness float64
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			if x == 0 || x >= width-1 || y == 0 || y >= height-1 {
-				//lightness = cie((*i).At(x, y))
-				lightness = 0
-			} else {
-				lightness = cies[y*width+x]*4.0 -
-					cies[x+(y-1)*width] -
-					cies[x-1+y*width] -
-					cies[x+1+y*width] -
-					cies[x+(y+1)*width]
+	edgeDetectRows(i, o, 0, i.Bounds().Dy())
+}
+
+// edgeDetectRows is edgeDetect for the rows y0 up to y1 of o only, relative
+// to the bounds. The rows around them are read from i all the same, so the
+// bands of an image can be detected concurrently.
+func edgeDetectRows(i *image.RGBA, o *image.RGBA, y0, y1 int) {
+	in, outRows := newRGBARows(i), newRGBARows(o)
+	width := in.width
+	height := in.height
+	ring := getFloats(3 * width)
+	defer putFloats(ring)
+
+	// row returns the lightness of row y, which must have been computed
+	row := func(y int) []float64 {
+		k := y % 3
+		return ring[k*width : (k+1)*width]
+	}
+	fill := func(y int) {
+		lightnessRow(row(y), in.row(y))
+	}
+
+	next := maxInt(y0-1, 0)
+	for y := y0; y < y1; y++ {
+		for ; next <= y+1 && next < height; next++ {
+			fill(next)
+		}
+
+		out := outRows.row(y)
+		if y == 0 || y >= height-1 {
+			for x := 0; x < width; x++ {
+				out[x*4], out[x*4+1], out[x*4+2], out[x*4+3] = 0, 0, 0, 255
 			}
+			continue
+		}
 
-			nc := color.RGBA{0, uint8(bounds(lightness)), 0, 255}
-			o.SetRGBA(x, y, nc)
+		above, cur, below := row(y-1), row(y), row(y+1)
+		out[0], out[1], out[2], out[3] = 0, 0, 0, 255
+		for x := 1; x < width-1; x++ {
+			lightness := cur[x]*4.0 -
+				above[x] -
+				cur[x-1] -
+				cur[x+1] -
+				below[x]
+			// the same as bounds, but clamped as an integer without branches
+			v := int(lightness)
+			if v < 0 {
+				v = 0
+			}
+			if v > 255 {
+				v = 255
+			}
+			p := out[x*4 : x*4+4 : x*4+4]
+			p[0], p[1], p[2], p[3] = 0, uint8(v), 0, 255
+		}
+		if width > 1 {
+			p := out[(width-1)*4:]
+			p[0], p[1], p[2], p[3] = 0, 0, 0, 255
 		}
 	}
 }
 
-func skinDetect(i *image.RGBA, o *image.RGBA) {
-	width := i.Bounds().Dx()
-	height := i.Bounds().Dy()
-
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			lightness := cie(i.RGBAAt(x, y)) / 255.0
-			skin := skinCol(i.RGBAAt(x, y))
-
-			c := o.RGBAAt(x, y)
-			if skin > skinThreshold && lightness >= skinBrightnessMin && lightness <= skinBrightnessMax {
-				r := (skin - skinThreshold) * (255.0 / (1.0 - skinThreshold))
-				nc := color.RGBA{uint8(bounds(r)), c.G, c.B, 255}
-				o.SetRGBA(x, y, nc)
-			} else {
-				nc := color.RGBA{0, c.G, c.B, 255}
-				o.SetRGBA(x, y, nc)
+func skinDetect(s *CropSettings, i *image.RGBA, o *image.RGBA) {
+	t := getColorTables()
+	k := 255.0 / (1.0 - s.SkinThreshold)
+	in, out := newRGBARows(i), newRGBARows(o)
+
+	for y := 0; y < in.height; y++ {
+		irow, orow := in.row(y), out.row(y)
+		for x := 0; x < in.width; x++ {
+			p, q := irow[x*4:x*4+4:x*4+4], orow[x*4:x*4+4:x*4+4]
+			r8, g8, b8 := p[0], p[1], p[2]
+
+			v := 0.0
+			// the lightness is much cheaper to check than the skin color
+			if lightness := t.cie(r8, g8, b8) / 255.0; lightness >= s.SkinBrightnessMin && lightness <= s.SkinBrightnessMax {
+				if skin := t.skin(r8, g8, b8); skin > s.SkinThreshold {
+					v = bounds((skin - s.SkinThreshold) * k)
+				}
 			}
+
+			q[0] = uint8(v)
+			q[3] = 255
 		}
 	}
 }
 
-func saturationDetect(i *image.RGBA, o *image.RGBA) {
-	width := i.Bounds().Dx()
-	height := i.Bounds().Dy()
-
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			lightness := cie(i.RGBAAt(x, y)) / 255.0
-			saturation := saturation(i.RGBAAt(x, y))
-
-			c := o.RGBAAt(x, y)
-			if saturation > saturationThreshold && lightness >= saturationBrightnessMin && lightness <= saturationBrightnessMax {
-				b := (saturation - saturationThreshold) * (255.0 / (1.0 - saturationThreshold))
-				nc := color.RGBA{c.R, c.G, uint8(bounds(b)), 255}
-				o.SetRGBA(x, y, nc)
-			} else {
-				nc := color.RGBA{c.R, c.G, 0, 255}
-				o.SetRGBA(x, y, nc)
+func saturationDetect(s *CropSettings, i *image.RGBA, o *image.RGBA) {
+	t := getColorTables()
+	k := 255.0 / (1.0 - s.SaturationThreshold)
+	in, out := newRGBARows(i), newRGBARows(o)
+
+	for y := 0; y < in.height; y++ {
+		irow, orow := in.row(y), out.row(y)
+		for x := 0; x < in.width; x++ {
+			p, q := irow[x*4:x*4+4:x*4+4], orow[x*4:x*4+4:x*4+4]
+			r8, g8, b8 := p[0], p[1], p[2]
+
+			v := 0.0
+			if saturation := t.saturation(r8, g8, b8); saturation > s.SaturationThreshold {
+				if lightness := t.cie(r8, g8, b8) / 255.0; lightness >= s.SaturationBrightnessMin && lightness <= s.SaturationBrightnessMax {
+					v = bounds((saturation - s.SaturationThreshold) * k)
+				}
 			}
+
+			q[2] = uint8(v)
+			q[3] = 255
 		}
 	}
 }
 
-func crops(i image.Image, cropWidth, cropHeight, realMinScale float64) []Crop {
-	res := []Crop{}
+// crops calls fn for every crop candidate, without materializing them all at
+// once. It stops as soon as fn returns false.
+func crops(s *CropSettings, i image.Image, cropWidth, cropHeight, realMinScale float64, fn func(Crop) bool) {
 	width := i.Bounds().Dx()
 	height := i.Bounds().Dy()
 
@@ -449,16 +483,49 @@ func crops(i image.Image, cropWidth, cropHeight, realMinScale float64) []Crop {
 		cropH = minDimension
 	}
 
-	for scale := maxScale; scale >= realMinScale; scale -= scaleStep {
-		for y := 0; float64(y)+cropH*scale <= float64(height); y += step {
-			for x := 0; float64(x)+cropW*scale <= float64(width); x += step {
-				res = append(res, Crop{
-					Rectangle: image.Rect(x, y, x+int(cropW*scale), y+int(cropH*scale)),
-				})
+	if s.DedupEpsilon > 0 {
+		seen, next := newCandidateSet(s.DedupEpsilon), fn
+		fn = func(crop Crop) bool {
+			return !seen.add(crop.Rectangle) || next(crop)
+		}
+	}
+
+	origin := i.Bounds().Min
+	if s.Samples > 0 {
+		sampleCrops(s, width, height, cropW, cropH, realMinScale, origin, fn)
+		return
+	}
+	for scale := s.MaxScale; scale >= realMinScale; scale -= s.ScaleStep {
+		w, h := int(cropW*scale), int(cropH*scale)
+		stepX, stepY := s.steps(cropW*scale, cropH*scale)
+		xs := positions(stepX, cropW*scale, width)
+		for _, y := range positions(stepY, cropH*scale, height) {
+			for _, x := range xs {
+				crop := Crop{
+					Rectangle: image.Rect(x, y, x+w, y+h).Add(origin),
+				}
+				if !fn(crop) {
+					return
+				}
 			}
 		}
 	}
+}
 
+// positions returns the offsets at which a crop of the given size fits into
+// size, in steps of step. Unless size is a multiple of step, the crop can't be
+// placed flush against the far edge that way, so that offset gets added too.
+func positions(step int, crop float64, size int) []int {
+	var res []int
+	last := -1
+	for p := 0; float64(p)+crop <= float64(size); p += step {
+		res = append(res, p)
+		last = p
+	}
+
+	if anchored := size - int(crop); last >= 0 && anchored > last {
+		res = append(res, anchored)
+	}
 	return res
 }
 
@@ -28,13 +28,17 @@
 package smartcrop
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"image"
+	"image/color"
 	_ "image/jpeg"
 	_ "image/png"
 	"io/ioutil"
+	"math/rand"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -68,7 +72,7 @@ func TestCrop(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	expected := image.Rect(464, 24, 719, 279)
+	expected := image.Rect(464, 29, 719, 284)
 	if topCrop != expected {
 		t.Fatalf("expected %v, got %v", expected, topCrop)
 	}
@@ -83,6 +87,92 @@ func TestCrop(t *testing.T) {
 	}
 }
 
+func TestPositions(t *testing.T) {
+	for _, tc := range []struct {
+		step     int
+		crop     float64
+		size     int
+		expected []int
+	}{
+		// the last step ends flush with the edge
+		{8, 20, 44, []int{0, 8, 16, 24}},
+		// it doesn't, so the edge gets an offset of its own
+		{8, 20, 47, []int{0, 8, 16, 24, 27}},
+		{8, 20.5, 47, []int{0, 8, 16, 24, 27}},
+		{8, 47, 47, []int{0}},
+		{8, 48, 47, nil},
+	} {
+		if got := positions(tc.step, tc.crop, tc.size); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("expected positions %v of %v in %d, got %v", tc.expected, tc.crop, tc.size, got)
+		}
+	}
+
+	// every scale has candidates against the right and bottom edges, also of
+	// an image not starting at the origin
+	s := DefaultCropSettings()
+	bounds := image.Rect(10, 20, 413, 321)
+	anchored := map[int]image.Point{}
+	crops(&s, bounds, 200, 200, 0.9, func(crop Crop) bool {
+		if crop.Max.X == bounds.Max.X && crop.Max.Y == bounds.Max.Y {
+			anchored[crop.Dx()] = crop.Min
+		}
+		return true
+	})
+	if len(anchored) != 2 {
+		t.Fatalf("expected a candidate in the bottom right corner per scale, got %v", anchored)
+	}
+}
+
+func TestStepFraction(t *testing.T) {
+	s := DefaultCropSettings()
+	s.StepFraction = 0.1
+	s.ScaleStep = 0.5
+
+	// the candidates are 20x10 pixels apart at full scale, 10x5 at half
+	counts := map[int]int{}
+	crops(&s, image.Rect(0, 0, 400, 300), 200, 100, 0.5, func(crop Crop) bool {
+		counts[crop.Dx()]++
+		return true
+	})
+	if counts[200] != 11*21 || counts[100] != 31*51 {
+		t.Fatalf("expected %d and %d candidates, got %v", 11*21, 31*51, counts)
+	}
+
+	if d := degrade(s, 1); d.StepFraction != 0.2 {
+		t.Fatalf("expected degrading to coarsen the step, got %v", d.StepFraction)
+	}
+}
+
+func TestEdgeDetect(t *testing.T) {
+	// the lightness of the whole image at once, as edgeDetect used to
+	reference := func(i *image.RGBA) *image.RGBA {
+		w, h := i.Bounds().Dx(), i.Bounds().Dy()
+		o := image.NewRGBA(i.Bounds())
+		cies := makeCies(i)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				lightness := 0.0
+				if x > 0 && x < w-1 && y > 0 && y < h-1 {
+					lightness = cies[y*w+x]*4.0 - cies[x+(y-1)*w] - cies[x-1+y*w] - cies[x+1+y*w] - cies[x+(y+1)*w]
+				}
+				o.SetRGBA(x, y, color.RGBA{0, uint8(bounds(lightness)), 0, 255})
+			}
+		}
+		return o
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for _, size := range []image.Point{{1, 1}, {2, 3}, {3, 3}, {17, 9}, {64, 5}} {
+		i := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+		r.Read(i.Pix)
+		o := image.NewRGBA(i.Bounds())
+		edgeDetect(i, o)
+		if expected := reference(i); !bytes.Equal(o.Pix, expected.Pix) {
+			t.Fatalf("expected the edges of a %v image to match the reference", size)
+		}
+	}
+}
+
 func BenchmarkCrop(b *testing.B) {
 	fi, err := os.Open(testFile)
 	if err != nil {
@@ -123,6 +213,69 @@ func BenchmarkEdge(b *testing.B) {
 	}
 }
 
+func BenchmarkEdge4K(b *testing.B) {
+	fi, err := os.Open(testFile)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	i := toRGBA(nfnt.NewDefaultResizer().Resize(img, 3840, 2160))
+	o := image.NewRGBA(i.Bounds())
+	b.SetBytes(int64(len(i.Pix)))
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		edgeDetect(i, o)
+	}
+}
+
+func BenchmarkSkin(b *testing.B) {
+	fi, err := os.Open(testFile)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	s := DefaultCropSettings()
+	rgbaImg := toRGBA(img)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		o := image.NewRGBA(img.Bounds())
+		skinDetect(&s, rgbaImg, o)
+	}
+}
+
+func BenchmarkSaturation(b *testing.B) {
+	fi, err := os.Open(testFile)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	s := DefaultCropSettings()
+	rgbaImg := toRGBA(img)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		o := image.NewRGBA(img.Bounds())
+		saturationDetect(&s, rgbaImg, o)
+	}
+}
+
 func BenchmarkImageDir(b *testing.B) {
 	files, err := ioutil.ReadDir("./examples")
 	if err != nil {
@@ -0,0 +1,194 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+//go:generate go test -run TestSnapshots -update
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"hash"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+var update = flag.Bool("update", false, "regenerate the snapshots of TestSnapshots")
+
+// snapshotSettings are the configurations the corpus gets analysed with,
+// covering the different ways of detecting and scoring.
+var snapshotSettings = map[string]func(*CropSettings){
+	"default":       func(s *CropSettings) {},
+	"fixedPoint":    func(s *CropSettings) { s.FixedPoint = true },
+	"quadtree":      func(s *CropSettings) { s.Quadtree = true },
+	"reducedPlanes": func(s *CropSettings) { s.ReducedPlanes = true },
+}
+
+// snapshotFile returns the file the snapshots are stored in. They are kept
+// per architecture, as floating-point math may round differently, e.g. with
+// fused multiply-adds.
+func snapshotFile() string {
+	return filepath.Join("testdata", "snapshots_"+runtime.GOARCH+".json")
+}
+
+// snapshot returns checksums of the state after every stage of the analysis
+// of img for a crop of the given dimensions.
+func snapshot(t *testing.T, img image.Image, width, height int, tweak func(*CropSettings)) map[string]string {
+	sums := map[string]string{}
+	record := func(stage Stage, st *State) error {
+		h := sha256.New()
+		switch stage {
+		case StagePrescale:
+			hashRGBA(h, st.Prescaled)
+		case StageDetect:
+			hashRGBA(h, st.Detected)
+			if st.Boost != nil {
+				fmt.Fprint(h, st.Boost.Bounds())
+				rows := newGrayRows(st.Boost)
+				for y := 0; y < rows.height; y++ {
+					h.Write(rows.row(y))
+				}
+			}
+			fmt.Fprint(h, st.Reduction, st.Regions)
+		case StageCandidates:
+			fmt.Fprint(h, st.CropWidth, st.CropHeight, st.MinScale)
+		case StageScore:
+			// Crop would be formatted like its Rectangle alone
+			fmt.Fprintf(h, "%d %v %+v", st.Candidates, st.Best.Rectangle, st.Best.Score)
+		case StageSelect:
+			fmt.Fprintf(h, "%v %+v", st.Result.Crop.Rectangle, st.Result.Crop.Score)
+		}
+		sums[stage.String()] = hex.EncodeToString(h.Sum(nil)[:8])
+		return nil
+	}
+
+	s := DefaultCropSettings()
+	tweak(&s)
+	for stage := StagePrescale; stage <= StageSelect; stage++ {
+		s.Hooks.AddAfter(stage, record)
+	}
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, s).(ResultAnalyzer)
+	if _, err := analyzer.FindBestResult(img, width, height); err != nil {
+		t.Fatal(err)
+	}
+	return sums
+}
+
+func hashRGBA(h hash.Hash, img *image.RGBA) {
+	fmt.Fprint(h, img.Bounds())
+	rows := newRGBARows(img)
+	for y := 0; y < rows.height; y++ {
+		h.Write(rows.row(y))
+	}
+}
+
+// TestSnapshots compares checksums of the state after every stage of the
+// analyses of the example images to the ones recorded, so any change of the
+// numbers shows, and at which stage it starts. If the change is intentional,
+// regenerate them with go generate, or go test -run TestSnapshots -update.
+func TestSnapshots(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("examples", "*.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]map[string]string{}
+	for _, file := range files {
+		fi, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, _, err := image.Decode(fi)
+		fi.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for name, tweak := range snapshotSettings {
+			for _, size := range []image.Point{{250, 250}, {400, 200}} {
+				key := fmt.Sprintf("%s/%s/%dx%d", filepath.Base(file), name, size.X, size.Y)
+				got[key] = snapshot(t, img, size.X, size.Y, tweak)
+			}
+		}
+	}
+
+	if *update {
+		b, err := json.MarshalIndent(got, "", "\t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(snapshotFile(), append(b, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	b, err := ioutil.ReadFile(snapshotFile())
+	if os.IsNotExist(err) {
+		t.Skipf("no snapshots recorded for %s, generate them with -update", runtime.GOARCH)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected map[string]map[string]string
+	if err := json.Unmarshal(b, &expected); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make([]string, 0, len(got))
+	for key := range got {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, ok := expected[key]; !ok {
+			t.Errorf("%s: no snapshot recorded, regenerate them with -update", key)
+			continue
+		}
+		// the first stage which drifted is the one to look into
+		for stage := StagePrescale; stage <= StageSelect; stage++ {
+			if sum := got[key][stage.String()]; sum != expected[key][stage.String()] {
+				t.Errorf("%s: expected checksum %s after stage %s, got %s; if the change is intentional, regenerate the snapshots with -update",
+					key, expected[key][stage.String()], stage, sum)
+				break
+			}
+		}
+	}
+}
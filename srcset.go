@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"errors"
+	"image"
+	"math"
+	"sort"
+
+	"github.com/muesli/smartcrop/options"
+)
+
+// ErrNoOutputs gets returned when planning outputs without any valid widths.
+var ErrNoOutputs = errors.New("Expect at least one output width")
+
+// Output describes a single rendition of a Plan: the region of the source image
+// to crop and the dimensions to resize it to.
+type Output struct {
+	Width    int             `json:"width"`
+	Height   int             `json:"height"`
+	Crop     image.Rectangle `json:"crop"`
+	Upscaled bool            `json:"upscaled"`
+}
+
+// Plan contains the outputs of a source image at multiple resolutions, e.g. for
+// a srcset attribute. All of them are derived from the same Result, so they
+// show the same subject no matter which one gets picked.
+type Plan struct {
+	Result  Result   `json:"result"`
+	Outputs []Output `json:"outputs"`
+}
+
+// PlanOutputs analyzes img once for the given aspect ratio (width / height) and
+// plans an Output for each of the given widths, ordered from the widest to the
+// narrowest one. Outputs that are wider than the crop are flagged as Upscaled.
+func PlanOutputs(analyzer ResultAnalyzer, img image.Image, widths []int, ratio float64) (Plan, error) {
+	if ratio <= 0 || math.IsNaN(ratio) || math.IsInf(ratio, 0) {
+		return Plan{}, ErrInvalidDimensions
+	}
+
+	var ws []int
+	for _, w := range widths {
+		if w > 0 {
+			ws = append(ws, w)
+		}
+	}
+	if len(ws) == 0 {
+		return Plan{}, ErrNoOutputs
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(ws)))
+
+	maxHeight := int(math.Max(1.0, math.Round(float64(ws[0])/ratio)))
+	res, err := analyzer.FindBestResult(img, ws[0], maxHeight)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	crop := res.Normalized.Rect(img.Bounds(), ratio)
+	plan := Plan{Result: res}
+	for _, w := range ws {
+		h := int(math.Max(1.0, math.Round(float64(w)/ratio)))
+		plan.Outputs = append(plan.Outputs, Output{
+			Width:    w,
+			Height:   h,
+			Crop:     crop,
+			Upscaled: w > crop.Dx() || h > crop.Dy(),
+		})
+	}
+
+	return plan, nil
+}
+
+// Render renders all outputs of the plan, in the same order. The source image
+// only gets read once: every output is scaled down from the previous, larger
+// one, as long as they share the same crop.
+func (p Plan) Render(img image.Image, resizer options.Resizer) []image.Image {
+	type SubImager interface {
+		SubImage(r image.Rectangle) image.Image
+	}
+
+	res := make([]image.Image, len(p.Outputs))
+	var prev image.Image
+	var prevCrop image.Rectangle
+	for i, o := range p.Outputs {
+		src := prev
+		if src == nil || o.Crop != prevCrop || o.Width > prev.Bounds().Dx() || o.Height > prev.Bounds().Dy() {
+			if sub, ok := img.(SubImager); ok {
+				src = sub.SubImage(o.Crop)
+			} else {
+				src = toRGBA(img).SubImage(o.Crop)
+			}
+		}
+
+		res[i] = resizer.Resize(src, uint(o.Width), uint(o.Height))
+		prev, prevCrop = res[i], o.Crop
+	}
+
+	return res
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestPlanOutputs(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resizer := nfnt.NewDefaultResizer()
+	analyzer := NewAnalyzer(resizer).(ResultAnalyzer)
+	plan, err := PlanOutputs(analyzer, img, []int{100, 400, 0, 200}, 16.0/9.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []image.Point{{400, 225}, {200, 113}, {100, 56}}
+	if len(plan.Outputs) != len(expected) {
+		t.Fatalf("expected %d outputs, got %d", len(expected), len(plan.Outputs))
+	}
+	for i, o := range plan.Outputs {
+		if o.Width != expected[i].X || o.Height != expected[i].Y {
+			t.Fatalf("expected output %d to be %v, got %dx%d", i, expected[i], o.Width, o.Height)
+		}
+		if o.Crop != plan.Outputs[0].Crop {
+			t.Fatalf("expected all outputs to share the same crop")
+		}
+	}
+
+	for i, out := range plan.Render(img, resizer) {
+		if out.Bounds().Dx() != expected[i].X || out.Bounds().Dy() != expected[i].Y {
+			t.Fatalf("expected rendition %d to be %v, got %v", i, expected[i], out.Bounds())
+		}
+	}
+}
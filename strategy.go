@@ -0,0 +1,280 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"sync"
+)
+
+// The names of the built-in strategies.
+const (
+	// StrategySmart is the content-aware analysis of this package.
+	StrategySmart = "smart"
+	// StrategyFaceCenter centers the crop on the faces found by the Detector
+	// registered as "face". It isn't confident at all if there is none.
+	StrategyFaceCenter = "face-center"
+	// StrategyCenter crops the center of the image.
+	StrategyCenter = "center"
+)
+
+// CropStrategy is an alternative way of finding a crop, which can serve as a
+// fallback for the smart analysis. See CropSettings.Strategies.
+type CropStrategy interface {
+	// Find returns the best crop for the requested dimensions in st, which
+	// has already been prescaled, and how confident the strategy is about
+	// it, ranging from 0 to 1.
+	Find(s *CropSettings, st *State) (NormalizedRect, float64, error)
+}
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = map[string]CropStrategy{
+		StrategyFaceCenter: faceCenterStrategy{},
+		StrategyCenter:     centerStrategy{},
+	}
+)
+
+// RegisterStrategy makes a CropStrategy available under the given name, so it
+// can be used in CropSettings.Strategies.
+func RegisterStrategy(name string, strategy CropStrategy) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+
+	if strategy == nil {
+		panic("smartcrop: RegisterStrategy strategy is nil")
+	}
+	strategies[name] = strategy
+}
+
+// Strategies returns the names of all available strategies.
+func Strategies() []string {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+
+	names := []string{StrategySmart}
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runStrategies tries the strategies of the settings in order, until one of
+// them is confident enough about its crop.
+func (p pipeline) runStrategies(st *State) error {
+	if len(p.settings.Ensemble) > 0 {
+		return p.runEnsemble(st)
+	}
+
+	names := p.settings.Strategies
+	if len(names) == 0 {
+		names = []string{StrategySmart}
+	}
+
+	prescaled := false
+	var points []AttentionPoint
+	for i, name := range names {
+		last := i == len(names)-1
+
+		if name == StrategySmart {
+			first := StagePrescale
+			if prescaled {
+				first = StageDetect
+			}
+			if err := p.runStages(st, first, StageSelect); err != nil {
+				return err
+			}
+			st.Result.Strategy = name
+			points = st.Result.AttentionPoints
+			if last || st.Result.Confidence >= p.settings.MinConfidence {
+				return nil
+			}
+			prescaled = true
+			continue
+		}
+
+		strategy, err := lookupStrategy(name)
+		if err != nil {
+			return err
+		}
+
+		if !prescaled {
+			if err := p.runStages(st, StagePrescale, StagePrescale); err != nil {
+				return err
+			}
+			prescaled = true
+		}
+
+		res, ok, err := p.strategyResult(st, name, strategy)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if last {
+				return ErrVetoed
+			}
+			continue
+		}
+
+		st.Result = res
+		// the attention points don't depend on the strategy
+		st.Result.AttentionPoints = points
+		if last || res.Confidence >= p.settings.MinConfidence {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// lookupStrategy returns the registered strategy of the given name.
+func lookupStrategy(name string) (CropStrategy, error) {
+	strategiesMu.RLock()
+	strategy, ok := strategies[name]
+	strategiesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("smartcrop: unknown strategy %q", name)
+	}
+	return strategy, nil
+}
+
+// strategyResult returns the Result of the strategy of the given name for
+// st, which has been prescaled already, and false if its crop got vetoed.
+func (p pipeline) strategyResult(st *State, name string, strategy CropStrategy) (Result, bool, error) {
+	norm, confidence, err := strategy.Find(p.settings, st)
+	if err != nil {
+		return Result{}, false, fmt.Errorf("smartcrop: strategy %q failed: %v", name, err)
+	}
+	if p.settings.Margin > 0 {
+		norm = norm.expandWithin(p.settings.Margin, st.normalizedArea())
+	}
+
+	lw, lh := float64(st.Prescaled.Bounds().Dx()), float64(st.Prescaled.Bounds().Dy())
+	if vetoed(rect{norm.X * lw, norm.Y * lh, norm.Width * lw, norm.Height * lh}, st.Regions) {
+		return Result{}, false, nil
+	}
+
+	res := p.result(st, Crop{}, norm)
+	res.Strategy = name
+	res.Confidence = confidence
+	return res, true, nil
+}
+
+// ratio returns the aspect ratio of the crop requested in st.
+func (st *State) ratio() float64 {
+	if st.Width > 0 && st.Height > 0 {
+		return float64(st.Width) / float64(st.Height)
+	}
+	return 0.0
+}
+
+// widest returns the largest crop of the requested aspect ratio within the
+// area of interest, centered as closely as possible on cx, cy, in normalized
+// coordinates.
+func widest(st *State, cx, cy float64) NormalizedRect {
+	b := st.Source.Bounds()
+	a := st.normalizedArea()
+	aspect := a.Width * float64(b.Dx()) / (a.Height * float64(b.Dy()))
+	ratio := st.ratio()
+	if ratio == 0 {
+		ratio = 1.0
+	}
+
+	w, h := a.Width, a.Height*aspect/ratio
+	if ratio < aspect {
+		w, h = a.Width*ratio/aspect, a.Height
+	}
+	return NormalizedRect{
+		X:      math.Min(math.Max(cx-w/2.0, a.X), a.X+a.Width-w),
+		Y:      math.Min(math.Max(cy-h/2.0, a.Y), a.Y+a.Height-h),
+		Width:  w,
+		Height: h,
+	}
+}
+
+type centerStrategy struct{}
+
+func (centerStrategy) Find(s *CropSettings, st *State) (NormalizedRect, float64, error) {
+	return widest(st, 0.5, 0.5), 1.0, nil
+}
+
+type faceCenterStrategy struct{}
+
+func (faceCenterStrategy) Find(s *CropSettings, st *State) (NormalizedRect, float64, error) {
+	center := widest(st, 0.5, 0.5)
+
+	detectorsMu.RLock()
+	factory, ok := detectors["face"]
+	detectorsMu.RUnlock()
+	if !ok {
+		return center, 0.0, nil
+	}
+
+	d, err := factory(*s)
+	if err != nil {
+		return center, 0.0, err
+	}
+	plane, err := d.Detect(st.Prescaled)
+	if err != nil {
+		return center, 0.0, err
+	}
+
+	// center the crop on the centroid of the faces
+	b := plane.Bounds()
+	var sum, sx, sy float64
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			v := float64(plane.Pix[plane.PixOffset(x, y)])
+			sum += v
+			sx += v * (float64(x-b.Min.X) + 0.5)
+			sy += v * (float64(y-b.Min.Y) + 0.5)
+		}
+	}
+	if sum == 0 {
+		return center, 0.0, nil
+	}
+	norm := widest(st, sx/sum/float64(b.Dx()), sy/sum/float64(b.Dy()))
+
+	// the confidence is the share of the faces within the crop
+	r := image.Rect(
+		b.Min.X+int(norm.X*float64(b.Dx())), b.Min.Y+int(norm.Y*float64(b.Dy())),
+		b.Min.X+int(math.Ceil((norm.X+norm.Width)*float64(b.Dx()))), b.Min.Y+int(math.Ceil((norm.Y+norm.Height)*float64(b.Dy()))),
+	).Intersect(b)
+	var inside float64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			inside += float64(plane.Pix[plane.PixOffset(x, y)])
+		}
+	}
+
+	return norm, inside / sum, nil
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+// rightDetector marks the right eighth of an image as a face.
+type rightDetector struct{}
+
+func (rightDetector) Detect(img *image.RGBA) (*image.Gray, error) {
+	b := img.Bounds()
+	plane := image.NewGray(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Max.X - b.Dx()/8; x < b.Max.X; x++ {
+			plane.Pix[plane.PixOffset(x, y)] = 255
+		}
+	}
+	return plane, nil
+}
+
+func TestStrategies(t *testing.T) {
+	RegisterDetector("face", func(s CropSettings) (Detector, error) {
+		return rightDetector{}, nil
+	})
+
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	find := func(settings CropSettings) (Result, error) {
+		analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings)
+		return analyzer.(ResultAnalyzer).FindBestResult(img, 250, 250)
+	}
+
+	res, err := find(DefaultCropSettings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Strategy != StrategySmart || res.Confidence <= 0 || res.Confidence > 1 {
+		t.Fatalf("expected a confident smart crop, got %q with confidence %v", res.Strategy, res.Confidence)
+	}
+	smart := res.Crop.Rectangle
+
+	// nothing can be more confident than 1, so the chain falls through
+	settings := DefaultCropSettings()
+	settings.Strategies = []string{StrategySmart, StrategyCenter}
+	settings.MinConfidence = 1.1
+	res, err = find(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Strategy != StrategyCenter || res.Crop.Rectangle != image.Rect(308, 0, 592, 284) {
+		t.Fatalf("expected the center crop, got %q with %v", res.Strategy, res.Crop.Rectangle)
+	}
+
+	// the smart crop is kept if it is confident enough
+	settings.MinConfidence = 0.0
+	res, err = find(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Strategy != StrategySmart || res.Crop.Rectangle != smart {
+		t.Fatalf("expected the smart crop %v, got %q with %v", smart, res.Strategy, res.Crop.Rectangle)
+	}
+
+	settings.Strategies = []string{StrategyFaceCenter, StrategyCenter}
+	settings.MinConfidence = 0.5
+	res, err = find(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Strategy != StrategyFaceCenter || res.Confidence != 1 || res.Crop.Max.X != img.Bounds().Dx() {
+		t.Fatalf("expected a crop centered on the face, got %q with %v and confidence %v", res.Strategy, res.Crop.Rectangle, res.Confidence)
+	}
+
+	settings.Strategies = []string{"unknown"}
+	if _, err := find(settings); err == nil {
+		t.Fatal("expected an error for an unknown strategy")
+	}
+}
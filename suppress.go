@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+// ErrVetoed gets returned if every crop candidate intersects a vetoed Region.
+var ErrVetoed = errors.New("Every crop intersects a vetoed region")
+
+// Region is an area of an image flagged by a RegionClassifier.
+type Region struct {
+	image.Rectangle
+
+	// Weight scales the importance of the pixels inside the region, e.g. 0.5
+	// halves it and 0 makes the region irrelevant to the scoring.
+	Weight float64
+
+	// Veto excludes every crop intersecting the region, regardless of its
+	// Weight.
+	Veto bool
+}
+
+// RegionClassifier flags regions an automatic crop should avoid, e.g. NSFW
+// content on avatars or thumbnails.
+type RegionClassifier interface {
+	// Classify returns the flagged regions of img, in coordinates of img.
+	Classify(img image.Image) ([]Region, error)
+}
+
+// classifyRegions runs the classifier of the settings on the prescaled image.
+func classifyRegions(s *CropSettings, img *image.RGBA) ([]Region, error) {
+	regions, err := s.Classifier.Classify(img)
+	if err != nil {
+		return nil, fmt.Errorf("smartcrop: region classifier failed: %v", err)
+	}
+	for i := range regions {
+		regions[i].Rectangle = regions[i].Intersect(img.Bounds())
+		if regions[i].Weight < 0 {
+			regions[i].Weight = 0
+		}
+	}
+	return regions, nil
+}
+
+// suppressRGBA scales the detector planes, which are scaled down by the factor
+// reduction, inside the regions by their weight.
+func suppressRGBA(o *image.RGBA, regions []Region, reduction int) {
+	for _, r := range regions {
+		if r.Weight >= 1 {
+			continue
+		}
+		rr := reduceRect(r.Rectangle, reduction).Intersect(o.Bounds())
+		for y := rr.Min.Y; y < rr.Max.Y; y++ {
+			for x := rr.Min.X; x < rr.Max.X; x++ {
+				off := o.PixOffset(x, y)
+				o.Pix[off] = uint8(float64(o.Pix[off]) * r.Weight)
+				o.Pix[off+1] = uint8(float64(o.Pix[off+1]) * r.Weight)
+				o.Pix[off+2] = uint8(float64(o.Pix[off+2]) * r.Weight)
+			}
+		}
+	}
+}
+
+// suppressGray scales the boost plane, which is scaled down by the factor
+// reduction, inside the regions by their weight.
+func suppressGray(g *image.Gray, regions []Region, reduction int) {
+	for _, r := range regions {
+		if r.Weight >= 1 {
+			continue
+		}
+		rr := reduceRect(r.Rectangle, reduction).Intersect(g.Bounds())
+		for y := rr.Min.Y; y < rr.Max.Y; y++ {
+			for x := rr.Min.X; x < rr.Max.X; x++ {
+				off := g.PixOffset(x, y)
+				g.Pix[off] = uint8(float64(g.Pix[off]) * r.Weight)
+			}
+		}
+	}
+}
+
+// vetoed returns whether crop intersects any of the vetoed regions.
+func vetoed(crop rect, regions []Region) bool {
+	for _, r := range regions {
+		if !r.Veto || r.Empty() {
+			continue
+		}
+		if crop.x < float64(r.Max.X) && float64(r.Min.X) < crop.x+crop.w &&
+			crop.y < float64(r.Max.Y) && float64(r.Min.Y) < crop.y+crop.h {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+// stripClassifier vetoes a vertical strip covering the given fraction of an
+// image, starting at its horizontal center.
+type stripClassifier struct {
+	fraction float64
+}
+
+func (c stripClassifier) Classify(img image.Image) ([]Region, error) {
+	b := img.Bounds()
+	w := int(float64(b.Dx()) * c.fraction)
+	x := b.Min.X + (b.Dx()-w)/2
+	return []Region{{
+		Rectangle: image.Rect(x, b.Min.Y, x+w, b.Max.Y),
+		Veto:      true,
+	}}, nil
+}
+
+func TestClassifier(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := DefaultCropSettings()
+	settings.Classifier = stripClassifier{0.1}
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings)
+	topCrop, err := analyzer.FindBestCrop(img, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// allow for a pixel of rounding when mapping back onto the source image
+	b := img.Bounds()
+	strip := image.Rect(b.Dx()*45/100+1, 0, b.Dx()*55/100-1, b.Dy())
+	if topCrop.Overlaps(strip) {
+		t.Fatalf("expected crop to avoid %v, got %v", strip, topCrop)
+	}
+
+	settings.Classifier = stripClassifier{1.0}
+	analyzer = NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings)
+	if _, err := analyzer.FindBestCrop(img, 250, 250); err != ErrVetoed {
+		t.Fatalf("expected ErrVetoed, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+/*
+Package tiny is a reduced build of smartcrop which compiles under TinyGo, for
+edge and embedded devices framing thumbnails on the device, e.g. on a camera.
+
+It finds crops like smartcrop does with its default settings, but only
+depends on the image packages of the standard library: no reflection, no
+nfnt/resize and none of the options, detectors or strategies of smartcrop.
+The detectors and the scoring use integer math only, which matters on devices
+without a floating-point unit. For images whose shorter side is 400 pixels at
+most, which smartcrop doesn't prescale, the crops match the ones of smartcrop,
+up to a candidate step on near ties. Larger images get prescaled to the same
+size as smartcrop scales them to, but by averaging blocks of pixels instead of
+with a bicubic filter, so their crops may deviate further.
+
+	crop, err := tiny.FindBestCrop(img, 250, 250)
+
+Build it with TinyGo as usual, e.g. the tinycrop command of the cmd module for
+a Raspberry Pi:
+
+	GOOS=linux GOARCH=arm tinygo build -o tinycrop ./tinycrop
+*/
+package tiny
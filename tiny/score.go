@@ -0,0 +1,275 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package tiny
+
+import (
+	"image"
+	"math"
+)
+
+// The default settings of smartcrop the scores get computed with.
+const (
+	edgeRadius        = 0.4
+	edgeWeight        = -20.0
+	outsideImportance = -0.5
+
+	// the lightness, as returned by lightness, the skin and saturation
+	// detectors accept
+	skinLightnessMin       = 510000
+	skinLightnessMax       = 2550000
+	saturationLightnessMin = 127500
+	saturationLightnessMax = 2295000
+
+	// the thresholds of the detectors, with q being 1
+	skinThreshold       = q * 4 / 5
+	saturationThreshold = q * 2 / 5
+
+	// the biases of the skin and saturation scores, scaled like the detail
+	// times 256
+	skinBias       = 653
+	saturationBias = 13056
+)
+
+// skinColor is the color of skin, scaled by 1<<16.
+var skinColor = [3]int64{51118, 37356, 28836}
+
+// planes contains the samples of the detector planes the scoring looks at,
+// every sample-th pixel of every sample-th row of the prescaled image. Each
+// sample combines the detail, skin and saturation scores of its pixel,
+// weighted like smartcrop does by default.
+type planes struct {
+	width, height int
+	cols, rows    int
+	values        []int64
+	// total is the sum of all values.
+	total int64
+}
+
+func newPlanes(pix []uint8, width, height int) *planes {
+	p := &planes{
+		width:  width,
+		height: height,
+		cols:   width / sample,
+		rows:   height / sample,
+	}
+	p.values = make([]int64, p.cols*p.rows)
+
+	at := func(x, y int) (int64, int64, int64) {
+		o := (y*width + x) * 3
+		return int64(pix[o]), int64(pix[o+1]), int64(pix[o+2])
+	}
+	lightnessAt := func(x, y int) int64 {
+		return lightness(at(x, y))
+	}
+
+	for j := 0; j < p.rows; j++ {
+		for i := 0; i < p.cols; i++ {
+			x, y := i*sample, j*sample
+			r, g, b := at(x, y)
+			l := lightness(r, g, b)
+
+			edge := int64(0)
+			if x > 0 && y > 0 && x < width-1 && y < height-1 {
+				edge = clamp((4*l - lightnessAt(x, y-1) - lightnessAt(x-1, y) - lightnessAt(x+1, y) - lightnessAt(x, y+1)) / 10000)
+			}
+			skin := int64(0)
+			if l >= skinLightnessMin && l <= skinLightnessMax {
+				if s := skinness(r, g, b); s > skinThreshold {
+					skin = clamp((s - skinThreshold) * 255 / (q - skinThreshold))
+				}
+			}
+			sat := int64(0)
+			if s := saturation(r, g, b); s > saturationThreshold && l >= saturationLightnessMin && l <= saturationLightnessMax {
+				sat = clamp((s - saturationThreshold) * 255 / (q - saturationThreshold))
+			}
+
+			// the weights of detail, skin and saturation are 0.2, 1.8 and
+			// 0.3, and their scores get scaled to the one of the skin
+			v := 2*255*256*edge + 18*skin*(256*edge+skinBias) + 3*sat*(256*edge+saturationBias)
+			p.values[j*p.cols+i] = v
+			p.total += v
+		}
+	}
+	return p
+}
+
+// score returns the score of crop, a rectangle of the prescaled image, whose
+// importance is given by t.
+func (p *planes) score(crop image.Rectangle, t *importanceTable) int64 {
+	outside := int64(outsideImportance * q)
+	sum := p.total * outside
+
+	i0, j0 := (crop.Min.X+sample-1)/sample, (crop.Min.Y+sample-1)/sample
+	for j := 0; j < t.rows && j0+j < p.rows; j++ {
+		row := p.values[(j0+j)*p.cols:]
+		imp := t.importance[j*t.cols:]
+		for i := 0; i < t.cols && i0+i < p.cols; i++ {
+			sum += row[i0+i] * (imp[i] - outside)
+		}
+	}
+	return sum / int64(crop.Dx()*crop.Dy())
+}
+
+// lightness returns the lightness of a color like smartcrop computes it,
+// scaled by 10000.
+func lightness(r, g, b int64) int64 {
+	return 722*r + 7152*g + 5126*b
+}
+
+// skinness returns how close a color is to the color of skin, up to q. It's
+// computed with 16 bits of precision, as dark colors get normalized by a
+// small magnitude.
+func skinness(r, g, b int64) int64 {
+	// the magnitude with 8 bits of precision
+	mag := isqrt((r*r + g*g + b*b) << 16)
+	if mag == 0 {
+		return 0
+	}
+	rd := r<<24/mag - skinColor[0]
+	gd := g<<24/mag - skinColor[1]
+	bd := b<<24/mag - skinColor[2]
+	return (1<<16 - isqrt(rd*rd+gd*gd+bd*bd)) >> (16 - qShift)
+}
+
+// saturation returns the saturation of a color in the HSL color space, up to
+// q.
+func saturation(r, g, b int64) int64 {
+	max, min := r, r
+	for _, c := range [2]int64{g, b} {
+		if c > max {
+			max = c
+		}
+		if c < min {
+			min = c
+		}
+	}
+	if max == min {
+		return 0
+	}
+	if max+min > 255 {
+		return (max - min) * q / (510 - max - min)
+	}
+	return (max - min) * q / (max + min)
+}
+
+func clamp(v int64) int64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return v
+}
+
+// isqrt returns the square root of v, rounded down.
+func isqrt(v int64) int64 {
+	if v <= 0 {
+		return 0
+	}
+	x := int64(math.MaxInt32)
+	if v < x {
+		x = v
+	}
+	for {
+		y := (x + v/x) / 2
+		if y >= x {
+			return x
+		}
+		x = y
+	}
+}
+
+// importanceTable contains the importance of the samples within a crop of a
+// given size, whose first sample lies at a given offset from its corner, in
+// fixed-point with q being 1.
+type importanceTable struct {
+	cols, rows int
+	importance []int64
+}
+
+type tableKey struct {
+	width, height int
+	offX, offY    int
+}
+
+// importanceTables caches importanceTables, as all candidates of a scale
+// share a few of them.
+type importanceTables map[tableKey]*importanceTable
+
+func (ts *importanceTables) get(crop image.Rectangle) *importanceTable {
+	k := tableKey{
+		width:  crop.Dx(),
+		height: crop.Dy(),
+		offX:   (sample - crop.Min.X%sample) % sample,
+		offY:   (sample - crop.Min.Y%sample) % sample,
+	}
+	if t, ok := (*ts)[k]; ok {
+		return t
+	}
+
+	t := &importanceTable{
+		cols: (k.width - k.offX + sample - 1) / sample,
+		rows: (k.height - k.offY + sample - 1) / sample,
+	}
+	t.importance = make([]int64, t.cols*t.rows)
+	for j := 0; j < t.rows; j++ {
+		for i := 0; i < t.cols; i++ {
+			xf := float64(k.offX+i*sample) / float64(k.width)
+			yf := float64(k.offY+j*sample) / float64(k.height)
+			t.importance[j*t.cols+i] = int64(math.Round(importance(xf, yf) * q))
+		}
+	}
+
+	if *ts == nil {
+		*ts = importanceTables{}
+	}
+	(*ts)[k] = t
+	return t
+}
+
+// importance returns the importance of a point inside a crop, given relative
+// to its dimensions, like smartcrop computes it. It's only computed once per
+// table, so it doesn't need to avoid floating-point math.
+func importance(xf, yf float64) float64 {
+	px := math.Abs(0.5-xf) * 2.0
+	py := math.Abs(0.5-yf) * 2.0
+
+	dx := math.Max(px-1.0+edgeRadius, 0.0)
+	dy := math.Max(py-1.0+edgeRadius, 0.0)
+	d := (dx*dx + dy*dy) * edgeWeight
+
+	i := 1.41 - math.Sqrt(px*px+py*py)
+	i += (math.Max(0.0, i+d+0.5) * 1.2) * (thirds(px) + thirds(py))
+	return i + d
+}
+
+func thirds(x float64) float64 {
+	x = (math.Mod(x-(1.0/3.0)+1.0, 2.0)*0.5 - 0.5) * 16.0
+	return math.Max(1.0-x*x, 0.0)
+}
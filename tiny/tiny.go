@@ -0,0 +1,211 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package tiny
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+)
+
+var (
+	// ErrInvalidDimensions gets returned when the requested width or height
+	// isn't positive.
+	ErrInvalidDimensions = errors.New("Width and height must be positive")
+	// ErrEmptyImage gets returned when the image has no pixels.
+	ErrEmptyImage = errors.New("Image is empty")
+)
+
+// The default settings of smartcrop, as integers.
+const (
+	// prescaleMin is the size of the shorter side of the prescaled image at
+	// most.
+	prescaleMin = 400
+	// step is the distance between candidates, and sample the one between
+	// the pixels sampled by the scoring, in pixels of the prescaled image.
+	step   = 8
+	sample = 8
+	// minScalePercent is the size of the smallest candidates relative to the
+	// largest ones, in percent, and scaleStepPercent the step between them.
+	minScalePercent  = 90
+	scaleStepPercent = 10
+
+	// qShift is the precision of the fixed-point numbers, q one.
+	qShift = 12
+	q      = 1 << qShift
+)
+
+// FindBestCrop returns the best crop of img with the aspect ratio of width and
+// height. It's at least width×height, unless the image is smaller than that.
+func FindBestCrop(img image.Image, width, height int) (image.Rectangle, error) {
+	if width <= 0 || height <= 0 {
+		return image.Rectangle{}, ErrInvalidDimensions
+	}
+	b := img.Bounds()
+	if b.Dx() <= 0 || b.Dy() <= 0 {
+		return image.Rectangle{}, ErrEmptyImage
+	}
+
+	// the largest crop of the requested aspect ratio
+	cropW, cropH := b.Dx(), b.Dy()
+	if b.Dx()*height > b.Dy()*width {
+		cropW = maxInt(b.Dy()*width/height, 1)
+	} else {
+		cropH = maxInt(b.Dx()*height/width, 1)
+	}
+	// candidates smaller than the requested size would need upscaling
+	minPercent := maxInt(minScalePercent, minInt(100, (100*width+cropW-1)/cropW))
+
+	p := analyze(img)
+	pw, ph := p.width, p.height
+	cropW, cropH = cropW*pw/b.Dx(), cropH*ph/b.Dy()
+
+	best := image.Rectangle{}
+	bestScore := int64(math.MinInt64)
+	var tables importanceTables
+	for percent := 100; percent >= minPercent; percent -= scaleStepPercent {
+		w, h := maxInt(cropW*percent/100, 1), maxInt(cropH*percent/100, 1)
+		for _, y := range positions(h, p.height) {
+			for _, x := range positions(w, p.width) {
+				crop := image.Rect(x, y, x+w, y+h)
+				if s := p.score(crop, tables.get(crop)); s > bestScore {
+					best, bestScore = crop, s
+				}
+			}
+		}
+	}
+
+	// back to the coordinates of the image, within it
+	best = image.Rect(
+		best.Min.X*b.Dx()/pw, best.Min.Y*b.Dy()/ph,
+		best.Max.X*b.Dx()/pw, best.Max.Y*b.Dy()/ph,
+	).Add(b.Min)
+	return best.Intersect(b), nil
+}
+
+// analyze returns the planes of img prescaled like smartcrop does it, so its
+// shorter side is prescaleMin at most.
+func analyze(img image.Image) *planes {
+	b := img.Bounds()
+	pw, ph := b.Dx(), b.Dy()
+	if short := minInt(b.Dx(), b.Dy()); short > prescaleMin {
+		pw, ph = maxInt(b.Dx()*prescaleMin/short, 1), maxInt(b.Dy()*prescaleMin/short, 1)
+	}
+	return newPlanes(prescale(img, pw, ph), pw, ph)
+}
+
+// positions returns the offsets at which a crop of the given size fits into
+// size, in steps of step, and flush against the far edge.
+func positions(crop, size int) []int {
+	var res []int
+	last := -1
+	for p := 0; p+crop <= size; p += step {
+		res = append(res, p)
+		last = p
+	}
+	if anchored := size - crop; last >= 0 && anchored > last {
+		res = append(res, anchored)
+	}
+	return res
+}
+
+// prescale returns img scaled down to width×height, averaging the blocks of
+// pixels each pixel covers, as 3 bytes per pixel.
+func prescale(img image.Image, width, height int) []uint8 {
+	b := img.Bounds()
+	pix := make([]uint8, width*height*3)
+	sums := make([]uint32, width*3)
+	at := pixelReader(img)
+
+	for y := 0; y < height; y++ {
+		for i := range sums {
+			sums[i] = 0
+		}
+		y0, y1 := y*b.Dy()/height, maxInt((y+1)*b.Dy()/height, y*b.Dy()/height+1)
+		for sy := y0; sy < y1; sy++ {
+			for x := 0; x < width; x++ {
+				x0, x1 := x*b.Dx()/width, maxInt((x+1)*b.Dx()/width, x*b.Dx()/width+1)
+				for sx := x0; sx < x1; sx++ {
+					r, g, bl := at(b.Min.X+sx, b.Min.Y+sy)
+					sums[x*3] += uint32(r)
+					sums[x*3+1] += uint32(g)
+					sums[x*3+2] += uint32(bl)
+				}
+			}
+		}
+		row := pix[y*width*3 : (y+1)*width*3]
+		for x := 0; x < width; x++ {
+			x0, x1 := x*b.Dx()/width, maxInt((x+1)*b.Dx()/width, x*b.Dx()/width+1)
+			n := uint32((x1 - x0) * (y1 - y0))
+			row[x*3] = uint8(sums[x*3] / n)
+			row[x*3+1] = uint8(sums[x*3+1] / n)
+			row[x*3+2] = uint8(sums[x*3+2] / n)
+		}
+	}
+	return pix
+}
+
+// pixelReader returns a function returning the color of a pixel of img, fast
+// for the image types decoders return.
+func pixelReader(img image.Image) func(x, y int) (r, g, b uint8) {
+	switch i := img.(type) {
+	case *image.RGBA:
+		return func(x, y int) (uint8, uint8, uint8) {
+			o := i.PixOffset(x, y)
+			return i.Pix[o], i.Pix[o+1], i.Pix[o+2]
+		}
+	case *image.YCbCr:
+		return func(x, y int) (uint8, uint8, uint8) {
+			return color.YCbCrToRGB(i.Y[i.YOffset(x, y)], i.Cb[i.COffset(x, y)], i.Cr[i.COffset(x, y)])
+		}
+	case *image.Gray:
+		return func(x, y int) (uint8, uint8, uint8) {
+			v := i.Pix[i.PixOffset(x, y)]
+			return v, v, v
+		}
+	}
+	return func(x, y int) (uint8, uint8, uint8) {
+		r, g, b, _ := img.At(x, y).RGBA()
+		return uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,198 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package tiny
+
+import (
+	"go/parser"
+	"go/token"
+	"image"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	_ "image/jpeg"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func decode(t *testing.T, path string) image.Image {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// overlap returns the intersection over union of two crops.
+func overlap(a, b image.Rectangle) float64 {
+	area := func(r image.Rectangle) float64 {
+		return float64(r.Dx()) * float64(r.Dy())
+	}
+	inter := area(a.Intersect(b))
+	return inter / (area(a) + area(b) - inter)
+}
+
+func TestFindBestCrop(t *testing.T) {
+	for _, path := range []string{"../examples/gopher.jpg", "../examples/goodtimes.jpg"} {
+		img := decode(t, path)
+		for _, size := range []image.Point{{250, 250}, {400, 200}, {100, 300}} {
+			crop, err := FindBestCrop(img, size.X, size.Y)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fits := size.X <= img.Bounds().Dx() && size.Y <= img.Bounds().Dy()
+			if !crop.In(img.Bounds()) || (fits && (crop.Dx() < size.X || crop.Dy() < size.Y)) {
+				t.Fatalf("%s: expected a crop of at least %v within %v, got %v", path, size, img.Bounds(), crop)
+			}
+			ratio := float64(size.X) / float64(size.Y)
+			if r := float64(crop.Dx()) / float64(crop.Dy()); math.Abs(r-ratio) > 0.05*ratio {
+				t.Fatalf("%s: expected a ratio of %v, got %v", path, ratio, r)
+			}
+
+			analyzer := smartcrop.NewAnalyzer(nfnt.NewDefaultResizer())
+			expected, err := analyzer.FindBestCrop(img, size.X, size.Y)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o := overlap(crop, expected); o < 0.8 {
+				t.Errorf("%s: expected a crop close to %v for %v, got %v overlapping by %.2f", path, expected, size, crop, o)
+			}
+		}
+	}
+
+	if _, err := FindBestCrop(image.NewRGBA(image.Rect(0, 0, 10, 10)), 0, 10); err != ErrInvalidDimensions {
+		t.Fatalf("expected ErrInvalidDimensions, got %v", err)
+	}
+	if _, err := FindBestCrop(image.NewRGBA(image.Rectangle{}), 10, 10); err != ErrEmptyImage {
+		t.Fatalf("expected ErrEmptyImage, got %v", err)
+	}
+}
+
+func TestDetectors(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for n := 0; n < 10000; n++ {
+		r, g, b := rnd.Intn(256), rnd.Intn(256), rnd.Intn(256)
+		rf, gf, bf := float64(r), float64(g), float64(b)
+
+		mag := math.Sqrt(rf*rf + gf*gf + bf*bf)
+		rd, gd, bd := rf/mag-0.78, gf/mag-0.57, bf/mag-0.44
+		skin := 1.0 - math.Sqrt(rd*rd+gd*gd+bd*bd)
+		if s := float64(skinness(int64(r), int64(g), int64(b))) / q; math.Abs(s-skin) > 0.002 {
+			t.Fatalf("expected a skinness of %v for %d,%d,%d, got %v", skin, r, g, b, s)
+		}
+
+		max := math.Max(rf, math.Max(gf, bf)) / 255
+		min := math.Min(rf, math.Min(gf, bf)) / 255
+		sat := 0.0
+		if max != min {
+			sat = (max - min) / (max + min)
+			if (max+min)/2 > 0.5 {
+				sat = (max - min) / (2 - max - min)
+			}
+		}
+		if s := float64(saturation(int64(r), int64(g), int64(b))) / q; math.Abs(s-sat) > 0.001 {
+			t.Fatalf("expected a saturation of %v for %d,%d,%d, got %v", sat, r, g, b, s)
+		}
+	}
+}
+
+// TestParity checks that the crops match the ones of smartcrop for images
+// smartcrop doesn't prescale, so both score the same pixels. The integer math
+// may tip a near tie towards a neighbouring candidate, so the edges may be a
+// step apart.
+func TestParity(t *testing.T) {
+	resizer := nfnt.NewDefaultResizer()
+	analyzer := smartcrop.NewAnalyzer(resizer)
+	for _, path := range []string{"../examples/gopher.jpg", "../examples/goodtimes.jpg"} {
+		src := decode(t, path)
+		for _, img := range []image.Image{
+			src,
+			resizer.Resize(src, 0, 200),
+			resizer.Resize(src, 0, 333),
+			resizer.Resize(src, 400, 0),
+		} {
+			for _, size := range []image.Point{{250, 250}, {400, 200}, {100, 300}, {300, 100}, {640, 360}, {50, 50}} {
+				crop, err := FindBestCrop(img, size.X, size.Y)
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected, err := analyzer.FindBestCrop(img, size.X, size.Y)
+				if err != nil {
+					t.Fatal(err)
+				}
+				d := [4]int{
+					crop.Min.X - expected.Min.X, crop.Min.Y - expected.Min.Y,
+					crop.Max.X - expected.Max.X, crop.Max.Y - expected.Max.Y,
+				}
+				for _, v := range d {
+					if v < -step || v > step {
+						t.Errorf("%s at %v: expected the crop %v for %v, got %v", path, img.Bounds().Size(), expected, size, crop)
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestImports checks that the package sticks to the packages it's known to
+// build with under TinyGo, as the tests run with Go.
+func TestImports(t *testing.T) {
+	allowed := map[string]bool{"errors": true, "image": true, "image/color": true, "math": true}
+
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.ImportsOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, imp := range f.Imports {
+			if path, _ := strconv.Unquote(imp.Path.Value); !allowed[path] {
+				t.Errorf("%s: unexpected import of %s", file, path)
+			}
+		}
+	}
+}
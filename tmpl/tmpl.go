@@ -0,0 +1,347 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+/*
+Package tmpl provides template functions requesting crops of images right in
+the templates of Go web apps, for registration in the FuncMap of an
+html/template:
+
+	funcs := tmpl.New(tmpl.Options{Dir: "static", OutputDir: "static/crops", URL: "/static/crops"})
+	t := template.Must(template.New("page").Funcs(funcs.FuncMap()).ParseFiles("page.html"))
+
+	<img src="{{ SmartCropImage "photos/gopher.jpg" 400 300 }}" width="400" height="300">
+	{{ with SmartCropRect "photos/gopher.jpg" 400 300 }}
+	  <img src="/static/photos/gopher.jpg" style="object-position: {{ .FocalX }}% {{ .FocalY }}%">
+	{{ end }}
+
+The crops get cached by the path, modification time and requested dimensions
+of the images, so templates rendered again don't analyze them again.
+*/
+package tmpl
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+	"github.com/muesli/smartcrop/options"
+
+	_ "image/gif" // register the GIF decoder
+)
+
+// defaultCacheSize is the number of crops cached by default.
+const defaultCacheSize = 1000
+
+// Options configures the template functions.
+type Options struct {
+	// Dir is the directory the paths of the images are relative to.
+	Dir string
+	// OutputDir is where SmartCropImage writes the crops to, and URL is the
+	// URL it's served at.
+	OutputDir string
+	URL       string
+
+	// Quality is the quality of the JPEG crops, defaulting to 85.
+	Quality int
+	// CacheSize is the number of crops to cache, defaulting to 1000.
+	CacheSize int
+
+	// Settings are the settings of the analysis, defaulting to
+	// smartcrop.DefaultCropSettings, and Resizer scales the images,
+	// defaulting to nfnt.
+	Settings *smartcrop.CropSettings
+	Resizer  options.Resizer
+}
+
+// Rect is the crop of an image returned by SmartCropRect.
+type Rect struct {
+	X, Y, Width, Height int
+
+	// FocalX and FocalY are the center of the crop in percent of the
+	// dimensions of the image, e.g. for the CSS object-position of the
+	// uncropped image.
+	FocalX, FocalY float64
+}
+
+// Funcs provides the template functions. It is safe for concurrent use, so
+// templates using them can be executed concurrently.
+type Funcs struct {
+	opts     Options
+	analyzer smartcrop.ResultAnalyzer
+
+	mu    sync.Mutex
+	cache map[cacheKey]*list.Element
+	lru   *list.List
+}
+
+// cacheKey identifies a crop of an image.
+type cacheKey struct {
+	path          string
+	modTime       time.Time
+	size          int64
+	width, height int
+}
+
+// cacheEntry is a cached crop, along with the URL of its image once
+// SmartCropImage has written it.
+type cacheEntry struct {
+	key    cacheKey
+	result smartcrop.Result
+	url    string
+}
+
+// New returns the template functions configured by opts.
+func New(opts Options) *Funcs {
+	if opts.Quality <= 0 {
+		opts.Quality = 85
+	}
+	if opts.CacheSize <= 0 {
+		opts.CacheSize = defaultCacheSize
+	}
+	if opts.Settings == nil {
+		settings := smartcrop.DefaultCropSettings()
+		opts.Settings = &settings
+	}
+	if opts.Resizer == nil {
+		opts.Resizer = nfnt.NewDefaultResizer()
+	}
+
+	return &Funcs{
+		opts:     opts,
+		analyzer: smartcrop.NewAnalyzerWithSettings(opts.Resizer, smartcrop.Logger{}, *opts.Settings).(smartcrop.ResultAnalyzer),
+		cache:    make(map[cacheKey]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// FuncMap returns the functions to register in a template: SmartCropRect
+// and SmartCropImage. Convert it to a text/template.FuncMap for text
+// templates.
+func (f *Funcs) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"SmartCropRect":  f.Rect,
+		"SmartCropImage": f.Image,
+	}
+}
+
+// Rect returns the best crop of the image at name, relative to Options.Dir,
+// for the dimensions width and height.
+func (f *Funcs) Rect(name string, width, height int) (Rect, error) {
+	e, _, err := f.crop(name, width, height)
+	if err != nil {
+		return Rect{}, err
+	}
+
+	res := e.result
+	return Rect{
+		X:      res.Crop.Min.X,
+		Y:      res.Crop.Min.Y,
+		Width:  res.Crop.Dx(),
+		Height: res.Crop.Dy(),
+		FocalX: round(res.FocalPoint.X * 100),
+		FocalY: round(res.FocalPoint.Y * 100),
+	}, nil
+}
+
+// Image returns the URL of the best crop of the image at name, relative to
+// Options.Dir, scaled to width and height. The crop gets written to
+// Options.OutputDir the first time it's requested.
+func (f *Funcs) Image(name string, width, height int) (string, error) {
+	e, img, err := f.crop(name, width, height)
+	if err != nil {
+		return "", err
+	}
+	if e.url != "" {
+		return e.url, nil
+	}
+	if img == nil {
+		// the crop was cached by Rect
+		if img, err = f.decode(e.key.path); err != nil {
+			return "", err
+		}
+	}
+
+	type SubImager interface {
+		SubImage(r image.Rectangle) image.Image
+	}
+	out := img.(SubImager).SubImage(e.result.Crop.Rectangle)
+	if out.Bounds().Dx() != width || out.Bounds().Dy() != height {
+		out = f.opts.Resizer.Resize(out, uint(width), uint(height))
+	}
+
+	base, err := f.write(e.key, out)
+	if err != nil {
+		return "", err
+	}
+	url := strings.TrimSuffix(f.opts.URL, "/") + "/" + base
+
+	f.mu.Lock()
+	e.url = url
+	f.mu.Unlock()
+	return url, nil
+}
+
+// crop returns the cached crop of the image at name, finding it if it's not
+// cached yet. The decoded image is returned along with it in that case.
+func (f *Funcs) crop(name string, width, height int) (*cacheEntry, image.Image, error) {
+	if width <= 0 || height <= 0 {
+		return nil, nil, smartcrop.ErrInvalidDimensions
+	}
+	name, err := clean(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := os.Stat(f.path(name))
+	if err != nil {
+		return nil, nil, err
+	}
+	key := cacheKey{path: name, modTime: info.ModTime(), size: info.Size(), width: width, height: height}
+
+	f.mu.Lock()
+	if el, ok := f.cache[key]; ok {
+		f.lru.MoveToFront(el)
+		f.mu.Unlock()
+		return el.Value.(*cacheEntry), nil, nil
+	}
+	f.mu.Unlock()
+
+	img, err := f.decode(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := f.analyzer.FindBestResult(img, width, height)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if el, ok := f.cache[key]; ok {
+		// found concurrently
+		return el.Value.(*cacheEntry), img, nil
+	}
+	e := &cacheEntry{key: key, result: res}
+	f.cache[key] = f.lru.PushFront(e)
+	if f.lru.Len() > f.opts.CacheSize {
+		delete(f.cache, f.lru.Remove(f.lru.Back()).(*cacheEntry).key)
+	}
+	return e, img, nil
+}
+
+// decode decodes the image at name.
+func (f *Funcs) decode(name string) (image.Image, error) {
+	r, err := os.Open(f.path(name))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	img, _, err := smartcrop.Decode(r)
+	return img, err
+}
+
+// clean returns the cleaned name of an image, which must be relative and stay
+// within Options.Dir, so neither the images read nor the crops written escape
+// their directories.
+func clean(name string) (string, error) {
+	c := path.Clean(filepath.ToSlash(name))
+	if path.IsAbs(c) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" ||
+		c == "." || c == ".." || strings.HasPrefix(c, "../") {
+		return "", fmt.Errorf("invalid image name %q", name)
+	}
+	return c, nil
+}
+
+// path returns the path of the image at name.
+func (f *Funcs) path(name string) string {
+	return filepath.Join(f.opts.Dir, filepath.FromSlash(name))
+}
+
+// write writes the crop identified by key to the output directory, unless it
+// exists already, and returns its path relative to it. Its name contains a
+// hash of key and the settings, so a changed image or changed settings never
+// get served from a stale file. It's written to a temporary file first, so
+// the crop is never served incomplete.
+func (f *Funcs) write(key cacheKey, img image.Image) (string, error) {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s %d %d %d %d %s", key.path, key.modTime.UnixNano(), key.size, key.width, key.height, f.opts.Settings.Fingerprint())))
+	ext := ".jpg"
+	if strings.EqualFold(path.Ext(key.path), ".png") {
+		ext = ".png"
+	}
+	base := fmt.Sprintf("%s_%dx%d_%s%s", strings.TrimSuffix(key.path, path.Ext(key.path)), key.width, key.height, hex.EncodeToString(sum[:4]), ext)
+
+	p := filepath.Join(f.opts.OutputDir, filepath.FromSlash(base))
+	if _, err := os.Stat(p); err == nil {
+		return base, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return "", err
+	}
+	w, err := ioutil.TempFile(filepath.Dir(p), ".tmp-")
+	if err != nil {
+		return "", err
+	}
+	if ext == ".png" {
+		err = png.Encode(w, img)
+	} else {
+		err = jpeg.Encode(w, img, &jpeg.Options{Quality: f.opts.Quality})
+	}
+	if err != nil {
+		w.Close()
+		os.Remove(w.Name())
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		os.Remove(w.Name())
+		return "", err
+	}
+	if err := os.Chmod(w.Name(), 0644); err != nil {
+		os.Remove(w.Name())
+		return "", err
+	}
+	return base, os.Rename(w.Name(), p)
+}
+
+// round rounds v to two decimals, enough for CSS percentages.
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
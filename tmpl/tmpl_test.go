@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package tmpl
+
+import (
+	"bytes"
+	"html/template"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFuncs(t *testing.T) {
+	out, err := ioutil.TempDir("", "smartcrop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(out)
+
+	funcs := New(Options{Dir: "../examples", OutputDir: out, URL: "/crops/", CacheSize: 1})
+	tpl := template.Must(template.New("page").Funcs(funcs.FuncMap()).Parse(
+		`{{ with SmartCropRect "gopher.jpg" 100 100 }}{{ .Width }}x{{ .Height }} {{ .FocalX }}{{ end }}|` +
+			`{{ SmartCropImage "./gopher.jpg" 100 100 }}`))
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(buf.String(), "|")
+	if !strings.HasPrefix(parts[0], "255x255 ") {
+		t.Errorf("expected a 255x255 crop, got %s", parts[0])
+	}
+	url := parts[1]
+	if !strings.HasPrefix(url, "/crops/gopher_100x100_") || !strings.HasSuffix(url, ".jpg") {
+		t.Fatalf("expected the URL of the crop, got %s", url)
+	}
+
+	f, err := os.Open(filepath.Join(out, strings.TrimPrefix(url, "/crops/")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := jpeg.DecodeConfig(f)
+	f.Close()
+	if err != nil || c.Width != 100 || c.Height != 100 {
+		t.Errorf("expected a 100x100 crop, got %dx%d (%v)", c.Width, c.Height, err)
+	}
+
+	// the crop is cached, and evicted by another one
+	if u, err := funcs.Image("gopher.jpg", 100, 100); err != nil || u != url {
+		t.Errorf("expected %s again, got %s (%v)", url, u, err)
+	}
+	if _, err := funcs.Rect("gopher.jpg", 160, 90); err != nil {
+		t.Fatal(err)
+	}
+	if funcs.lru.Len() != 1 {
+		t.Errorf("expected 1 cached crop, got %d", funcs.lru.Len())
+	}
+
+	if _, err := funcs.Rect("missing.jpg", 100, 100); err == nil {
+		t.Error("expected an error for a missing image")
+	}
+}
+
+func TestFuncsContainment(t *testing.T) {
+	out, err := ioutil.TempDir("", "smartcrop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(out)
+
+	// the images stay within Dir, and so the crops within OutputDir
+	funcs := New(Options{Dir: "../examples/..", OutputDir: out})
+	for _, name := range []string{
+		"../examples/gopher.jpg",
+		"examples/../../examples/gopher.jpg",
+		"/examples/gopher.jpg",
+		filepath.Join(abs(t, "../examples"), "gopher.jpg"),
+		"..",
+		".",
+	} {
+		if _, err := funcs.Image(name, 100, 100); err == nil || !strings.Contains(err.Error(), "invalid image name") {
+			t.Errorf("expected %s to be rejected, got %v", name, err)
+		}
+	}
+
+	if _, err := funcs.Image("examples/./gopher.jpg", 100, 100); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(out, "examples", "*"))
+	if err != nil || len(files) != 1 || !strings.HasSuffix(files[0], ".jpg") {
+		t.Errorf("expected only the crop in the output directory, got %v (%v)", files, err)
+	}
+}
+
+func abs(t *testing.T, p string) string {
+	p, err := filepath.Abs(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import "time"
+
+// traceTopCrops is the number of best candidates a Trace keeps.
+const traceTopCrops = 10
+
+// StageTiming is the time a stage of the analysis took.
+type StageTiming struct {
+	Stage    string        `json:"stage"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Trace is a machine-readable record of an analysis, as passed to the OnTrace
+// callback of the settings. It is meant to be attached to support tickets or
+// collected for performance tracking. Durations are given in nanoseconds.
+type Trace struct {
+	AnalyzerVersion string       `json:"analyzerVersion"`
+	Settings        CropSettings `json:"settings"`
+	ImageWidth      int          `json:"imageWidth"`
+	ImageHeight     int          `json:"imageHeight"`
+	Width           int          `json:"width"`
+	Height          int          `json:"height"`
+
+	PrescaleFactor float64       `json:"prescaleFactor"`
+	Stages         []StageTiming `json:"stages"`
+	Duration       time.Duration `json:"duration"`
+
+	// Candidates is the number of candidates scored, TopCrops the best of
+	// them, best first, in coordinates of the prescaled image.
+	Candidates int    `json:"candidates"`
+	TopCrops   []Crop `json:"topCrops"`
+
+	// Result is the outcome of the analysis, unless it failed with Error.
+	Result *Result `json:"result,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// newTrace returns the Trace of the analysis of st.
+func newTrace(s CropSettings, st *State) *Trace {
+	b := st.Source.Bounds()
+	return &Trace{
+		AnalyzerVersion: Version,
+		Settings:        s,
+		ImageWidth:      b.Dx(),
+		ImageHeight:     b.Dy(),
+		Width:           st.Width,
+		Height:          st.Height,
+	}
+}
+
+// addStage records the duration of a stage.
+func (t *Trace) addStage(stage Stage, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.Stages = append(t.Stages, StageTiming{Stage: stage.String(), Duration: d})
+}
+
+// addCrop records a scored candidate, if it is among the best ones.
+func (t *Trace) addCrop(c Crop) {
+	if t == nil {
+		return
+	}
+
+	i := len(t.TopCrops)
+	for i > 0 && t.TopCrops[i-1].Score.Total < c.Score.Total {
+		i--
+	}
+	if i >= traceTopCrops {
+		return
+	}
+	if len(t.TopCrops) < traceTopCrops {
+		t.TopCrops = append(t.TopCrops, Crop{})
+	}
+	copy(t.TopCrops[i+1:], t.TopCrops[i:])
+	t.TopCrops[i] = c
+}
+
+// finish completes the Trace once the analysis of st is over.
+func (t *Trace) finish(st *State, d time.Duration, err error) Trace {
+	t.PrescaleFactor = st.PrescaleFactor
+	t.Candidates = st.Candidates
+	t.Duration = d
+	if err != nil {
+		t.Error = err.Error()
+	} else {
+		res := st.Result
+		t.Result = &res
+	}
+	return *t
+}
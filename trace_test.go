@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"encoding/json"
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestTrace(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var traces []Trace
+	settings := DefaultCropSettings()
+	settings.OnTrace = func(tr Trace) {
+		traces = append(traces, tr)
+	}
+	analyzer := NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ResultAnalyzer)
+	res, err := analyzer.FindBestResult(img, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 1 {
+		t.Fatalf("expected 1 trace, got %d", len(traces))
+	}
+
+	tr := traces[0]
+	if len(tr.Stages) != 5 || tr.Stages[0].Stage != "prescale" || tr.Stages[4].Stage != "select" {
+		t.Fatalf("expected the timings of all stages, got %+v", tr.Stages)
+	}
+	if tr.Candidates == 0 || len(tr.TopCrops) != traceTopCrops || tr.PrescaleFactor == 0 {
+		t.Fatalf("expected the candidates to be traced, got %+v", tr)
+	}
+	for i := 1; i < len(tr.TopCrops); i++ {
+		if tr.TopCrops[i].Score.Total > tr.TopCrops[i-1].Score.Total {
+			t.Fatalf("expected the top crops to be ordered by score, got %+v", tr.TopCrops)
+		}
+	}
+	if tr.Result == nil || tr.Result.Crop.Rectangle != res.Crop.Rectangle || tr.Error != "" {
+		t.Fatalf("expected the result %v, got %+v", res.Crop.Rectangle, tr.Result)
+	}
+	if _, err := json.Marshal(tr); err != nil {
+		t.Fatal(err)
+	}
+
+	// failed analyses get traced as well
+	settings.Denoise = "unknown"
+	analyzer = NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), Logger{}, settings).(ResultAnalyzer)
+	if _, err := analyzer.FindBestResult(img, 250, 250); err == nil {
+		t.Fatal("expected an error for an unknown denoise filter")
+	}
+	if len(traces) != 2 || traces[1].Error == "" || traces[1].Result != nil {
+		t.Fatalf("expected the error to be traced, got %+v", traces[1:])
+	}
+}
@@ -0,0 +1,241 @@
+//go:build onnx
+// +build onnx
+
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package u2net
+
+import (
+	"errors"
+	"image"
+	"sync"
+
+	"github.com/muesli/smartcrop"
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// ErrNoModel gets returned if no model has been configured.
+var ErrNoModel = errors.New("No model configured")
+
+// Config configures the model a Detector runs.
+type Config struct {
+	// ModelPath is the path of the ONNX model file.
+	ModelPath string
+	// LibraryPath is the path of the ONNX Runtime shared library. The
+	// default location of the platform gets used if empty.
+	LibraryPath string
+	// InputName and OutputName are the names of the input and output
+	// tensors of the model, defaulting to the ones of the reference export
+	// of U²-Net.
+	InputName  string
+	OutputName string
+	// Size is the width and height the model expects, defaulting to 320.
+	Size int
+	// Threshold is the saliency, ranging from 0 to 1, above which a pixel
+	// belongs to the foreground, defaulting to 0.5.
+	Threshold float64
+	// Coverage is the share, ranging from 0 to 1, of the most foreground a
+	// crop of its size can contain, that a candidate has to contain when
+	// the mask is used as a Prior, defaulting to 0.9.
+	Coverage float64
+}
+
+var (
+	// the ImageNet statistics the model has been trained with
+	mean = [3]float32{0.485, 0.456, 0.406}
+	std  = [3]float32{0.229, 0.224, 0.225}
+
+	initOnce sync.Once
+	initErr  error
+)
+
+// session runs the model, reading its input and writing its output tensor.
+type session interface {
+	Run() error
+	Destroy() error
+}
+
+// ortSession is a session of ONNX Runtime, owning its tensors.
+type ortSession struct {
+	*ort.AdvancedSession
+	input, output *ort.Tensor[float32]
+}
+
+func (s ortSession) Destroy() error {
+	err := s.AdvancedSession.Destroy()
+	s.input.Destroy()
+	s.output.Destroy()
+	return err
+}
+
+// Detector predicts the foreground mask of images. It is safe for concurrent
+// use, but runs one prediction at a time.
+type Detector struct {
+	mu      sync.Mutex
+	config  Config
+	input   []float32
+	output  []float32
+	session session
+}
+
+// NewDetector loads the model configured in config.
+func NewDetector(config Config) (*Detector, error) {
+	if config.ModelPath == "" {
+		return nil, ErrNoModel
+	}
+	if config.InputName == "" {
+		config.InputName = "input.1"
+	}
+	if config.OutputName == "" {
+		config.OutputName = "1959"
+	}
+	if config.Size == 0 {
+		config.Size = 320
+	}
+	if config.Threshold == 0 {
+		config.Threshold = 0.5
+	}
+	if config.Coverage == 0 {
+		config.Coverage = 0.9
+	}
+
+	initOnce.Do(func() {
+		if config.LibraryPath != "" {
+			ort.SetSharedLibraryPath(config.LibraryPath)
+		}
+		initErr = ort.InitializeEnvironment()
+	})
+	if initErr != nil {
+		return nil, initErr
+	}
+
+	size := int64(config.Size)
+	input, err := ort.NewEmptyTensor[float32](ort.NewShape(1, 3, size, size))
+	if err != nil {
+		return nil, err
+	}
+	output, err := ort.NewEmptyTensor[float32](ort.NewShape(1, 1, size, size))
+	if err != nil {
+		input.Destroy()
+		return nil, err
+	}
+	session, err := ort.NewAdvancedSession(config.ModelPath,
+		[]string{config.InputName}, []string{config.OutputName},
+		[]ort.Value{input}, []ort.Value{output}, nil)
+	if err != nil {
+		input.Destroy()
+		output.Destroy()
+		return nil, err
+	}
+
+	return &Detector{
+		config:  config,
+		input:   input.GetData(),
+		output:  output.GetData(),
+		session: ortSession{session, input, output},
+	}, nil
+}
+
+// Register loads the model configured in config and registers a Detector
+// running it under the given name. Enabling it in the settings only weights
+// the foreground like any other Detector, see Prior to enforce it.
+func Register(name string, config Config) error {
+	d, err := NewDetector(config)
+	if err != nil {
+		return err
+	}
+
+	smartcrop.RegisterDetector(name, func(s smartcrop.CropSettings) (smartcrop.Detector, error) {
+		return d, nil
+	})
+	return nil
+}
+
+// Close releases the resources of the model.
+func (d *Detector) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.session.Destroy()
+}
+
+// Detect implements smartcrop.Detector. It returns 255 for the pixels of the
+// foreground and 0 for the rest of img.
+func (d *Detector) Detect(img *image.RGBA) (*image.Gray, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	b := img.Bounds()
+	size := d.config.Size
+	plane := image.NewGray(b)
+	if b.Empty() {
+		return plane, nil
+	}
+
+	// sample the image down to the size of the model
+	in := d.input
+	for y := 0; y < size; y++ {
+		sy := b.Min.Y + y*b.Dy()/size
+		for x := 0; x < size; x++ {
+			i := img.PixOffset(b.Min.X+x*b.Dx()/size, sy)
+			for c := 0; c < 3; c++ {
+				in[c*size*size+y*size+x] = (float32(img.Pix[i+c])/255.0 - mean[c]) / std[c]
+			}
+		}
+	}
+
+	if err := d.session.Run(); err != nil {
+		return nil, err
+	}
+
+	// normalize the prediction to 0..1
+	out := d.output
+	min, max := out[0], out[0]
+	for _, v := range out {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	if max <= min {
+		return plane, nil
+	}
+
+	threshold := min + float32(d.config.Threshold)*(max-min)
+	for y := 0; y < b.Dy(); y++ {
+		my := y * size / b.Dy()
+		for x := 0; x < b.Dx(); x++ {
+			if out[my*size+x*size/b.Dx()] >= threshold {
+				plane.Pix[plane.PixOffset(b.Min.X+x, b.Min.Y+y)] = 255
+			}
+		}
+	}
+	return plane, nil
+}
@@ -0,0 +1,169 @@
+//go:build onnx
+// +build onnx
+
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package u2net
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+// foreground is the square of the test image the stub model predicts as its
+// foreground.
+var foreground = image.Rect(300, 70, 360, 130)
+
+// stubSession predicts the pixels with more red than green as foreground.
+type stubSession struct {
+	size          int
+	input, output []float32
+}
+
+func (s stubSession) Run() error {
+	n := s.size * s.size
+	for i := range s.output {
+		s.output[i] = 0
+		if s.input[i]-s.input[n+i] > 0.15 {
+			s.output[i] = 1
+		}
+	}
+	return nil
+}
+
+func (s stubSession) Destroy() error {
+	return nil
+}
+
+func newStubDetector() *Detector {
+	size := 100
+	input, output := make([]float32, 3*size*size), make([]float32, size*size)
+	return &Detector{
+		config:  Config{Size: size, Threshold: 0.5, Coverage: 0.9},
+		input:   input,
+		output:  output,
+		session: stubSession{size, input, output},
+	}
+}
+
+// testImage returns a gray image with a detailed checkerboard on its left,
+// and a dull reddish square, the foreground, on its right.
+func testImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			c := color.RGBA{128, 128, 128, 255}
+			switch {
+			case image.Pt(x, y).In(foreground):
+				c = color.RGBA{140, 120, 120, 255}
+			case x < 150 && (x/4+y/4)%2 == 0:
+				c = color.RGBA{255, 255, 255, 255}
+			case x < 150:
+				c = color.RGBA{0, 0, 0, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestDetect(t *testing.T) {
+	img := testImage()
+	mask, err := newStubDetector().Detect(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mask.Bounds() != img.Bounds() {
+		t.Fatalf("expected mask bounds %v, got %v", img.Bounds(), mask.Bounds())
+	}
+
+	// the model runs at a lower resolution, so allow the edges of the
+	// square to be off by a sample
+	inner, outer := foreground.Inset(4), foreground.Inset(-4)
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			p, v := image.Pt(x, y), mask.GrayAt(x, y).Y
+			if p.In(inner) && v != 255 {
+				t.Fatalf("expected foreground at %v", p)
+			}
+			if !p.In(outer) && v != 0 {
+				t.Fatalf("expected background at %v", p)
+			}
+		}
+	}
+}
+
+func TestPrior(t *testing.T) {
+	img := testImage()
+	center := image.Pt(330, 100)
+
+	settings := smartcrop.DefaultCropSettings()
+	analyzer := smartcrop.NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), smartcrop.Logger{}, settings)
+	crop, err := analyzer.FindBestCrop(img, 100, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if center.In(crop) {
+		t.Fatalf("expected the detail to be cropped without the prior, got %v", crop)
+	}
+
+	for _, refine := range []bool{false, true} {
+		settings := smartcrop.DefaultCropSettings()
+		settings.Refine = refine
+		d := newStubDetector()
+		d.Prior(&settings)
+		analyzer := smartcrop.NewAnalyzerWithSettings(nfnt.NewDefaultResizer(), smartcrop.Logger{}, settings)
+		crop, err := analyzer.FindBestCrop(img, 100, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := crop.Intersect(foreground); got.Dx()*got.Dy() < foreground.Dx()*foreground.Dy()*8/10 {
+			t.Fatalf("expected the foreground %v to be cropped with refine %v, got %v", foreground, refine, crop)
+		}
+	}
+}
+
+func TestPriorKeepsHooks(t *testing.T) {
+	settings := smartcrop.DefaultCropSettings()
+	settings.Hooks.AddAfter(smartcrop.StageDetect, func(smartcrop.Stage, *smartcrop.State) error {
+		return nil
+	})
+	hooks := settings.Hooks
+
+	newStubDetector().Prior(&settings)
+	if len(hooks.After[smartcrop.StagePrescale]) != 0 {
+		t.Fatal("expected the hooks of the original settings to be unchanged")
+	}
+	if len(settings.Hooks.After[smartcrop.StageDetect]) != 1 || len(settings.Hooks.After[smartcrop.StagePrescale]) != 1 {
+		t.Fatalf("expected the prior to be added to the hooks, got %v", settings.Hooks.After)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+/*
+Package u2net implements a smartcrop.Detector running a pretrained U²-Net
+style salient object segmentation model through ONNX Runtime. The binary
+foreground mask it predicts serves as a hard importance prior, which gets
+close to human subject framing at the cost of about 100ms per image.
+
+The package requires cgo and the ONNX Runtime shared library, so it is a
+module of its own and only built with the onnx build tag:
+
+	go build -tags onnx
+
+A model, e.g. u2netp.onnx, needs to be downloaded separately. Load it and add
+the prior to the settings, which skips the candidates that cut off the
+foreground:
+
+	d, err := u2net.NewDetector(u2net.Config{ModelPath: "u2netp.onnx"})
+	...
+	settings := smartcrop.DefaultCropSettings()
+	d.Prior(&settings)
+
+Registered with Register, the Detector can also be enabled in the settings
+like any other, which merely weights the foreground instead.
+*/
+package u2net
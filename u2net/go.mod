@@ -0,0 +1,15 @@
+module github.com/muesli/smartcrop/u2net
+
+go 1.19
+
+require (
+	github.com/muesli/smartcrop v0.0.0-20261016125610-7709603b20e0
+	github.com/yalue/onnxruntime_go v1.36.0
+)
+
+require (
+	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646 // indirect
+	golang.org/x/image v0.0.0-20190802002840-cff245a6509b // indirect
+)
+
+replace github.com/muesli/smartcrop => ../
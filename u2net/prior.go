@@ -0,0 +1,131 @@
+//go:build onnx
+// +build onnx
+
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package u2net
+
+import (
+	"image"
+
+	"github.com/muesli/smartcrop"
+)
+
+// Prior makes the foreground predicted by d a hard prior of the analysis
+// configured by settings: candidates containing less than Config.Coverage of
+// the most foreground a crop of their size can contain get skipped, and the
+// crop doesn't get refined off the foreground either. The mask gets predicted
+// on the prescaled image, in addition to any filter already in place.
+func (d *Detector) Prior(settings *smartcrop.CropSettings) {
+	// copy the hooks, which may be shared with other settings
+	hooks := smartcrop.Hooks{}
+	for stage, hs := range settings.Hooks.Before {
+		for _, h := range hs {
+			hooks.AddBefore(stage, h)
+		}
+	}
+	for stage, hs := range settings.Hooks.After {
+		for _, h := range hs {
+			hooks.AddAfter(stage, h)
+		}
+	}
+
+	hooks.AddAfter(smartcrop.StagePrescale, func(stage smartcrop.Stage, st *smartcrop.State) error {
+		mask, err := d.Detect(st.Prescaled)
+		if err != nil {
+			return err
+		}
+
+		c := newCoverage(mask, st.PrescaledArea())
+		filter := st.Filter
+		st.Filter = func(crop smartcrop.Crop) bool {
+			if filter != nil && !filter(crop) {
+				return false
+			}
+			return float64(c.sum(crop.Rectangle)) >= d.config.Coverage*float64(c.best(crop.Dx(), crop.Dy()))
+		}
+		return nil
+	})
+	settings.Hooks = hooks
+}
+
+// coverage counts the foreground pixels of a mask within rectangles, using a
+// summed-area table.
+type coverage struct {
+	area image.Rectangle
+	sums []int
+	most map[image.Point]int
+}
+
+// newCoverage returns the coverage of the foreground of mask within area.
+func newCoverage(mask *image.Gray, area image.Rectangle) *coverage {
+	area = area.Intersect(mask.Bounds())
+	w, h := area.Dx(), area.Dy()
+	c := &coverage{area: area, sums: make([]int, (w+1)*(h+1)), most: map[image.Point]int{}}
+	for y := 0; y < h; y++ {
+		row := 0
+		for x := 0; x < w; x++ {
+			if mask.GrayAt(area.Min.X+x, area.Min.Y+y).Y != 0 {
+				row++
+			}
+			c.sums[(y+1)*(w+1)+x+1] = c.sums[y*(w+1)+x+1] + row
+		}
+	}
+	return c
+}
+
+// sum returns the number of foreground pixels within r.
+func (c *coverage) sum(r image.Rectangle) int {
+	r = r.Intersect(c.area).Sub(c.area.Min)
+	if r.Empty() {
+		return 0
+	}
+	w := c.area.Dx() + 1
+	return c.sums[r.Max.Y*w+r.Max.X] - c.sums[r.Min.Y*w+r.Max.X] -
+		c.sums[r.Max.Y*w+r.Min.X] + c.sums[r.Min.Y*w+r.Min.X]
+}
+
+// best returns the most foreground pixels a rectangle of the given size within
+// the area can contain.
+func (c *coverage) best(width, height int) int {
+	size := image.Pt(width, height)
+	if most, ok := c.most[size]; ok {
+		return most
+	}
+
+	most := 0
+	for y := c.area.Min.Y; y+height <= c.area.Max.Y; y++ {
+		for x := c.area.Min.X; x+width <= c.area.Max.X; x++ {
+			if n := c.sum(image.Rect(x, y, x+width, y+height)); n > most {
+				most = n
+			}
+		}
+	}
+	c.most[size] = most
+	return most
+}
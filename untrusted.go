@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// ErrImageTooLarge is matched by the LimitError returned by FindCropUntrusted
+// and DecodeUntrusted for images exceeding their Limits.
+var ErrImageTooLarge = errors.New("Image exceeds the limits")
+
+// LimitError is returned by FindCropUntrusted and DecodeUntrusted for images
+// exceeding their Limits. Limit is the name of the field of Limits exceeded,
+// Value the value of the image exceeding it.
+type LimitError struct {
+	Limit string
+	Value int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("smartcrop: image exceeds %s with %d", e.Limit, e.Value)
+}
+
+// Is reports whether target is ErrImageTooLarge.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrImageTooLarge
+}
+
+// Limits bounds the resources FindCropUntrusted spends on an image.
+type Limits struct {
+	// MaxBytes is the size of the largest encoded image to read.
+	MaxBytes int64
+
+	// MaxPixels is the number of pixels of the largest image to decode, and
+	// MaxDimension the longest side. They are checked against the header of
+	// the image before decoding it, so a small file claiming huge dimensions
+	// doesn't exhaust the memory.
+	MaxPixels    int64
+	MaxDimension int
+
+	// Timeout bounds the time the analysis takes. It fails with
+	// context.DeadlineExceeded once it's exceeded, unless Request.Partial is
+	// set.
+	Timeout time.Duration
+}
+
+// DefaultLimits returns Limits suitable for photos uploaded by users.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxBytes:     32 << 20,
+		MaxPixels:    50000000,
+		MaxDimension: 16384,
+		Timeout:      10 * time.Second,
+	}
+}
+
+// FindCropUntrusted decodes the image read from r and finds its best crop for
+// everything specified in req, like FindCropReader, but hardened for
+// untrusted input like uploads of users: the image must not exceed limits,
+// the settings must pass Validate, and panics of the decoder get returned as
+// errors like the ones of the analysis. Zero fields of limits are not
+// enforced.
+func FindCropUntrusted(r io.Reader, req Request, limits Limits) (Result, error) {
+	if req.Settings != nil {
+		if err := req.Settings.Validate(); err != nil {
+			return Result{}, err
+		}
+	}
+
+	if limits.MaxBytes > 0 {
+		r = io.LimitReader(r, limits.MaxBytes+1)
+	}
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Result{}, err
+	}
+	img, _, err := DecodeUntrusted(buf, limits)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if limits.Timeout > 0 {
+		ctx := req.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		var cancel context.CancelFunc
+		req.Context, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
+	return FindCrop(img, req)
+}
+
+// Check returns a LimitError if an image of the given dimensions exceeds the
+// MaxDimension or MaxPixels of l, e.g. one a server is asked to scale to.
+func (l Limits) Check(width, height int) error {
+	if side := maxInt(width, height); l.MaxDimension > 0 && side > l.MaxDimension {
+		return &LimitError{Limit: "MaxDimension", Value: int64(side)}
+	}
+	if pixels := int64(width) * int64(height); l.MaxPixels > 0 && pixels > l.MaxPixels {
+		return &LimitError{Limit: "MaxPixels", Value: pixels}
+	}
+	return nil
+}
+
+// DecodeUntrusted decodes the encoded image buf like Decode, hardened for
+// untrusted input like FindCropUntrusted: buf and the image must not exceed
+// limits, which get checked against the header of the image before decoding
+// it, and a panic of the decoder gets returned as an error. Zero fields of
+// limits are not enforced, and Timeout is left to the caller.
+func DecodeUntrusted(buf []byte, limits Limits) (img image.Image, format string, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			img, format, err = nil, "", fmt.Errorf("smartcrop: decoding image panicked: %v", v)
+		}
+	}()
+
+	if limits.MaxBytes > 0 && int64(len(buf)) > limits.MaxBytes {
+		return nil, "", &LimitError{Limit: "MaxBytes", Value: int64(len(buf))}
+	}
+	if c, _, err := image.DecodeConfig(bytes.NewReader(buf)); err == nil {
+		if err := limits.Check(c.Width, c.Height); err != nil {
+			return nil, "", err
+		}
+	}
+	if img, format, err = Decode(bytes.NewReader(buf)); err != nil {
+		return nil, "", err
+	}
+	// the header might not match the image
+	if err := limits.Check(img.Bounds().Dx(), img.Bounds().Dy()); err != nil {
+		return nil, "", err
+	}
+	return img, format, nil
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	_ "image/jpeg"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func init() {
+	image.RegisterFormat("panic", "PANIC", func(io.Reader) (image.Image, error) {
+		panic("broken decoder")
+	}, func(io.Reader) (image.Config, error) {
+		return image.Config{Width: 1, Height: 1}, nil
+	})
+}
+
+func TestFindCropUntrusted(t *testing.T) {
+	buf, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := Request{Width: 250, Height: 250, Resizer: nfnt.NewDefaultResizer()}
+
+	res, err := FindCropUntrusted(bytes.NewReader(buf), req, DefaultLimits())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := FindCropReader(bytes.NewReader(buf), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Crop != want.Crop {
+		t.Errorf("expected %v, got %v", want.Crop, res.Crop)
+	}
+
+	tests := map[string]Limits{
+		"MaxBytes":     {MaxBytes: int64(len(buf)) - 1},
+		"MaxPixels":    {MaxPixels: 900*284 - 1},
+		"MaxDimension": {MaxDimension: 899},
+	}
+	for limit, limits := range tests {
+		_, err := FindCropUntrusted(bytes.NewReader(buf), req, limits)
+		if !errors.Is(err, ErrImageTooLarge) {
+			t.Errorf("expected ErrImageTooLarge exceeding %s, got %v", limit, err)
+			continue
+		}
+		var lerr *LimitError
+		if !errors.As(err, &lerr) || lerr.Limit != limit {
+			t.Errorf("expected a LimitError for %s, got %v", limit, err)
+		}
+	}
+}
+
+func TestDecodeUntrusted(t *testing.T) {
+	buf, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, format, err := DecodeUntrusted(buf, DefaultLimits())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "jpeg" || img.Bounds() != image.Rect(0, 0, 900, 284) {
+		t.Fatalf("expected a 900x284 jpeg, got a %v %s", img.Bounds(), format)
+	}
+
+	if _, _, err := DecodeUntrusted(buf, Limits{MaxDimension: 899}); !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("expected ErrImageTooLarge, got %v", err)
+	}
+	if _, _, err := DecodeUntrusted([]byte("PANIC"), Limits{}); err == nil || !strings.Contains(err.Error(), "panicked") {
+		t.Fatalf("expected the panic of the decoder as an error, got %v", err)
+	}
+}
+
+func TestFindCropUntrustedInvalid(t *testing.T) {
+	settings := DefaultCropSettings()
+	settings.Workers = -2
+	_, err := FindCropUntrusted(strings.NewReader("garbage"), Request{Width: 1, Height: 1, Settings: &settings, Resizer: nfnt.NewDefaultResizer()}, DefaultLimits())
+	if err == nil || !strings.Contains(err.Error(), "Workers") {
+		t.Errorf("expected the settings to be rejected, got %v", err)
+	}
+
+	_, err = FindCropUntrusted(strings.NewReader("PANIC"), Request{Width: 1, Height: 1, Resizer: nfnt.NewDefaultResizer()}, DefaultLimits())
+	if err == nil || !strings.Contains(err.Error(), "broken decoder") {
+		t.Errorf("expected the panic of the decoder as an error, got %v", err)
+	}
+
+	_, err = FindCropUntrusted(strings.NewReader("\xff\xd8\xff\xe0truncated"), Request{Width: 1, Height: 1, Resizer: nfnt.NewDefaultResizer()}, DefaultLimits())
+	if err == nil {
+		t.Error("expected an error for a truncated image")
+	}
+}
+
+func TestFindCropUntrustedTimeout(t *testing.T) {
+	buf, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	limits := DefaultLimits()
+	limits.Timeout = time.Nanosecond
+	_, err = FindCropUntrusted(bytes.NewReader(buf), Request{Width: 250, Height: 250, Context: ctx, Resizer: nfnt.NewDefaultResizer()}, limits)
+	if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the analysis to be aborted, got %v", err)
+	}
+}
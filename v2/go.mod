@@ -0,0 +1,7 @@
+module github.com/muesli/smartcrop/v2
+
+go 1.12
+
+require github.com/muesli/smartcrop v0.0.0-20261016125650-cdee02c9db43
+
+replace github.com/muesli/smartcrop => ../
@@ -0,0 +1,161 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+/*
+Package smartcrop is version 2 of the smartcrop API. Its Analyzer takes a
+context, gets configured with option structs and returns the full Result of an
+analysis.
+
+Migrating from version 1 mostly means replacing NewAnalyzer,
+NewAnalyzerWithLogger and NewAnalyzerWithSettings with NewAnalyzer(Options{}),
+passing a context and a Request to FindBestCrop and using Result.Crop instead
+of the returned rectangle. Version 1 remains available unchanged.
+
+Version 2 is a thin layer over the analysis of version 1, so both share their
+types, settings and registries: Detectors and CropStrategies registered with
+either version are available to both. Resizers are the ones of the options and
+nfnt packages of version 1.
+*/
+package smartcrop
+
+import (
+	"context"
+	"image"
+
+	v1 "github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+	"github.com/muesli/smartcrop/options"
+)
+
+// Types shared with version 1 of the API.
+type (
+	CropSettings     = v1.CropSettings
+	Result           = v1.Result
+	Crop             = v1.Crop
+	Score            = v1.Score
+	Boost            = v1.Boost
+	Logger           = v1.Logger
+	Stage            = v1.Stage
+	State            = v1.State
+	Hook             = v1.Hook
+	Hooks            = v1.Hooks
+	Detector         = v1.Detector
+	DetectorFactory  = v1.DetectorFactory
+	CropStrategy     = v1.CropStrategy
+	Region           = v1.Region
+	RegionClassifier = v1.RegionClassifier
+)
+
+// Errors shared with version 1 of the API.
+var (
+	ErrInvalidDimensions = v1.ErrInvalidDimensions
+	ErrVetoed            = v1.ErrVetoed
+)
+
+// DefaultCropSettings returns the default settings.
+func DefaultCropSettings() CropSettings {
+	return v1.DefaultCropSettings()
+}
+
+// RegisterDetector makes a Detector available under the given name, so it can
+// be enabled in CropSettings.Detectors.
+func RegisterDetector(name string, factory DetectorFactory) {
+	v1.RegisterDetector(name, factory)
+}
+
+// RegisterStrategy makes a CropStrategy available under the given name, so it
+// can be used in CropSettings.Strategies.
+func RegisterStrategy(name string, strategy CropStrategy) {
+	v1.RegisterStrategy(name, strategy)
+}
+
+// Options configures an Analyzer.
+type Options struct {
+	// Settings defaults to DefaultCropSettings.
+	Settings *CropSettings
+
+	// Resizer is used for prescaling images. It defaults to the nfnt Resizer.
+	Resizer options.Resizer
+
+	// Logger defaults to a Logger discarding all output.
+	Logger Logger
+}
+
+// Request describes a single crop.
+type Request struct {
+	// Width and Height are the requested dimensions of the crop.
+	Width, Height int
+
+	// Boosts are regions of the source image to prefer.
+	Boosts []Boost
+
+	// Partial returns the best crop scored so far, flagged as
+	// Result.Partial, if the context is done while the candidates are being
+	// scored, instead of failing.
+	Partial bool
+}
+
+// Analyzer finds the best crop of an image.
+type Analyzer interface {
+	// FindBestCrop analyzes img for the given Request. The analysis gets
+	// aborted between its stages and while scoring the candidates once ctx
+	// is done, returning ctx.Err() unless Request.Partial is set.
+	FindBestCrop(ctx context.Context, img image.Image, req Request) (Result, error)
+}
+
+type analyzer struct {
+	opts Options
+}
+
+// NewAnalyzer returns a new Analyzer with the given Options.
+func NewAnalyzer(opts Options) Analyzer {
+	if opts.Settings == nil {
+		settings := DefaultCropSettings()
+		opts.Settings = &settings
+	}
+	if opts.Resizer == nil {
+		opts.Resizer = nfnt.NewDefaultResizer()
+	}
+	return analyzer{opts: opts}
+}
+
+func (a analyzer) FindBestCrop(ctx context.Context, img image.Image, req Request) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	return v1.FindCrop(img, v1.Request{
+		Width:    req.Width,
+		Height:   req.Height,
+		Settings: a.opts.Settings,
+		Boosts:   req.Boosts,
+		Resizer:  a.opts.Resizer,
+		Logger:   a.opts.Logger,
+		Context:  ctx,
+		Partial:  req.Partial,
+	})
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"context"
+	"image"
+	_ "image/jpeg"
+	"os"
+	"testing"
+
+	v1 "github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+const testFile = "../examples/gopher.jpg"
+
+func TestFindBestCrop(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := NewAnalyzer(Options{}).FindBestCrop(context.Background(), img, Request{Width: 250, Height: 250})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := image.Rect(464, 29, 719, 284)
+	if res.Crop.Rectangle != expected {
+		t.Fatalf("expected %v, got %v", expected, res.Crop.Rectangle)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewAnalyzer(Options{}).FindBestCrop(ctx, img, Request{Width: 250, Height: 250}); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+// leftDetector marks the left quarter of an image as important.
+type leftDetector struct{}
+
+func (leftDetector) Detect(img *image.RGBA) (*image.Gray, error) {
+	plane := image.NewGray(img.Bounds())
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx()/4; x++ {
+			plane.Pix[plane.PixOffset(x, y)] = 255
+		}
+	}
+	return plane, nil
+}
+
+func TestVersion1(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// both versions find the same crop
+	res, err := NewAnalyzer(Options{}).FindBestCrop(context.Background(), img, Request{Width: 250, Height: 250})
+	if err != nil {
+		t.Fatal(err)
+	}
+	analyzer := v1.NewAnalyzer(nfnt.NewDefaultResizer()).(v1.ResultAnalyzer)
+	expected, err := analyzer.FindBestResult(img, 250, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Crop != expected.Crop || res.ParamsHash != expected.ParamsHash {
+		t.Fatalf("expected the crop %+v of version 1, got %+v", expected.Crop, res.Crop)
+	}
+
+	// and share their registries
+	RegisterDetector("v2-left", func(s CropSettings) (Detector, error) {
+		return leftDetector{}, nil
+	})
+	settings := DefaultCropSettings()
+	settings.Detectors = map[string]float64{"v2-left": 1.0}
+	left, err := NewAnalyzer(Options{Settings: &settings}).FindBestCrop(context.Background(), img, Request{Width: 250, Height: 250})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if left.Crop.Min.X >= res.Crop.Min.X {
+		t.Fatalf("expected the detector to move the crop left of %v, got %v", res.Crop.Rectangle, left.Crop.Rectangle)
+	}
+	found := false
+	for _, name := range v1.Detectors() {
+		found = found || name == "v2-left"
+	}
+	if !found {
+		t.Errorf("expected the detector to be registered with version 1, got %v", v1.Detectors())
+	}
+}
@@ -0,0 +1,186 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"reflect"
+	"sort"
+)
+
+var (
+	// ErrNilImage gets returned when no image is given.
+	ErrNilImage = errors.New("Image is nil")
+	// ErrEmptyImage gets returned when the image has no pixels.
+	ErrEmptyImage = errors.New("Image is empty")
+	// ErrNegativeDimensions gets returned when the width or height of the
+	// requested crop is negative.
+	ErrNegativeDimensions = errors.New("Width and height must not be negative")
+	// ErrDegenerateCrop gets returned when the requested aspect ratio is so
+	// extreme that no crop of it would be at least a pixel wide and high.
+	ErrDegenerateCrop = errors.New("Aspect ratio leaves no pixels to crop")
+)
+
+// validateDimensions checks the dimensions of a crop requested for an image
+// with the given dimensions. If only one of width and height is given, the
+// other one gets derived from the aspect ratio of the image.
+func validateDimensions(imgWidth, imgHeight, width, height int) (int, int, error) {
+	if imgWidth <= 0 || imgHeight <= 0 {
+		return 0, 0, ErrEmptyImage
+	}
+	if width < 0 || height < 0 {
+		return 0, 0, ErrNegativeDimensions
+	}
+	if width == 0 && height == 0 {
+		return 0, 0, ErrInvalidDimensions
+	}
+
+	if width == 0 {
+		width = maxInt(int(math.Round(float64(height)*float64(imgWidth)/float64(imgHeight))), 1)
+	}
+	if height == 0 {
+		height = maxInt(int(math.Round(float64(width)*float64(imgHeight)/float64(imgWidth))), 1)
+	}
+
+	// the widest crop of the requested aspect ratio
+	scale := math.Min(float64(imgWidth)/float64(width), float64(imgHeight)/float64(height))
+	if float64(width)*scale < 1 || float64(height)*scale < 1 {
+		return 0, 0, ErrDegenerateCrop
+	}
+	return width, height, nil
+}
+
+// validateImage checks img and the dimensions of the crop requested for it,
+// see validateDimensions.
+func validateImage(img image.Image, width, height int) (int, int, error) {
+	if img == nil {
+		return 0, 0, ErrNilImage
+	}
+	b := img.Bounds()
+	return validateDimensions(b.Dx(), b.Dy(), width, height)
+}
+
+// Validate checks the settings for values the analysis can't work with, which
+// would otherwise lead to endless loops or NaN scores.
+func (s CropSettings) Validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("smartcrop: invalid settings: "+format, args...)
+	}
+
+	if name, v := nonFinite("", reflect.ValueOf(s)); name != "" {
+		return invalid("%s is %v", name, v)
+	}
+
+	for name, w := range s.Ensemble {
+		if !(w > 0) || math.IsInf(w, 0) {
+			return invalid("weight of strategy %q in Ensemble must be positive, got %v", name, w)
+		}
+	}
+
+	switch {
+	case s.ScoreDownSample <= 0:
+		return invalid("ScoreDownSample must be positive, got %d", s.ScoreDownSample)
+	case s.Step <= 0:
+		return invalid("Step must be positive, got %d", s.Step)
+	case s.ScaleStep <= 0:
+		return invalid("ScaleStep must be positive, got %v", s.ScaleStep)
+	case s.MinScale <= 0 || s.MinScale > s.MaxScale:
+		return invalid("MinScale must be positive and at most MaxScale, got %v and %v", s.MinScale, s.MaxScale)
+	case s.SkinThreshold >= 1 || s.SaturationThreshold >= 1:
+		return invalid("thresholds must be below 1, got %v and %v", s.SkinThreshold, s.SaturationThreshold)
+	case s.Prescale && s.PrescaleMin <= 0:
+		return invalid("PrescaleMin must be positive, got %v", s.PrescaleMin)
+	case s.StepFraction < 0:
+		return invalid("StepFraction must not be negative, got %v", s.StepFraction)
+	case s.RatioTolerance < 0 || s.RatioTolerance >= 1:
+		return invalid("RatioTolerance must be at least 0 and below 1, got %v", s.RatioTolerance)
+	case s.MaxDuration < 0:
+		return invalid("MaxDuration must not be negative, got %v", s.MaxDuration)
+	case s.EnsembleMode != "" && s.EnsembleMode != EnsembleBest && s.EnsembleMode != EnsembleBlend:
+		return invalid("EnsembleMode must be %q or %q, got %q", EnsembleBest, EnsembleBlend, s.EnsembleMode)
+	case s.Workers < AutoWorkers:
+		return invalid("Workers must not be negative except for AutoWorkers, got %d", s.Workers)
+	case s.DedupEpsilon < 0:
+		return invalid("DedupEpsilon must not be negative, got %d", s.DedupEpsilon)
+	case s.Samples < 0:
+		return invalid("Samples must not be negative, got %d", s.Samples)
+	case s.Margin < 0:
+		return invalid("Margin must not be negative, got %v", s.Margin)
+	}
+	return nil
+}
+
+// nonFinite returns the name and value of the first NaN or infinite number in
+// v, the value of the setting name, or "" if there is none. It looks into
+// every field of the settings, their maps and the fields they point to,
+// except the ones JSON ignores, like the Classifier.
+func nonFinite(name string, v reflect.Value) (string, float64) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" || f.Tag.Get("json") == "-" {
+				continue
+			}
+			field := f.Name
+			if name != "" {
+				field = name + "." + field
+			}
+			if field, x := nonFinite(field, v.Field(i)); field != "" {
+				return field, x
+			}
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return nonFinite(name, v.Elem())
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, k := range keys {
+			if field, x := nonFinite(fmt.Sprintf("%s[%q]", name, k.String()), v.MapIndex(k)); field != "" {
+				return field, x
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if field, x := nonFinite(fmt.Sprintf("%s[%d]", name, i), v.Index(i)); field != "" {
+				return field, x
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		if x := v.Float(); math.IsNaN(x) || math.IsInf(x, 0) {
+			return name, x
+		}
+	}
+	return "", 0
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestValidateImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	analyzer := NewAnalyzer(nfnt.NewDefaultResizer())
+
+	for _, tc := range []struct {
+		img           image.Image
+		width, height int
+		err           error
+	}{
+		{nil, 100, 100, ErrNilImage},
+		{image.NewRGBA(image.Rect(0, 0, 0, 10)), 100, 100, ErrEmptyImage},
+		{img, -1, 100, ErrNegativeDimensions},
+		{img, 0, 0, ErrInvalidDimensions},
+		{img, 1, 100000, ErrDegenerateCrop},
+	} {
+		if _, err := analyzer.FindBestCrop(tc.img, tc.width, tc.height); err != tc.err {
+			t.Fatalf("expected %v for %dx%d, got %v", tc.err, tc.width, tc.height, err)
+		}
+	}
+
+	// a missing dimension follows the aspect ratio of the image
+	w, h, err := validateImage(img, 0, 100)
+	if err != nil || w != 150 || h != 100 {
+		t.Fatalf("expected 150x100, got %dx%d (%v)", w, h, err)
+	}
+}
+
+func TestValidateSettings(t *testing.T) {
+	if err := DefaultCropSettings().Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, modify := range []func(*CropSettings){
+		func(s *CropSettings) { s.Step = 0 },
+		func(s *CropSettings) { s.ScaleStep = -0.1 },
+		func(s *CropSettings) { s.ScoreDownSample = 0 },
+		func(s *CropSettings) { s.MinScale = 2.0 },
+		func(s *CropSettings) { s.SkinWeight = math.NaN() },
+		func(s *CropSettings) { s.SkinThreshold = 1.0 },
+		func(s *CropSettings) { s.Ensemble = map[string]float64{StrategySmart: 0} },
+		func(s *CropSettings) { s.EnsembleMode = "average" },
+	} {
+		s := DefaultCropSettings()
+		modify(&s)
+		if err := s.Validate(); err == nil {
+			t.Fatalf("expected %+v to be invalid", s)
+		}
+	}
+}
+
+// TestValidateNonFinite sets every number of the settings to NaN and to
+// infinity in turn, including the ones in maps and in the TextZone, and
+// expects Validate to name it. It fails for fields it doesn't know how to
+// set, so new kinds of settings get covered.
+func TestValidateNonFinite(t *testing.T) {
+	type field struct {
+		name string
+		set  func(s *CropSettings, v float64)
+	}
+	var fields []field
+
+	typ := reflect.TypeOf(CropSettings{})
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Tag.Get("json") == "-" {
+			continue
+		}
+		index := i
+		switch f.Type {
+		case reflect.TypeOf(float64(0)):
+			fields = append(fields, field{f.Name, func(s *CropSettings, v float64) {
+				reflect.ValueOf(s).Elem().Field(index).SetFloat(v)
+			}})
+		case reflect.TypeOf(map[string]float64(nil)):
+			fields = append(fields, field{f.Name + `["x"]`, func(s *CropSettings, v float64) {
+				reflect.ValueOf(s).Elem().Field(index).Set(reflect.ValueOf(map[string]float64{"x": v}))
+			}})
+		case reflect.TypeOf(&NormalizedRect{}):
+			rt := f.Type.Elem()
+			for j := 0; j < rt.NumField(); j++ {
+				sub := j
+				fields = append(fields, field{f.Name + "." + rt.Field(j).Name, func(s *CropSettings, v float64) {
+					r := &NormalizedRect{0.1, 0.1, 0.5, 0.5}
+					reflect.ValueOf(r).Elem().Field(sub).SetFloat(v)
+					reflect.ValueOf(s).Elem().Field(index).Set(reflect.ValueOf(r))
+				}})
+			}
+		case reflect.TypeOf(0), reflect.TypeOf(int64(0)), reflect.TypeOf(false), reflect.TypeOf(""),
+			reflect.TypeOf(time.Duration(0)), reflect.TypeOf([]string(nil)):
+		default:
+			t.Fatalf("don't know how to set %s of type %v", f.Name, f.Type)
+		}
+	}
+
+	for _, f := range fields {
+		f := f
+		t.Run(f.name, func(t *testing.T) {
+			for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+				s := DefaultCropSettings()
+				f.set(&s, v)
+				err := s.Validate()
+				if err == nil || !strings.Contains(err.Error(), f.name+" is ") {
+					t.Fatalf("expected %s = %v to be invalid, got %v", f.name, v, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// DetectorVanishingPoint is the name of the built-in Detector marking the
+// vanishing point of converging lines like roads, rails or corridors. With
+// RuleOfThirds enabled, enabling it in CropSettings.Detectors places the
+// vanishing point close to a power point of the crop.
+const DetectorVanishingPoint = "vanishing-point"
+
+const (
+	// vpEdgeThreshold is the smallest Sobel gradient magnitude of a pixel
+	// voting for the lines through it.
+	vpEdgeThreshold = 100.0
+	// vpLines is the maximum number of the strongest lines considered.
+	vpLines = 16
+	// vpMinAngle is the smallest angle, in degrees, between a line and the
+	// axes of the image for it to count as a leading line, as well as
+	// between two lines for their intersection to count.
+	vpMinAngle = 10
+	// vpTolerance is the largest distance of a supporting line from the
+	// vanishing point, relative to the diagonal of the image.
+	vpTolerance = 0.02
+	// vpMinSupport is the smallest number of lines supporting a vanishing
+	// point.
+	vpMinSupport = 3
+	// vpRadius is the standard deviation of the blob marking the vanishing
+	// point, relative to the diagonal of the image.
+	vpRadius = 0.03
+)
+
+func init() {
+	RegisterDetector(DetectorVanishingPoint, func(s CropSettings) (Detector, error) {
+		return vanishingPointDetector{}, nil
+	})
+}
+
+type vanishingPointDetector struct{}
+
+func (vanishingPointDetector) Detect(img *image.RGBA) (*image.Gray, error) {
+	b := img.Bounds()
+	plane := image.NewGray(b)
+	x, y, ok := findVanishingPoint(img)
+	if !ok {
+		return plane, nil
+	}
+
+	sigma := vpRadius * math.Hypot(float64(b.Dx()), float64(b.Dy()))
+	for py := 0; py < b.Dy(); py++ {
+		for px := 0; px < b.Dx(); px++ {
+			dx, dy := float64(px)+0.5-x, float64(py)+0.5-y
+			v := 255.0 * math.Exp(-(dx*dx+dy*dy)/(2.0*sigma*sigma))
+			plane.Pix[plane.PixOffset(b.Min.X+px, b.Min.Y+py)] = uint8(v)
+		}
+	}
+	return plane, nil
+}
+
+// houghLine is a line of all points x, y with x·cos(theta) + y·sin(theta) =
+// rho, weighted by the gradient magnitudes of its pixels.
+type houghLine struct {
+	theta, rho float64
+	weight     float64
+}
+
+// findVanishingPoint returns the point most of the strongest oblique lines of
+// img converge to, relative to its bounds, if there is one within the image.
+func findVanishingPoint(img *image.RGBA) (float64, float64, bool) {
+	lines := houghLines(img)
+	if len(lines) < vpMinSupport {
+		return 0, 0, false
+	}
+
+	b := img.Bounds()
+	w, h := float64(b.Dx()), float64(b.Dy())
+	tolerance := vpTolerance * math.Hypot(w, h)
+
+	// every intersection of two lines is a candidate, supported by all the
+	// lines passing close to it
+	bestX, bestY, best, support := 0.0, 0.0, 0.0, 0
+	for i := range lines {
+		for j := i + 1; j < len(lines); j++ {
+			a, c := lines[i], lines[j]
+			det := math.Sin(c.theta - a.theta)
+			if math.Abs(det) < math.Sin(vpMinAngle*math.Pi/180.0) {
+				continue
+			}
+			x := (a.rho*math.Sin(c.theta) - c.rho*math.Sin(a.theta)) / det
+			y := (c.rho*math.Cos(a.theta) - a.rho*math.Cos(c.theta)) / det
+			if x < 0 || y < 0 || x >= w || y >= h {
+				continue
+			}
+
+			weight, n := 0.0, 0
+			for _, l := range lines {
+				if math.Abs(x*math.Cos(l.theta)+y*math.Sin(l.theta)-l.rho) <= tolerance {
+					weight += l.weight
+					n++
+				}
+			}
+			if weight > best {
+				bestX, bestY, best, support = x, y, weight, n
+			}
+		}
+	}
+
+	if support < vpMinSupport {
+		return 0, 0, false
+	}
+	return bestX, bestY, true
+}
+
+// houghLines returns the strongest oblique lines of img, found by a Hough
+// transform in which every pixel only votes for the lines along its edge.
+func houghLines(img *image.RGBA) []houghLine {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w < 3 || h < 3 {
+		return nil
+	}
+	l := makeCies(img)
+
+	const thetas = 180
+	maxRho := int(math.Ceil(math.Hypot(float64(w), float64(h))))
+	rhos := 2*maxRho + 1
+	acc := make([]float64, thetas*rhos)
+
+	var cos, sin [thetas]float64
+	for t := 0; t < thetas; t++ {
+		cos[t] = math.Cos(float64(t) * math.Pi / thetas)
+		sin[t] = math.Sin(float64(t) * math.Pi / thetas)
+	}
+
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			at := func(dx, dy int) float64 {
+				return l[(y+dy)*w+x+dx]
+			}
+			gx := at(1, -1) + 2*at(1, 0) + at(1, 1) - at(-1, -1) - 2*at(-1, 0) - at(-1, 1)
+			gy := at(-1, 1) + 2*at(0, 1) + at(1, 1) - at(-1, -1) - 2*at(0, -1) - at(1, -1)
+			m := math.Hypot(gx, gy)
+			if m < vpEdgeThreshold {
+				continue
+			}
+
+			// the gradient is the normal of the line
+			angle := math.Atan2(gy, gx)
+			if angle < 0 {
+				angle += math.Pi
+			}
+			t0 := int(math.Round(angle * thetas / math.Pi))
+			for dt := -2; dt <= 2; dt++ {
+				t := ((t0+dt)%thetas + thetas) % thetas
+				rho := int(math.Round(float64(x)*cos[t]+float64(y)*sin[t])) + maxRho
+				acc[t*rhos+rho] += m
+			}
+		}
+	}
+
+	// local maxima of the accumulator, leaving out lines parallel to the axes
+	var lines []houghLine
+	for t := 0; t < thetas; t++ {
+		if t < vpMinAngle || absInt(t-90) < vpMinAngle || t > thetas-vpMinAngle {
+			continue
+		}
+		for r := 0; r < rhos; r++ {
+			v := acc[t*rhos+r]
+			if v == 0 || !localMaximum(acc, thetas, rhos, t, r) {
+				continue
+			}
+			lines = append(lines, houghLine{
+				theta:  float64(t) * math.Pi / thetas,
+				rho:    float64(r - maxRho),
+				weight: v,
+			})
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].weight > lines[j].weight
+	})
+	if len(lines) > vpLines {
+		lines = lines[:vpLines]
+	}
+	return lines
+}
+
+// localMaximum reports whether the accumulator cell t, r is the largest of its
+// neighborhood, preferring the first one of equal cells.
+func localMaximum(acc []float64, thetas, rhos, t, r int) bool {
+	v := acc[t*rhos+r]
+	for dt := -3; dt <= 3; dt++ {
+		nt := t + dt
+		if nt < 0 || nt >= thetas {
+			continue
+		}
+		for dr := -5; dr <= 5; dr++ {
+			nr := r + dr
+			if nr < 0 || nr >= rhos || (dt == 0 && dr == 0) {
+				continue
+			}
+			n := acc[nt*rhos+nr]
+			if n > v || (n == v && (dt < 0 || (dt == 0 && dr < 0))) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestVanishingPoint(t *testing.T) {
+	// rails converging in the upper right
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for i := range img.Pix {
+		img.Pix[i] = 40
+	}
+	vx, vy := 210.0, 70.0
+	for _, start := range [][2]float64{{0, 199}, {80, 199}, {299, 199}, {0, 120}, {0, 0}} {
+		for s := 0.0; s <= 1.0; s += 0.001 {
+			x := start[0] + (vx-start[0])*s
+			y := start[1] + (vy-start[1])*s
+			for d := -1; d <= 1; d++ {
+				img.SetRGBA(int(x)+d, int(y), color.RGBA{230, 230, 230, 255})
+			}
+		}
+	}
+
+	x, y, ok := findVanishingPoint(img)
+	if !ok || math.Hypot(x-vx, y-vy) > 5 {
+		t.Fatalf("expected the vanishing point at %v,%v, got %v,%v (%v)", vx, vy, x, y, ok)
+	}
+
+	plane, err := vanishingPointDetector{}.Detect(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := plane.GrayAt(int(x), int(y)).Y; v < 250 {
+		t.Fatalf("expected the vanishing point to be marked, got %d", v)
+	}
+
+	// a plain image has none
+	flat := image.NewRGBA(img.Bounds())
+	if _, _, ok := findVanishingPoint(flat); ok {
+		t.Fatal("expected no vanishing point on a plain image")
+	}
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+/*
+Package xdraw implements a Resizer using the scalers of golang.org/x/image/draw.
+*/
+package xdraw
+
+import (
+	"image"
+	"math"
+
+	"github.com/muesli/smartcrop/options"
+
+	"golang.org/x/image/draw"
+)
+
+type xdrawResizer struct {
+	interpolator draw.Interpolator
+}
+
+// Resize scales img to the given dimensions. If either width or height is 0, it
+// gets derived from the other one, preserving the aspect ratio of img.
+func (r xdrawResizer) Resize(img image.Image, width, height uint) image.Image {
+	b := img.Bounds()
+	w, h := float64(width), float64(height)
+	if w == 0 && h == 0 {
+		return img
+	}
+	if w == 0 {
+		w = math.Max(1.0, math.Round(h*float64(b.Dx())/float64(b.Dy())))
+	}
+	if h == 0 {
+		h = math.Max(1.0, math.Round(w*float64(b.Dy())/float64(b.Dx())))
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, int(w), int(h)))
+	r.interpolator.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// NewResizer creates a new Resizer with the given interpolator.
+func NewResizer(interpolator draw.Interpolator) options.Resizer {
+	return xdrawResizer{interpolator: interpolator}
+}
+
+// NewDefaultResizer creates a new Resizer with the default interpolator.
+func NewDefaultResizer() options.Resizer {
+	return NewResizer(draw.CatmullRom)
+}
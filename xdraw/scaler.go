@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package xdraw
+
+import (
+	"image"
+
+	"github.com/muesli/smartcrop"
+
+	"golang.org/x/image/draw"
+)
+
+type smartScaler struct {
+	analyzer smartcrop.Analyzer
+	scaler   draw.Scaler
+}
+
+// boundedImage restricts the bounds of an image which can't return a
+// SubImage.
+type boundedImage struct {
+	image.Image
+	bounds image.Rectangle
+}
+
+func (b boundedImage) Bounds() image.Rectangle {
+	return b.bounds
+}
+
+// Scale implements draw.Scaler. Instead of all of sr, it scales the best crop
+// within sr for the aspect ratio of dr. If no crop can be found, all of sr
+// gets scaled.
+func (s smartScaler) Scale(dst draw.Image, dr image.Rectangle, src image.Image, sr image.Rectangle, op draw.Op, opts *draw.Options) {
+	sr = sr.Intersect(src.Bounds())
+
+	type SubImager interface {
+		SubImage(r image.Rectangle) image.Image
+	}
+	var sub image.Image = boundedImage{src, sr}
+	if si, ok := src.(SubImager); ok {
+		sub = si.SubImage(sr)
+	}
+
+	if !sr.Empty() && !dr.Empty() {
+		if crop, err := s.analyzer.FindBestCrop(sub, dr.Dx(), dr.Dy()); err == nil && !crop.Empty() {
+			sr = crop
+		}
+	}
+	s.scaler.Scale(dst, dr, src, sr, op, opts)
+}
+
+// NewScaler returns a draw.Scaler which scales the best crop of the source
+// rectangle for the aspect ratio of the destination rectangle, found by
+// analyzer, with scaler. Code written against draw.Scaler can adopt smart
+// cropping that way without any other changes.
+func NewScaler(analyzer smartcrop.Analyzer, scaler draw.Scaler) draw.Scaler {
+	return smartScaler{analyzer: analyzer, scaler: scaler}
+}
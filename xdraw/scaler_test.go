@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package xdraw
+
+import (
+	"image"
+	_ "image/jpeg"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop"
+
+	"golang.org/x/image/draw"
+)
+
+func TestScaler(t *testing.T) {
+	fi, err := os.Open("../examples/gopher.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer := smartcrop.NewAnalyzer(NewDefaultResizer())
+	dr := image.Rect(0, 0, 100, 100)
+	dst := image.NewRGBA(dr)
+	NewScaler(analyzer, draw.ApproxBiLinear).Scale(dst, dr, img, img.Bounds(), draw.Src, nil)
+
+	crop, err := analyzer.FindBestCrop(img, 100, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := image.NewRGBA(dr)
+	draw.ApproxBiLinear.Scale(expected, dr, img, crop, draw.Src, nil)
+	if string(dst.Pix) != string(expected.Pix) {
+		t.Fatalf("expected the crop %v to get scaled", crop)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/muesli/smartcrop/yaml
+
+go 1.12
+
+require (
+	github.com/muesli/smartcrop v0.0.0-20261016125610-7709603b20e0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+replace github.com/muesli/smartcrop => ../
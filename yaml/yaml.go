@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/muesli/smartcrop"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	smartcrop.RegisterConfigFormat(".yaml", ToJSON)
+	smartcrop.RegisterConfigFormat(".yml", ToJSON)
+}
+
+// ToJSON converts a YAML document to JSON. The keys of its mappings need to be
+// scalars. An empty document converts to an empty object.
+func ToJSON(b []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(doc)
+}
+
+// LoadConfig reads a smartcrop.Config in YAML from r, see
+// smartcrop.LoadConfig.
+func LoadConfig(r io.Reader) (*smartcrop.Config, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if b, err = ToJSON(b); err != nil {
+		return nil, fmt.Errorf("smartcrop: invalid config: %v", err)
+	}
+	return smartcrop.LoadConfig(bytes.NewReader(b))
+}
+
+// LoadSettings reads the settings of a config in YAML from r, ignoring its
+// profiles, see LoadConfig.
+func LoadSettings(r io.Reader) (smartcrop.CropSettings, error) {
+	c, err := LoadConfig(r)
+	if err != nil {
+		return smartcrop.CropSettings{}, err
+	}
+	return c.Settings, nil
+}
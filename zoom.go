@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"errors"
+	"image"
+	"math"
+)
+
+// ErrNoZoomLevels gets returned when planning a Zoom without any levels.
+var ErrNoZoomLevels = errors.New("Expect at least one zoom level")
+
+// Zoom contains a series of crops of the same aspect ratio, from wide to
+// tight, all centered on the focal point of Result as closely as the image
+// permits. It is meant for progressive zoom UIs and hover animations.
+type Zoom struct {
+	Result Result            `json:"result"`
+	Crops  []image.Rectangle `json:"crops"`
+}
+
+// PlanZoom analyzes img for the given dimensions and plans n crops. The first
+// one is the largest crop of that aspect ratio fitting into the image, the
+// last one the best crop, shrunk by the factor zoom. The crops in between get
+// tighter by a constant factor, so the zoom appears to run at a steady pace.
+func PlanZoom(analyzer ResultAnalyzer, img image.Image, width, height, n int, zoom float64) (Zoom, error) {
+	if width <= 0 || height <= 0 {
+		return Zoom{}, ErrInvalidDimensions
+	}
+	if n < 1 {
+		return Zoom{}, ErrNoZoomLevels
+	}
+	if zoom < 1.0 || math.IsNaN(zoom) || math.IsInf(zoom, 0) {
+		zoom = 1.0
+	}
+
+	res, err := analyzer.FindBestResult(img, width, height)
+	if err != nil {
+		return Zoom{}, err
+	}
+
+	bounds := img.Bounds()
+	ratio := float64(width) / float64(height)
+	aspect := float64(bounds.Dx()) / float64(bounds.Dy())
+
+	// the widest crop, in normalized coordinates
+	wideW, wideH := 1.0, aspect/ratio
+	if ratio < aspect {
+		wideW, wideH = ratio/aspect, 1.0
+	}
+	tightW := res.Normalized.Width / zoom
+	tightH := res.Normalized.Height / zoom
+
+	z := Zoom{Result: res}
+	for i := 0; i < n; i++ {
+		t := 1.0
+		if n > 1 {
+			t = float64(i) / float64(n-1)
+		}
+		w := wideW * math.Pow(tightW/wideW, t)
+		h := wideH * math.Pow(tightH/wideH, t)
+		norm := NormalizedRect{
+			X:      res.FocalPoint.X - w/2.0,
+			Y:      res.FocalPoint.Y - h/2.0,
+			Width:  w,
+			Height: h,
+		}
+		z.Crops = append(z.Crops, norm.Rect(bounds, ratio))
+	}
+
+	return z, nil
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2014-2020 Christian Muehlhaeuser
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ *	Authors:
+ *		Christian Muehlhaeuser <muesli@gmail.com>
+ *		Michael Wendland <michael@michiwend.com>
+ *		Bjørn Erik Pedersen <bjorn.erik.pedersen@gmail.com>
+ */
+
+package smartcrop
+
+import (
+	"image"
+	"os"
+	"testing"
+
+	"github.com/muesli/smartcrop/nfnt"
+)
+
+func TestPlanZoom(t *testing.T) {
+	fi, _ := os.Open(testFile)
+	defer fi.Close()
+
+	img, _, err := image.Decode(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer := NewAnalyzer(nfnt.NewDefaultResizer()).(ResultAnalyzer)
+	z, err := PlanZoom(analyzer, img, 250, 250, 4, 2.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(z.Crops) != 4 {
+		t.Fatalf("expected 4 crops, got %d", len(z.Crops))
+	}
+	if z.Crops[0].Dy() != img.Bounds().Dy() {
+		t.Fatalf("expected the first crop to span the image, got %v", z.Crops[0])
+	}
+	for i, c := range z.Crops {
+		if !c.In(img.Bounds()) {
+			t.Fatalf("expected crop %d within the image, got %v", i, c)
+		}
+		if i > 0 && c.Dx() >= z.Crops[i-1].Dx() {
+			t.Fatalf("expected crops to get tighter, got %v", z.Crops)
+		}
+	}
+
+	if _, err := PlanZoom(analyzer, img, 250, 250, 0, 2.0); err != ErrNoZoomLevels {
+		t.Fatalf("expected ErrNoZoomLevels, got %v", err)
+	}
+}